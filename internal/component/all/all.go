@@ -66,7 +66,9 @@ import (
 	_ "github.com/grafana/agent/internal/component/otelcol/auth/headers"                     // Import otelcol.auth.headers
 	_ "github.com/grafana/agent/internal/component/otelcol/auth/oauth2"                      // Import otelcol.auth.oauth2
 	_ "github.com/grafana/agent/internal/component/otelcol/auth/sigv4"                       // Import otelcol.auth.sigv4
+	_ "github.com/grafana/agent/internal/component/otelcol/connector/count"                  // Import otelcol.connector.count
 	_ "github.com/grafana/agent/internal/component/otelcol/connector/host_info"              // Import otelcol.connector.host_info
+	_ "github.com/grafana/agent/internal/component/otelcol/connector/routing"                // Import otelcol.connector.routing
 	_ "github.com/grafana/agent/internal/component/otelcol/connector/servicegraph"           // Import otelcol.connector.servicegraph
 	_ "github.com/grafana/agent/internal/component/otelcol/connector/spanlogs"               // Import otelcol.connector.spanlogs
 	_ "github.com/grafana/agent/internal/component/otelcol/connector/spanmetrics"            // Import otelcol.connector.spanmetrics
@@ -97,6 +99,7 @@ import (
 	_ "github.com/grafana/agent/internal/component/otelcol/receiver/prometheus"              // Import otelcol.receiver.prometheus
 	_ "github.com/grafana/agent/internal/component/otelcol/receiver/vcenter"                 // Import otelcol.receiver.vcenter
 	_ "github.com/grafana/agent/internal/component/otelcol/receiver/zipkin"                  // Import otelcol.receiver.zipkin
+	_ "github.com/grafana/agent/internal/component/otelcol/storage/file"                     // Import otelcol.storage.file
 	_ "github.com/grafana/agent/internal/component/prometheus/exporter/apache"               // Import prometheus.exporter.apache
 	_ "github.com/grafana/agent/internal/component/prometheus/exporter/azure"                // Import prometheus.exporter.azure
 	_ "github.com/grafana/agent/internal/component/prometheus/exporter/blackbox"             // Import prometheus.exporter.blackbox
@@ -135,6 +138,8 @@ import (
 	_ "github.com/grafana/agent/internal/component/pyroscope/java"                           // Import pyroscope.java
 	_ "github.com/grafana/agent/internal/component/pyroscope/scrape"                         // Import pyroscope.scrape
 	_ "github.com/grafana/agent/internal/component/pyroscope/write"                          // Import pyroscope.write
+	_ "github.com/grafana/agent/internal/component/remote/aws/secretsmanager"                // Import remote.aws.secretsmanager
+	_ "github.com/grafana/agent/internal/component/remote/gcp/secretmanager"                 // Import remote.gcp.secretmanager
 	_ "github.com/grafana/agent/internal/component/remote/http"                              // Import remote.http
 	_ "github.com/grafana/agent/internal/component/remote/kubernetes/configmap"              // Import remote.kubernetes.configmap
 	_ "github.com/grafana/agent/internal/component/remote/kubernetes/secret"                 // Import remote.kubernetes.secret
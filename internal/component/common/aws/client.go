@@ -0,0 +1,82 @@
+// Package aws holds the options shared by the components connecting to AWS
+// services.
+package aws
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	aws_config "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/grafana/river/rivertypes"
+)
+
+// Client implements specific AWS configuration options
+type Client struct {
+	AccessKey     string            `river:"key,attr,optional"`
+	Secret        rivertypes.Secret `river:"secret,attr,optional"`
+	Endpoint      string            `river:"endpoint,attr,optional"`
+	DisableSSL    bool              `river:"disable_ssl,attr,optional"`
+	Region        string            `river:"region,attr,optional"`
+	SigningRegion string            `river:"signing_region,attr,optional"`
+}
+
+// GenerateConfig returns the AWS configuration for the client options. The
+// credentials and region which aren't overridden come from the default AWS
+// configuration sources.
+func GenerateConfig(opts Client) (*awssdk.Config, error) {
+	configOptions := make([]func(*aws_config.LoadOptions) error, 0)
+	// Override the endpoint.
+	if opts.Endpoint != "" {
+		endFunc := awssdk.EndpointResolverWithOptionsFunc(func(service, region string, _ ...interface{}) (awssdk.Endpoint, error) {
+			// The S3 compatible system used for testing with does not require signing region, so it's fine to be blank
+			// but when using a proxy to real S3 it needs to be injected.
+			return awssdk.Endpoint{URL: opts.Endpoint, SigningRegion: opts.SigningRegion}, nil
+		})
+		endResolver := aws_config.WithEndpointResolverWithOptions(endFunc)
+		configOptions = append(configOptions, endResolver)
+	}
+
+	// This incredibly nested option turns off SSL.
+	if opts.DisableSSL {
+		httpOverride := aws_config.WithHTTPClient(
+			&http.Client{
+				Transport: &http.Transport{
+					TLSClientConfig: &tls.Config{
+						InsecureSkipVerify: opts.DisableSSL,
+					},
+				},
+			},
+		)
+		configOptions = append(configOptions, httpOverride)
+	}
+
+	// Check to see if we need to override the credentials, else it will use the default ones.
+	// https://docs.aws.amazon.com/cli/latest/userguide/cli-configure-envvars.html
+	if opts.AccessKey != "" {
+		if opts.Secret == "" {
+			return nil, fmt.Errorf("if accesskey or secret are specified then the other must also be specified")
+		}
+		credFunc := awssdk.CredentialsProviderFunc(func(ctx context.Context) (awssdk.Credentials, error) {
+			return awssdk.Credentials{
+				AccessKeyID:     opts.AccessKey,
+				SecretAccessKey: string(opts.Secret),
+			}, nil
+		})
+		credProvider := aws_config.WithCredentialsProvider(credFunc)
+		configOptions = append(configOptions, credProvider)
+	}
+
+	cfg, err := aws_config.LoadDefaultConfig(context.TODO(), configOptions...)
+	if err != nil {
+		return nil, err
+	}
+	// Set region.
+	if opts.Region != "" {
+		cfg.Region = opts.Region
+	}
+
+	return &cfg, nil
+}
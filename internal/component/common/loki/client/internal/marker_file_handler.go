@@ -8,7 +8,6 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
-	"github.com/grafana/agent/internal/component/common/loki/wal"
 	"github.com/natefinch/atomic"
 )
 
@@ -22,10 +21,11 @@ const (
 	MarkerWindowsFileMode   os.FileMode = 0o666
 )
 
-// MarkerFileHandler is a file-backed wal.Marker, that also allows one to write to the backing store as particular
-// segment number as the last one marked.
+// MarkerFileHandler is a file-backed store of the last marked segment, that allows one to read it and to write a
+// particular segment number as the last one marked.
 type MarkerFileHandler interface {
-	wal.Marker
+	// LastMarkedSegment returns the last segment marked in the backing file-store, or -1 if there is no mark.
+	LastMarkedSegment() int
 
 	// MarkSegment writes in the backing file-store that a particular segment is the last one marked.
 	MarkSegment(segment int)
@@ -35,15 +35,19 @@ type markerFileHandler struct {
 	logger                    log.Logger
 	lastMarkedSegmentDir      string
 	lastMarkedSegmentFilePath string
+	// legacyMarkerFilePath is the location of the marker file shared by all clients, used before each client kept its
+	// own. It's only read from if the client marker has not been written yet.
+	legacyMarkerFilePath string
 }
 
 var (
 	_ MarkerFileHandler = (*markerFileHandler)(nil)
 )
 
-// NewMarkerFileHandler creates a new markerFileHandler.
-func NewMarkerFileHandler(logger log.Logger, walDir string) (MarkerFileHandler, error) {
-	markerDir := filepath.Join(walDir, MarkerFolderName)
+// NewMarkerFileHandler creates a new markerFileHandler. If clientName is not empty, the marker file is kept in a folder
+// specific to that client, since clients reading from the same WAL consume it at a different pace.
+func NewMarkerFileHandler(logger log.Logger, walDir, clientName string) (MarkerFileHandler, error) {
+	markerDir := filepath.Join(walDir, MarkerFolderName, clientName)
 	// attempt to create dir if doesn't exist
 	if err := os.MkdirAll(markerDir, MarkerFolderMode); err != nil {
 		return nil, fmt.Errorf("error creating segment marker folder %q: %w", markerDir, err)
@@ -54,24 +58,34 @@ func NewMarkerFileHandler(logger log.Logger, walDir string) (MarkerFileHandler,
 		lastMarkedSegmentDir:      filepath.Join(markerDir),
 		lastMarkedSegmentFilePath: filepath.Join(markerDir, MarkerFileName),
 	}
+	if clientName != "" {
+		mfh.legacyMarkerFilePath = filepath.Join(walDir, MarkerFolderName, MarkerFileName)
+	}
 
 	return mfh, nil
 }
 
 // LastMarkedSegment implements wlog.Marker.
 func (mfh *markerFileHandler) LastMarkedSegment() int {
-	bs, err := os.ReadFile(mfh.lastMarkedSegmentFilePath)
+	path := mfh.lastMarkedSegmentFilePath
+	if _, err := os.Stat(path); os.IsNotExist(err) && mfh.legacyMarkerFilePath != "" {
+		if _, err := os.Stat(mfh.legacyMarkerFilePath); err == nil {
+			path = mfh.legacyMarkerFilePath
+		}
+	}
+
+	bs, err := os.ReadFile(path)
 	if os.IsNotExist(err) {
-		level.Warn(mfh.logger).Log("msg", "marker segment file does not exist", "file", mfh.lastMarkedSegmentFilePath)
+		level.Warn(mfh.logger).Log("msg", "marker segment file does not exist", "file", path)
 		return -1
 	} else if err != nil {
-		level.Error(mfh.logger).Log("msg", "could not access segment marker file", "file", mfh.lastMarkedSegmentFilePath, "err", err)
+		level.Error(mfh.logger).Log("msg", "could not access segment marker file", "file", path, "err", err)
 		return -1
 	}
 
 	savedSegment, err := DecodeMarkerV1(bs)
 	if err != nil {
-		level.Error(mfh.logger).Log("msg", "could not decode segment marker file", "file", mfh.lastMarkedSegmentFilePath, "err", err)
+		level.Error(mfh.logger).Log("msg", "could not decode segment marker file", "file", path, "err", err)
 		return -1
 	}
 
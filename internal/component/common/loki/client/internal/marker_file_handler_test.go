@@ -19,7 +19,7 @@ func TestMarkerFileHandler(t *testing.T) {
 
 	t.Run("invalid last marked segment when there's no marker file", func(t *testing.T) {
 		dir := getTempDir(t)
-		fh, err := NewMarkerFileHandler(logger, dir)
+		fh, err := NewMarkerFileHandler(logger, dir, "")
 		require.NoError(t, err)
 
 		require.Equal(t, -1, fh.LastMarkedSegment())
@@ -27,7 +27,7 @@ func TestMarkerFileHandler(t *testing.T) {
 
 	t.Run("reads the last segment from existing marker file", func(t *testing.T) {
 		dir := getTempDir(t)
-		fh, err := NewMarkerFileHandler(logger, dir)
+		fh, err := NewMarkerFileHandler(logger, dir, "")
 		require.NoError(t, err)
 
 		// write first something to marker
@@ -42,7 +42,7 @@ func TestMarkerFileHandler(t *testing.T) {
 
 	t.Run("marks segment, and then reads value from it", func(t *testing.T) {
 		dir := getTempDir(t)
-		fh, err := NewMarkerFileHandler(logger, dir)
+		fh, err := NewMarkerFileHandler(logger, dir, "")
 		require.NoError(t, err)
 
 		fh.MarkSegment(12)
@@ -51,7 +51,7 @@ func TestMarkerFileHandler(t *testing.T) {
 
 	t.Run("marker file and directory is created with correct permissions", func(t *testing.T) {
 		dir := getTempDir(t)
-		fh, err := NewMarkerFileHandler(logger, dir)
+		fh, err := NewMarkerFileHandler(logger, dir, "")
 		require.NoError(t, err)
 
 		fh.MarkSegment(12)
@@ -72,4 +72,33 @@ func TestMarkerFileHandler(t *testing.T) {
 			require.Equal(t, MarkerFileMode, stats.Mode().Perm())
 		}
 	})
+
+	t.Run("client marker is kept in its own folder", func(t *testing.T) {
+		dir := getTempDir(t)
+		fh1, err := NewMarkerFileHandler(logger, dir, "client-1")
+		require.NoError(t, err)
+		fh2, err := NewMarkerFileHandler(logger, dir, "client-2")
+		require.NoError(t, err)
+
+		fh1.MarkSegment(12)
+		fh2.MarkSegment(5)
+		require.Equal(t, 12, fh1.LastMarkedSegment())
+		require.Equal(t, 5, fh2.LastMarkedSegment())
+		require.FileExists(t, filepath.Join(dir, MarkerFolderName, "client-1", MarkerFileName))
+	})
+
+	t.Run("falls back to shared marker file until the client one is written", func(t *testing.T) {
+		dir := getTempDir(t)
+		fh, err := NewMarkerFileHandler(logger, dir, "client-1")
+		require.NoError(t, err)
+
+		bs, err := EncodeMarkerV1(10)
+		require.NoError(t, err)
+		err = os.WriteFile(filepath.Join(dir, MarkerFolderName, MarkerFileName), bs, MarkerFileMode)
+		require.NoError(t, err)
+		require.Equal(t, 10, fh.LastMarkedSegment())
+
+		fh.MarkSegment(11)
+		require.Equal(t, 11, fh.LastMarkedSegment())
+	})
 }
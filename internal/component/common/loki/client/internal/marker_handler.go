@@ -40,10 +40,12 @@ type markerHandler struct {
 	wg                sync.WaitGroup
 }
 
-// dataUpdate is an update event that some amount of data has been read out of the WAL and enqueued, delivered or dropped.
+// dataUpdate is an update event that some amount of data has been read out of the WAL and enqueued, delivered or dropped,
+// or that a segment has been read until its end when the Watcher stopped.
 type dataUpdate struct {
 	segmentId int
 	dataCount int
+	readToEnd bool
 }
 
 var (
@@ -95,6 +97,13 @@ func (mh *markerHandler) UpdateSentData(segmentId, dataCount int) {
 	}
 }
 
+func (mh *markerHandler) UpdateReadToEnd(segmentId int) {
+	mh.dataIOUpdate <- dataUpdate{
+		segmentId: segmentId,
+		readToEnd: true,
+	}
+}
+
 // countDataItem tracks inside a map the count of in-flight log entries, and the last update received, for a given segment.
 type countDataItem struct {
 	count      int
@@ -113,10 +122,21 @@ type processDataItem struct {
 // and a procedure is triggered to find the "last consumed segment", implemented by FindMarkableSegment. Since this
 // last procedure could be expensive, it's execution is run at most if a segment has reached count zero, of when a timer
 // is fired (once per second).
+//
+// The highest numbered segment data has been received from (the head segment) is not marked, even if all its data has
+// been delivered. While running, the Writer might still be appending to it, and on stop, the Watcher might not have read
+// it until its end, for example if its drain timed out. Since the marker is used on startup to replay the WAL from the
+// segment following the marked one, marking it would cause the data that wasn't read to be skipped. The head segment is
+// only marked on stop if the Watcher reported it read the head segment until its end.
 func (mh *markerHandler) runUpdatePendingData() {
 	defer mh.wg.Done()
 
 	segmentDataCount := make(map[int]*countDataItem)
+	// consumedSegment is the highest numbered segment found as consumed, and headSegment the highest numbered one that
+	// has received data. The segment that ends up being marked is the lower of consumedSegment and the one before the head.
+	consumedSegment, headSegment := -1, -1
+	// readToEndSegment is the segment the Watcher read until its end when it stopped.
+	readToEndSegment := -1
 
 	for {
 		// shouldRunFind will be true if a markable segment should be found after the update, that is if one reached a count
@@ -124,27 +144,46 @@ func (mh *markerHandler) runUpdatePendingData() {
 		shouldRunFind := false
 		select {
 		case <-mh.quit:
+			// Process all updates that are still buffered, and mark the last consumed segment before the head so that data
+			// already delivered is not replayed when reading the WAL on the next start.
+			for pending := true; pending; {
+				select {
+				case update := <-mh.dataIOUpdate:
+					if update.readToEnd {
+						readToEndSegment = update.segmentId
+						continue
+					}
+					applyDataUpdate(segmentDataCount, update)
+					headSegment = max(headSegment, update.segmentId)
+				default:
+					pending = false
+				}
+			}
+			if found := FindMarkableSegment(segmentDataCount, mh.maxSegmentAge); found > consumedSegment {
+				consumedSegment = found
+			}
+			if readToEndSegment >= headSegment {
+				// No data is left to be read from the head segment.
+				mh.markSegment(consumedSegment)
+			} else {
+				mh.markConsumedSegment(consumedSegment, headSegment)
+			}
 			return
 		case update := <-mh.dataIOUpdate:
-			if di, ok := segmentDataCount[update.segmentId]; ok {
-				di.lastUpdate = time.Now()
-				resultingCount := di.count + update.dataCount
-				di.count = resultingCount
-				// if a segment reached zero, run find routine because a segment might be ready to be marked
-				shouldRunFind = resultingCount == 0
-			} else {
-				segmentDataCount[update.segmentId] = &countDataItem{
-					count:      update.dataCount,
-					lastUpdate: time.Now(),
-				}
+			if update.readToEnd {
+				readToEndSegment = update.segmentId
+				continue
+			}
+			// if a segment reached zero, run find routine because a segment might be ready to be marked
+			shouldRunFind = applyDataUpdate(segmentDataCount, update)
+			// if data is received from a new segment, the Watcher moved on, and the previous head might be ready to be marked
+			if update.segmentId > headSegment {
+				headSegment = update.segmentId
+				shouldRunFind = true
 			}
-		}
-
-		// if ticker fired, force run find
-		select {
 		case <-mh.runFindTicker.C:
+			// if ticker fired, force run find
 			shouldRunFind = true
-		default:
 		}
 
 		if !shouldRunFind {
@@ -153,12 +192,46 @@ func (mh *markerHandler) runUpdatePendingData() {
 
 		markableSegment := FindMarkableSegment(segmentDataCount, mh.maxSegmentAge)
 		level.Debug(mh.logger).Log("msg", fmt.Sprintf("found as markable segment %d", markableSegment))
-		if markableSegment > mh.lastMarkedSegment {
-			mh.markerFileHandler.MarkSegment(markableSegment)
-			mh.lastMarkedSegment = markableSegment
-			mh.metrics.lastMarkedSegment.WithLabelValues().Set(float64(markableSegment))
+		if markableSegment > consumedSegment {
+			consumedSegment = markableSegment
 		}
+		mh.markConsumedSegment(consumedSegment, headSegment)
+	}
+}
+
+// markConsumedSegment marks the lower of consumedSegment and the segment before headSegment.
+func (mh *markerHandler) markConsumedSegment(consumedSegment, headSegment int) {
+	if consumedSegment < headSegment {
+		mh.markSegment(consumedSegment)
+	} else {
+		mh.markSegment(headSegment - 1)
+	}
+}
+
+// applyDataUpdate applies a data update event to the in-flight count of the segment it corresponds to. Returns true if
+// the resulting count for the segment is zero.
+func applyDataUpdate(segmentDataCount map[int]*countDataItem, update dataUpdate) bool {
+	di, ok := segmentDataCount[update.segmentId]
+	if !ok {
+		segmentDataCount[update.segmentId] = &countDataItem{
+			count:      update.dataCount,
+			lastUpdate: time.Now(),
+		}
+		return false
+	}
+	di.lastUpdate = time.Now()
+	di.count += update.dataCount
+	return di.count == 0
+}
+
+// markSegment persists segment as the last marked one, if it's higher than the one previously marked.
+func (mh *markerHandler) markSegment(segment int) {
+	if segment <= mh.lastMarkedSegment {
+		return
 	}
+	mh.markerFileHandler.MarkSegment(segment)
+	mh.lastMarkedSegment = segment
+	mh.metrics.lastMarkedSegment.WithLabelValues().Set(float64(segment))
 }
 
 func (mh *markerHandler) Stop() {
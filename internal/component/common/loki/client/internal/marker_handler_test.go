@@ -48,6 +48,8 @@ func TestMarkerHandler(t *testing.T) {
 		mh.UpdateReceivedData(11, 10)
 		mh.UpdateSentData(11, 5)
 		mh.UpdateSentData(11, 5)
+		// the watcher moves on to segment 12, which means no more data will be read from segment 11
+		mh.UpdateReceivedData(12, 1)
 
 		require.Eventually(t, func() bool {
 			return mh.LastMarkedSegment() == 11
@@ -75,6 +77,65 @@ func TestMarkerHandler(t *testing.T) {
 		}, 3*time.Second, time.Millisecond*100, "expected last marked segment to catch up")
 		require.Equal(t, 11, mockMFH.LastMarkedSegment())
 	})
+
+	t.Run("head segment is not marked while running", func(t *testing.T) {
+		mockMFH := newMockMarkerFileHandler(10)
+		mh := NewMarkerHandler(mockMFH, time.Minute, logger, metrics)
+		defer mh.Stop()
+
+		mh.UpdateReceivedData(11, 10)
+		mh.UpdateSentData(11, 10)
+
+		// wait for the find ticker to fire at least once
+		time.Sleep(time.Second + time.Millisecond*100)
+		mh.UpdateReceivedData(11, 1)
+		mh.UpdateSentData(11, 1)
+
+		require.Never(t, func() bool {
+			return mh.LastMarkedSegment() != 10
+		}, time.Second, time.Millisecond*100, "expected head segment not to be marked")
+	})
+
+	t.Run("consumed segments before the head are marked on stop", func(t *testing.T) {
+		mockMFH := newMockMarkerFileHandler(10)
+		mh := NewMarkerHandler(mockMFH, time.Minute, logger, metrics)
+
+		mh.UpdateReceivedData(11, 10)
+		mh.UpdateSentData(11, 10)
+		mh.UpdateReceivedData(12, 10)
+		mh.UpdateSentData(12, 5)
+		mh.Stop()
+
+		require.Equal(t, 11, mockMFH.LastMarkedSegment())
+	})
+
+	t.Run("head segment is not marked on stop", func(t *testing.T) {
+		mockMFH := newMockMarkerFileHandler(10)
+		mh := NewMarkerHandler(mockMFH, time.Minute, logger, metrics)
+
+		// All the data read from the head segment was delivered, but the Watcher might not have read it until its end.
+		mh.UpdateReceivedData(11, 10)
+		mh.UpdateSentData(11, 10)
+		mh.UpdateReceivedData(12, 10)
+		mh.UpdateSentData(12, 10)
+		mh.Stop()
+
+		require.Equal(t, 11, mockMFH.LastMarkedSegment())
+	})
+
+	t.Run("head segment read to end is marked on stop", func(t *testing.T) {
+		mockMFH := newMockMarkerFileHandler(10)
+		mh := NewMarkerHandler(mockMFH, time.Minute, logger, metrics)
+
+		mh.UpdateReceivedData(11, 10)
+		mh.UpdateSentData(11, 10)
+		mh.UpdateReceivedData(12, 10)
+		mh.UpdateSentData(12, 10)
+		mh.UpdateReadToEnd(12)
+		mh.Stop()
+
+		require.Equal(t, 12, mockMFH.LastMarkedSegment())
+	})
 }
 
 func TestFindLastMarkableSegment(t *testing.T) {
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/grafana/dskit/backoff"
 	"github.com/grafana/dskit/flagext"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 
@@ -195,6 +197,93 @@ func TestManager_WALEnabled(t *testing.T) {
 	require.Len(t, seenEntries, totalLines)
 }
 
+func TestManager_WALReplaysUnsentEntriesOnRestart(t *testing.T) {
+	walDir := t.TempDir()
+	walConfig := wal.Config{
+		Dir:           walDir,
+		Enabled:       true,
+		MaxSegmentAge: time.Minute,
+		WatchConfig:   wal.DefaultWatchConfig,
+	}
+	logger := log.NewLogfmtLogger(os.Stdout)
+	testClientConfig, rwReceivedReqs, closeServer := newServerAndClientConfig(t)
+	defer closeServer.Close()
+
+	receivedRequests := utils.NewSyncSlice[utils.RemoteWriteRequest]()
+	go func() {
+		for req := range rwReceivedReqs {
+			receivedRequests.Append(req)
+		}
+	}()
+
+	var testLabels = model.LabelSet{
+		"wal_enabled": "true",
+	}
+	writeLines := func(writer *wal.Writer, from, to int) {
+		for i := from; i < to; i++ {
+			writer.Chan() <- loki.Entry{
+				Labels: testLabels,
+				Entry: logproto.Entry{
+					Timestamp: time.Now(),
+					Line:      fmt.Sprintf("line%d", i),
+				},
+			}
+		}
+	}
+
+	// first run, all written entries are delivered
+	writer, err := wal.NewWriter(walConfig, logger, prometheus.NewRegistry())
+	require.NoError(t, err)
+	manager, err := NewManager(NewMetrics(nil), logger, testLimitsConfig, prometheus.NewRegistry(), walConfig, writer, testClientConfig)
+	require.NoError(t, err)
+	writeLines(writer, 0, 50)
+	require.Eventually(t, func() bool {
+		return receivedRequests.Length() == 50
+	}, 5*time.Second, 100*time.Millisecond, "timed out waiting for requests to be received")
+	writer.Stop()
+	manager.Stop()
+
+	// entries are written to the WAL, but the agent stops before the client gets to read them
+	writer, err = wal.NewWriter(walConfig, logger, prometheus.NewRegistry())
+	require.NoError(t, err)
+	writeLines(writer, 50, 100)
+	writer.Stop()
+
+	// after restarting, only the entries that weren't delivered are replayed
+	reg := prometheus.NewRegistry()
+	writer, err = wal.NewWriter(walConfig, logger, prometheus.NewRegistry())
+	require.NoError(t, err)
+	manager, err = NewManager(NewMetrics(nil), logger, testLimitsConfig, reg, walConfig, writer, testClientConfig)
+	require.NoError(t, err)
+	defer func() {
+		writer.Stop()
+		manager.Stop()
+	}()
+
+	require.Eventually(t, func() bool {
+		return receivedRequests.Length() == 100
+	}, 5*time.Second, 100*time.Millisecond, "timed out waiting for replayed requests to be received")
+	// give the watcher some time to read the WAL further, in case it replays already delivered entries
+	time.Sleep(time.Second)
+
+	var seenEntries = map[string]struct{}{}
+	for _, req := range receivedRequests.StartIterate() {
+		seenEntries[req.Request.Streams[0].Entries[0].Line] = struct{}{}
+	}
+	receivedRequests.DoneIterate()
+	require.Len(t, seenEntries, 100)
+	require.Equal(t, 100, receivedRequests.Length(), "expected no entry to be delivered twice")
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP loki_write_wal_watcher_replay_records_read_total Number of records read by the WAL watcher while replaying segments written before it started.
+# TYPE loki_write_wal_watcher_replay_records_read_total counter
+loki_write_wal_watcher_replay_records_read_total{id="test-client"} 100
+# HELP loki_write_wal_watcher_replay_segments_remaining Number of segments written before the WAL watcher started, that are left to be replayed.
+# TYPE loki_write_wal_watcher_replay_segments_remaining gauge
+loki_write_wal_watcher_replay_segments_remaining{id="test-client"} 0
+`), "loki_write_wal_watcher_replay_records_read_total", "loki_write_wal_watcher_replay_segments_remaining"))
+}
+
 func TestManager_WALDisabled(t *testing.T) {
 	walConfig := wal.Config{}
 	// start all necessary resources
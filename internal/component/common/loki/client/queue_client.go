@@ -284,14 +284,15 @@ func (c *queueClient) AppendEntries(entries wal.RefEntries, segment int) error {
 	c.seriesLock.RUnlock()
 	var maxSeenTimestamp int64 = -1
 	if ok {
+		// count all appended entries as received from WAL before enqueueing them, so that the marker handler never
+		// sees data from a segment as sent before it's received
+		c.markerHandler.UpdateReceivedData(segment, len(entries.Entries))
 		for _, e := range entries.Entries {
 			c.appendSingleEntry(segment, l, e)
 			if e.Timestamp.Unix() > maxSeenTimestamp {
 				maxSeenTimestamp = e.Timestamp.Unix()
 			}
 		}
-		// count all enqueued appended entries as received from WAL
-		c.markerHandler.UpdateReceivedData(segment, len(entries.Entries))
 	} else {
 		// TODO(thepalbi): Add metric here
 		level.Debug(c.logger).Log("msg", "series for entry not found")
@@ -312,6 +313,8 @@ func (c *queueClient) appendSingleEntry(segmentNum int, lbs model.LabelSet, e lo
 		if !c.maxLineSizeTruncate {
 			c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonLineTooLong).Inc()
 			c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonLineTooLong).Add(float64(len(e.Line)))
+			// the entry won't ever be sent, so report it as given up on to not hold back the segment from being marked
+			c.markerHandler.UpdateSentData(segmentNum, 1)
 			return
 		}
 
@@ -367,6 +370,7 @@ func (c *queueClient) appendSingleEntry(segmentNum int, lbs model.LabelSet, e lo
 		}
 		c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host, tenantID, reason).Add(float64(len(e.Line)))
 		c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host, tenantID, reason).Inc()
+		c.markerHandler.UpdateSentData(segmentNum, 1)
 	}
 }
 
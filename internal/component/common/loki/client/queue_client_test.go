@@ -216,7 +216,7 @@ func BenchmarkClientImplementations(b *testing.B) {
 					dir := b.TempDir()
 					nopLogger := log.NewNopLogger()
 
-					markerFileHandler, err := internal.NewMarkerFileHandler(nopLogger, dir)
+					markerFileHandler, err := internal.NewMarkerFileHandler(nopLogger, dir, "")
 					require.NoError(b, err)
 
 					markerHandler := internal.NewMarkerHandler(markerFileHandler, time.Minute, nopLogger, internal.NewMarkerMetrics(nil).WithCurriedId("test"))
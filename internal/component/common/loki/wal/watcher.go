@@ -71,6 +71,11 @@ type Marker interface {
 	// The Watcher will start reading the first segment whose value is greater
 	// than the return value.
 	LastMarkedSegment() int
+
+	// UpdateReadToEnd is called when the Watcher stops after reading the
+	// segment it was reading from until its end. Since the Writer is stopped
+	// before the Watcher, no more data is written to that segment.
+	UpdateReadToEnd(segmentNum int)
 }
 
 type Watcher struct {
@@ -92,6 +97,9 @@ type Watcher struct {
 	drainTimeout time.Duration
 	marker       Marker
 	savedSegment int
+	// replayUntil is the last segment in the WAL when the Watcher started tailing it. Segments before it were written
+	// before that, and reading them is considered a replay.
+	replayUntil int
 }
 
 // NewWatcher creates a new Watcher.
@@ -106,6 +114,7 @@ func NewWatcher(walDir, id string, metrics *WatcherMetrics, writeTo WriteTo, log
 		MaxSegment:   -1,
 		marker:       marker,
 		savedSegment: -1,
+		replayUntil:  -1,
 		logger:       logger,
 		metrics:      metrics,
 		minReadFreq:  config.MinReadFrequency,
@@ -170,9 +179,12 @@ func (w *Watcher) run() error {
 		level.Debug(w.logger).Log("msg", fmt.Sprintf("failed to find segment for marked index %d", w.savedSegment), "err", err)
 	}
 
+	w.replayUntil = lastSegment
+
 	level.Debug(w.logger).Log("msg", "Tailing WAL", "currentSegment", currentSegment, "lastSegment", lastSegment)
 	for !w.state.IsStopping() {
 		w.metrics.currentSegment.WithLabelValues(w.id).Set(float64(currentSegment))
+		w.metrics.replaySegmentsRemaining.WithLabelValues(w.id).Set(float64(max(lastSegment-currentSegment, 0)))
 
 		// On start, we have a pointer to what is the latest segment. On subsequent calls to this function,
 		// currentSegment will have been incremented, and we should open that segment.
@@ -228,6 +240,7 @@ func (w *Watcher) watch(segmentNum int, tail bool) error {
 	for {
 		select {
 		case <-w.state.WaitForStopping():
+			w.reportReadToEnd(reader, segmentNum)
 			return nil
 
 		case <-segmentTicker.C:
@@ -259,6 +272,11 @@ func (w *Watcher) watch(segmentNum int, tail bool) error {
 				return err
 			}
 
+			// when draining, the last segment is only read once, before the Watcher finds no segment follows it and stops
+			if last <= segmentNum {
+				w.reportReadToEnd(reader, segmentNum)
+			}
+
 			// return after reading the whole segment
 			return nil
 
@@ -307,6 +325,23 @@ func (w *Watcher) watch(segmentNum int, tail bool) error {
 	}
 }
 
+// reportReadToEnd informs the marker that segmentNum was read until its end, if r reached the current size of the segment.
+func (w *Watcher) reportReadToEnd(r *wlog.LiveReader, segmentNum int) {
+	if w.marker == nil {
+		return
+	}
+	size, err := getSegmentSize(w.walDir, segmentNum)
+	if err != nil {
+		return
+	}
+	// The Writer pads the segment when it's closed, so the reader must go past the padding to reach the end. Any record
+	// left is data that wasn't read.
+	if r.Next() || r.Offset() != size {
+		return
+	}
+	w.marker.UpdateReadToEnd(segmentNum)
+}
+
 // Read entries from a segment, decode them and dispatch them.
 func (w *Watcher) readSegment(r *wlog.LiveReader, segmentNum int) (bool, error) {
 	var readData bool
@@ -314,6 +349,9 @@ func (w *Watcher) readSegment(r *wlog.LiveReader, segmentNum int) (bool, error)
 	for r.Next() && !w.state.IsStopping() {
 		rec := r.Record()
 		w.metrics.recordsRead.WithLabelValues(w.id).Inc()
+		if segmentNum < w.replayUntil {
+			w.metrics.replayRecordsRead.WithLabelValues(w.id).Inc()
+		}
 		read, err := w.decodeAndDispatch(rec, segmentNum)
 		// keep true if data was read at least once
 		readData = readData || read
@@ -12,6 +12,8 @@ type WatcherMetrics struct {
 	segmentRead               *prometheus.CounterVec
 	currentSegment            *prometheus.GaugeVec
 	replaySegment             *prometheus.GaugeVec
+	replaySegmentsRemaining   *prometheus.GaugeVec
+	replayRecordsRead         *prometheus.CounterVec
 	watchersRunning           *prometheus.GaugeVec
 }
 
@@ -71,6 +73,24 @@ func NewWatcherMetrics(reg prometheus.Registerer) *WatcherMetrics {
 			},
 			[]string{"id"},
 		),
+		replaySegmentsRemaining: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "loki_write",
+				Subsystem: "wal_watcher",
+				Name:      "replay_segments_remaining",
+				Help:      "Number of segments written before the WAL watcher started, that are left to be replayed.",
+			},
+			[]string{"id"},
+		),
+		replayRecordsRead: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "loki_write",
+				Subsystem: "wal_watcher",
+				Name:      "replay_records_read_total",
+				Help:      "Number of records read by the WAL watcher while replaying segments written before it started.",
+			},
+			[]string{"id"},
+		),
 		watchersRunning: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Namespace: "loki_write",
@@ -88,6 +108,9 @@ func NewWatcherMetrics(reg prometheus.Registerer) *WatcherMetrics {
 		m.droppedWriteNotifications = util.MustRegisterOrGet(reg, m.droppedWriteNotifications).(*prometheus.CounterVec)
 		m.segmentRead = util.MustRegisterOrGet(reg, m.segmentRead).(*prometheus.CounterVec)
 		m.currentSegment = util.MustRegisterOrGet(reg, m.currentSegment).(*prometheus.GaugeVec)
+		m.replaySegment = util.MustRegisterOrGet(reg, m.replaySegment).(*prometheus.GaugeVec)
+		m.replaySegmentsRemaining = util.MustRegisterOrGet(reg, m.replaySegmentsRemaining).(*prometheus.GaugeVec)
+		m.replayRecordsRead = util.MustRegisterOrGet(reg, m.replayRecordsRead).(*prometheus.CounterVec)
 		m.watchersRunning = util.MustRegisterOrGet(reg, m.watchersRunning).(*prometheus.GaugeVec)
 	}
 
@@ -326,6 +326,8 @@ func (n noMarker) LastMarkedSegment() int {
 	return -1
 }
 
+func (n noMarker) UpdateReadToEnd(int) {}
+
 // TestWatcher is the main test function, that works as framework to test different scenarios of the Watcher. It bootstraps
 // necessary test components.
 func TestWatcher(t *testing.T) {
@@ -389,6 +391,8 @@ func (m mockMarker) LastMarkedSegment() int {
 	return m.LastMarkedSegmentFunc()
 }
 
+func (m mockMarker) UpdateReadToEnd(int) {}
+
 func TestWatcher_Replay(t *testing.T) {
 	labels := model.LabelSet{
 		"app": "test",
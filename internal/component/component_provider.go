@@ -14,6 +14,10 @@ var (
 	// specified component isn't found.
 	ErrComponentNotFound = errors.New("component not found")
 
+	// ErrComponentExists is returned when adding a component with the same ID
+	// as an existing component.
+	ErrComponentExists = errors.New("component already exists")
+
 	// ErrModuleNotFound is returned by [Provider.ListComponents] when the
 	// specified module isn't found.
 	ErrModuleNotFound = errors.New("module not found")
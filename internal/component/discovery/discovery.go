@@ -26,12 +26,31 @@ type DistributedTargets struct {
 	useClustering bool
 	cluster       cluster.Cluster
 	targets       []Target
+	key           func(Target) shard.Key
 }
 
 // NewDistributedTargets creates the abstraction that allows components to
 // dynamically shard targets between components.
 func NewDistributedTargets(e bool, n cluster.Cluster, t []Target) DistributedTargets {
-	return DistributedTargets{e, n, t}
+	return DistributedTargets{e, n, t, nonMetaLabelsKey}
+}
+
+// NewDistributedTargetsWithKey is like NewDistributedTargets, but uses key to
+// determine the owner of each target instead of the target's non-meta
+// labels.
+func NewDistributedTargetsWithKey(e bool, n cluster.Cluster, t []Target, key func(Target) shard.Key) DistributedTargets {
+	return DistributedTargets{e, n, t, key}
+}
+
+// PathShardKey returns the key used to shard targets pointing at files by the
+// path in their __path__ label, so that each file is owned by a single peer
+// regardless of the labels attached to it.
+func PathShardKey(tgt Target) shard.Key {
+	return shard.StringKey(tgt["__path__"])
+}
+
+func nonMetaLabelsKey(tgt Target) shard.Key {
+	return shard.StringKey(tgt.NonMetaLabels().String())
 }
 
 // Get distributes discovery targets a clustered environment.
@@ -52,7 +71,7 @@ func (t *DistributedTargets) Get() []Target {
 	res := make([]Target, 0, resCap)
 
 	for _, tgt := range t.targets {
-		peers, err := t.cluster.Lookup(shard.StringKey(tgt.NonMetaLabels().String()), 1, shard.OpReadWrite)
+		peers, err := t.cluster.Lookup(t.key(tgt), 1, shard.OpReadWrite)
 		if err != nil {
 			// This can only fail in case we ask for more owners than the
 			// available peers. This will never happen, but in any case we fall
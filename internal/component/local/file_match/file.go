@@ -9,6 +9,7 @@ import (
 	"github.com/grafana/agent/internal/component/discovery"
 	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/agent/internal/flow/logging/level"
+	"github.com/grafana/agent/internal/service/cluster"
 )
 
 func init() {
@@ -28,9 +29,14 @@ func init() {
 type Arguments struct {
 	PathTargets []discovery.Target `river:"path_targets,attr"`
 	SyncPeriod  time.Duration      `river:"sync_period,attr,optional"`
+
+	Clustering cluster.ComponentBlock `river:"clustering,block,optional"`
 }
 
-var _ component.Component = (*Component)(nil)
+var (
+	_ component.Component = (*Component)(nil)
+	_ cluster.Component   = (*Component)(nil)
+)
 
 // Component implements the local.file_match component.
 type Component struct {
@@ -40,6 +46,11 @@ type Component struct {
 	args     Arguments
 	watches  []watch
 	watchDog *time.Ticker
+
+	// cluster is only set once clustering is enabled, as the cluster service
+	// isn't required otherwise.
+	cluster       cluster.Cluster
+	clusterChange chan struct{}
 }
 
 // New creates a new local.file_match component.
@@ -50,6 +61,8 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		args:     args,
 		watches:  make([]watch, 0),
 		watchDog: time.NewTicker(args.SyncPeriod),
+
+		clusterChange: make(chan struct{}, 1),
 	}
 
 	if err := c.Update(args); err != nil {
@@ -76,7 +89,16 @@ func (c *Component) Update(args component.Arguments) error {
 	if args.(Arguments).SyncPeriod != c.args.SyncPeriod {
 		c.watchDog.Reset(c.args.SyncPeriod)
 	}
-	c.args = args.(Arguments)
+	newArgs := args.(Arguments)
+	if newArgs.Clustering.Enabled && c.cluster == nil {
+		data, err := c.opts.GetServiceData(cluster.ServiceName)
+		if err != nil {
+			return err
+		}
+		c.cluster = data.(cluster.Cluster)
+	}
+
+	c.args = newArgs
 	c.watches = c.watches[:0]
 	for _, v := range c.args.PathTargets {
 		c.watches = append(c.watches, watch{
@@ -106,6 +128,9 @@ func (c *Component) Run(ctx context.Context) error {
 		case <-c.watchDog.C:
 			// This triggers a check for any new paths, along with pushing new targets.
 			update()
+		case <-c.clusterChange:
+			// The peers owning the matched paths may have changed.
+			update()
 		case <-ctx.Done():
 			return nil
 		}
@@ -122,5 +147,26 @@ func (c *Component) getWatchedFiles() []discovery.Target {
 		}
 		paths = append(paths, newPaths...)
 	}
-	return paths
+
+	// Only export the paths owned by this peer, so that each file is tailed by
+	// a single peer.
+	dt := discovery.NewDistributedTargetsWithKey(c.args.Clustering.Enabled, c.cluster, paths, discovery.PathShardKey)
+	return dt.Get()
+}
+
+// NotifyClusterChange implements cluster.Component.
+func (c *Component) NotifyClusterChange() {
+	c.mut.RLock()
+	enabled := c.args.Clustering.Enabled
+	c.mut.RUnlock()
+
+	if !enabled {
+		return
+	}
+
+	select {
+	case c.clusterChange <- struct{}{}:
+	default:
+		// An update is already pending.
+	}
 }
@@ -15,7 +15,10 @@ import (
 	"context"
 
 	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/service/cluster"
 	"github.com/grafana/agent/internal/util"
+	"github.com/grafana/ckit/peer"
+	"github.com/grafana/ckit/shard"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
 )
@@ -238,6 +241,49 @@ func TestMultiLabels(t *testing.T) {
 	require.True(t, contains([]discovery.Target{foundFiles[1]}, "t1.txt"))
 }
 
+func TestClustering(t *testing.T) {
+	dir := path.Join(os.TempDir(), "agent_testing", "t5")
+	os.MkdirAll(dir, 0755)
+	writeFile(t, dir, "t1.txt")
+	writeFile(t, dir, "t2.txt")
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	ring := &fakeCluster{owned: map[shard.Key]bool{
+		discovery.PathShardKey(discovery.Target{"__path__": path.Join(dir, "t1.txt")}): true,
+	}}
+	c := createComponent(t, dir, []string{path.Join(dir, "*.txt")}, nil)
+	c.opts.GetServiceData = func(name string) (interface{}, error) {
+		return ring, nil
+	}
+	args := c.args
+	args.Clustering.Enabled = true
+	require.NoError(t, c.Update(args))
+
+	foundFiles := c.getWatchedFiles()
+	require.Len(t, foundFiles, 1)
+	require.True(t, contains(foundFiles, "t1.txt"))
+}
+
+// fakeCluster owns the keys in owned, all other keys belong to another peer.
+type fakeCluster struct {
+	owned map[shard.Key]bool
+}
+
+func (c *fakeCluster) Lookup(key shard.Key, _ int, _ shard.Op) ([]peer.Peer, error) {
+	if c.owned[key] {
+		return []peer.Peer{{Name: "self", Self: true, State: peer.StateParticipant}}, nil
+	}
+	return []peer.Peer{{Name: "other", State: peer.StateParticipant}}, nil
+}
+
+func (c *fakeCluster) Peers() []peer.Peer {
+	return []peer.Peer{{Name: "self", Self: true}, {Name: "other"}}
+}
+
+var _ cluster.Cluster = (*fakeCluster)(nil)
+
 // createComponent creates a component with the given paths and labels. The paths and excluded slices are zipped together
 // to create the set of targets to pass to the component.
 func createComponent(t *testing.T, dir string, paths []string, excluded []string) *Component {
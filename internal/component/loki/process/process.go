@@ -5,13 +5,16 @@ package process
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"sync"
+	"time"
 
 	"github.com/grafana/agent/internal/component"
 	"github.com/grafana/agent/internal/component/common/loki"
 	"github.com/grafana/agent/internal/component/loki/process/stages"
 	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/agent/internal/service/livedebugging"
 )
 
 // TODO(thampiotr): We should reconsider which parts of this component should be exported and which should
@@ -50,7 +53,8 @@ var (
 
 // Component implements the loki.process component.
 type Component struct {
-	opts component.Options
+	opts      component.Options
+	debugData livedebugging.Publisher
 
 	mut          sync.RWMutex
 	receiver     loki.LogsReceiver
@@ -66,7 +70,8 @@ type Component struct {
 // New creates a new loki.process component.
 func New(o component.Options, args Arguments) (*Component, error) {
 	c := &Component{
-		opts: o,
+		opts:      o,
+		debugData: livedebugging.PublisherFrom(o.GetServiceData),
 	}
 
 	// Create and immediately export the receiver which remains the same for
@@ -149,6 +154,7 @@ func (c *Component) handleIn(ctx context.Context, wg *sync.WaitGroup) {
 		case <-ctx.Done():
 			return
 		case entry := <-c.receiver.Chan():
+			c.publishEntry(entry, livedebugging.DirectionReceived)
 			c.mut.RLock()
 			select {
 			case <-ctx.Done():
@@ -172,6 +178,7 @@ func (c *Component) handleOut(shutdownCh chan struct{}, wg *sync.WaitGroup) {
 		case <-shutdownCh:
 			return
 		case entry := <-c.processOut:
+			c.publishEntry(entry, livedebugging.DirectionEmitted)
 			c.fanoutMut.RLock()
 			fanout := c.fanout
 			c.fanoutMut.RUnlock()
@@ -187,6 +194,22 @@ func (c *Component) handleOut(shutdownCh chan struct{}, wg *sync.WaitGroup) {
 	}
 }
 
+// publishEntry publishes entry to the live debugging streams of the
+// component.
+func (c *Component) publishEntry(entry loki.Entry, dir livedebugging.Direction) {
+	if !c.debugData.IsActive(c.opts.ID) {
+		return
+	}
+	c.debugData.Publish(livedebugging.Data{
+		ComponentID: c.opts.ID,
+		Type:        livedebugging.DataTypeLog,
+		Direction:   dir,
+		Render: func() string {
+			return fmt.Sprintf("%s %s %s", entry.Timestamp.Format(time.RFC3339Nano), entry.Labels, entry.Line)
+		},
+	})
+}
+
 func stagesChanged(prev, next []stages.StageConfig) bool {
 	if len(prev) != len(next) {
 		return true
@@ -17,6 +17,7 @@ import (
 	"github.com/grafana/agent/internal/component/loki/process/stages"
 	lsf "github.com/grafana/agent/internal/component/loki/source/file"
 	"github.com/grafana/agent/internal/flow/componenttest"
+	"github.com/grafana/agent/internal/service/livedebugging"
 	"github.com/grafana/agent/internal/util"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/river"
@@ -834,3 +835,55 @@ func (t *tester) updateAndTest(numLogsToSend int, cfg, expectedMetricsBeforeSend
 		require.NoError(t.t, err)
 	}
 }
+
+func TestLiveDebugging(t *testing.T) {
+	debugging := livedebugging.New()
+	require.NoError(t, debugging.Update(livedebugging.Arguments{Enabled: true, MaxRate: 100}))
+
+	sub, err := debugging.Subscribe("loki.process.test", livedebugging.SubscribeOptions{})
+	require.NoError(t, err)
+	defer sub.Close()
+
+	var stagesCfg struct {
+		Stages []stages.StageConfig `river:"stage,enum"`
+	}
+	require.NoError(t, river.Unmarshal([]byte(`stage.static_labels {
+		values = { env = "prod" }
+	}`), &stagesCfg))
+
+	out := loki.NewLogsReceiver()
+	opts := component.Options{
+		ID:            "loki.process.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+		GetServiceData: func(name string) (interface{}, error) {
+			return debugging, nil
+		},
+	}
+	c, err := New(opts, Arguments{ForwardTo: []loki.LogsReceiver{out}, Stages: stagesCfg.Stages})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	c.receiver.Chan() <- loki.Entry{
+		Labels: model.LabelSet{"job": "test"},
+		Entry:  logproto.Entry{Timestamp: ts, Line: "hello"},
+	}
+	<-out.Chan()
+
+	for _, want := range []livedebugging.Event{
+		{Type: livedebugging.DataTypeLog, Direction: livedebugging.DirectionReceived, Data: `2024-01-02T03:04:05Z {job="test"} hello`},
+		{Type: livedebugging.DataTypeLog, Direction: livedebugging.DirectionEmitted, Data: `2024-01-02T03:04:05Z {env="prod", job="test"} hello`},
+	} {
+		select {
+		case ev := <-sub.Events():
+			ev.Time = time.Time{}
+			require.Equal(t, want, ev)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "failed waiting for live debugging event")
+		}
+	}
+}
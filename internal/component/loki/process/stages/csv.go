@@ -0,0 +1,253 @@
+package stages
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/internal/flow/logging/level"
+)
+
+// Config Errors
+const (
+	ErrCSVColumnsRequired  = "columns are required"
+	ErrCSVInvalidDelimiter = "delimiter must be a single character other than a quote or a line break"
+	ErrCSVInvalidQuote     = "quote must be empty or a single character other than a line break"
+	ErrCSVUnknownColumn    = "expression references an unknown column"
+	ErrEmptyCSVStageSource = "empty source"
+	ErrMalformedCSV        = "malformed csv"
+)
+
+// CSVConfig represents a CSV Stage configuration
+type CSVConfig struct {
+	Columns       []string          `river:"columns,attr"`
+	Header        bool              `river:"header,attr,optional"`
+	Delimiter     string            `river:"delimiter,attr,optional"`
+	Quote         string            `river:"quote,attr,optional"`
+	Expressions   map[string]string `river:"expressions,attr,optional"`
+	Source        *string           `river:"source,attr,optional"`
+	DropMalformed bool              `river:"drop_malformed,attr,optional"`
+}
+
+// DefaultCSVConfig sets the defaults.
+var DefaultCSVConfig = CSVConfig{
+	Delimiter: ",",
+	Quote:     `"`,
+}
+
+// SetToDefault implements river.Defaulter.
+func (c *CSVConfig) SetToDefault() {
+	*c = DefaultCSVConfig
+}
+
+// validateCSVConfig validates a csv config and returns its delimiter and quote
+// characters. quote is 0 if quoting is disabled.
+func validateCSVConfig(c *CSVConfig) (delimiter, quote rune, err error) {
+	if len(c.Columns) == 0 {
+		return 0, 0, errors.New(ErrCSVColumnsRequired)
+	}
+
+	if c.Source != nil && *c.Source == "" {
+		return 0, 0, errors.New(ErrEmptyCSVStageSource)
+	}
+
+	if c.Quote != "" {
+		if utf8.RuneCountInString(c.Quote) != 1 || strings.ContainsAny(c.Quote, "\r\n") {
+			return 0, 0, errors.New(ErrCSVInvalidQuote)
+		}
+		quote, _ = utf8.DecodeRuneInString(c.Quote)
+	}
+
+	if utf8.RuneCountInString(c.Delimiter) != 1 || strings.ContainsAny(c.Delimiter, "\r\n") || c.Delimiter == c.Quote {
+		return 0, 0, errors.New(ErrCSVInvalidDelimiter)
+	}
+	delimiter, _ = utf8.DecodeRuneInString(c.Delimiter)
+
+	for n, col := range c.Expressions {
+		if col == "" {
+			col = n
+		}
+		if slices.Index(c.Columns, col) < 0 {
+			return 0, 0, fmt.Errorf("%s: %q", ErrCSVUnknownColumn, col)
+		}
+	}
+	return delimiter, quote, nil
+}
+
+// csvStage sets extracted data from the columns of CSV log lines
+type csvStage struct {
+	cfg       *CSVConfig
+	delimiter rune
+	quote     rune
+	logger    log.Logger
+}
+
+// newCSVStage creates a new csv pipeline stage from a config.
+func newCSVStage(logger log.Logger, cfg CSVConfig) (Stage, error) {
+	delimiter, quote, err := validateCSVConfig(&cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &csvStage{
+		cfg:       &cfg,
+		delimiter: delimiter,
+		quote:     quote,
+		logger:    log.With(logger, "component", "stage", "type", "csv"),
+	}, nil
+}
+
+func (c *csvStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+		for e := range in {
+			header, err := c.processEntry(e.Extracted, &e.Line)
+			if header || (err != nil && c.cfg.DropMalformed) {
+				continue
+			}
+			out <- e
+		}
+	}()
+	return out
+}
+
+// processEntry extracts the columns of the entry. header is true if the entry
+// is a header line, which must be dropped.
+func (c *csvStage) processEntry(extracted map[string]interface{}, entry *string) (header bool, err error) {
+	// If a source key is provided, the csv stage should process it
+	// from the extracted map, otherwise should fall back to the entry
+	input := entry
+
+	if c.cfg.Source != nil {
+		if _, ok := extracted[*c.cfg.Source]; !ok {
+			if Debug {
+				level.Debug(c.logger).Log("msg", "source does not exist in the set of extracted values", "source", *c.cfg.Source)
+			}
+			return false, nil
+		}
+
+		value, err := getString(extracted[*c.cfg.Source])
+		if err != nil {
+			if Debug {
+				level.Debug(c.logger).Log("msg", "failed to convert source value to string", "source", *c.cfg.Source, "err", err, "type", reflect.TypeOf(extracted[*c.cfg.Source]))
+			}
+			return false, nil
+		}
+
+		input = &value
+	}
+
+	if input == nil {
+		if Debug {
+			level.Debug(c.logger).Log("msg", "cannot parse a nil entry")
+		}
+		return false, nil
+	}
+
+	fields, err := splitCSV(*input, c.delimiter, c.quote)
+	if err != nil {
+		if Debug {
+			level.Debug(c.logger).Log("msg", "failed to parse log line", "err", err)
+		}
+		return false, errors.New(ErrMalformedCSV)
+	}
+
+	// Header lines are recognized by matching the configured columns rather
+	// than by their position, so that they're dropped wherever reading a
+	// stream starts or resumes, and after a file is rotated.
+	columns := c.cfg.Columns
+	if c.cfg.Header && slices.Equal(fields, columns) {
+		return true, nil
+	}
+
+	if len(fields) != len(columns) {
+		if Debug {
+			level.Debug(c.logger).Log("msg", "log line doesn't have the expected number of columns", "expected", len(columns), "actual", len(fields))
+		}
+		return false, errors.New(ErrMalformedCSV)
+	}
+
+	if len(c.cfg.Expressions) == 0 {
+		for i, col := range columns {
+			extracted[col] = fields[i]
+		}
+	} else {
+		for n, col := range c.cfg.Expressions {
+			if col == "" {
+				col = n
+			}
+			// Expressions only reference configured columns.
+			extracted[n] = fields[slices.Index(columns, col)]
+		}
+	}
+	if Debug {
+		level.Debug(c.logger).Log("msg", "extracted data debug in csv stage", "extracted data", fmt.Sprintf("%v", extracted))
+	}
+	return false, nil
+}
+
+// splitCSV splits a CSV record into its fields. Fields starting with quote may
+// contain the delimiter, and a doubled quote inside them stands for a single
+// one. Quoting is disabled if quote is 0.
+func splitCSV(line string, delimiter, quote rune) ([]string, error) {
+	line = strings.TrimSuffix(line, "\r")
+
+	var (
+		fields []string
+		field  strings.Builder
+	)
+	for {
+		if quote == 0 || !strings.HasPrefix(line, string(quote)) {
+			i := strings.IndexRune(line, delimiter)
+			if i < 0 {
+				return append(fields, line), nil
+			}
+			fields = append(fields, line[:i])
+			line = line[i+utf8.RuneLen(delimiter):]
+			continue
+		}
+
+		// Quoted field.
+		line = line[utf8.RuneLen(quote):]
+		field.Reset()
+		for {
+			i := strings.IndexRune(line, quote)
+			if i < 0 {
+				return nil, fmt.Errorf("unterminated quoted field")
+			}
+			field.WriteString(line[:i])
+			line = line[i+utf8.RuneLen(quote):]
+			if strings.HasPrefix(line, string(quote)) {
+				field.WriteRune(quote)
+				line = line[utf8.RuneLen(quote):]
+				continue
+			}
+			break
+		}
+		fields = append(fields, field.String())
+
+		if line == "" {
+			return fields, nil
+		}
+		r, size := utf8.DecodeRuneInString(line)
+		if r != delimiter {
+			return nil, fmt.Errorf("unexpected %q after quoted field", r)
+		}
+		line = line[size:]
+	}
+}
+
+// Name implements Stage
+func (c *csvStage) Name() string {
+	return StageTypeCSV
+}
+
+// Cleanup implements Stage.
+func (*csvStage) Cleanup() {
+	// no-op
+}
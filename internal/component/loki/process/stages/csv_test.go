@@ -0,0 +1,210 @@
+package stages
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/internal/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testCSVRiverMultiStageWithSource = `
+stage.csv {
+    columns     = ["time", "level", "app", "msg"]
+    expressions = { "level" = "", "message" = "msg" }
+}
+stage.csv {
+    columns   = ["module", "function"]
+    delimiter = ":"
+    source    = "message"
+}
+`
+
+var testCSVRiverHeader = `
+stage.csv {
+    columns   = ["time", "level", "msg"]
+    header    = true
+    delimiter = ";"
+    quote     = "'"
+}
+`
+
+func TestPipeline_CSV(t *testing.T) {
+	logger := util.TestFlowLogger(t)
+	pl, err := NewPipeline(logger, loadConfig(testCSVRiverMultiStageWithSource), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, `2024-01-02T03:04:05Z,ERROR,billing,"payments:charge, retried"`, time.Now()))[0]
+	assert.Equal(t, map[string]interface{}{
+		"level":    "ERROR",
+		"message":  "payments:charge, retried",
+		"module":   "payments",
+		"function": "charge, retried",
+	}, out.Extracted)
+}
+
+func TestPipeline_CSVHeader(t *testing.T) {
+	logger := util.TestFlowLogger(t)
+	pl, err := NewPipeline(logger, loadConfig(testCSVRiverHeader), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	app := model.LabelSet{"filename": "/var/log/app.csv"}
+	out := processEntries(pl,
+		newEntry(nil, app, "time;level;msg", time.Now()),
+		newEntry(nil, app, "2024-01-02;INFO;'started; ok'", time.Now()),
+		// The header is repeated after a rotation.
+		newEntry(nil, app, "time;level;msg", time.Now()),
+		newEntry(nil, app, "2024-01-03;WARN;'it''s slow'", time.Now()),
+	)
+
+	// Header lines are dropped.
+	require.Len(t, out, 2)
+	assert.Equal(t, map[string]interface{}{
+		"filename": "/var/log/app.csv",
+		"time":     "2024-01-02",
+		"level":    "INFO",
+		"msg":      "started; ok",
+	}, out[0].Extracted)
+	assert.Equal(t, map[string]interface{}{
+		"filename": "/var/log/app.csv",
+		"time":     "2024-01-03",
+		"level":    "WARN",
+		"msg":      "it's slow",
+	}, out[1].Extracted)
+}
+
+func TestPipeline_CSVHeaderResumedStream(t *testing.T) {
+	logger := util.TestFlowLogger(t)
+	pl, err := NewPipeline(logger, loadConfig(testCSVRiverHeader), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	// Reading the stream resumes after its header, for example after a
+	// restart, so the first line seen is a data line.
+	app := model.LabelSet{"filename": "/var/log/app.csv"}
+	out := processEntries(pl,
+		newEntry(nil, app, "2024-01-02;INFO;started", time.Now()),
+		newEntry(nil, app, "2024-01-02;ERROR;failed", time.Now()),
+	)
+
+	require.Len(t, out, 2)
+	assert.Equal(t, map[string]interface{}{
+		"filename": "/var/log/app.csv",
+		"time":     "2024-01-02",
+		"level":    "INFO",
+		"msg":      "started",
+	}, out[0].Extracted)
+	assert.Equal(t, "ERROR", out[1].Extracted["level"])
+}
+
+func TestCSVConfig_validate(t *testing.T) {
+	t.Parallel()
+	empty := ""
+	tests := map[string]struct {
+		config CSVConfig
+		err    error
+	}{
+		"missing columns": {
+			CSVConfig{Delimiter: ",", Quote: `"`},
+			errors.New(ErrCSVColumnsRequired),
+		},
+		"empty source": {
+			CSVConfig{Columns: []string{"a"}, Delimiter: ",", Quote: `"`, Source: &empty},
+			errors.New(ErrEmptyCSVStageSource),
+		},
+		"multi-character delimiter": {
+			CSVConfig{Columns: []string{"a"}, Delimiter: "::", Quote: `"`},
+			errors.New(ErrCSVInvalidDelimiter),
+		},
+		"delimiter equal to quote": {
+			CSVConfig{Columns: []string{"a"}, Delimiter: `"`, Quote: `"`},
+			errors.New(ErrCSVInvalidDelimiter),
+		},
+		"invalid quote": {
+			CSVConfig{Columns: []string{"a"}, Delimiter: ",", Quote: "\n"},
+			errors.New(ErrCSVInvalidQuote),
+		},
+		"unknown column": {
+			CSVConfig{Columns: []string{"a"}, Delimiter: ",", Quote: `"`, Expressions: map[string]string{"b": ""}},
+			errors.New(ErrCSVUnknownColumn + `: "b"`),
+		},
+		"valid with columns": {
+			CSVConfig{Columns: []string{"a", "b"}, Delimiter: "\t", Expressions: map[string]string{"x": "b"}},
+			nil,
+		},
+		"header without columns": {
+			CSVConfig{Header: true, Delimiter: ",", Quote: `"`},
+			errors.New(ErrCSVColumnsRequired),
+		},
+		"valid with header": {
+			CSVConfig{Columns: []string{"a", "b"}, Header: true, Delimiter: ",", Quote: `"`, Expressions: map[string]string{"x": "b"}},
+			nil,
+		},
+	}
+	for tName, tt := range tests {
+		tt := tt
+		t.Run(tName, func(t *testing.T) {
+			_, _, err := validateCSVConfig(&tt.config)
+			if tt.err == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tt.err.Error())
+		})
+	}
+}
+
+func TestSplitCSV(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		line      string
+		delimiter rune
+		quote     rune
+		expected  []string
+		err       string
+	}{
+		"simple":               {"a,b,c", ',', '"', []string{"a", "b", "c"}, ""},
+		"empty fields":         {",b,", ',', '"', []string{"", "b", ""}, ""},
+		"quoted delimiter":     {`a,"b,c",d`, ',', '"', []string{"a", "b,c", "d"}, ""},
+		"escaped quote":        {`"say ""hi""",x`, ',', '"', []string{`say "hi"`, "x"}, ""},
+		"quote inside field":   {`a"b,c`, ',', '"', []string{`a"b`, "c"}, ""},
+		"quoting disabled":     {`"a,b"`, ',', 0, []string{`"a`, `b"`}, ""},
+		"multi-byte delimiter": {"a│b", '│', '"', []string{"a", "b"}, ""},
+		"carriage return":      {"a,b\r", ',', '"', []string{"a", "b"}, ""},
+		"unterminated quote":   {`a,"b`, ',', '"', nil, "unterminated quoted field"},
+		"text after quote":     {`"a"b,c`, ',', '"', nil, `unexpected 'b' after quoted field`},
+	}
+	for tName, tt := range tests {
+		tt := tt
+		t.Run(tName, func(t *testing.T) {
+			fields, err := splitCSV(tt.line, tt.delimiter, tt.quote)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, fields)
+		})
+	}
+}
+
+func TestValidateCSVDrop(t *testing.T) {
+	logger := util.TestFlowLogger(t)
+	config := DefaultCSVConfig
+	config.Columns = []string{"level", "msg"}
+	config.DropMalformed = true
+	s, err := newCSVStage(logger, config)
+	require.NoError(t, err)
+
+	out := processEntries(s, newEntry(nil, nil, "info,hello", time.Now()))
+	assert.Equal(t, 1, len(out), "stage should have kept one valid csv line but got %v", out)
+
+	out = processEntries(s, newEntry(nil, nil, "info,hello,extra", time.Now()))
+	assert.Equal(t, 0, len(out), "stage should have dropped the line with too many columns but got %v", out)
+
+	out = processEntries(s, newEntry(nil, nil, `info,"hello`, time.Now()))
+	assert.Equal(t, 0, len(out), "stage should have dropped the malformed csv line but got %v", out)
+}
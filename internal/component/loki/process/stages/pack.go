@@ -16,6 +16,10 @@ import (
 	"github.com/prometheus/common/model"
 )
 
+// PackedTimestampKey is the key under which the pack stage stores the
+// original timestamp of the log entry when store_timestamp is set.
+const PackedTimestampKey = "_timestamp"
+
 // Packed keeps track of the labels and log entry.
 type Packed struct {
 	Labels map[string]string `json:",inline"`
@@ -103,6 +107,7 @@ func (w Packed) MarshalJSON() ([]byte, error) {
 type PackConfig struct {
 	Labels          []string `river:"labels,attr"`
 	IngestTimestamp bool     `river:"ingest_timestamp,attr,optional"`
+	StoreTimestamp  bool     `river:"store_timestamp,attr,optional"`
 }
 
 // DefaultPackConfig sets the defaults.
@@ -162,6 +167,11 @@ func (m *packStage) pack(e Entry) Entry {
 		}
 	}
 
+	// Store the original timestamp so that the unpack stage can restore it
+	if m.cfg.StoreTimestamp {
+		packedLabels[PackedTimestampKey] = e.Timestamp.Format(time.RFC3339Nano)
+	}
+
 	// Embed the extracted labels into the wrapper object
 	w := Packed{
 		Labels: packedLabels,
@@ -138,6 +138,34 @@ func TestPackStage(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "store timestamp",
+			config: &PackConfig{
+				Labels:          []string{"foo"},
+				IngestTimestamp: true,
+				StoreTimestamp:  true,
+			},
+			inputEntry: Entry{
+				Extracted: map[string]interface{}{},
+				Entry: loki.Entry{
+					Labels: model.LabelSet{
+						"foo": "bar",
+					},
+					Entry: logproto.Entry{
+						Timestamp: time.Unix(1, 0).UTC(),
+						Line:      "test line 1",
+					},
+				},
+			},
+			expectedEntry: Entry{
+				Entry: loki.Entry{
+					Labels: model.LabelSet{},
+					Entry: logproto.Entry{
+						Line: "{\"" + PackedTimestampKey + "\":\"1970-01-01T00:00:01Z\",\"foo\":\"bar\",\"" + logqlmodel.PackedEntryKey + "\":\"test line 1\"}",
+					},
+				},
+			},
+		},
 		{
 			name: "match one supplied label",
 			config: &PackConfig{
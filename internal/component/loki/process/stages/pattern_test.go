@@ -0,0 +1,236 @@
+package stages
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/internal/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testPatternRiverMultiStageWithSource = `
+stage.pattern {
+    pattern = "<ip> <identd> <user> [<timestamp>] \"<action> <path> <protocol>\" <status> <size> \"<referer>\" \"<useragent>\""
+}
+stage.pattern {
+    pattern = "HTTP/<protocol_version>"
+    source  = "protocol"
+}
+`
+
+var testPatternRiverSourceWithMissingKey = `
+stage.json {
+    expressions = { "time" = "" }
+}
+stage.pattern {
+    pattern = "<year>-<_>"
+    source  = "time"
+}
+`
+
+var testPatternApacheCommonLog = `<ip> <identd> <user> [<timestamp>] "<action> <path> <protocol>" <status> <size> "<referer>" "<useragent>"`
+
+func TestPipeline_Pattern(t *testing.T) {
+	logger := util.TestFlowLogger(t)
+	pl, err := NewPipeline(logger, loadConfig(testPatternRiverMultiStageWithSource), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, testRegexLogLine, time.Now()))[0]
+	assert.Equal(t, map[string]interface{}{
+		"ip":               "11.11.11.11",
+		"identd":           "-",
+		"user":             "frank",
+		"timestamp":        "25/Jan/2000:14:00:01 -0500",
+		"action":           "GET",
+		"path":             "/1986.js",
+		"protocol":         "HTTP/1.1",
+		"protocol_version": "1.1",
+		"status":           "200",
+		"size":             "932",
+		"referer":          "-",
+		"useragent":        "Mozilla/5.0 (Windows; U; Windows NT 5.1; de; rv:1.9.1.7) Gecko/20091221 Firefox/3.5.7 GTB6",
+	}, out.Extracted)
+}
+
+func TestPipelineWithMissingKey_Pattern(t *testing.T) {
+	var buf bytes.Buffer
+	w := log.NewSyncWriter(&buf)
+	logger := log.NewLogfmtLogger(w)
+	pl, err := NewPipeline(logger, loadConfig(testPatternRiverSourceWithMissingKey), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+	_ = processEntries(pl, newEntry(nil, nil, testRegexLogLineWithMissingKey, time.Now()))[0]
+
+	expectedLog := "level=debug component=stage type=pattern msg=\"failed to convert source value to string\" source=time err=\"Can't convert <nil> to string\" type=null"
+	if !(strings.Contains(buf.String(), expectedLog)) {
+		t.Errorf("\nexpected: %s\n+actual: %s", expectedLog, buf.String())
+	}
+}
+
+func TestPatternConfig_validate(t *testing.T) {
+	t.Parallel()
+	empty := ""
+	source := "log"
+	tests := map[string]struct {
+		config PatternConfig
+		err    error
+	}{
+		"missing pattern": {
+			PatternConfig{},
+			ErrPatternRequired,
+		},
+		"pattern without captures": {
+			PatternConfig{Pattern: "foo bar"},
+			errors.New(ErrCouldNotParsePattern.Error() + ": at least one capture is required"),
+		},
+		"consecutive captures": {
+			PatternConfig{Pattern: "<foo><bar>"},
+			errors.New(ErrCouldNotParsePattern.Error() + ": found consecutive capture '<foo><bar>': invalid expression"),
+		},
+		"empty source": {
+			PatternConfig{Pattern: "<ts> <_>", Source: &empty},
+			ErrEmptyPatternStageSource,
+		},
+		"valid without source": {
+			PatternConfig{Pattern: "<ts> <_>"},
+			nil,
+		},
+		"valid with source": {
+			PatternConfig{Pattern: "<ts> <_>", Source: &source},
+			nil,
+		},
+	}
+	for tName, tt := range tests {
+		tt := tt
+		t.Run(tName, func(t *testing.T) {
+			_, err := validatePatternConfig(tt.config)
+			if tt.err == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tt.err.Error())
+		})
+	}
+}
+
+func TestPatternParser_Parse(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		config          PatternConfig
+		extracted       map[string]interface{}
+		entry           string
+		expectedExtract map[string]interface{}
+	}{
+		"successfully match pattern on entry": {
+			PatternConfig{Pattern: `<ip> <_> <user> [<_>] "<action> <path> <_>" <status> <_>`},
+			map[string]interface{}{},
+			regexLogFixture,
+			map[string]interface{}{
+				"ip":     "11.11.11.11",
+				"user":   "frank",
+				"action": "GET",
+				"path":   "/1986.js",
+				"status": "200",
+			},
+		},
+		"successfully match pattern on extracted[source]": {
+			PatternConfig{Pattern: "HTTP/<protocol_version>", Source: &protocolStr},
+			map[string]interface{}{
+				"protocol": "HTTP/1.1",
+			},
+			regexLogFixture,
+			map[string]interface{}{
+				"protocol":         "HTTP/1.1",
+				"protocol_version": "1.1",
+			},
+		},
+		"capture takes the rest of the line when the next literal is missing": {
+			PatternConfig{Pattern: "<level> [<component>] <msg>"},
+			map[string]interface{}{},
+			"error something went wrong",
+			map[string]interface{}{
+				"level": "error something went wrong",
+			},
+		},
+		"failed to match leading literal": {
+			PatternConfig{Pattern: "HTTP/<protocol_version>", Source: &protocolStr},
+			map[string]interface{}{
+				"protocol": "unknown",
+			},
+			"unknown/unknown",
+			map[string]interface{}{
+				"protocol": "unknown",
+			},
+		},
+		"missing extracted[source]": {
+			PatternConfig{Pattern: "HTTP/<protocol_version>", Source: &protocolStr},
+			map[string]interface{}{},
+			"blahblahblah",
+			map[string]interface{}{},
+		},
+		"invalid data type in extracted[source]": {
+			PatternConfig{Pattern: "HTTP/<protocol_version>", Source: &protocolStr},
+			map[string]interface{}{
+				"protocol": true,
+			},
+			"unknown/unknown",
+			map[string]interface{}{
+				"protocol": true,
+			},
+		},
+	}
+	for tName, tt := range tests {
+		tt := tt
+		t.Run(tName, func(t *testing.T) {
+			t.Parallel()
+			logger := util.TestFlowLogger(t)
+			p, err := New(logger, nil, StageConfig{PatternConfig: &tt.config}, nil)
+			require.NoError(t, err)
+			out := processEntries(p, newEntry(tt.extracted, nil, tt.entry, time.Now()))[0]
+			assert.Equal(t, tt.expectedExtract, out.Extracted)
+		})
+	}
+}
+
+// BenchmarkPatternStage parses the same log line as BenchmarkRegexStage.
+func BenchmarkPatternStage(b *testing.B) {
+	benchmarks := []struct {
+		name   string
+		config PatternConfig
+		entry  string
+	}{
+		{"apache common log",
+			PatternConfig{Pattern: testPatternApacheCommonLog},
+			regexLogFixture,
+		},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			logger := util.TestFlowLogger(b)
+			stage, err := New(logger, nil, StageConfig{PatternConfig: &bm.config}, nil)
+			if err != nil {
+				panic(err)
+			}
+			labels := model.LabelSet{}
+			ts := time.Now()
+			extr := map[string]interface{}{}
+
+			in := make(chan Entry)
+			out := stage.Run(in)
+			go func() {
+				for range out {
+				}
+			}()
+			for i := 0; i < b.N; i++ {
+				in <- newEntry(extr, labels, bm.entry, ts)
+			}
+			close(in)
+		})
+	}
+}
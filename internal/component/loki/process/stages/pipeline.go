@@ -17,6 +17,7 @@ import (
 type StageConfig struct {
 	//TODO(thampiotr): sync these with new stages
 	CRIConfig             *CRIConfig             `river:"cri,block,optional"`
+	CSVConfig             *CSVConfig             `river:"csv,block,optional"`
 	DecolorizeConfig      *DecolorizeConfig      `river:"decolorize,block,optional"`
 	DockerConfig          *DockerConfig          `river:"docker,block,optional"`
 	DropConfig            *DropConfig            `river:"drop,block,optional"`
@@ -34,6 +35,7 @@ type StageConfig struct {
 	MultilineConfig       *MultilineConfig       `river:"multiline,block,optional"`
 	OutputConfig          *OutputConfig          `river:"output,block,optional"`
 	PackConfig            *PackConfig            `river:"pack,block,optional"`
+	PatternConfig         *PatternConfig         `river:"pattern,block,optional"`
 	RegexConfig           *RegexConfig           `river:"regex,block,optional"`
 	ReplaceConfig         *ReplaceConfig         `river:"replace,block,optional"`
 	StaticLabelsConfig    *StaticLabelsConfig    `river:"static_labels,block,optional"`
@@ -42,6 +44,8 @@ type StageConfig struct {
 	TemplateConfig        *TemplateConfig        `river:"template,block,optional"`
 	TenantConfig          *TenantConfig          `river:"tenant,block,optional"`
 	TimestampConfig       *TimestampConfig       `river:"timestamp,block,optional"`
+	UnpackConfig          *UnpackConfig          `river:"unpack,block,optional"`
+	XMLConfig             *XMLConfig             `river:"xml,block,optional"`
 }
 
 var rateLimiter *rate.Limiter
@@ -16,6 +16,7 @@ import (
 // TODO(@tpaschalis) Let's use this as the list of stages we need to port over.
 const (
 	StageTypeCRI        = "cri"
+	StageTypeCSV        = "csv"
 	StageTypeDecolorize = "decolorize"
 	StageTypeDocker     = "docker"
 	StageTypeDrop       = "drop"
@@ -34,6 +35,7 @@ const (
 	StageTypeMultiline          = "multiline"
 	StageTypeOutput             = "output"
 	StageTypePack               = "pack"
+	StageTypePattern            = "pattern"
 	StageTypePipeline           = "pipeline"
 	StageTypeRegex              = "regex"
 	StageTypeReplace            = "replace"
@@ -43,6 +45,8 @@ const (
 	StageTypeTemplate           = "template"
 	StageTypeTenant             = "tenant"
 	StageTypeTimestamp          = "timestamp"
+	StageTypeUnpack             = "unpack"
+	StageTypeXML                = "xml"
 )
 
 // Processor takes an existing set of labels, timestamp and log entry and returns either a possibly mutated
@@ -133,6 +137,16 @@ func New(logger log.Logger, jobName *string, cfg StageConfig, registerer prometh
 		if err != nil {
 			return nil, err
 		}
+	case cfg.CSVConfig != nil:
+		s, err = newCSVStage(logger, *cfg.CSVConfig)
+		if err != nil {
+			return nil, err
+		}
+	case cfg.XMLConfig != nil:
+		s, err = newXMLStage(logger, *cfg.XMLConfig)
+		if err != nil {
+			return nil, err
+		}
 	case cfg.LogfmtConfig != nil:
 		s, err = newLogfmtStage(logger, *cfg.LogfmtConfig)
 		if err != nil {
@@ -210,6 +224,13 @@ func New(logger log.Logger, jobName *string, cfg StageConfig, registerer prometh
 		}
 	case cfg.PackConfig != nil:
 		s = newPackStage(logger, *cfg.PackConfig, registerer)
+	case cfg.PatternConfig != nil:
+		s, err = newPatternStage(logger, *cfg.PatternConfig)
+		if err != nil {
+			return nil, err
+		}
+	case cfg.UnpackConfig != nil:
+		s = newUnpackStage(logger, *cfg.UnpackConfig)
 	case cfg.LabelAllowConfig != nil:
 		s, err = newLabelAllowStage(*cfg.LabelAllowConfig)
 		if err != nil {
@@ -0,0 +1,134 @@
+package stages
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/internal/flow/logging/level"
+	"github.com/grafana/loki/pkg/logqlmodel"
+	json "github.com/json-iterator/go"
+	"github.com/prometheus/common/model"
+)
+
+// UnpackConfig contains the configuration for an unpackStage
+type UnpackConfig struct {
+	Labels           []string `river:"labels,attr,optional"`
+	RestoreTimestamp bool     `river:"restore_timestamp,attr,optional"`
+}
+
+// DefaultUnpackConfig sets the defaults.
+var DefaultUnpackConfig = UnpackConfig{
+	RestoreTimestamp: true,
+}
+
+// SetToDefault implements river.Defaulter.
+func (u *UnpackConfig) SetToDefault() {
+	*u = DefaultUnpackConfig
+}
+
+// newUnpackStage creates an unpackStage from config
+func newUnpackStage(logger log.Logger, config UnpackConfig) Stage {
+	var allowlist map[string]struct{}
+	if len(config.Labels) > 0 {
+		allowlist = make(map[string]struct{}, len(config.Labels))
+		for _, l := range config.Labels {
+			allowlist[l] = struct{}{}
+		}
+	}
+	return &unpackStage{
+		logger:    log.With(logger, "component", "stage", "type", "unpack"),
+		cfg:       &config,
+		allowlist: allowlist,
+	}
+}
+
+// unpackStage restores the log line, labels and timestamp of log entries
+// packed by a packStage
+type unpackStage struct {
+	logger    log.Logger
+	cfg       *UnpackConfig
+	allowlist map[string]struct{}
+}
+
+func (m *unpackStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+		for e := range in {
+			out <- m.unpack(e)
+		}
+	}()
+	return out
+}
+
+func (m *unpackStage) unpack(e Entry) Entry {
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(e.Line), &obj); err != nil {
+		level.Debug(m.logger).Log("msg", "log line is not a packed JSON object, unpacking will be skipped", "err", err)
+		return e
+	}
+	line, ok := obj[logqlmodel.PackedEntryKey].(string)
+	if !ok {
+		level.Debug(m.logger).Log("msg", fmt.Sprintf("log line has no string '%s' key, unpacking will be skipped", logqlmodel.PackedEntryKey))
+		return e
+	}
+
+	if e.Extracted == nil {
+		e.Extracted = map[string]interface{}{}
+	}
+	if e.Labels == nil {
+		e.Labels = model.LabelSet{}
+	}
+
+	for k, v := range obj {
+		if k == logqlmodel.PackedEntryKey {
+			continue
+		}
+		sv, ok := v.(string)
+		if !ok {
+			level.Debug(m.logger).Log("msg", fmt.Sprintf("value for key: '%s' is not a string and cannot be unpacked", k))
+			continue
+		}
+
+		if k == PackedTimestampKey {
+			if m.cfg.RestoreTimestamp {
+				ts, err := time.Parse(time.RFC3339Nano, sv)
+				if err != nil {
+					level.Debug(m.logger).Log("msg", "failed to parse packed timestamp, the timestamp will not be restored", "err", err)
+				} else {
+					e.Timestamp = ts
+				}
+			}
+			continue
+		}
+
+		// Every unpacked value can be used by later stages, but only the
+		// allowed ones are restored as labels.
+		e.Extracted[k] = sv
+		if m.allowlist != nil {
+			if _, ok := m.allowlist[k]; !ok {
+				continue
+			}
+		}
+		name, value := model.LabelName(k), model.LabelValue(sv)
+		if !name.IsValid() || !value.IsValid() {
+			level.Debug(m.logger).Log("msg", fmt.Sprintf("key: '%s' is not a valid label and will only be extracted", k))
+			continue
+		}
+		e.Labels[name] = value
+	}
+
+	e.Line = line
+	return e
+}
+
+// Name implements Stage
+func (m *unpackStage) Name() string {
+	return StageTypeUnpack
+}
+
+// Cleanup implements Stage.
+func (*unpackStage) Cleanup() {
+	// no-op
+}
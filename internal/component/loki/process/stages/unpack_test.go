@@ -0,0 +1,147 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/internal/component/common/loki"
+	"github.com/grafana/agent/internal/util"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testUnpackRiver = `
+stage.pack {
+		labels           = ["pod", "container"]
+		ingest_timestamp = true
+		store_timestamp  = true
+}
+stage.unpack {
+		labels = ["pod"]
+}`
+
+// TestUnpackPipeline verifies that entries packed by the pack stage are
+// restored by the unpack stage.
+func TestUnpackPipeline(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	plName := "test_unpack_pipeline"
+	logger := util.TestFlowLogger(t)
+	pl, err := NewPipeline(logger, loadConfig(testUnpackRiver), &plName, registry)
+	require.NoError(t, err)
+
+	lbls := model.LabelSet{
+		"pod":       "foo-xsfs3",
+		"container": "foo",
+		"namespace": "dev",
+	}
+	testTime := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	out := processEntries(pl, newEntry(nil, lbls, testMatchLogLineApp1, testTime))[0]
+
+	// Only the allowed label is restored, but both are extracted.
+	assert.Equal(t, model.LabelSet{"pod": "foo-xsfs3", "namespace": "dev"}, out.Labels)
+	assert.Equal(t, "foo", out.Extracted["container"])
+	assert.Equal(t, testMatchLogLineApp1, out.Line)
+	assert.True(t, testTime.Equal(out.Timestamp))
+}
+
+func TestUnpackStage(t *testing.T) {
+	testTime := time.Unix(1, 0)
+
+	tests := []struct {
+		name          string
+		config        UnpackConfig
+		line          string
+		expectedLine  string
+		expectedLbls  model.LabelSet
+		expectedTime  time.Time
+		expectedExtra map[string]interface{}
+	}{
+		{
+			name:          "all labels",
+			config:        DefaultUnpackConfig,
+			line:          `{"env":"dev","user_id":"f8fas0r","_entry":"something went wrong"}`,
+			expectedLine:  "something went wrong",
+			expectedLbls:  model.LabelSet{"job": "app", "env": "dev", "user_id": "f8fas0r"},
+			expectedTime:  testTime,
+			expectedExtra: map[string]interface{}{"env": "dev", "user_id": "f8fas0r"},
+		},
+		{
+			name:          "label allowlist",
+			config:        UnpackConfig{Labels: []string{"env"}, RestoreTimestamp: true},
+			line:          `{"env":"dev","user_id":"f8fas0r","_entry":"something went wrong"}`,
+			expectedLine:  "something went wrong",
+			expectedLbls:  model.LabelSet{"job": "app", "env": "dev"},
+			expectedTime:  testTime,
+			expectedExtra: map[string]interface{}{"env": "dev", "user_id": "f8fas0r"},
+		},
+		{
+			name:          "restore timestamp",
+			config:        DefaultUnpackConfig,
+			line:          `{"_timestamp":"2024-01-02T03:04:05.000000006Z","_entry":"line"}`,
+			expectedLine:  "line",
+			expectedLbls:  model.LabelSet{"job": "app"},
+			expectedTime:  time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+			expectedExtra: map[string]interface{}{},
+		},
+		{
+			name:          "keep timestamp",
+			config:        UnpackConfig{RestoreTimestamp: false},
+			line:          `{"_timestamp":"2024-01-02T03:04:05.000000006Z","_entry":"line"}`,
+			expectedLine:  "line",
+			expectedLbls:  model.LabelSet{"job": "app"},
+			expectedTime:  testTime,
+			expectedExtra: map[string]interface{}{},
+		},
+		{
+			name:          "invalid label name and non-string value",
+			config:        DefaultUnpackConfig,
+			line:          `{"not-a-label":"v","count":3,"_entry":"line"}`,
+			expectedLine:  "line",
+			expectedLbls:  model.LabelSet{"job": "app"},
+			expectedTime:  testTime,
+			expectedExtra: map[string]interface{}{"not-a-label": "v"},
+		},
+		{
+			name:          "not packed",
+			config:        DefaultUnpackConfig,
+			line:          `{"env":"dev","msg":"something went wrong"}`,
+			expectedLine:  `{"env":"dev","msg":"something went wrong"}`,
+			expectedLbls:  model.LabelSet{"job": "app"},
+			expectedTime:  testTime,
+			expectedExtra: map[string]interface{}{},
+		},
+		{
+			name:          "not JSON",
+			config:        DefaultUnpackConfig,
+			line:          "something went wrong",
+			expectedLine:  "something went wrong",
+			expectedLbls:  model.LabelSet{"job": "app"},
+			expectedTime:  testTime,
+			expectedExtra: map[string]interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newUnpackStage(util.TestFlowLogger(t), tt.config)
+			in := Entry{
+				Extracted: map[string]interface{}{},
+				Entry: loki.Entry{
+					Labels: model.LabelSet{"job": "app"},
+					Entry: logproto.Entry{
+						Timestamp: testTime,
+						Line:      tt.line,
+					},
+				},
+			}
+			out := processEntries(s, in)[0]
+			assert.Equal(t, tt.expectedLine, out.Line)
+			assert.Equal(t, tt.expectedLbls, out.Labels)
+			assert.True(t, tt.expectedTime.Equal(out.Timestamp))
+			assert.Equal(t, tt.expectedExtra, out.Extracted)
+		})
+	}
+}
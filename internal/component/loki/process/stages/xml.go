@@ -0,0 +1,464 @@
+package stages
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/internal/flow/logging/level"
+)
+
+// Config Errors
+const (
+	ErrXMLExpressionsRequired = "XPath expression is required"
+	ErrCouldNotCompileXPath   = "could not compile XPath expression"
+	ErrEmptyXMLStageSource    = "empty source"
+	ErrMalformedXML           = "malformed xml"
+)
+
+// XMLConfig represents an XML Stage configuration
+type XMLConfig struct {
+	Expressions   map[string]string `river:"expressions,attr"`
+	Source        *string           `river:"source,attr,optional"`
+	DropMalformed bool              `river:"drop_malformed,attr,optional"`
+}
+
+// validateXMLConfig validates an xml config and returns a map of compiled
+// XPath expressions.
+func validateXMLConfig(c *XMLConfig) (map[string]xpathExpr, error) {
+	if len(c.Expressions) == 0 {
+		return nil, errors.New(ErrXMLExpressionsRequired)
+	}
+
+	if c.Source != nil && *c.Source == "" {
+		return nil, errors.New(ErrEmptyXMLStageSource)
+	}
+
+	expressions := map[string]xpathExpr{}
+
+	for n, e := range c.Expressions {
+		var err error
+		path := e
+		// If there is no expression, use the name as the expression.
+		if e == "" {
+			path = n
+		}
+		expressions[n], err = compileXPath(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ErrCouldNotCompileXPath, err)
+		}
+	}
+	return expressions, nil
+}
+
+// xmlStage sets extracted data using XPath expressions
+type xmlStage struct {
+	cfg         *XMLConfig
+	expressions map[string]xpathExpr
+	logger      log.Logger
+}
+
+// newXMLStage creates a new xml pipeline stage from a config.
+func newXMLStage(logger log.Logger, cfg XMLConfig) (Stage, error) {
+	expressions, err := validateXMLConfig(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &xmlStage{
+		cfg:         &cfg,
+		expressions: expressions,
+		logger:      log.With(logger, "component", "stage", "type", "xml"),
+	}, nil
+}
+
+func (x *xmlStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+		for e := range in {
+			err := x.processEntry(e.Extracted, &e.Line)
+			if err != nil && x.cfg.DropMalformed {
+				continue
+			}
+			out <- e
+		}
+	}()
+	return out
+}
+
+func (x *xmlStage) processEntry(extracted map[string]interface{}, entry *string) error {
+	// If a source key is provided, the xml stage should process it
+	// from the extracted map, otherwise should fall back to the entry
+	input := entry
+
+	if x.cfg.Source != nil {
+		if _, ok := extracted[*x.cfg.Source]; !ok {
+			if Debug {
+				level.Debug(x.logger).Log("msg", "source does not exist in the set of extracted values", "source", *x.cfg.Source)
+			}
+			return nil
+		}
+
+		value, err := getString(extracted[*x.cfg.Source])
+		if err != nil {
+			if Debug {
+				level.Debug(x.logger).Log("msg", "failed to convert source value to string", "source", *x.cfg.Source, "err", err, "type", reflect.TypeOf(extracted[*x.cfg.Source]))
+			}
+			return nil
+		}
+
+		input = &value
+	}
+
+	if input == nil {
+		if Debug {
+			level.Debug(x.logger).Log("msg", "cannot parse a nil entry")
+		}
+		return nil
+	}
+
+	doc, err := parseXML(*input)
+	if err != nil {
+		if Debug {
+			level.Debug(x.logger).Log("msg", "failed to unmarshal log line", "err", err)
+		}
+		return errors.New(ErrMalformedXML)
+	}
+
+	for n, e := range x.expressions {
+		if v, ok := e.evaluate(doc); ok {
+			extracted[n] = v
+		}
+	}
+	if Debug {
+		level.Debug(x.logger).Log("msg", "extracted data debug in xml stage", "extracted data", fmt.Sprintf("%v", extracted))
+	}
+	return nil
+}
+
+// Name implements Stage
+func (x *xmlStage) Name() string {
+	return StageTypeXML
+}
+
+// Cleanup implements Stage.
+func (*xmlStage) Cleanup() {
+	// no-op
+}
+
+// xmlNode is an element of a parsed XML document, or a text node if name is
+// empty.
+type xmlNode struct {
+	name     string
+	attrs    []xml.Attr
+	text     string
+	children []*xmlNode
+}
+
+// parseXML parses an XML document into a tree, whose root is the document
+// node. Namespaces are ignored, and comments, processing instructions and
+// directives are dropped.
+func parseXML(s string) (*xmlNode, error) {
+	doc := &xmlNode{}
+	stack := []*xmlNode{doc}
+
+	d := xml.NewDecoder(strings.NewReader(s))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		parent := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{name: t.Name.Local, attrs: t.Attr}
+			parent.children = append(parent.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if parent == doc {
+				if len(strings.TrimSpace(string(t))) > 0 {
+					return nil, errors.New("text outside of the root element")
+				}
+				continue
+			}
+			parent.children = append(parent.children, &xmlNode{text: string(t)})
+		}
+	}
+	if len(doc.children) == 0 {
+		return nil, errors.New("no root element")
+	}
+	return doc, nil
+}
+
+// textContent returns the concatenation of the text nodes under n, or of the
+// direct text children of n only if direct is true.
+func (n *xmlNode) textContent(direct bool) string {
+	var sb strings.Builder
+	var walk func(n *xmlNode)
+	walk = func(n *xmlNode) {
+		for _, c := range n.children {
+			if c.name == "" {
+				sb.WriteString(c.text)
+			} else if !direct {
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	return strings.TrimSpace(sb.String())
+}
+
+func (n *xmlNode) attr(name string) (string, bool) {
+	for _, a := range n.attrs {
+		if a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// xpathExpr is a compiled expression of the XPath subset supported by the xml
+// stage: location paths of element names or *, separated by / or //, each
+// optionally followed by [n], [@attr] or [@attr='value'] predicates, and
+// optionally ending with @attr or text().
+type xpathExpr struct {
+	steps []xpathStep
+	// attr is the attribute selected by the last step, if any.
+	attr string
+	// text is true if the last step is text().
+	text bool
+}
+
+type xpathStep struct {
+	descendant bool
+	name       string
+	predicates []xpathPredicate
+}
+
+// xpathPredicate filters the nodes selected by a step: by position if index is
+// set, otherwise by attribute.
+type xpathPredicate struct {
+	index    int
+	attr     string
+	value    string
+	hasValue bool
+}
+
+func compileXPath(path string) (xpathExpr, error) {
+	var expr xpathExpr
+
+	// Relative paths are evaluated from the document node, like absolute ones.
+	rest := path
+	descendant := false
+	if strings.HasPrefix(rest, "//") {
+		rest, descendant = rest[2:], true
+	} else {
+		rest = strings.TrimPrefix(rest, "/")
+	}
+
+	for {
+		if rest == "" {
+			return xpathExpr{}, fmt.Errorf("empty step in %q", path)
+		}
+
+		var step string
+		i := indexOutsideBrackets(rest, '/')
+		if i < 0 {
+			step, rest = rest, ""
+		} else {
+			step, rest = rest[:i], rest[i+1:]
+		}
+		last := rest == "" && i < 0
+
+		switch {
+		case strings.HasPrefix(step, "@"):
+			if !last || descendant {
+				return xpathExpr{}, fmt.Errorf("attribute %q must be the last step of %q", step, path)
+			}
+			if len(expr.steps) == 0 {
+				return xpathExpr{}, fmt.Errorf("attribute %q must follow an element in %q", step, path)
+			}
+			expr.attr = step[1:]
+			if expr.attr == "" {
+				return xpathExpr{}, fmt.Errorf("empty attribute name in %q", path)
+			}
+		case step == "text()":
+			if !last || descendant {
+				return xpathExpr{}, fmt.Errorf("text() must be the last step of %q", path)
+			}
+			if len(expr.steps) == 0 {
+				return xpathExpr{}, fmt.Errorf("text() must follow an element in %q", path)
+			}
+			expr.text = true
+		default:
+			s, err := compileXPathStep(step)
+			if err != nil {
+				return xpathExpr{}, fmt.Errorf("%w in %q", err, path)
+			}
+			s.descendant = descendant
+			expr.steps = append(expr.steps, s)
+		}
+
+		if last {
+			return expr, nil
+		}
+		descendant = false
+		if strings.HasPrefix(rest, "/") {
+			rest, descendant = rest[1:], true
+		}
+	}
+}
+
+func compileXPathStep(step string) (xpathStep, error) {
+	name := step
+	var preds []xpathPredicate
+	if i := strings.IndexByte(step, '['); i >= 0 {
+		name = step[:i]
+		rest := step[i:]
+		for rest != "" {
+			if rest[0] != '[' {
+				return xpathStep{}, fmt.Errorf("unexpected %q after predicate", rest)
+			}
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return xpathStep{}, fmt.Errorf("unterminated predicate %q", rest)
+			}
+			p, err := compileXPathPredicate(rest[1:end])
+			if err != nil {
+				return xpathStep{}, err
+			}
+			preds = append(preds, p)
+			rest = rest[end+1:]
+		}
+	}
+	if name == "" {
+		return xpathStep{}, fmt.Errorf("empty element name in step %q", step)
+	}
+	if name != "*" && strings.ContainsAny(name, "@()=' \"") {
+		return xpathStep{}, fmt.Errorf("invalid element name %q", name)
+	}
+	return xpathStep{name: name, predicates: preds}, nil
+}
+
+func compileXPathPredicate(p string) (xpathPredicate, error) {
+	if n, err := strconv.Atoi(p); err == nil {
+		if n < 1 {
+			return xpathPredicate{}, fmt.Errorf("position %d must be at least 1", n)
+		}
+		return xpathPredicate{index: n}, nil
+	}
+	if !strings.HasPrefix(p, "@") {
+		return xpathPredicate{}, fmt.Errorf("unsupported predicate [%s]", p)
+	}
+	attr, value, hasValue := strings.Cut(p[1:], "=")
+	if attr == "" {
+		return xpathPredicate{}, fmt.Errorf("empty attribute name in predicate [%s]", p)
+	}
+	if hasValue {
+		if len(value) < 2 || (value[0] != '\'' && value[0] != '"') || value[len(value)-1] != value[0] {
+			return xpathPredicate{}, fmt.Errorf("attribute value must be quoted in predicate [%s]", p)
+		}
+		value = value[1 : len(value)-1]
+	}
+	return xpathPredicate{attr: attr, value: value, hasValue: hasValue}, nil
+}
+
+// indexOutsideBrackets returns the index of the first c in s which isn't
+// inside a predicate, or -1.
+func indexOutsideBrackets(s string, c byte) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case c:
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// evaluate returns the string value of the first node selected by e in doc.
+func (e xpathExpr) evaluate(doc *xmlNode) (string, bool) {
+	nodes := []*xmlNode{doc}
+	for _, s := range e.steps {
+		var next []*xmlNode
+		for _, n := range nodes {
+			next = append(next, s.selectFrom(n)...)
+		}
+		nodes = next
+	}
+
+	for _, n := range nodes {
+		switch {
+		case e.attr != "":
+			if v, ok := n.attr(e.attr); ok {
+				return v, true
+			}
+		case e.text:
+			return n.textContent(true), true
+		default:
+			return n.textContent(false), true
+		}
+	}
+	return "", false
+}
+
+// selectFrom returns the elements selected by s under n, in document order.
+func (s xpathStep) selectFrom(n *xmlNode) []*xmlNode {
+	var res []*xmlNode
+	var candidates []*xmlNode
+	for _, c := range n.children {
+		if c.name == "" {
+			continue
+		}
+		if s.name == "*" || c.name == s.name {
+			candidates = append(candidates, c)
+		}
+	}
+	res = append(res, s.filter(candidates)...)
+
+	if s.descendant {
+		for _, c := range n.children {
+			if c.name != "" {
+				res = append(res, s.selectFrom(c)...)
+			}
+		}
+	}
+	return res
+}
+
+// filter applies the predicates of s to the elements it selected under the
+// same parent.
+func (s xpathStep) filter(nodes []*xmlNode) []*xmlNode {
+	for _, p := range s.predicates {
+		var res []*xmlNode
+		for i, n := range nodes {
+			if p.index > 0 {
+				if i+1 == p.index {
+					res = append(res, n)
+				}
+				continue
+			}
+			if v, ok := n.attr(p.attr); ok && (!p.hasValue || v == p.value) {
+				res = append(res, n)
+			}
+		}
+		nodes = res
+	}
+	return nodes
+}
@@ -0,0 +1,164 @@
+package stages
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/internal/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testXMLRiverMultiStageWithSource = `
+stage.xml {
+    expressions = {
+        "level"    = "/event/@level",
+        "provider" = "//System/Provider/@Name",
+        "id"       = "/event/System/EventID",
+        "user"     = "//Data[@Name='TargetUserName']",
+        "first"    = "//Data[1]",
+        "message"  = "/event/Message",
+    }
+}
+stage.xml {
+    expressions = { "code" = "error/text()" }
+    source      = "message"
+}
+`
+
+var testXMLLogLine = `<?xml version="1.0"?>
+<event xmlns="http://schemas.microsoft.com/win/2004/08/events/event" level="warning">
+  <!-- comment -->
+  <System>
+    <Provider Name="Microsoft-Windows-Security-Auditing"/>
+    <EventID>4625</EventID>
+  </System>
+  <EventData>
+    <Data Name="SubjectUserName">-</Data>
+    <Data Name="TargetUserName">frank</Data>
+  </EventData>
+  <Message>&lt;error&gt;0xC000006D&lt;/error&gt;</Message>
+</event>`
+
+func TestPipeline_XML(t *testing.T) {
+	logger := util.TestFlowLogger(t)
+	pl, err := NewPipeline(logger, loadConfig(testXMLRiverMultiStageWithSource), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, testXMLLogLine, time.Now()))[0]
+	assert.Equal(t, map[string]interface{}{
+		"level":    "warning",
+		"provider": "Microsoft-Windows-Security-Auditing",
+		"id":       "4625",
+		"user":     "frank",
+		"first":    "-",
+		"message":  "<error>0xC000006D</error>",
+		"code":     "0xC000006D",
+	}, out.Extracted)
+}
+
+func TestXMLConfig_validate(t *testing.T) {
+	t.Parallel()
+	empty := ""
+	tests := map[string]struct {
+		config XMLConfig
+		err    error
+	}{
+		"missing expressions": {
+			XMLConfig{},
+			errors.New(ErrXMLExpressionsRequired),
+		},
+		"empty source": {
+			XMLConfig{Expressions: map[string]string{"a": ""}, Source: &empty},
+			errors.New(ErrEmptyXMLStageSource),
+		},
+		"attribute before the last step": {
+			XMLConfig{Expressions: map[string]string{"a": "/a/@b/c"}},
+			errors.New(ErrCouldNotCompileXPath + `: attribute "@b" must be the last step of "/a/@b/c"`),
+		},
+		"unsupported predicate": {
+			XMLConfig{Expressions: map[string]string{"a": "/a[last()]"}},
+			errors.New(ErrCouldNotCompileXPath + `: unsupported predicate [last()] in "/a[last()]"`),
+		},
+		"unquoted attribute value": {
+			XMLConfig{Expressions: map[string]string{"a": "/a[@b=c]"}},
+			errors.New(ErrCouldNotCompileXPath + `: attribute value must be quoted in predicate [@b=c] in "/a[@b=c]"`),
+		},
+		"trailing slash": {
+			XMLConfig{Expressions: map[string]string{"a": "/a/"}},
+			errors.New(ErrCouldNotCompileXPath + `: empty step in "/a/"`),
+		},
+		"name as expression": {
+			XMLConfig{Expressions: map[string]string{"level": ""}},
+			nil,
+		},
+		"valid": {
+			XMLConfig{Expressions: map[string]string{"a": "//a/*[2]/b[@c='d/e']/text()"}},
+			nil,
+		},
+	}
+	for tName, tt := range tests {
+		tt := tt
+		t.Run(tName, func(t *testing.T) {
+			_, err := validateXMLConfig(&tt.config)
+			if tt.err == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tt.err.Error())
+		})
+	}
+}
+
+func TestXMLParser_Parse(t *testing.T) {
+	t.Parallel()
+	doc := `<log><entry id="1"><level>info</level></entry><entry id="2"><level>error</level><msg>disk <b>full</b></msg></entry></log>`
+	tests := map[string]struct {
+		expression string
+		expected   string
+		found      bool
+	}{
+		"relative path":           {"log/entry/level", "info", true},
+		"position":                {"/log/entry[2]/level", "error", true},
+		"attribute predicate":     {"/log/entry[@id='2']/level", "error", true},
+		"attribute existence":     {"/log/entry[@id]/@id", "1", true},
+		"wildcard":                {"/log/*[2]/@id", "2", true},
+		"descendant":              {"//msg", "disk full", true},
+		"direct text":             {"//msg/text()", "disk", true},
+		"nested descendant":       {"/log//b", "full", true},
+		"element without match":   {"/log/missing", "", false},
+		"attribute without match": {"/log/entry/@missing", "", false},
+	}
+	for tName, tt := range tests {
+		tt := tt
+		t.Run(tName, func(t *testing.T) {
+			parsed, err := parseXML(doc)
+			require.NoError(t, err)
+			expr, err := compileXPath(tt.expression)
+			require.NoError(t, err)
+			v, ok := expr.evaluate(parsed)
+			assert.Equal(t, tt.found, ok)
+			assert.Equal(t, tt.expected, v)
+		})
+	}
+}
+
+func TestValidateXMLDrop(t *testing.T) {
+	logger := util.TestFlowLogger(t)
+	s, err := newXMLStage(logger, XMLConfig{
+		DropMalformed: true,
+		Expressions:   map[string]string{"level": "/log/level"},
+	})
+	require.NoError(t, err)
+
+	out := processEntries(s, newEntry(nil, nil, `<log><level>info</level></log>`, time.Now()))
+	assert.Equal(t, 1, len(out), "stage should have kept one valid xml line but got %v", out)
+
+	out = processEntries(s, newEntry(nil, nil, `<log><level>info</log>`, time.Now()))
+	assert.Equal(t, 0, len(out), "stage should have dropped the malformed xml line but got %v", out)
+
+	out = processEntries(s, newEntry(nil, nil, `level=info`, time.Now()))
+	assert.Equal(t, 0, len(out), "stage should have dropped the line without xml but got %v", out)
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/grafana/agent/internal/component/discovery"
 	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/agent/internal/flow/logging/level"
+	"github.com/grafana/agent/internal/service/cluster"
 	"github.com/grafana/tail/watch"
 	"github.com/prometheus/common/model"
 )
@@ -46,6 +47,8 @@ type Arguments struct {
 	FileWatch           FileWatch           `river:"file_watch,block,optional"`
 	TailFromEnd         bool                `river:"tail_from_end,attr,optional"`
 	LegacyPositionsFile string              `river:"legacy_positions_file,attr,optional"`
+
+	Clustering cluster.ComponentBlock `river:"clustering,block,optional"`
 }
 
 type FileWatch struct {
@@ -71,7 +74,10 @@ type DecompressionConfig struct {
 	Format       CompressionFormat `river:"format,attr"`
 }
 
-var _ component.Component = (*Component)(nil)
+var (
+	_ component.Component = (*Component)(nil)
+	_ cluster.Component   = (*Component)(nil)
+)
 
 // Component implements the loki.source.file component.
 type Component struct {
@@ -86,6 +92,19 @@ type Component struct {
 	receivers []loki.LogsReceiver
 	posFile   positions.Positions
 	readers   map[positions.Entry]reader
+
+	// Entries which are targets of the component but are owned by other
+	// peers. Their positions are kept so they can be handed off.
+	released map[positions.Entry]struct{}
+
+	// cluster is only set once clustering is enabled, as the cluster service
+	// isn't required otherwise.
+	cluster cluster.Cluster
+
+	// handoffCancel cancels the handoff in progress, if any, and handoffDone
+	// is closed once it returned. They're guarded by updateMut.
+	handoffCancel context.CancelFunc
+	handoffDone   chan struct{}
 }
 
 // New creates a new loki.source.file component.
@@ -117,6 +136,7 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		receivers: args.ForwardTo,
 		posFile:   positionsFile,
 		readers:   make(map[positions.Entry]reader),
+		released:  make(map[positions.Entry]struct{}),
 	}
 
 	// Call to Update() to start readers and set receivers once at the start.
@@ -134,6 +154,10 @@ func New(o component.Options, args Arguments) (*Component, error) {
 func (c *Component) Run(ctx context.Context) error {
 	defer func() {
 		level.Info(c.opts.Logger).Log("msg", "loki.source.file component shutting down, stopping readers and positions file")
+		c.updateMut.Lock()
+		c.stopHandoff()
+		c.updateMut.Unlock()
+
 		c.mut.RLock()
 		for _, r := range c.readers {
 			r.Stop()
@@ -182,62 +206,198 @@ func (c *Component) Update(args component.Arguments) error {
 	// * Stop tailing any files that were no longer in the new targets
 	//   and conditionally remove their readers only by calling toStopTailing
 	//   and c.stopTailingAndRemovePosition.
+	c.stopHandoff()
 	oldPaths := c.stopReaders()
 
-	newArgs := args.(Arguments)
+	return c.sync(args.(Arguments), oldPaths)
+}
 
-	c.mut.Lock()
-	defer c.mut.Unlock()
-	c.args = newArgs
-	c.receivers = newArgs.ForwardTo
+// NotifyClusterChange implements cluster.Component. Only the readers of the
+// files which moved between peers are stopped or started; the other readers
+// keep tailing their files.
+func (c *Component) NotifyClusterChange() {
+	c.updateMut.Lock()
+	defer c.updateMut.Unlock()
 
-	c.readers = make(map[positions.Entry]reader)
+	c.mut.RLock()
+	args, cl := c.args, c.cluster
+	c.mut.RUnlock()
 
-	if len(newArgs.Targets) == 0 {
-		level.Debug(c.opts.Logger).Log("msg", "no files targets were passed, nothing will be tailed")
-		return nil
+	if !args.Clustering.Enabled || cl == nil {
+		return
 	}
 
-	for _, target := range newArgs.Targets {
-		path := target[pathLabel]
+	// The files of a handoff in progress are handed off again below if this
+	// peer still owns them.
+	c.stopHandoff()
 
-		labels := make(model.LabelSet)
-		for k, v := range target {
-			if strings.HasPrefix(k, model.ReservedLabelPrefix) {
-				continue
-			}
-			labels[model.LabelName(k)] = model.LabelValue(v)
+	dt := discovery.NewDistributedTargetsWithKey(true, cl, args.Targets, discovery.PathShardKey)
+	ownedTargets := dt.Get()
+	owned := make(map[positions.Entry]struct{}, len(ownedTargets))
+	for _, target := range ownedTargets {
+		owned[targetEntry(target)] = struct{}{}
+	}
+
+	var (
+		lost   = make(map[positions.Entry]reader)
+		gained []discovery.Target
+	)
+	c.mut.RLock()
+	for e, r := range c.readers {
+		if _, ok := owned[e]; !ok {
+			lost[e] = r
+		}
+	}
+	for _, target := range ownedTargets {
+		if _, ok := c.readers[targetEntry(target)]; !ok {
+			gained = append(gained, target)
 		}
+	}
+	c.mut.RUnlock()
 
-		// Deduplicate targets which have the same public label set.
-		readersKey := positions.Entry{Path: path, Labels: labels.String()}
-		if _, exist := c.readers[readersKey]; exist {
-			continue
+	// Readers must be stopped without holding c.mut; see Update.
+	for _, r := range lost {
+		r.Stop()
+	}
+
+	// Release the lost entries before handing off positions, so that peers
+	// don't wait on files this peer no longer tails.
+	c.mut.Lock()
+	for e := range lost {
+		delete(c.readers, e)
+	}
+	c.released = make(map[positions.Entry]struct{})
+	for _, target := range args.Targets {
+		e := targetEntry(target)
+		if _, ok := owned[e]; !ok {
+			c.released[e] = struct{}{}
 		}
+	}
+	c.mut.Unlock()
 
-		c.reportSize(path, labels.String())
+	c.startHandoff(gained)
+}
 
-		handler := loki.AddLabelsMiddleware(labels).Wrap(loki.NewEntryHandler(c.handler.Chan(), func() {}))
-		reader, err := c.startTailing(path, labels, handler)
+// sync starts readers for the targets of newArgs owned by this peer. It must
+// be called with updateMut held, after the previous readers, whose entries
+// are given by oldPaths, have been stopped.
+func (c *Component) sync(newArgs Arguments, oldPaths map[positions.Entry]struct{}) error {
+	if newArgs.Clustering.Enabled && c.cluster == nil {
+		data, err := c.opts.GetServiceData(cluster.ServiceName)
 		if err != nil {
+			return err
+		}
+		c.mut.Lock()
+		c.cluster = data.(cluster.Cluster)
+		c.mut.Unlock()
+	}
+
+	var (
+		allEntries = make(map[positions.Entry]struct{}, len(newArgs.Targets))
+		owned      = make(map[positions.Entry]struct{})
+		gained     []discovery.Target
+	)
+	for _, target := range newArgs.Targets {
+		allEntries[targetEntry(target)] = struct{}{}
+	}
+	dt := discovery.NewDistributedTargetsWithKey(newArgs.Clustering.Enabled, c.cluster, newArgs.Targets, discovery.PathShardKey)
+	ownedTargets := dt.Get()
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.args = newArgs
+	c.receivers = newArgs.ForwardTo
+	c.readers = make(map[positions.Entry]reader)
+	oldReleased := c.released
+
+	if len(ownedTargets) == 0 {
+		level.Debug(c.opts.Logger).Log("msg", "no files targets were passed, nothing will be tailed")
+	}
+
+	// The files this peer already tailed are tailed again right away, while
+	// the files it gained are only tailed once their positions were handed
+	// off.
+	for _, target := range ownedTargets {
+		e := targetEntry(target)
+		owned[e] = struct{}{}
+		if _, ok := oldPaths[e]; !ok && newArgs.Clustering.Enabled {
+			gained = append(gained, target)
 			continue
 		}
+		c.startReaderLocked(target)
+	}
 
-		c.readers[readersKey] = readerWithHandler{
-			reader:  reader,
-			handler: handler,
+	// Keep the positions of targets owned by other peers so they can be
+	// handed off.
+	c.released = make(map[positions.Entry]struct{})
+	for e := range allEntries {
+		if _, ok := owned[e]; !ok {
+			c.released[e] = struct{}{}
 		}
 	}
 
 	// Remove from the positions file any entries that had a Reader before, but
 	// are no longer in the updated set of Targets.
-	for r := range missing(c.readers, oldPaths) {
-		c.posFile.Remove(r.Path, r.Labels)
+	for e := range oldReleased {
+		oldPaths[e] = struct{}{}
+	}
+	for e := range oldPaths {
+		_, kept := owned[e]
+		_, released := c.released[e]
+		if !kept && !released {
+			c.posFile.Remove(e.Path, e.Labels)
+		}
 	}
 
+	c.startHandoff(gained)
 	return nil
 }
 
+// startReaderLocked starts a reader for target, unless a reader for a target
+// with the same path and public labels already exists. It must be called with
+// c.mut held.
+func (c *Component) startReaderLocked(target discovery.Target) {
+	path := target[pathLabel]
+	labels := publicLabels(target)
+
+	// Deduplicate targets which have the same public label set.
+	readersKey := positions.Entry{Path: path, Labels: labels.String()}
+	if _, exist := c.readers[readersKey]; exist {
+		return
+	}
+
+	c.reportSize(path, labels.String())
+
+	handler := loki.AddLabelsMiddleware(labels).Wrap(loki.NewEntryHandler(c.handler.Chan(), func() {}))
+	reader, err := c.startTailing(path, labels, handler)
+	if err != nil {
+		return
+	}
+
+	c.readers[readersKey] = readerWithHandler{
+		reader:  reader,
+		handler: handler,
+	}
+}
+
+// targetEntry returns the positions entry for the target.
+func targetEntry(target discovery.Target) positions.Entry {
+	return positions.Entry{Path: target[pathLabel], Labels: publicLabels(target).String()}
+}
+
+// publicLabels returns the labels of target without the reserved labels.
+func publicLabels(target discovery.Target) model.LabelSet {
+	labels := make(model.LabelSet)
+	for k, v := range target {
+		if strings.HasPrefix(k, model.ReservedLabelPrefix) {
+			continue
+		}
+		labels[model.LabelName(k)] = model.LabelValue(v)
+	}
+	return labels
+}
+
 // readerWithHandler combines a reader with an entry handler associated with
 // it. Closing the reader will also close the handler.
 type readerWithHandler struct {
@@ -294,17 +454,6 @@ type targetInfo struct {
 	ReadOffset int64  `river:"read_offset,attr"`
 }
 
-// Returns the elements from set b which are missing from set a
-func missing(as map[positions.Entry]reader, bs map[positions.Entry]struct{}) map[positions.Entry]struct{} {
-	c := map[positions.Entry]struct{}{}
-	for a := range bs {
-		if _, ok := as[a]; !ok {
-			c[a] = struct{}{}
-		}
-	}
-	return c
-}
-
 // startTailing starts and returns a reader for the given path. For most files,
 // this will be a tailer implementation. If the file suffix alludes to it being
 // a compressed file, then a decompressor will be started instead.
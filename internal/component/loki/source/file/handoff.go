@@ -0,0 +1,281 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grafana/agent/internal/component/common/loki/positions"
+	"github.com/grafana/agent/internal/component/discovery"
+	"github.com/grafana/agent/internal/flow/logging/level"
+	http_service "github.com/grafana/agent/internal/service/http"
+	"github.com/grafana/ckit/peer"
+)
+
+// When clustering is enabled, files move between peers as the cluster
+// changes. To avoid reading a file from the start (or from a stale position)
+// after it moved, the new owner of a file asks the other peers for the
+// position they reached before it starts tailing the file. Peers which are
+// still tailing the file are polled until they stop or handoffTimeout
+// elapses. The handoff runs in the background, so that only the files which
+// moved wait for it.
+const (
+	handoffTimeout       = 5 * time.Second
+	handoffRetryInterval = 250 * time.Millisecond
+)
+
+// positionsPath is the path of the component's HTTP handler serving the
+// positions of the files known to the component.
+const positionsPath = "/positions"
+
+// positionsResponse is the response of the positions endpoint.
+type positionsResponse struct {
+	Positions []positionEntry `json:"positions"`
+}
+
+type positionEntry struct {
+	Path     string `json:"path"`
+	Labels   string `json:"labels"`
+	Position int64  `json:"position"`
+	Tailing  bool   `json:"tailing"`
+}
+
+// Handler implements http_service.Component. It serves the positions of the
+// files tailed by the component and of the files it handed off to other
+// peers. Positions are only served to the peers of the cluster.
+func (c *Component) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(positionsPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !c.fromPeer(r) {
+			http.Error(w, "positions are only served to cluster peers", http.StatusForbidden)
+			return
+		}
+
+		c.mut.RLock()
+		var resp positionsResponse
+		for e := range c.readers {
+			resp.Positions = append(resp.Positions, c.positionEntry(e, true))
+		}
+		for e := range c.released {
+			resp.Positions = append(resp.Positions, c.positionEntry(e, false))
+		}
+		c.mut.RUnlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+	return mux
+}
+
+// fromPeer reports whether r was sent by a peer of the cluster.
+//
+// When the HTTP server verifies client certificates, peers present the
+// certificate they use for the cluster, so requests with a verified
+// certificate are accepted like the cluster service accepts them. Otherwise,
+// the check is best-effort: the IP address r was sent from is matched against
+// the addresses of the peers, which can't tell peers apart from other
+// processes sharing their address, for example behind NAT or on the same
+// node. Addresses which can't be parsed as IP addresses never match.
+func (c *Component) fromPeer(r *http.Request) bool {
+	c.mut.RLock()
+	cl := c.cluster
+	c.mut.RUnlock()
+	if cl == nil {
+		return false
+	}
+
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		return true
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	remoteIP := net.ParseIP(host)
+	if remoteIP == nil {
+		return false
+	}
+	for _, p := range cl.Peers() {
+		if p.Self {
+			continue
+		}
+		peerHost, _, err := net.SplitHostPort(p.Addr)
+		if err != nil {
+			continue
+		}
+		if peerIP := net.ParseIP(peerHost); peerIP != nil && peerIP.Equal(remoteIP) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Component) positionEntry(e positions.Entry, tailing bool) positionEntry {
+	pos, _ := c.posFile.Get(e.Path, e.Labels)
+	return positionEntry{Path: e.Path, Labels: e.Labels, Position: pos, Tailing: tailing}
+}
+
+// startHandoff hands off the positions of the gained targets in the
+// background, and starts their readers once it's done. It must be called with
+// updateMut held, after stopping the previous handoff.
+func (c *Component) startHandoff(gained []discovery.Target) {
+	if len(gained) == 0 {
+		return
+	}
+
+	entries := make(map[positions.Entry]struct{}, len(gained))
+	for _, target := range gained {
+		entries[targetEntry(target)] = struct{}{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	c.handoffCancel, c.handoffDone = cancel, done
+
+	go func() {
+		defer close(done)
+		c.handoff(ctx, entries)
+
+		c.mut.Lock()
+		defer c.mut.Unlock()
+		// The handoff was stopped by an update or a cluster change, which
+		// handles the gained targets again.
+		if ctx.Err() != nil {
+			return
+		}
+		for _, target := range gained {
+			c.startReaderLocked(target)
+		}
+	}()
+}
+
+// stopHandoff cancels the handoff in progress, if any, and waits for it to
+// return. The readers of its targets aren't started. It must be called with
+// updateMut held.
+func (c *Component) stopHandoff() {
+	if c.handoffCancel == nil {
+		return
+	}
+	c.handoffCancel()
+	<-c.handoffDone
+	c.handoffCancel, c.handoffDone = nil, nil
+}
+
+// handoff fetches the positions of the gained entries from the other peers
+// and records them if they are further ahead than the local positions.
+func (c *Component) handoff(ctx context.Context, gained map[positions.Entry]struct{}) {
+	c.mut.RLock()
+	cl := c.cluster
+	c.mut.RUnlock()
+	if len(gained) == 0 || cl == nil {
+		return
+	}
+
+	data, err := c.opts.GetServiceData(http_service.ServiceName)
+	if err != nil {
+		level.Warn(c.opts.Logger).Log("msg", "failed to get the HTTP service; positions won't be handed off", "err", err)
+		return
+	}
+	httpData := data.(http_service.Data)
+	if httpData.PeerClient == nil {
+		level.Warn(c.opts.Logger).Log("msg", "the HTTP service can't send requests to peers; positions won't be handed off")
+		return
+	}
+	client, scheme := httpData.PeerClient()
+	path := strings.TrimSuffix(httpData.HTTPPathForComponent(c.opts.ID), "/") + positionsPath
+
+	ctx, cancel := context.WithTimeout(ctx, handoffTimeout)
+	defer cancel()
+
+	for _, peer := range cl.Peers() {
+		if peer.Self {
+			continue
+		}
+		c.handoffFromPeer(ctx, client, peer, fmt.Sprintf("%s://%s%s", scheme, peer.Addr, path), gained)
+	}
+}
+
+// handoffFromPeer fetches the positions of the gained entries from a single
+// peer, polling it until it stopped tailing all of them.
+func (c *Component) handoffFromPeer(ctx context.Context, client *http.Client, p peer.Peer, url string, gained map[positions.Entry]struct{}) {
+	for {
+		tailing, err := c.fetchPositions(ctx, client, url, gained)
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return
+		}
+		if err != nil {
+			level.Warn(c.opts.Logger).Log("msg", "failed to fetch positions from peer", "peer", p.Name, "err", err)
+			return
+		}
+		if !tailing {
+			return
+		}
+
+		// The peer still tails some of the gained files; wait for it to notice
+		// the cluster change and stop.
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				level.Warn(c.opts.Logger).Log("msg", "timed out waiting for peer to stop tailing files handed off to this peer", "peer", p.Name)
+			}
+			return
+		case <-time.After(handoffRetryInterval):
+		}
+	}
+}
+
+// fetchPositions fetches the positions of a peer from url, recording the
+// positions of the gained entries. It reports whether the peer is still
+// tailing any of the gained entries.
+func (c *Component) fetchPositions(ctx context.Context, client *http.Client, url string, gained map[positions.Entry]struct{}) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	// Peers which don't run this component have nothing to hand off.
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var body positionsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return false, err
+	}
+
+	var tailing bool
+	for _, p := range body.Positions {
+		e := positions.Entry{Path: p.Path, Labels: p.Labels}
+		if _, ok := gained[e]; !ok {
+			continue
+		}
+		if p.Tailing {
+			tailing = true
+			continue
+		}
+
+		if local, _ := c.posFile.Get(e.Path, e.Labels); p.Position > local {
+			level.Info(c.opts.Logger).Log("msg", "handed off file position from peer", "path", e.Path, "position", p.Position)
+			c.posFile.Put(e.Path, e.Labels, p.Position)
+		}
+	}
+	return tailing, nil
+}
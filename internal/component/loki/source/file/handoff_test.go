@@ -0,0 +1,329 @@
+//go:build !race
+
+package file
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/component/common/loki"
+	"github.com/grafana/agent/internal/component/common/loki/positions"
+	"github.com/grafana/agent/internal/component/discovery"
+	"github.com/grafana/agent/internal/service/cluster"
+	http_service "github.com/grafana/agent/internal/service/http"
+	"github.com/grafana/agent/internal/util"
+	"github.com/grafana/ckit/peer"
+	"github.com/grafana/ckit/shard"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusteringHandoff(t *testing.T) {
+	dir := t.TempDir()
+	f1, f2 := filepath.Join(dir, "one.log"), filepath.Join(dir, "two.log")
+	require.NoError(t, os.WriteFile(f1, []byte("one-1\n"), 0644))
+	require.NoError(t, os.WriteFile(f2, []byte("two-1\n"), 0644))
+
+	// Both files start out owned by node a.
+	ring := &fakeRing{owners: map[string]string{f1: "a", f2: "a"}}
+
+	var (
+		a, b         = newHandoffNode(t, "a", ring), newHandoffNode(t, "b", ring)
+		receiverA    = loki.NewLogsReceiver()
+		receiverB    = loki.NewLogsReceiver()
+		ctx, cancel  = context.WithCancel(context.Background())
+		args         = Arguments{Targets: []discovery.Target{{"__path__": f1}, {"__path__": f2}}, Clustering: cluster.ComponentBlock{Enabled: true}}
+		argsA, argsB = args, args
+		componentA   *Component
+		componentB   *Component
+		err          error
+		wg           sync.WaitGroup
+		run          = func(c *Component) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = c.Run(ctx)
+			}()
+		}
+	)
+	defer wg.Wait()
+	defer cancel()
+	argsA.ForwardTo, argsB.ForwardTo = []loki.LogsReceiver{receiverA}, []loki.LogsReceiver{receiverB}
+	argsA.FileWatch, argsB.FileWatch = DefaultArguments.FileWatch, DefaultArguments.FileWatch
+
+	componentA, err = New(a.opts(t), argsA)
+	require.NoError(t, err)
+	a.setHandler(componentA.Handler())
+	componentB, err = New(b.opts(t), argsB)
+	require.NoError(t, err)
+	b.setHandler(componentB.Handler())
+	run(componentA)
+	run(componentB)
+
+	requireLines(t, receiverA, "one-1", "two-1")
+
+	// Positions are only served to peers.
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, positionsPath, nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	componentA.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	// Requests with a verified client certificate are accepted from any
+	// address.
+	rec = httptest.NewRecorder()
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}
+	componentA.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	componentA.mut.RLock()
+	readerTwo := componentA.readers[positions.Entry{Path: f2, Labels: "{}"}].(readerWithHandler).reader
+	componentA.mut.RUnlock()
+
+	// Move the first file to node b. Node a stops tailing it and node b picks
+	// it up from where node a left off.
+	ring.setOwner(f1, "b")
+
+	var notified sync.WaitGroup
+	for _, c := range []*Component{componentB, componentA} {
+		notified.Add(1)
+		go func(c *Component) {
+			defer notified.Done()
+			c.NotifyClusterChange()
+		}(c)
+	}
+	notified.Wait()
+
+	appendLine(t, f1, "one-2")
+	appendLine(t, f2, "two-2")
+
+	requireLines(t, receiverB, "one-2")
+	requireLines(t, receiverA, "two-2")
+	requireNoLines(t, receiverA)
+	requireNoLines(t, receiverB)
+
+	// The reader of the file which stayed on node a kept running.
+	componentA.mut.RLock()
+	defer componentA.mut.RUnlock()
+	require.Same(t, readerTwo, componentA.readers[positions.Entry{Path: f2, Labels: "{}"}].(readerWithHandler).reader)
+}
+
+func TestClusteringHandoff_Async(t *testing.T) {
+	dir := t.TempDir()
+	f1, f2 := filepath.Join(dir, "one.log"), filepath.Join(dir, "two.log")
+	require.NoError(t, os.WriteFile(f1, []byte("one-1\n"), 0644))
+	require.NoError(t, os.WriteFile(f2, []byte("two-1\n"), 0644))
+
+	ring := &fakeRing{owners: map[string]string{f1: "a", f2: "b"}}
+
+	var (
+		a, b         = newHandoffNode(t, "a", ring), newHandoffNode(t, "b", ring)
+		receiverA    = loki.NewLogsReceiver()
+		receiverB    = loki.NewLogsReceiver()
+		ctx, cancel  = context.WithCancel(context.Background())
+		args         = Arguments{Targets: []discovery.Target{{"__path__": f1}, {"__path__": f2}}, Clustering: cluster.ComponentBlock{Enabled: true}}
+		argsA, argsB = args, args
+		wg           sync.WaitGroup
+	)
+	defer wg.Wait()
+	defer cancel()
+	argsA.ForwardTo, argsB.ForwardTo = []loki.LogsReceiver{receiverA}, []loki.LogsReceiver{receiverB}
+	argsA.FileWatch, argsB.FileWatch = DefaultArguments.FileWatch, DefaultArguments.FileWatch
+
+	componentA, err := New(a.opts(t), argsA)
+	require.NoError(t, err)
+	a.setHandler(componentA.Handler())
+	componentB, err := New(b.opts(t), argsB)
+	require.NoError(t, err)
+	b.setHandler(componentB.Handler())
+	for _, c := range []*Component{componentA, componentB} {
+		wg.Add(1)
+		go func(c *Component) {
+			defer wg.Done()
+			_ = c.Run(ctx)
+		}(c)
+	}
+
+	requireLines(t, receiverA, "one-1")
+	requireLines(t, receiverB, "two-1")
+
+	// Node b gains the first file while node a still tails it. Updating node b
+	// doesn't wait for node a, and node b keeps tailing its other file.
+	ring.setOwner(f1, "b")
+	start := time.Now()
+	require.NoError(t, componentB.Update(argsB))
+	require.Less(t, time.Since(start), handoffTimeout/2)
+
+	appendLine(t, f2, "two-2")
+	requireLines(t, receiverB, "two-2")
+
+	// Node b picks up the first file once node a stopped tailing it.
+	componentA.NotifyClusterChange()
+	appendLine(t, f1, "one-2")
+	requireLines(t, receiverB, "one-2")
+	requireNoLines(t, receiverA)
+	requireNoLines(t, receiverB)
+}
+
+// fakeRing assigns files to nodes by their path.
+type fakeRing struct {
+	mut    sync.Mutex
+	owners map[string]string
+	nodes  []*handoffNode
+}
+
+func (r *fakeRing) setOwner(path, node string) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.owners[path] = node
+}
+
+// handoffNode is a node of the fake cluster, serving the HTTP handler of its
+// component.
+type handoffNode struct {
+	name string
+	ring *fakeRing
+	srv  *httptest.Server
+
+	mut     sync.Mutex
+	handler http.Handler
+}
+
+const handoffComponentID = "loki.source.file.test"
+
+func newHandoffNode(t *testing.T, name string, ring *fakeRing) *handoffNode {
+	n := &handoffNode{name: name, ring: ring}
+
+	prefix := "/api/v0/component/" + handoffComponentID
+	n.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n.mut.Lock()
+		h := n.handler
+		n.mut.Unlock()
+
+		if h == nil || !strings.HasPrefix(r.URL.Path, prefix) {
+			http.NotFound(w, r)
+			return
+		}
+		http.StripPrefix(prefix, h).ServeHTTP(w, r)
+	}))
+	t.Cleanup(n.srv.Close)
+
+	ring.mut.Lock()
+	ring.nodes = append(ring.nodes, n)
+	ring.mut.Unlock()
+	return n
+}
+
+func (n *handoffNode) setHandler(h http.Handler) {
+	n.mut.Lock()
+	defer n.mut.Unlock()
+	n.handler = h
+}
+
+func (n *handoffNode) opts(t *testing.T) component.Options {
+	return component.Options{
+		ID:            handoffComponentID,
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+		DataPath:      t.TempDir(),
+		GetServiceData: func(name string) (interface{}, error) {
+			switch name {
+			case cluster.ServiceName:
+				return &fakeCluster{self: n}, nil
+			case http_service.ServiceName:
+				return http_service.Data{
+					BaseHTTPPath: "/api/v0/component/",
+					PeerClient:   func() (*http.Client, string) { return n.srv.Client(), "http" },
+				}, nil
+			default:
+				return nil, os.ErrNotExist
+			}
+		},
+	}
+}
+
+// fakeCluster is the view of the fake cluster from a single node.
+type fakeCluster struct {
+	self *handoffNode
+}
+
+func (c *fakeCluster) Lookup(key shard.Key, _ int, _ shard.Op) ([]peer.Peer, error) {
+	c.self.ring.mut.Lock()
+	defer c.self.ring.mut.Unlock()
+
+	for path, owner := range c.self.ring.owners {
+		if shard.StringKey(path) == key {
+			return []peer.Peer{c.peer(owner)}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *fakeCluster) Peers() []peer.Peer {
+	c.self.ring.mut.Lock()
+	defer c.self.ring.mut.Unlock()
+
+	var peers []peer.Peer
+	for _, n := range c.self.ring.nodes {
+		peers = append(peers, c.peer(n.name))
+	}
+	return peers
+}
+
+func (c *fakeCluster) peer(name string) peer.Peer {
+	for _, n := range c.self.ring.nodes {
+		if n.name == name {
+			return peer.Peer{
+				Name:  n.name,
+				Addr:  strings.TrimPrefix(n.srv.URL, "http://"),
+				Self:  n == c.self,
+				State: peer.StateParticipant,
+			}
+		}
+	}
+	return peer.Peer{}
+}
+
+func appendLine(t *testing.T, path, line string) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.WriteString(line + "\n")
+	require.NoError(t, err)
+}
+
+func requireLines(t *testing.T, r loki.LogsReceiver, lines ...string) {
+	t.Helper()
+
+	var got []string
+	for len(got) < len(lines) {
+		select {
+		case e := <-r.Chan():
+			got = append(got, e.Line)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timed out waiting for log lines", "got %v, want %v", got, lines)
+		}
+	}
+	require.ElementsMatch(t, lines, got)
+}
+
+func requireNoLines(t *testing.T, r loki.LogsReceiver) {
+	t.Helper()
+
+	select {
+	case e := <-r.Chan():
+		require.FailNow(t, "unexpected log line", e.Line)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
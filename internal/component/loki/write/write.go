@@ -55,8 +55,7 @@ func (wa *WalArguments) Validate() error {
 }
 
 func (wa *WalArguments) SetToDefault() {
-	// todo(thepalbi): Once we are in a good state: replay implemented, and a better cleanup mechanism
-	// make WAL enabled the default
+	// todo(thepalbi): Once we are in a good state: a better cleanup mechanism, make WAL enabled the default
 	*wa = WalArguments{
 		Enabled:          false,
 		MaxSegmentAge:    wal.DefaultMaxSegmentAge,
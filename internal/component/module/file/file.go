@@ -2,7 +2,10 @@ package file
 
 import (
 	"context"
+	"errors"
+	"os"
 	"sync"
+	"time"
 
 	"go.uber.org/atomic"
 
@@ -10,6 +13,7 @@ import (
 	"github.com/grafana/agent/internal/component/local/file"
 	"github.com/grafana/agent/internal/component/module"
 	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/agent/internal/integrity"
 	"github.com/grafana/river/rivertypes"
 )
 
@@ -32,6 +36,9 @@ type Arguments struct {
 
 	// Arguments to pass into the module.
 	Arguments map[string]any `river:"arguments,block,optional"`
+
+	// Verify configures the verification of the file.
+	Verify *integrity.Arguments `river:"verify,block,optional"`
 }
 
 // SetToDefault implements river.Defaulter.
@@ -44,13 +51,15 @@ type Component struct {
 	opts component.Options
 	mod  *module.ModuleComponent
 
-	mut     sync.RWMutex
-	args    Arguments
-	content rivertypes.OptionalSecret
+	mut      sync.RWMutex
+	args     Arguments
+	content  rivertypes.OptionalSecret
+	verifier *integrity.Verifier
 
 	managedLocalFile *file.Component
 	inUpdate         atomic.Bool
 	isCreated        atomic.Bool
+	verifyFailed     atomic.Bool
 }
 
 var (
@@ -90,7 +99,7 @@ func (c *Component) newManagedLocalComponent(o component.Options) (*file.Compone
 
 		if !c.inUpdate.Load() && c.isCreated.Load() {
 			// Any errors found here are reported via component health
-			_ = c.mod.LoadFlowSource(c.getArgs().Arguments, c.getContent().Value)
+			_ = c.loadFlowSource()
 		}
 	}
 
@@ -112,12 +121,26 @@ func (c *Component) Run(ctx context.Context) error {
 
 	go c.mod.RunFlowController(ctx)
 
+	// Failed verifications are retried, as the signature of the file may be
+	// updated after the file itself.
+	retryFrequency := c.getArgs().LocalFileArguments.PollFrequency
+	if retryFrequency <= 0 {
+		retryFrequency = file.DefaultArguments.PollFrequency
+	}
+	retryTicker := time.NewTicker(retryFrequency)
+	defer retryTicker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
 		case err := <-ch:
 			return err
+		case <-retryTicker.C:
+			if c.verifyFailed.Load() {
+				// Any errors found here are reported via component health
+				_ = c.loadFlowSource()
+			}
 		}
 	}
 }
@@ -128,16 +151,30 @@ func (c *Component) Update(args component.Arguments) error {
 	defer c.inUpdate.Store(false)
 
 	newArgs := args.(Arguments)
-	c.setArgs(newArgs)
 
-	err := c.managedLocalFile.Update(newArgs.LocalFileArguments)
+	verifier, err := integrity.NewVerifier(newArgs.Verify)
+	if err != nil {
+		return err
+	}
+	c.setArgs(newArgs, verifier)
+
+	err = c.managedLocalFile.Update(newArgs.LocalFileArguments)
 	if err != nil {
 		return err
 	}
 
 	// Force a content load here and bubble up any error. This will catch problems
 	// on initial load.
-	return c.mod.LoadFlowSource(newArgs.Arguments, c.getContent().Value)
+	return c.loadFlowSource()
+}
+
+// loadFlowSource verifies the content of the file and loads it into the
+// module.
+func (c *Component) loadFlowSource() error {
+	args, verifier := c.getArgs(), c.getVerifier()
+	err := c.mod.LoadVerifiedFlowSource(args.Arguments, args.LocalFileArguments.Filename, c.getContent().Value, verifier, os.ReadFile)
+	c.verifyFailed.Store(errors.As(err, &integrity.VerificationFailedError{}))
+	return err
 }
 
 // CurrentHealth implements component.HealthComponent.
@@ -161,13 +198,22 @@ func (c *Component) getArgs() Arguments {
 	return c.args
 }
 
-// setArgs is a goroutine safe way to set args
-func (c *Component) setArgs(args Arguments) {
+// setArgs is a goroutine safe way to set args and the verifier built from
+// them
+func (c *Component) setArgs(args Arguments, verifier *integrity.Verifier) {
 	c.mut.Lock()
 	c.args = args
+	c.verifier = verifier
 	c.mut.Unlock()
 }
 
+// getVerifier is a goroutine safe way to get the verifier
+func (c *Component) getVerifier() *integrity.Verifier {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	return c.verifier
+}
+
 // getContent is a goroutine safe way to get content
 func (c *Component) getContent() rivertypes.OptionalSecret {
 	c.mut.RLock()
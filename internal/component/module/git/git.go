@@ -14,6 +14,7 @@ import (
 	"github.com/grafana/agent/internal/component/module"
 	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/agent/internal/flow/logging/level"
+	"github.com/grafana/agent/internal/integrity"
 	"github.com/grafana/agent/internal/vcs"
 )
 
@@ -39,6 +40,8 @@ type Arguments struct {
 
 	Arguments     map[string]any    `river:"arguments,block,optional"`
 	GitAuthConfig vcs.GitAuthConfig `river:",squash"`
+
+	Verify *integrity.Arguments `river:"verify,block,optional"`
 }
 
 // DefaultArguments holds default settings for Arguments.
@@ -61,6 +64,7 @@ type Component struct {
 	mut      sync.RWMutex
 	repo     *vcs.GitRepo
 	repoOpts vcs.GitRepoOptions
+	verifier *integrity.Verifier
 	args     Arguments
 
 	argsChanged chan struct{}
@@ -186,6 +190,12 @@ func (c *Component) Update(args component.Arguments) (err error) {
 
 	newArgs := args.(Arguments)
 
+	verifier, err := integrity.NewVerifier(newArgs.Verify)
+	if err != nil {
+		return err
+	}
+	c.verifier = verifier
+
 	// TODO(rfratto): store in a repo-specific directory so changing repositories
 	// doesn't risk break the module loader if there's a SHA collision between
 	// the two different repositories.
@@ -241,7 +251,7 @@ func (c *Component) pollFile(ctx context.Context, args Arguments) error {
 		return err
 	}
 
-	return c.mod.LoadFlowSource(args.Arguments, string(bb))
+	return c.mod.LoadVerifiedFlowSource(args.Arguments, args.Path, string(bb), c.verifier, c.repo.ReadFile)
 }
 
 // CurrentHealth implements component.HealthComponent.
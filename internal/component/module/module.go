@@ -9,6 +9,7 @@ import (
 
 	"github.com/grafana/agent/internal/component"
 	"github.com/grafana/agent/internal/flow/logging/level"
+	"github.com/grafana/agent/internal/integrity"
 )
 
 // ModuleComponent holds the common properties for module components.
@@ -70,6 +71,33 @@ func (c *ModuleComponent) LoadFlowSource(args map[string]any, contentValue strin
 	return nil
 }
 
+// LoadVerifiedFlowSource verifies the content retrieved from location with
+// verifier before loading it with LoadFlowSource. If the verification fails,
+// the module keeps running the last loaded content and the component is
+// reported as unhealthy.
+func (c *ModuleComponent) LoadVerifiedFlowSource(args map[string]any, location string, contentValue string, verifier *integrity.Verifier, readSignature func(location string) ([]byte, error)) error {
+	if err := verifier.Verify(location, []byte(contentValue), readSignature); err != nil {
+		c.setHealth(component.Health{
+			Health:     component.HealthTypeUnhealthy,
+			Message:    fmt.Sprintf("failed to verify module content: %s", err),
+			UpdateTime: time.Now(),
+		})
+		return err
+	}
+
+	// The last loaded content may have been reported as unhealthy by a
+	// previous failed verification.
+	if reflect.DeepEqual(args, c.getLatestArgs()) && contentValue == c.getLatestContent() {
+		c.setHealth(component.Health{
+			Health:     component.HealthTypeHealthy,
+			Message:    "module content loaded",
+			UpdateTime: time.Now(),
+		})
+		return nil
+	}
+	return c.LoadFlowSource(args, contentValue)
+}
+
 // RunFlowController runs the flow controller that all module components start.
 func (c *ModuleComponent) RunFlowController(ctx context.Context) {
 	err := c.mod.Run(ctx)
@@ -18,6 +18,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	otelcomponent "go.opentelemetry.io/collector/component"
 	otelconnector "go.opentelemetry.io/collector/connector"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
 	otelextension "go.opentelemetry.io/collector/extension"
 	sdkprometheus "go.opentelemetry.io/otel/exporters/prometheus"
 	"go.opentelemetry.io/otel/sdk/metric"
@@ -33,6 +34,12 @@ const (
 	ConnectorLogsToTraces
 	ConnectorLogsToMetrics
 	ConnectorLogsToLogs
+	// ConnectorAllToMetrics connectors receive traces, metrics and logs, and
+	// output metrics.
+	ConnectorAllToMetrics
+	// ConnectorAllToSame connectors receive traces, metrics and logs, and
+	// output the signal they receive.
+	ConnectorAllToSame
 )
 
 // Arguments is an extension of component.Arguments which contains necessary
@@ -58,6 +65,17 @@ type Arguments interface {
 	ConnectorType() int
 }
 
+// RoutingArguments is an extension of Arguments for connectors which send
+// data to the pipelines referenced by their OpenTelemetry Collector
+// configuration rather than to their next consumers.
+type RoutingArguments interface {
+	Arguments
+
+	// Pipelines returns the consumers of each pipeline referenced by the
+	// connector configuration.
+	Pipelines() map[otelcomponent.ID]*otelcol.ConsumerArguments
+}
+
 // Connector is a Flow component shim which manages an OpenTelemetry Collector
 // connector component.
 type Connector struct {
@@ -190,6 +208,63 @@ func (p *Connector) Update(args component.Arguments) error {
 				components = append(components, tracesConnector)
 			}
 		}
+	case ConnectorAllToMetrics:
+		if len(next.Traces) > 0 || len(next.Logs) > 0 {
+			return errors.New("this connector can only output metrics")
+		}
+
+		if len(next.Metrics) > 0 {
+			nextMetrics := fanoutconsumer.Metrics(next.Metrics)
+			tracesConnector, err = p.factory.CreateTracesToMetrics(p.ctx, settings, connectorConfig, nextMetrics)
+			if err != nil && !errors.Is(err, otelcomponent.ErrDataTypeIsNotSupported) {
+				return err
+			} else if tracesConnector != nil {
+				components = append(components, tracesConnector)
+			}
+
+			metricsConnector, err = p.factory.CreateMetricsToMetrics(p.ctx, settings, connectorConfig, nextMetrics)
+			if err != nil && !errors.Is(err, otelcomponent.ErrDataTypeIsNotSupported) {
+				return err
+			} else if metricsConnector != nil {
+				components = append(components, metricsConnector)
+			}
+
+			logsConnector, err = p.factory.CreateLogsToMetrics(p.ctx, settings, connectorConfig, nextMetrics)
+			if err != nil && !errors.Is(err, otelcomponent.ErrDataTypeIsNotSupported) {
+				return err
+			} else if logsConnector != nil {
+				components = append(components, logsConnector)
+			}
+		}
+	case ConnectorAllToSame:
+		nextTraces, nextMetrics, nextLogs := sameSignalConsumers(pargs)
+
+		if nextTraces != nil {
+			tracesConnector, err = p.factory.CreateTracesToTraces(p.ctx, settings, connectorConfig, nextTraces)
+			if err != nil && !errors.Is(err, otelcomponent.ErrDataTypeIsNotSupported) {
+				return err
+			} else if tracesConnector != nil {
+				components = append(components, tracesConnector)
+			}
+		}
+
+		if nextMetrics != nil {
+			metricsConnector, err = p.factory.CreateMetricsToMetrics(p.ctx, settings, connectorConfig, nextMetrics)
+			if err != nil && !errors.Is(err, otelcomponent.ErrDataTypeIsNotSupported) {
+				return err
+			} else if metricsConnector != nil {
+				components = append(components, metricsConnector)
+			}
+		}
+
+		if nextLogs != nil {
+			logsConnector, err = p.factory.CreateLogsToLogs(p.ctx, settings, connectorConfig, nextLogs)
+			if err != nil && !errors.Is(err, otelcomponent.ErrDataTypeIsNotSupported) {
+				return err
+			} else if logsConnector != nil {
+				components = append(components, logsConnector)
+			}
+		}
 	default:
 		return errors.New("unsupported connector type")
 	}
@@ -204,3 +279,65 @@ func (p *Connector) Update(args component.Arguments) error {
 func (p *Connector) CurrentHealth() component.Health {
 	return p.sched.CurrentHealth()
 }
+
+// sameSignalConsumers returns the consumers of the connectors created for
+// each signal of a ConnectorAllToSame connector. The consumer of a signal is
+// nil if no data of that signal is sent.
+//
+// The consumers of connectors implementing RoutingArguments route data to
+// the pipelines of the connector, and are created if any of the pipelines
+// consumes the signal.
+func sameSignalConsumers(args Arguments) (otelconsumer.Traces, otelconsumer.Metrics, otelconsumer.Logs) {
+	var (
+		nextTraces  otelconsumer.Traces
+		nextMetrics otelconsumer.Metrics
+		nextLogs    otelconsumer.Logs
+	)
+
+	rargs, ok := args.(RoutingArguments)
+	if !ok {
+		next := args.NextConsumers()
+		if len(next.Traces) > 0 {
+			nextTraces = fanoutconsumer.Traces(next.Traces)
+		}
+		if len(next.Metrics) > 0 {
+			nextMetrics = fanoutconsumer.Metrics(next.Metrics)
+		}
+		if len(next.Logs) > 0 {
+			nextLogs = fanoutconsumer.Logs(next.Logs)
+		}
+		return nextTraces, nextMetrics, nextLogs
+	}
+
+	var (
+		pipelines = rargs.Pipelines()
+
+		tracesPipelines  = make(map[otelcomponent.ID]otelconsumer.Traces, len(pipelines))
+		metricsPipelines = make(map[otelcomponent.ID]otelconsumer.Metrics, len(pipelines))
+		logsPipelines    = make(map[otelcomponent.ID]otelconsumer.Logs, len(pipelines))
+
+		hasTraces, hasMetrics, hasLogs bool
+	)
+	for id, next := range pipelines {
+		// Every pipeline must be known to the routers, even the ones which
+		// don't consume the signal.
+		tracesPipelines[id] = fanoutconsumer.Traces(next.Traces)
+		metricsPipelines[id] = fanoutconsumer.Metrics(next.Metrics)
+		logsPipelines[id] = fanoutconsumer.Logs(next.Logs)
+
+		hasTraces = hasTraces || len(next.Traces) > 0
+		hasMetrics = hasMetrics || len(next.Metrics) > 0
+		hasLogs = hasLogs || len(next.Logs) > 0
+	}
+
+	if hasTraces {
+		nextTraces = otelconnector.NewTracesRouter(tracesPipelines)
+	}
+	if hasMetrics {
+		nextMetrics = otelconnector.NewMetricsRouter(metricsPipelines)
+	}
+	if hasLogs {
+		nextLogs = otelconnector.NewLogsRouter(logsPipelines)
+	}
+	return nextTraces, nextMetrics, nextLogs
+}
@@ -0,0 +1,177 @@
+// Package count provides an otelcol.connector.count component.
+package count
+
+import (
+	"fmt"
+
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/component/otelcol"
+	"github.com/grafana/agent/internal/component/otelcol/connector"
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/river"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/connector/countconnector"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelextension "go.opentelemetry.io/collector/extension"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:      "otelcol.connector.count",
+		Stability: featuregate.StabilityExperimental,
+		Args:      Arguments{},
+		Exports:   otelcol.ConsumerExports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			fact := countconnector.NewFactory()
+			return connector.New(opts, fact, args.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the otelcol.connector.count component.
+type Arguments struct {
+	// The metrics counting each type of telemetry. The default metric of a type
+	// is emitted if no metric is defined for it.
+	Spans      []MetricInfo `river:"span,block,optional"`
+	SpanEvents []MetricInfo `river:"spanevent,block,optional"`
+	Metrics    []MetricInfo `river:"metric,block,optional"`
+	DataPoints []MetricInfo `river:"datapoint,block,optional"`
+	Logs       []MetricInfo `river:"log,block,optional"`
+
+	// Output configures where to send processed data. Required.
+	Output *otelcol.ConsumerArguments `river:"output,block"`
+}
+
+var (
+	_ river.Validator     = (*Arguments)(nil)
+	_ connector.Arguments = (*Arguments)(nil)
+)
+
+// MetricInfo configures a metric counting the telemetry which matches its
+// conditions.
+type MetricInfo struct {
+	Name        string            `river:"name,attr"`
+	Description string            `river:"description,attr,optional"`
+	Conditions  []string          `river:"conditions,attr,optional"`
+	Attributes  []AttributeConfig `river:"attribute,block,optional"`
+}
+
+// AttributeConfig configures an attribute of a metric, whose values are
+// counted separately.
+type AttributeConfig struct {
+	Key          string `river:"key,attr"`
+	DefaultValue string `river:"default_value,attr,optional"`
+}
+
+// The default metrics emitted for each type of telemetry.
+var (
+	defaultSpans = []MetricInfo{{
+		Name:        "trace.span.count",
+		Description: "The number of spans observed.",
+	}}
+	defaultSpanEvents = []MetricInfo{{
+		Name:        "trace.span.event.count",
+		Description: "The number of span events observed.",
+	}}
+	defaultMetrics = []MetricInfo{{
+		Name:        "metric.count",
+		Description: "The number of metrics observed.",
+	}}
+	defaultDataPoints = []MetricInfo{{
+		Name:        "metric.datapoint.count",
+		Description: "The number of data points observed.",
+	}}
+	defaultLogs = []MetricInfo{{
+		Name:        "log.record.count",
+		Description: "The number of log records observed.",
+	}}
+)
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	otelArgs, err := args.convertImpl()
+	if err != nil {
+		return err
+	}
+	return otelArgs.Validate()
+}
+
+// Convert implements connector.Arguments.
+func (args Arguments) Convert() (otelcomponent.Config, error) {
+	return args.convertImpl()
+}
+
+// convertImpl is a helper function which returns the real type of the config,
+// instead of the otelcomponent.Config interface.
+func (args Arguments) convertImpl() (*countconnector.Config, error) {
+	var (
+		res countconnector.Config
+		err error
+	)
+	if res.Spans, err = convertMetricInfos(args.Spans, defaultSpans); err != nil {
+		return nil, fmt.Errorf("span: %w", err)
+	}
+	if res.SpanEvents, err = convertMetricInfos(args.SpanEvents, defaultSpanEvents); err != nil {
+		return nil, fmt.Errorf("spanevent: %w", err)
+	}
+	if res.Metrics, err = convertMetricInfos(args.Metrics, defaultMetrics); err != nil {
+		return nil, fmt.Errorf("metric: %w", err)
+	}
+	if res.DataPoints, err = convertMetricInfos(args.DataPoints, defaultDataPoints); err != nil {
+		return nil, fmt.Errorf("datapoint: %w", err)
+	}
+	if res.Logs, err = convertMetricInfos(args.Logs, defaultLogs); err != nil {
+		return nil, fmt.Errorf("log: %w", err)
+	}
+	return &res, nil
+}
+
+func convertMetricInfos(infos []MetricInfo, defaults []MetricInfo) (map[string]countconnector.MetricInfo, error) {
+	if len(infos) == 0 {
+		infos = defaults
+	}
+
+	res := make(map[string]countconnector.MetricInfo, len(infos))
+	for _, info := range infos {
+		if _, ok := res[info.Name]; ok {
+			return nil, fmt.Errorf("metric %q is defined more than once", info.Name)
+		}
+		res[info.Name] = info.Convert()
+	}
+	return res, nil
+}
+
+// Convert converts the MetricInfo into its OpenTelemetry Collector type.
+func (info MetricInfo) Convert() countconnector.MetricInfo {
+	res := countconnector.MetricInfo{
+		Description: info.Description,
+		Conditions:  append([]string(nil), info.Conditions...),
+	}
+	for _, attr := range info.Attributes {
+		res.Attributes = append(res.Attributes, countconnector.AttributeConfig{
+			Key:          attr.Key,
+			DefaultValue: attr.DefaultValue,
+		})
+	}
+	return res
+}
+
+// Extensions implements connector.Arguments.
+func (args Arguments) Extensions() map[otelcomponent.ID]otelextension.Extension {
+	return nil
+}
+
+// Exporters implements connector.Arguments.
+func (args Arguments) Exporters() map[otelcomponent.DataType]map[otelcomponent.ID]otelcomponent.Component {
+	return nil
+}
+
+// NextConsumers implements connector.Arguments.
+func (args Arguments) NextConsumers() *otelcol.ConsumerArguments {
+	return args.Output
+}
+
+// ConnectorType() int implements connector.Arguments.
+func (Arguments) ConnectorType() int {
+	return connector.ConnectorAllToMetrics
+}
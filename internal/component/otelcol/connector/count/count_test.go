@@ -0,0 +1,272 @@
+package count_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/internal/component/otelcol"
+	"github.com/grafana/agent/internal/component/otelcol/connector/count"
+	"github.com/grafana/agent/internal/component/otelcol/internal/fakeconsumer"
+	"github.com/grafana/agent/internal/flow/componenttest"
+	"github.com/grafana/agent/internal/flow/logging/level"
+	"github.com/grafana/agent/internal/util"
+	"github.com/grafana/dskit/backoff"
+	"github.com/grafana/river"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/connector/countconnector"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	defaultConfig := func() countconnector.Config {
+		return countconnector.Config{
+			Spans: map[string]countconnector.MetricInfo{
+				"trace.span.count": {Description: "The number of spans observed."},
+			},
+			SpanEvents: map[string]countconnector.MetricInfo{
+				"trace.span.event.count": {Description: "The number of span events observed."},
+			},
+			Metrics: map[string]countconnector.MetricInfo{
+				"metric.count": {Description: "The number of metrics observed."},
+			},
+			DataPoints: map[string]countconnector.MetricInfo{
+				"metric.datapoint.count": {Description: "The number of data points observed."},
+			},
+			Logs: map[string]countconnector.MetricInfo{
+				"log.record.count": {Description: "The number of log records observed."},
+			},
+		}
+	}
+
+	tests := []struct {
+		testName string
+		cfg      string
+		expected func() countconnector.Config
+		errorMsg string
+	}{
+		{
+			testName: "defaultConfig",
+			cfg: `
+			output {}
+			`,
+			expected: defaultConfig,
+		},
+		{
+			testName: "customMetrics",
+			cfg: `
+			span {
+				name        = "span.error.count"
+				description = "The number of spans with errors."
+				conditions  = ["status.code == STATUS_CODE_ERROR"]
+
+				attribute {
+					key           = "env"
+					default_value = "unknown"
+				}
+			}
+
+			log {
+				name       = "log.error.count"
+				conditions = ["severity_number >= SEVERITY_NUMBER_ERROR"]
+			}
+
+			log {
+				name = "log.count"
+			}
+
+			output {}
+			`,
+			expected: func() countconnector.Config {
+				cfg := defaultConfig()
+				cfg.Spans = map[string]countconnector.MetricInfo{
+					"span.error.count": {
+						Description: "The number of spans with errors.",
+						Conditions:  []string{"status.code == STATUS_CODE_ERROR"},
+						Attributes: []countconnector.AttributeConfig{
+							{Key: "env", DefaultValue: "unknown"},
+						},
+					},
+				}
+				cfg.Logs = map[string]countconnector.MetricInfo{
+					"log.error.count": {
+						Conditions: []string{"severity_number >= SEVERITY_NUMBER_ERROR"},
+					},
+					"log.count": {},
+				}
+				return cfg
+			},
+		},
+		{
+			testName: "duplicateMetric",
+			cfg: `
+			datapoint {
+				name = "datapoint.count"
+			}
+
+			datapoint {
+				name = "datapoint.count"
+			}
+
+			output {}
+			`,
+			errorMsg: `datapoint: metric "datapoint.count" is defined more than once`,
+		},
+		{
+			testName: "invalidCondition",
+			cfg: `
+			spanevent {
+				name       = "spanevent.count"
+				conditions = ["invalid condition"]
+			}
+
+			output {}
+			`,
+			errorMsg: `spanevents condition: metric "spanevent.count"`,
+		},
+		{
+			testName: "metricAttributes",
+			cfg: `
+			metric {
+				name = "metric.env.count"
+
+				attribute {
+					key = "env"
+				}
+			}
+
+			output {}
+			`,
+			errorMsg: `metrics attributes not supported: metric "metric.env.count"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testName, func(t *testing.T) {
+			var args count.Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			if tc.errorMsg != "" {
+				require.ErrorContains(t, err, tc.errorMsg)
+				return
+			}
+			require.NoError(t, err)
+
+			actualPtr, err := args.Convert()
+			require.NoError(t, err)
+
+			actual := actualPtr.(*countconnector.Config)
+			require.Equal(t, tc.expected(), *actual)
+		})
+	}
+}
+
+// Test performs a basic integration test which runs the
+// otelcol.connector.count component and ensures that it counts the log
+// records it receives.
+func Test(t *testing.T) {
+	ctx := componenttest.TestContext(t)
+	l := util.TestLogger(t)
+
+	ctrl, err := componenttest.NewControllerFromID(l, "otelcol.connector.count")
+	require.NoError(t, err)
+
+	cfg := `
+		log {
+			name       = "log.error.count"
+			conditions = ["severity_number >= SEVERITY_NUMBER_ERROR"]
+		}
+
+		output {
+			// no-op: will be overridden by test code.
+		}
+	`
+	var args count.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	// Override our arguments so metrics get forwarded to metricCh.
+	metricCh := make(chan pmetric.Metrics)
+	args.Output = makeMetricsOutput(metricCh)
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+	require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+
+	// Send logs in the background to our connector.
+	go func() {
+		exports := ctrl.Exports().(otelcol.ConsumerExports)
+
+		bo := backoff.New(ctx, backoff.Config{
+			MinBackoff: 10 * time.Millisecond,
+			MaxBackoff: 100 * time.Millisecond,
+		})
+		for bo.Ongoing() {
+			err := exports.Input.ConsumeLogs(ctx, createTestLogs())
+			if err != nil {
+				level.Error(l).Log("msg", "failed to send logs", "err", err)
+				bo.Wait()
+				continue
+			}
+
+			return
+		}
+	}()
+
+	// Wait for our connector to count the logs and forward the metric to
+	// metricCh.
+	select {
+	case <-time.After(time.Second):
+		require.FailNow(t, "failed waiting for metrics")
+	case md := <-metricCh:
+		require.Equal(t, 1, md.MetricCount())
+		metric := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
+		require.Equal(t, "log.error.count", metric.Name())
+		require.Equal(t, int64(2), metric.Sum().DataPoints().At(0).IntValue())
+	}
+}
+
+// makeMetricsOutput returns ConsumerArguments which will forward metrics to
+// the provided channel.
+func makeMetricsOutput(ch chan pmetric.Metrics) *otelcol.ConsumerArguments {
+	metricConsumer := fakeconsumer.Consumer{
+		ConsumeMetricsFunc: func(ctx context.Context, md pmetric.Metrics) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case ch <- md:
+				return nil
+			}
+		},
+	}
+
+	return &otelcol.ConsumerArguments{
+		Metrics: []otelcol.Consumer{&metricConsumer},
+	}
+}
+
+func createTestLogs() plog.Logs {
+	// Matches format from the protobuf definition:
+	// https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/logs/v1/logs.proto
+	var bb = `{
+		"resource_logs": [{
+			"scope_logs": [{
+				"log_records": [
+					{ "severity_number": 17, "body": { "stringValue": "error" } },
+					{ "severity_number": 9, "body": { "stringValue": "info" } },
+					{ "severity_number": 21, "body": { "stringValue": "fatal" } }
+				]
+			}]
+		}]
+	}`
+
+	decoder := &plog.JSONUnmarshaler{}
+	data, err := decoder.UnmarshalLogs([]byte(bb))
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
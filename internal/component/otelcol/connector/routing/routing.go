@@ -0,0 +1,145 @@
+// Package routing provides an otelcol.connector.routing component.
+package routing
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/component/otelcol"
+	"github.com/grafana/agent/internal/component/otelcol/connector"
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/river"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/connector/routingconnector"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelextension "go.opentelemetry.io/collector/extension"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:      "otelcol.connector.routing",
+		Stability: featuregate.StabilityExperimental,
+		Args:      Arguments{},
+		Exports:   otelcol.ConsumerExports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			fact := routingconnector.NewFactory()
+			return connector.New(opts, fact, args.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the otelcol.connector.routing component.
+type Arguments struct {
+	// ErrorMode determines how the connector reacts to errors that occur while
+	// evaluating the statement of a route.
+	ErrorMode ottl.ErrorMode `river:"error_mode,attr,optional"`
+	// MatchOnce determines whether data is only sent to the first route whose
+	// statement matches.
+	MatchOnce bool `river:"match_once,attr,optional"`
+
+	Routes []Route `river:"route,block"`
+
+	// Output configures where to send the data which doesn't match any route.
+	// Required.
+	Output *otelcol.ConsumerArguments `river:"output,block"`
+}
+
+var (
+	_ river.Validator            = (*Arguments)(nil)
+	_ river.Defaulter            = (*Arguments)(nil)
+	_ connector.RoutingArguments = (*Arguments)(nil)
+)
+
+// Route configures where to send the data matching an OTTL statement.
+type Route struct {
+	Statement string `river:"statement,attr"`
+
+	// Output configures where to send the matching data. Required.
+	Output *otelcol.ConsumerArguments `river:"output,block"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	ErrorMode: ottl.PropagateError,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if len(args.Routes) == 0 {
+		return errors.New("at least one route must be defined")
+	}
+	return args.convertImpl().Validate()
+}
+
+// The OpenTelemetry Collector connector sends data to pipelines, which are
+// the outputs of the routes.
+var routeType = otelcomponent.MustNewType("route")
+
+// routeID returns the ID of the pipeline of the route at index i, or of the
+// default output if i is negative.
+func routeID(i int) otelcomponent.ID {
+	if i < 0 {
+		return otelcomponent.NewIDWithName(routeType, "default")
+	}
+	return otelcomponent.NewIDWithName(routeType, strconv.Itoa(i))
+}
+
+// Convert implements connector.Arguments.
+func (args Arguments) Convert() (otelcomponent.Config, error) {
+	return args.convertImpl(), nil
+}
+
+// convertImpl is a helper function which returns the real type of the config,
+// instead of the otelcomponent.Config interface.
+func (args Arguments) convertImpl() *routingconnector.Config {
+	res := &routingconnector.Config{
+		DefaultPipelines: []otelcomponent.ID{routeID(-1)},
+		ErrorMode:        args.ErrorMode,
+		MatchOnce:        args.MatchOnce,
+	}
+	for i, route := range args.Routes {
+		res.Table = append(res.Table, routingconnector.RoutingTableItem{
+			Statement: route.Statement,
+			Pipelines: []otelcomponent.ID{routeID(i)},
+		})
+	}
+	return res
+}
+
+// Extensions implements connector.Arguments.
+func (args Arguments) Extensions() map[otelcomponent.ID]otelextension.Extension {
+	return nil
+}
+
+// Exporters implements connector.Arguments.
+func (args Arguments) Exporters() map[otelcomponent.DataType]map[otelcomponent.ID]otelcomponent.Component {
+	return nil
+}
+
+// NextConsumers implements connector.Arguments. It returns the default
+// output, while the outputs of the routes are returned by Pipelines.
+func (args Arguments) NextConsumers() *otelcol.ConsumerArguments {
+	return args.Output
+}
+
+// Pipelines implements connector.RoutingArguments.
+func (args Arguments) Pipelines() map[otelcomponent.ID]*otelcol.ConsumerArguments {
+	res := make(map[otelcomponent.ID]*otelcol.ConsumerArguments, len(args.Routes)+1)
+	res[routeID(-1)] = args.Output
+	for i, route := range args.Routes {
+		res[routeID(i)] = route.Output
+	}
+	return res
+}
+
+// ConnectorType() int implements connector.Arguments.
+func (Arguments) ConnectorType() int {
+	return connector.ConnectorAllToSame
+}
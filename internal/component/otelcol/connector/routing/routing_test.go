@@ -0,0 +1,231 @@
+package routing_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/internal/component/otelcol"
+	"github.com/grafana/agent/internal/component/otelcol/connector/routing"
+	"github.com/grafana/agent/internal/component/otelcol/internal/fakeconsumer"
+	"github.com/grafana/agent/internal/flow/componenttest"
+	"github.com/grafana/agent/internal/flow/logging/level"
+	"github.com/grafana/agent/internal/util"
+	"github.com/grafana/dskit/backoff"
+	"github.com/grafana/river"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/connector/routingconnector"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"github.com/stretchr/testify/require"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	tests := []struct {
+		testName string
+		cfg      string
+		expected routingconnector.Config
+		errorMsg string
+	}{
+		{
+			testName: "defaultConfig",
+			cfg: `
+			route {
+				statement = "route() where attributes[\"tenant\"] == \"acme\""
+				output {}
+			}
+
+			output {}
+			`,
+			expected: routingconnector.Config{
+				DefaultPipelines: []otelcomponent.ID{otelcomponent.MustNewIDWithName("route", "default")},
+				ErrorMode:        ottl.PropagateError,
+				Table: []routingconnector.RoutingTableItem{{
+					Statement: `route() where attributes["tenant"] == "acme"`,
+					Pipelines: []otelcomponent.ID{otelcomponent.MustNewIDWithName("route", "0")},
+				}},
+			},
+		},
+		{
+			testName: "explicitConfig",
+			cfg: `
+			error_mode = "ignore"
+			match_once = true
+
+			route {
+				statement = "route() where attributes[\"tenant\"] == \"acme\""
+				output {}
+			}
+
+			route {
+				statement = "route() where attributes[\"tenant\"] == \"globex\""
+				output {}
+			}
+
+			output {}
+			`,
+			expected: routingconnector.Config{
+				DefaultPipelines: []otelcomponent.ID{otelcomponent.MustNewIDWithName("route", "default")},
+				ErrorMode:        ottl.IgnoreError,
+				MatchOnce:        true,
+				Table: []routingconnector.RoutingTableItem{
+					{
+						Statement: `route() where attributes["tenant"] == "acme"`,
+						Pipelines: []otelcomponent.ID{otelcomponent.MustNewIDWithName("route", "0")},
+					},
+					{
+						Statement: `route() where attributes["tenant"] == "globex"`,
+						Pipelines: []otelcomponent.ID{otelcomponent.MustNewIDWithName("route", "1")},
+					},
+				},
+			},
+		},
+		{
+			testName: "noRoutes",
+			cfg: `
+			output {}
+			`,
+			errorMsg: `missing required block "route"`,
+		},
+		{
+			testName: "emptyStatement",
+			cfg: `
+			route {
+				statement = ""
+				output {}
+			}
+
+			output {}
+			`,
+			errorMsg: "invalid route: no statement provided",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testName, func(t *testing.T) {
+			var args routing.Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			if tc.errorMsg != "" {
+				require.ErrorContains(t, err, tc.errorMsg)
+				return
+			}
+			require.NoError(t, err)
+
+			actualPtr, err := args.Convert()
+			require.NoError(t, err)
+
+			actual := actualPtr.(*routingconnector.Config)
+			require.Equal(t, tc.expected, *actual)
+		})
+	}
+}
+
+// Test performs a basic integration test which runs the
+// otelcol.connector.routing component and ensures that it routes the traces
+// it receives to the output of the matching route, or to its default output.
+func Test(t *testing.T) {
+	ctx := componenttest.TestContext(t)
+	l := util.TestLogger(t)
+
+	ctrl, err := componenttest.NewControllerFromID(l, "otelcol.connector.routing")
+	require.NoError(t, err)
+
+	cfg := `
+		route {
+			statement = "route() where attributes[\"tenant\"] == \"acme\""
+
+			output {
+				// no-op: will be overridden by test code.
+			}
+		}
+
+		output {
+			// no-op: will be overridden by test code.
+		}
+	`
+	var args routing.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	// Override our arguments so traces get forwarded to routeCh and
+	// defaultCh.
+	var (
+		routeCh   = make(chan ptrace.Traces)
+		defaultCh = make(chan ptrace.Traces)
+	)
+	args.Routes[0].Output = makeTracesOutput(routeCh)
+	args.Output = makeTracesOutput(defaultCh)
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+	require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+
+	tt := []struct {
+		tenant string
+		ch     chan ptrace.Traces
+	}{
+		{tenant: "acme", ch: routeCh},
+		{tenant: "globex", ch: defaultCh},
+	}
+	for _, tc := range tt {
+		// Send traces in the background to our connector.
+		go func(tenant string) {
+			exports := ctrl.Exports().(otelcol.ConsumerExports)
+
+			bo := backoff.New(ctx, backoff.Config{
+				MinBackoff: 10 * time.Millisecond,
+				MaxBackoff: 100 * time.Millisecond,
+			})
+			for bo.Ongoing() {
+				err := exports.Input.ConsumeTraces(ctx, createTestTraces(tenant))
+				if err != nil {
+					level.Error(l).Log("msg", "failed to send traces", "err", err)
+					bo.Wait()
+					continue
+				}
+
+				return
+			}
+		}(tc.tenant)
+
+		// Wait for our connector to route the traces.
+		select {
+		case <-time.After(time.Second):
+			require.FailNow(t, "failed waiting for traces", "tenant %s", tc.tenant)
+		case tr := <-tc.ch:
+			require.Equal(t, 1, tr.SpanCount())
+			tenant, _ := tr.ResourceSpans().At(0).Resource().Attributes().Get("tenant")
+			require.Equal(t, tc.tenant, tenant.Str())
+		}
+	}
+}
+
+// makeTracesOutput returns ConsumerArguments which will forward traces to the
+// provided channel.
+func makeTracesOutput(ch chan ptrace.Traces) *otelcol.ConsumerArguments {
+	traceConsumer := fakeconsumer.Consumer{
+		ConsumeTracesFunc: func(ctx context.Context, t ptrace.Traces) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case ch <- t:
+				return nil
+			}
+		},
+	}
+
+	return &otelcol.ConsumerArguments{
+		Traces: []otelcol.Consumer{&traceConsumer},
+	}
+}
+
+func createTestTraces(tenant string) ptrace.Traces {
+	data := ptrace.NewTraces()
+	rs := data.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("tenant", tenant)
+	rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("TestSpan")
+	return data
+}
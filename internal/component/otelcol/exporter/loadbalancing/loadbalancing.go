@@ -225,7 +225,11 @@ func (k8sSvcResolver *KubernetesResolver) Convert() loadbalancingexporter.K8sSvc
 
 // Extensions implements exporter.Arguments.
 func (args Arguments) Extensions() map[otelcomponent.ID]otelextension.Extension {
-	return args.Protocol.OTLP.Client.Extensions()
+	m := args.Protocol.OTLP.Client.Extensions()
+	for id, ext := range args.Protocol.OTLP.Queue.Extensions() {
+		m[id] = ext
+	}
+	return m
 }
 
 // Exporters implements exporter.Arguments.
@@ -69,7 +69,11 @@ func (args Arguments) Convert() (otelcomponent.Config, error) {
 
 // Extensions implements exporter.Arguments.
 func (args Arguments) Extensions() map[otelcomponent.ID]otelextension.Extension {
-	return (*otelcol.GRPCClientArguments)(&args.Client).Extensions()
+	m := (*otelcol.GRPCClientArguments)(&args.Client).Extensions()
+	for id, ext := range args.Queue.Extensions() {
+		m[id] = ext
+	}
+	return m
 }
 
 // Exporters implements exporter.Arguments.
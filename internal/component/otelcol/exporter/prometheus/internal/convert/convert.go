@@ -49,6 +49,7 @@ type Converter struct {
 
 	seriesCache   sync.Map // Cache of active series.
 	metadataCache sync.Map // Cache of active metadata entries.
+	deltaCache    sync.Map // Cumulative state of delta streams.
 
 	next storage.Appendable // Location to write converted metrics.
 }
@@ -67,6 +68,10 @@ type Options struct {
 	AddMetricSuffixes bool
 	// ResourceToTelemetryConversion controls whether to convert resource attributes to Prometheus-compatible datapoint attributes
 	ResourceToTelemetryConversion bool
+	// DeltaToCumulativeTTL is how long the cumulative state of a delta stream
+	// is kept after the stream was last updated. A stream which receives a data
+	// point after its state expired starts over from zero.
+	DeltaToCumulativeTTL time.Duration
 }
 
 var _ consumer.Metrics = (*Converter)(nil)
@@ -360,6 +365,20 @@ func (conv *Converter) writeExemplar(app storage.Appender, series *memorySeries,
 // resource, scope, metric, and attributes. The LastSeen field of the
 // *memorySeries is updated before returning.
 func (conv *Converter) getOrCreateSeries(res *memorySeries, scope *memorySeries, name string, attrs pcommon.Map, extraLabels ...labels.Label) *memorySeries {
+	labels := conv.seriesLabels(res, scope, name, attrs, extraLabels...)
+
+	entry := newMemorySeries(nil, labels)
+	if actual, loaded := conv.seriesCache.LoadOrStore(labels.String(), entry); loaded {
+		entry = actual.(*memorySeries)
+	}
+
+	entry.Ping()
+	return entry
+}
+
+// seriesLabels builds the labels of a series from the provided resource,
+// scope, metric, and attributes.
+func (conv *Converter) seriesLabels(res *memorySeries, scope *memorySeries, name string, attrs pcommon.Map, extraLabels ...labels.Label) labels.Labels {
 	seriesBaseLabels := labels.FromStrings(
 		model.MetricNameLabel, name,
 		model.JobLabel, res.metadata[model.JobLabel],
@@ -383,15 +402,7 @@ func (conv *Converter) getOrCreateSeries(res *memorySeries, scope *memorySeries,
 		return true
 	})
 
-	labels := lb.Labels()
-
-	entry := newMemorySeries(nil, labels)
-	if actual, loaded := conv.seriesCache.LoadOrStore(labels.String(), entry); loaded {
-		entry = actual.(*memorySeries)
-	}
-
-	entry.Ping()
-	return entry
+	return lb.Labels()
 }
 
 func getNumberDataPointValue(dp pmetric.NumberDataPoint) float64 {
@@ -418,18 +429,18 @@ func (conv *Converter) consumeSum(app storage.Appender, memResource *memorySerie
 	//   SHOULD be converted to a cumulative temporarlity and become a Prometheus
 	//   Sum.
 	// * Otherwise, it MUST be dropped.
-	var convType textparse.MetricType
+	var (
+		convType textparse.MetricType
+		isDelta  bool
+	)
 	switch {
 	case m.Sum().AggregationTemporality() == pmetric.AggregationTemporalityCumulative && m.Sum().IsMonotonic():
 		convType = textparse.MetricTypeCounter
 	case m.Sum().AggregationTemporality() == pmetric.AggregationTemporalityCumulative && !m.Sum().IsMonotonic():
 		convType = textparse.MetricTypeGauge
 	case m.Sum().AggregationTemporality() == pmetric.AggregationTemporalityDelta && m.Sum().IsMonotonic():
-		level.Debug(conv.log).Log("msg", "dropped unsupported delta sum")
-		// Drop non-cumulative summaries for now, which is permitted by the spec.
-		//
-		// TODO(rfratto): implement delta-to-cumulative for sums.
-		return
+		convType = textparse.MetricTypeCounter
+		isDelta = true
 	default:
 		// Drop the metric.
 		return
@@ -454,6 +465,13 @@ func (conv *Converter) consumeSum(app storage.Appender, memResource *memorySerie
 		memSeries := conv.getOrCreateSeries(memResource, memScope, metricName, dp.Attributes())
 
 		val := getNumberDataPointValue(dp)
+		if isDelta {
+			var ok bool
+			if val, ok = conv.accumulateSum(memSeries.labels.String(), dp); !ok {
+				level.Debug(conv.log).Log("msg", "dropped out-of-order delta sum data point", "metric name", metricName)
+				continue
+			}
+		}
 		if err := writeSeries(app, memSeries, dp, val); err != nil {
 			level.Error(conv.log).Log("msg", "failed to write metric sample", metricName, "err", err)
 		}
@@ -471,10 +489,9 @@ func (conv *Converter) consumeSum(app storage.Appender, memResource *memorySerie
 func (conv *Converter) consumeHistogram(app storage.Appender, memResource *memorySeries, memScope *memorySeries, m pmetric.Metric, resAttrs pcommon.Map) {
 	metricName := prometheus.BuildCompliantName(m, "", conv.opts.AddMetricSuffixes)
 
-	if m.Histogram().AggregationTemporality() != pmetric.AggregationTemporalityCumulative {
-		// Drop non-cumulative histograms for now, which is permitted by the spec.
-		//
-		// TODO(rfratto): implement delta-to-cumulative for histograms.
+	isDelta := m.Histogram().AggregationTemporality() == pmetric.AggregationTemporalityDelta
+	if !isDelta && m.Histogram().AggregationTemporality() != pmetric.AggregationTemporalityCumulative {
+		// Drop histograms with an unspecified temporality.
 		return
 	}
 
@@ -494,6 +511,16 @@ func (conv *Converter) consumeHistogram(app storage.Appender, memResource *memor
 			joinAttributeMaps(resAttrs, dp.Attributes())
 		}
 
+		if isDelta {
+			key := conv.seriesLabels(memResource, memScope, metricName, dp.Attributes()).String()
+
+			var ok bool
+			if dp, ok = conv.accumulateHistogram(key, dp); !ok {
+				level.Debug(conv.log).Log("msg", "dropped out-of-order delta histogram data point", "metric name", metricName)
+				continue
+			}
+		}
+
 		// Sum metric
 		if dp.HasSum() {
 			sumMetric := conv.getOrCreateSeries(memResource, memScope, metricName+"_sum", dp.Attributes())
@@ -607,8 +634,9 @@ func (conv *Converter) consumeHistogram(app storage.Appender, memResource *memor
 func (conv *Converter) consumeExponentialHistogram(app storage.Appender, memResource *memorySeries, memScope *memorySeries, m pmetric.Metric, resAttrs pcommon.Map) {
 	metricName := prometheus.BuildCompliantName(m, "", conv.opts.AddMetricSuffixes)
 
-	if m.ExponentialHistogram().AggregationTemporality() != pmetric.AggregationTemporalityCumulative {
-		// Drop non-cumulative histograms for now, which is permitted by the spec.
+	isDelta := m.ExponentialHistogram().AggregationTemporality() == pmetric.AggregationTemporalityDelta
+	if !isDelta && m.ExponentialHistogram().AggregationTemporality() != pmetric.AggregationTemporalityCumulative {
+		// Drop histograms with an unspecified temporality.
 		return
 	}
 
@@ -635,6 +663,14 @@ func (conv *Converter) consumeExponentialHistogram(app storage.Appender, memReso
 			// Out-of-order; skip.
 			continue
 		}
+
+		if isDelta {
+			var ok bool
+			if dp, ok = conv.accumulateExponentialHistogram(memSeries.labels.String(), dp); !ok {
+				level.Debug(conv.log).Log("msg", "dropped out-of-order delta histogram data point", "metric name", metricName)
+				continue
+			}
+		}
 		memSeries.SetTimestamp(ts)
 
 		promHistogram, err := exponentialToNativeHistogram(dp)
@@ -743,10 +779,16 @@ func (conv *Converter) consumeSummary(app storage.Appender, memResource *memoryS
 }
 
 // GC cleans up stale metrics which have not been updated in the time specified
-// by staleTime.
+// by staleTime. The cumulative state of delta streams is cleaned up after the
+// configured DeltaToCumulativeTTL instead, if set.
 func (conv *Converter) GC(staleTime time.Duration) {
 	now := time.Now()
 
+	deltaStaleTime := staleTime
+	if ttl := conv.getOpts().DeltaToCumulativeTTL; ttl > 0 {
+		deltaStaleTime = ttl
+	}
+
 	// In the code below, we use TryLock as a small performance optimization.
 	//
 	// The garbage collector doesn't bother to wait for locks for anything in the
@@ -778,6 +820,19 @@ func (conv *Converter) GC(staleTime time.Duration) {
 		}
 		return true
 	})
+
+	conv.deltaCache.Range(func(key, value any) bool {
+		stream := value.(*deltaStream)
+		if !stream.TryLock() {
+			return true
+		}
+		defer stream.Unlock()
+
+		if now.Sub(stream.lastSeen) > deltaStaleTime {
+			conv.deltaCache.Delete(key)
+		}
+		return true
+	})
 }
 
 // FlushMetadata empties out the metadata cache, forcing metadata to get
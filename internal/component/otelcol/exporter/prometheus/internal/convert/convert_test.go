@@ -3,7 +3,9 @@ package convert_test
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/grafana/agent/internal/component/otelcol/exporter/prometheus/internal/convert"
 	"github.com/grafana/agent/internal/util"
@@ -1333,6 +1335,270 @@ func TestConverterExponentialHistograms(t *testing.T) {
 	}
 }
 
+func TestConverterDeltaToCumulative(t *testing.T) {
+	deltaSum := func(start, ts int, value float64) string {
+		return fmt.Sprintf(`{
+			"resource_metrics": [{
+				"scope_metrics": [{
+					"metrics": [{
+						"name": "test_metric_total",
+						"sum": {
+							"aggregation_temporality": 1,
+							"is_monotonic": true,
+							"data_points": [{
+								"start_time_unix_nano": %d000000000,
+								"time_unix_nano": %d000000000,
+								"as_double": %v
+							}]
+						}
+					}]
+				}]
+			}]
+		}`, start, ts, value)
+	}
+	deltaHistogram := func(start, ts int, count int, sum float64, bounds string, buckets string) string {
+		return fmt.Sprintf(`{
+			"resource_metrics": [{
+				"scope_metrics": [{
+					"metrics": [{
+						"name": "test_metric_histogram",
+						"histogram": {
+							"aggregation_temporality": 1,
+							"data_points": [{
+								"start_time_unix_nano": %d000000000,
+								"time_unix_nano": %d000000000,
+								"count": %d,
+								"sum": %v,
+								"explicit_bounds": %s,
+								"bucket_counts": %s
+							}]
+						}
+					}]
+				}]
+			}]
+		}`, start, ts, count, sum, bounds, buckets)
+	}
+
+	tt := []struct {
+		name   string
+		inputs []string
+		expect string
+
+		deltaToCumulativeTTL time.Duration
+	}{
+		{
+			name: "Delta sum",
+			inputs: []string{
+				deltaSum(1, 2, 1),
+				deltaSum(2, 3, 2),
+				deltaSum(3, 4, 3),
+			},
+			expect: `
+				# TYPE test_metric counter
+				test_metric_total 6.0
+			`,
+		},
+		{
+			name: "Delta sum with gaps between intervals",
+			inputs: []string{
+				deltaSum(1, 2, 1),
+				deltaSum(5, 6, 2),
+			},
+			expect: `
+				# TYPE test_metric counter
+				test_metric_total 3.0
+			`,
+		},
+		{
+			name: "Delta sum drops out-of-order data points",
+			inputs: []string{
+				deltaSum(1, 2, 1),
+				deltaSum(2, 3, 2),
+				deltaSum(1, 2, 100),
+				deltaSum(2, 4, 100),
+				deltaSum(3, 4, 4),
+			},
+			expect: `
+				# TYPE test_metric counter
+				test_metric_total 7.0
+			`,
+		},
+		{
+			name: "Delta sum starts over after its state expired",
+			inputs: []string{
+				deltaSum(1, 2, 1),
+				deltaSum(2, 3, 2),
+			},
+			expect: `
+				# TYPE test_metric counter
+				test_metric_total 2.0
+			`,
+			deltaToCumulativeTTL: time.Nanosecond,
+		},
+		{
+			name: "Delta histogram",
+			inputs: []string{
+				deltaHistogram(1, 2, 3, 1.5, `[0.5, 1]`, `[1, 1, 1]`),
+				deltaHistogram(2, 3, 2, 2.5, `[0.5, 1]`, `[0, 0, 2]`),
+			},
+			expect: `
+				# TYPE test_metric_histogram histogram
+				test_metric_histogram_bucket{le="0.5"} 1
+				test_metric_histogram_bucket{le="1.0"} 2
+				test_metric_histogram_bucket{le="+Inf"} 5
+				test_metric_histogram_sum 4.0
+				test_metric_histogram_count 5
+			`,
+		},
+		{
+			name: "Delta histogram resets when buckets change",
+			inputs: []string{
+				deltaHistogram(1, 2, 3, 1.5, `[0.5, 1]`, `[1, 1, 1]`),
+				deltaHistogram(2, 3, 2, 2.5, `[0.5, 2]`, `[0, 1, 1]`),
+			},
+			expect: `
+				# TYPE test_metric_histogram histogram
+				test_metric_histogram_bucket{le="0.5"} 0
+				test_metric_histogram_bucket{le="2.0"} 1
+				test_metric_histogram_bucket{le="+Inf"} 2
+				test_metric_histogram_sum 2.5
+				test_metric_histogram_count 2
+			`,
+		},
+	}
+
+	decoder := &pmetric.JSONUnmarshaler{}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var app *testappender.Appender
+			appendable := appendableFunc(func() storage.Appender {
+				app = &testappender.Appender{HideTimestamps: true}
+				return app
+			})
+
+			l := util.TestLogger(t)
+			conv := convert.New(l, appendable, convert.Options{
+				DeltaToCumulativeTTL: tc.deltaToCumulativeTTL,
+			})
+
+			for _, input := range tc.inputs {
+				// Only the last input is compared; flush the metadata so that
+				// it gets written again.
+				conv.FlushMetadata()
+
+				payload, err := decoder.UnmarshalMetrics([]byte(input))
+				require.NoError(t, err)
+				require.NoError(t, conv.ConsumeMetrics(context.Background(), payload))
+			}
+
+			families, err := app.MetricFamilies()
+			require.NoError(t, err)
+
+			c := testappender.Comparer{OpenMetrics: true}
+			require.NoError(t, c.Compare(families, tc.expect))
+		})
+	}
+}
+
+func TestConverterDeltaToCumulativeExponentialHistograms(t *testing.T) {
+	inputs := []string{
+		`{
+			"resource_metrics": [{
+				"scope_metrics": [{
+					"metrics": [{
+						"name": "test_exponential_histogram",
+						"exponential_histogram": {
+							"aggregation_temporality": 1,
+							"data_points": [{
+								"start_time_unix_nano": 1000000000,
+								"time_unix_nano": 2000000000,
+								"scale": 1,
+								"count": 4,
+								"sum": 10,
+								"zero_count": 1,
+								"positive": {
+									"offset": 0,
+									"bucket_counts": [1, 2]
+								}
+							}]
+						}
+					}]
+				}]
+			}]
+		}`,
+		// The scale decreases, so the buckets accumulated so far are merged at
+		// the lower scale.
+		`{
+			"resource_metrics": [{
+				"scope_metrics": [{
+					"metrics": [{
+						"name": "test_exponential_histogram",
+						"exponential_histogram": {
+							"aggregation_temporality": 1,
+							"data_points": [{
+								"start_time_unix_nano": 2000000000,
+								"time_unix_nano": 3000000000,
+								"scale": 0,
+								"count": 2,
+								"sum": 5,
+								"positive": {
+									"offset": 0,
+									"bucket_counts": [1, 1]
+								}
+							}]
+						}
+					}]
+				}]
+			}]
+		}`,
+	}
+
+	var app *testappender.Appender
+	appendable := appendableFunc(func() storage.Appender {
+		app = &testappender.Appender{}
+		return app
+	})
+	conv := convert.New(util.TestLogger(t), appendable, convert.Options{})
+
+	decoder := &pmetric.JSONUnmarshaler{}
+	for _, input := range inputs {
+		payload, err := decoder.UnmarshalMetrics([]byte(input))
+		require.NoError(t, err)
+		require.NoError(t, conv.ConsumeMetrics(context.Background(), payload))
+	}
+
+	families, err := app.MetricFamilies()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	require.NotNil(t, families[0].Metric[0].Histogram)
+
+	histJsonRep, err := json.Marshal(families[0].Metric[0].Histogram)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"positive_delta": [4, -3],
+		"positive_span": [
+			{
+				"length": 2,
+				"offset": 1
+			}
+		],
+		"sample_count": 6,
+		"sample_sum": 15,
+		"schema": 0,
+		"zero_count": 1,
+		"zero_threshold": 1e-128
+	}`, string(histJsonRep))
+}
+
+// appendableFunc returns a new Appender from a function.
+type appendableFunc func() storage.Appender
+
+var _ storage.Appendable = appendableFunc(nil)
+
+func (f appendableFunc) Appender(context.Context) storage.Appender {
+	return f()
+}
+
 // appenderAppendable always returns the same Appender.
 type appenderAppendable struct {
 	Inner storage.Appender
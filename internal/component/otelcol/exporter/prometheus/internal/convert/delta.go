@@ -0,0 +1,358 @@
+package convert
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// deltaStream holds the cumulative state for a single stream of delta data
+// points. Prometheus has no concept of delta temporality, so each delta data
+// point is added to the running total of its stream before being written.
+type deltaStream struct {
+	sync.Mutex
+
+	kind      pmetric.MetricType // Type of the metric accumulated into the stream.
+	start     pcommon.Timestamp  // Start of the cumulative stream.
+	timestamp pcommon.Timestamp  // Timestamp of the last accumulated data point.
+	lastSeen  time.Time          // Timestamp used for garbage collection.
+
+	// Set when the stream holds accumulated state. Unset streams are started
+	// from scratch by the next data point.
+	initialized bool
+
+	// Sums.
+	value float64
+
+	// Explicit bucket histograms.
+	count        uint64
+	sum          float64
+	hasSum       bool
+	min, max     float64
+	hasMinMax    bool
+	bounds       []float64
+	bucketCounts []uint64
+
+	// Exponential histograms.
+	scale         int32
+	zeroCount     uint64
+	zeroThreshold float64
+	positive      expBuckets
+	negative      expBuckets
+}
+
+// getOrCreateDeltaStream gets or creates the [*deltaStream] identified by key.
+func (conv *Converter) getOrCreateDeltaStream(key string) *deltaStream {
+	entry := &deltaStream{}
+	if actual, loaded := conv.deltaCache.LoadOrStore(key, entry); loaded {
+		entry = actual.(*deltaStream)
+	}
+	return entry
+}
+
+// accept prepares the stream for accumulating a data point of the given kind
+// covering the interval (start, ts]. It reports false if the data point must
+// be dropped because it is out-of-order or overlaps with data points which
+// have already been accumulated.
+//
+// Streams which have not been seen within ttl, or which previously
+// accumulated a different kind of metric, are reset before accepting the
+// data point. accept must be called with the stream locked.
+func (s *deltaStream) accept(kind pmetric.MetricType, start, ts pcommon.Timestamp, ttl time.Duration) bool {
+	now := time.Now()
+
+	if s.initialized && (s.kind != kind || (ttl > 0 && now.Sub(s.lastSeen) > ttl)) {
+		s.reset()
+	}
+
+	if s.initialized {
+		if ts <= s.timestamp {
+			// Out-of-order or duplicate data point; skip.
+			return false
+		}
+		if start != 0 && start < s.timestamp {
+			// The interval of the data point overlaps with data points that
+			// have already been accumulated; adding it would double count.
+			return false
+		}
+	}
+
+	s.kind = kind
+	s.timestamp = ts
+	s.lastSeen = now
+	return true
+}
+
+// begin marks the stream as initialized, starting a new cumulative stream at
+// start if the stream was previously reset.
+func (s *deltaStream) begin(start, ts pcommon.Timestamp) {
+	if s.initialized {
+		return
+	}
+	s.initialized = true
+	s.start = start
+	if s.start == 0 {
+		s.start = ts
+	}
+}
+
+// reset discards the accumulated state of the stream. The next accepted data
+// point starts a new cumulative stream, which Prometheus will observe as a
+// counter reset.
+func (s *deltaStream) reset() {
+	s.initialized = false
+	s.start, s.timestamp = 0, 0
+
+	s.value = 0
+
+	s.count, s.sum, s.hasSum = 0, 0, false
+	s.min, s.max, s.hasMinMax = 0, 0, false
+	s.bounds, s.bucketCounts = nil, nil
+
+	s.scale, s.zeroCount, s.zeroThreshold = 0, 0, 0
+	s.positive, s.negative = expBuckets{}, expBuckets{}
+}
+
+// accumulateSum adds the delta data point dp to the running total of the
+// stream identified by key. It returns the cumulative value to write, or
+// false if dp must be dropped.
+func (conv *Converter) accumulateSum(key string, dp pmetric.NumberDataPoint) (float64, bool) {
+	s := conv.getOrCreateDeltaStream(key)
+	s.Lock()
+	defer s.Unlock()
+
+	if !s.accept(pmetric.MetricTypeSum, dp.StartTimestamp(), dp.Timestamp(), conv.getOpts().DeltaToCumulativeTTL) {
+		return 0, false
+	}
+	if dp.Flags().NoRecordedValue() {
+		// The stream has ended; start over from the next data point.
+		s.reset()
+		return 0, true
+	}
+
+	s.begin(dp.StartTimestamp(), dp.Timestamp())
+	s.value += getNumberDataPointValue(dp)
+	return s.value, true
+}
+
+// accumulateHistogram adds the delta data point dp to the stream identified
+// by key. It returns a cumulative copy of dp to write, or false if dp must be
+// dropped.
+func (conv *Converter) accumulateHistogram(key string, dp pmetric.HistogramDataPoint) (pmetric.HistogramDataPoint, bool) {
+	s := conv.getOrCreateDeltaStream(key)
+	s.Lock()
+	defer s.Unlock()
+
+	if !s.accept(pmetric.MetricTypeHistogram, dp.StartTimestamp(), dp.Timestamp(), conv.getOpts().DeltaToCumulativeTTL) {
+		return pmetric.HistogramDataPoint{}, false
+	}
+
+	out := pmetric.NewHistogramDataPoint()
+	dp.CopyTo(out)
+
+	if dp.Flags().NoRecordedValue() {
+		s.reset()
+		return out, true
+	}
+
+	// Data points can't be added together if their buckets don't match. Treat
+	// a change in bucket layout as a reset.
+	if s.initialized && !equalBounds(s.bounds, dp.ExplicitBounds()) {
+		s.reset()
+		s.kind = pmetric.MetricTypeHistogram
+		s.timestamp = dp.Timestamp()
+	}
+	if !s.initialized {
+		s.bounds = dp.ExplicitBounds().AsRaw()
+		s.bucketCounts = make([]uint64, dp.BucketCounts().Len())
+	}
+	s.begin(dp.StartTimestamp(), dp.Timestamp())
+
+	s.count += dp.Count()
+	if dp.HasSum() {
+		s.sum += dp.Sum()
+		s.hasSum = true
+	}
+	if dp.HasMin() && dp.HasMax() {
+		if !s.hasMinMax {
+			s.min, s.max = dp.Min(), dp.Max()
+		} else {
+			s.min, s.max = math.Min(s.min, dp.Min()), math.Max(s.max, dp.Max())
+		}
+		s.hasMinMax = true
+	}
+	for i := 0; i < dp.BucketCounts().Len(); i++ {
+		if i >= len(s.bucketCounts) {
+			s.bucketCounts = append(s.bucketCounts, 0)
+		}
+		s.bucketCounts[i] += dp.BucketCounts().At(i)
+	}
+
+	out.SetStartTimestamp(s.start)
+	out.SetCount(s.count)
+	if s.hasSum {
+		out.SetSum(s.sum)
+	}
+	if s.hasMinMax {
+		out.SetMin(s.min)
+		out.SetMax(s.max)
+	}
+	out.BucketCounts().FromRaw(s.bucketCounts)
+	return out, true
+}
+
+func equalBounds(bounds []float64, other pcommon.Float64Slice) bool {
+	if len(bounds) != other.Len() {
+		return false
+	}
+	for i, bound := range bounds {
+		if bound != other.At(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// accumulateExponentialHistogram adds the delta data point dp to the stream
+// identified by key. It returns a cumulative copy of dp to write, or false if
+// dp must be dropped.
+//
+// Data points with different scales are merged at the smallest scale of
+// either, so the resolution of the cumulative histogram only ever decreases
+// until the stream is reset.
+func (conv *Converter) accumulateExponentialHistogram(key string, dp pmetric.ExponentialHistogramDataPoint) (pmetric.ExponentialHistogramDataPoint, bool) {
+	s := conv.getOrCreateDeltaStream(key)
+	s.Lock()
+	defer s.Unlock()
+
+	if !s.accept(pmetric.MetricTypeExponentialHistogram, dp.StartTimestamp(), dp.Timestamp(), conv.getOpts().DeltaToCumulativeTTL) {
+		return pmetric.ExponentialHistogramDataPoint{}, false
+	}
+
+	out := pmetric.NewExponentialHistogramDataPoint()
+	dp.CopyTo(out)
+
+	if dp.Flags().NoRecordedValue() {
+		s.reset()
+		return out, true
+	}
+
+	// Observations in the zero bucket can't be redistributed if the zero
+	// threshold changes. Treat it as a reset.
+	if s.initialized && s.zeroThreshold != dp.ZeroThreshold() {
+		s.reset()
+		s.kind = pmetric.MetricTypeExponentialHistogram
+		s.timestamp = dp.Timestamp()
+	}
+
+	positive := newExpBuckets(dp.Positive())
+	negative := newExpBuckets(dp.Negative())
+	if !s.initialized {
+		s.scale = dp.Scale()
+		s.zeroThreshold = dp.ZeroThreshold()
+	}
+	s.begin(dp.StartTimestamp(), dp.Timestamp())
+
+	if scale := dp.Scale(); scale < s.scale {
+		s.positive = s.positive.downscale(s.scale - scale)
+		s.negative = s.negative.downscale(s.scale - scale)
+		s.scale = scale
+	} else if scale > s.scale {
+		positive = positive.downscale(scale - s.scale)
+		negative = negative.downscale(scale - s.scale)
+	}
+	s.positive = s.positive.merge(positive)
+	s.negative = s.negative.merge(negative)
+
+	s.count += dp.Count()
+	s.zeroCount += dp.ZeroCount()
+	if dp.HasSum() {
+		s.sum += dp.Sum()
+		s.hasSum = true
+	}
+	if dp.HasMin() && dp.HasMax() {
+		if !s.hasMinMax {
+			s.min, s.max = dp.Min(), dp.Max()
+		} else {
+			s.min, s.max = math.Min(s.min, dp.Min()), math.Max(s.max, dp.Max())
+		}
+		s.hasMinMax = true
+	}
+
+	out.SetStartTimestamp(s.start)
+	out.SetScale(s.scale)
+	out.SetCount(s.count)
+	out.SetZeroCount(s.zeroCount)
+	if s.hasSum {
+		out.SetSum(s.sum)
+	}
+	if s.hasMinMax {
+		out.SetMin(s.min)
+		out.SetMax(s.max)
+	}
+	s.positive.CopyTo(out.Positive())
+	s.negative.CopyTo(out.Negative())
+	return out, true
+}
+
+// expBuckets is a dense set of exponential histogram buckets starting at
+// index offset.
+type expBuckets struct {
+	offset int32
+	counts []uint64
+}
+
+func newExpBuckets(b pmetric.ExponentialHistogramDataPointBuckets) expBuckets {
+	return expBuckets{offset: b.Offset(), counts: b.BucketCounts().AsRaw()}
+}
+
+// downscale returns the buckets reduced in scale by the given amount. Each
+// reduction in scale merges pairs of neighbouring buckets.
+func (b expBuckets) downscale(by int32) expBuckets {
+	if by <= 0 || len(b.counts) == 0 {
+		return b
+	}
+
+	// Bucket indices are floored when downscaling, which an arithmetic shift
+	// does for negative indices too.
+	res := expBuckets{offset: b.offset >> by}
+	for i, c := range b.counts {
+		idx := int((b.offset+int32(i))>>by - res.offset)
+		for idx >= len(res.counts) {
+			res.counts = append(res.counts, 0)
+		}
+		res.counts[idx] += c
+	}
+	return res
+}
+
+// merge returns the sum of b and other, which must have the same scale.
+func (b expBuckets) merge(other expBuckets) expBuckets {
+	if len(other.counts) == 0 {
+		return b
+	}
+	if len(b.counts) == 0 {
+		return expBuckets{offset: other.offset, counts: append([]uint64(nil), other.counts...)}
+	}
+
+	lo := min(b.offset, other.offset)
+	hi := max(b.offset+int32(len(b.counts)), other.offset+int32(len(other.counts)))
+
+	res := expBuckets{offset: lo, counts: make([]uint64, hi-lo)}
+	for i, c := range b.counts {
+		res.counts[int(b.offset-lo)+i] += c
+	}
+	for i, c := range other.counts {
+		res.counts[int(other.offset-lo)+i] += c
+	}
+	return res
+}
+
+// CopyTo copies the buckets into dest.
+func (b expBuckets) CopyTo(dest pmetric.ExponentialHistogramDataPointBuckets) {
+	dest.SetOffset(b.offset)
+	dest.BucketCounts().FromRaw(b.counts)
+}
@@ -40,6 +40,7 @@ type Arguments struct {
 	ForwardTo                     []storage.Appendable `river:"forward_to,attr"`
 	AddMetricSuffixes             bool                 `river:"add_metric_suffixes,attr,optional"`
 	ResourceToTelemetryConversion bool                 `river:"resource_to_telemetry_conversion,attr,optional"`
+	DeltaToCumulativeTTL          time.Duration        `river:"delta_to_cumulative_ttl,attr,optional"`
 }
 
 // DefaultArguments holds defaults values.
@@ -50,6 +51,7 @@ var DefaultArguments = Arguments{
 	GCFrequency:                   5 * time.Minute,
 	AddMetricSuffixes:             true,
 	ResourceToTelemetryConversion: false,
+	DeltaToCumulativeTTL:          5 * time.Minute,
 }
 
 // SetToDefault implements river.Defaulter.
@@ -62,6 +64,9 @@ func (args *Arguments) Validate() error {
 	if args.GCFrequency == 0 {
 		return fmt.Errorf("gc_frequency must be greater than 0")
 	}
+	if args.DeltaToCumulativeTTL <= 0 {
+		return fmt.Errorf("delta_to_cumulative_ttl must be greater than 0")
+	}
 
 	return nil
 }
@@ -159,5 +164,6 @@ func convertArgumentsToConvertOptions(args Arguments) convert.Options {
 		IncludeScopeInfo:              args.IncludeScopeInfo,
 		AddMetricSuffixes:             args.AddMetricSuffixes,
 		ResourceToTelemetryConversion: args.ResourceToTelemetryConversion,
+		DeltaToCumulativeTTL:          args.DeltaToCumulativeTTL,
 	}
 }
@@ -30,6 +30,7 @@ func TestArguments_UnmarshalRiver(t *testing.T) {
 				AddMetricSuffixes:             true,
 				ForwardTo:                     []storage.Appendable{},
 				ResourceToTelemetryConversion: false,
+				DeltaToCumulativeTTL:          5 * time.Minute,
 			},
 		},
 		{
@@ -41,6 +42,7 @@ func TestArguments_UnmarshalRiver(t *testing.T) {
 					gc_frequency = "1s"
 					add_metric_suffixes = false
 					resource_to_telemetry_conversion = true
+					delta_to_cumulative_ttl = "10m"
 					forward_to = []
 				`,
 			expected: prometheus.Arguments{
@@ -51,6 +53,7 @@ func TestArguments_UnmarshalRiver(t *testing.T) {
 				AddMetricSuffixes:             false,
 				ForwardTo:                     []storage.Appendable{},
 				ResourceToTelemetryConversion: true,
+				DeltaToCumulativeTTL:          10 * time.Minute,
 			},
 		},
 		{
@@ -61,6 +64,14 @@ func TestArguments_UnmarshalRiver(t *testing.T) {
 				`,
 			errorMsg: "gc_frequency must be greater than 0",
 		},
+		{
+			testName: "Zero DeltaToCumulativeTTL",
+			cfg: `
+					delta_to_cumulative_ttl = "0s"
+					forward_to = []
+				`,
+			errorMsg: "delta_to_cumulative_ttl must be greater than 0",
+		},
 	}
 
 	for _, tc := range tests {
@@ -0,0 +1,192 @@
+// Package livedebuggingconsumer implements consumers which publish the data
+// they consume to the live debugging streams of a component before forwarding
+// it.
+package livedebuggingconsumer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/agent/internal/service/livedebugging"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Traces wraps next to publish the spans it consumes. It returns nil if next
+// is nil.
+func Traces(next otelconsumer.Traces, pub livedebugging.Publisher, componentID string, dir livedebugging.Direction) otelconsumer.Traces {
+	if next == nil {
+		return nil
+	}
+	return &traces{next: next, publisher: publisher{pub, componentID, dir}}
+}
+
+// Metrics wraps next to publish the metrics it consumes. It returns nil if
+// next is nil.
+func Metrics(next otelconsumer.Metrics, pub livedebugging.Publisher, componentID string, dir livedebugging.Direction) otelconsumer.Metrics {
+	if next == nil {
+		return nil
+	}
+	return &metrics{next: next, publisher: publisher{pub, componentID, dir}}
+}
+
+// Logs wraps next to publish the log records it consumes. It returns nil if
+// next is nil.
+func Logs(next otelconsumer.Logs, pub livedebugging.Publisher, componentID string, dir livedebugging.Direction) otelconsumer.Logs {
+	if next == nil {
+		return nil
+	}
+	return &logs{next: next, publisher: publisher{pub, componentID, dir}}
+}
+
+type publisher struct {
+	pub         livedebugging.Publisher
+	componentID string
+	dir         livedebugging.Direction
+}
+
+func (p publisher) active() bool {
+	return p.pub.IsActive(p.componentID)
+}
+
+func (p publisher) publish(typ livedebugging.DataType, render func() string) {
+	p.pub.Publish(livedebugging.Data{
+		ComponentID: p.componentID,
+		Type:        typ,
+		Direction:   p.dir,
+		Render:      render,
+	})
+}
+
+type traces struct {
+	next otelconsumer.Traces
+	publisher
+}
+
+func (c *traces) Capabilities() otelconsumer.Capabilities { return c.next.Capabilities() }
+
+func (c *traces) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	if c.active() {
+		for i := 0; i < td.ResourceSpans().Len(); i++ {
+			rs := td.ResourceSpans().At(i)
+			for j := 0; j < rs.ScopeSpans().Len(); j++ {
+				spans := rs.ScopeSpans().At(j).Spans()
+				for k := 0; k < spans.Len(); k++ {
+					span := spans.At(k)
+					c.publish(livedebugging.DataTypeSpan, func() string {
+						return fmt.Sprintf(
+							"name=%q trace_id=%s span_id=%s kind=%s status=%s attributes=%s resource=%s",
+							span.Name(), span.TraceID(), span.SpanID(), span.Kind(), span.Status().Code(),
+							formatAttributes(span.Attributes()), formatAttributes(rs.Resource().Attributes()),
+						)
+					})
+				}
+			}
+		}
+	}
+	return c.next.ConsumeTraces(ctx, td)
+}
+
+type metrics struct {
+	next otelconsumer.Metrics
+	publisher
+}
+
+func (c *metrics) Capabilities() otelconsumer.Capabilities { return c.next.Capabilities() }
+
+func (c *metrics) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+	if c.active() {
+		for i := 0; i < md.ResourceMetrics().Len(); i++ {
+			rm := md.ResourceMetrics().At(i)
+			for j := 0; j < rm.ScopeMetrics().Len(); j++ {
+				metrics := rm.ScopeMetrics().At(j).Metrics()
+				for k := 0; k < metrics.Len(); k++ {
+					c.publishMetric(metrics.At(k), rm.Resource().Attributes())
+				}
+			}
+		}
+	}
+	return c.next.ConsumeMetrics(ctx, md)
+}
+
+// publishMetric publishes each data point of gauges and sums, and a summary
+// of other metrics.
+func (c *metrics) publishMetric(m pmetric.Metric, resource pcommon.Map) {
+	var points pmetric.NumberDataPointSlice
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		points = m.Gauge().DataPoints()
+	case pmetric.MetricTypeSum:
+		points = m.Sum().DataPoints()
+	default:
+		c.publish(livedebugging.DataTypeMetric, func() string {
+			return fmt.Sprintf("name=%q type=%s resource=%s", m.Name(), m.Type(), formatAttributes(resource))
+		})
+		return
+	}
+
+	for i := 0; i < points.Len(); i++ {
+		dp := points.At(i)
+		c.publish(livedebugging.DataTypeMetric, func() string {
+			var value any
+			if dp.ValueType() == pmetric.NumberDataPointValueTypeInt {
+				value = dp.IntValue()
+			} else {
+				value = dp.DoubleValue()
+			}
+			return fmt.Sprintf(
+				"%s name=%q type=%s value=%v attributes=%s resource=%s",
+				dp.Timestamp(), m.Name(), m.Type(), value,
+				formatAttributes(dp.Attributes()), formatAttributes(resource),
+			)
+		})
+	}
+}
+
+type logs struct {
+	next otelconsumer.Logs
+	publisher
+}
+
+func (c *logs) Capabilities() otelconsumer.Capabilities { return c.next.Capabilities() }
+
+func (c *logs) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	if c.active() {
+		for i := 0; i < ld.ResourceLogs().Len(); i++ {
+			rl := ld.ResourceLogs().At(i)
+			for j := 0; j < rl.ScopeLogs().Len(); j++ {
+				records := rl.ScopeLogs().At(j).LogRecords()
+				for k := 0; k < records.Len(); k++ {
+					lr := records.At(k)
+					c.publish(livedebugging.DataTypeLog, func() string {
+						return fmt.Sprintf(
+							"%s severity=%s body=%q attributes=%s resource=%s",
+							lr.Timestamp(), lr.SeverityText(), lr.Body().AsString(),
+							formatAttributes(lr.Attributes()), formatAttributes(rl.Resource().Attributes()),
+						)
+					})
+				}
+			}
+		}
+	}
+	return c.next.ConsumeLogs(ctx, ld)
+}
+
+// formatAttributes formats attrs as {key="value", ...}.
+func formatAttributes(attrs pcommon.Map) string {
+	var sb strings.Builder
+	sb.WriteByte('{')
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		if sb.Len() > 1 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, "%s=%q", k, v.AsString())
+		return true
+	})
+	sb.WriteByte('}')
+	return sb.String()
+}
@@ -13,7 +13,9 @@ import (
 	"github.com/grafana/agent/internal/component/otelcol/internal/fanoutconsumer"
 	"github.com/grafana/agent/internal/component/otelcol/internal/lazycollector"
 	"github.com/grafana/agent/internal/component/otelcol/internal/lazyconsumer"
+	"github.com/grafana/agent/internal/component/otelcol/internal/livedebuggingconsumer"
 	"github.com/grafana/agent/internal/component/otelcol/internal/scheduler"
+	"github.com/grafana/agent/internal/service/livedebugging"
 	"github.com/grafana/agent/internal/util/zapadapter"
 	"github.com/prometheus/client_golang/prometheus"
 	otelcomponent "go.opentelemetry.io/collector/component"
@@ -50,9 +52,10 @@ type Processor struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 
-	opts     component.Options
-	factory  otelprocessor.Factory
-	consumer *lazyconsumer.Consumer
+	opts      component.Options
+	factory   otelprocessor.Factory
+	consumer  *lazyconsumer.Consumer
+	debugData livedebugging.Publisher
 
 	sched     *scheduler.Scheduler
 	collector *lazycollector.Collector
@@ -90,9 +93,10 @@ func New(opts component.Options, f otelprocessor.Factory, args Arguments) (*Proc
 		ctx:    ctx,
 		cancel: cancel,
 
-		opts:     opts,
-		factory:  f,
-		consumer: consumer,
+		opts:      opts,
+		factory:   f,
+		consumer:  consumer,
+		debugData: livedebugging.PublisherFrom(opts.GetServiceData),
 
 		sched:     scheduler.New(opts.Logger),
 		collector: collector,
@@ -151,11 +155,13 @@ func (p *Processor) Update(args component.Arguments) error {
 		return err
 	}
 
+	// The data consumed by the processors and the data they emit are
+	// published to live debugging.
 	var (
 		next        = pargs.NextConsumers()
-		nextTraces  = fanoutconsumer.Traces(next.Traces)
-		nextMetrics = fanoutconsumer.Metrics(next.Metrics)
-		nextLogs    = fanoutconsumer.Logs(next.Logs)
+		nextTraces  = livedebuggingconsumer.Traces(fanoutconsumer.Traces(next.Traces), p.debugData, p.opts.ID, livedebugging.DirectionEmitted)
+		nextMetrics = livedebuggingconsumer.Metrics(fanoutconsumer.Metrics(next.Metrics), p.debugData, p.opts.ID, livedebugging.DirectionEmitted)
+		nextLogs    = livedebuggingconsumer.Logs(fanoutconsumer.Logs(next.Logs), p.debugData, p.opts.ID, livedebugging.DirectionEmitted)
 	)
 
 	// Create instances of the processor from our factory for each of our
@@ -194,7 +200,11 @@ func (p *Processor) Update(args component.Arguments) error {
 
 	// Schedule the components to run once our component is running.
 	p.sched.Schedule(host, components...)
-	p.consumer.SetConsumers(tracesProcessor, metricsProcessor, logsProcessor)
+	p.consumer.SetConsumers(
+		livedebuggingconsumer.Traces(tracesProcessor, p.debugData, p.opts.ID, livedebugging.DirectionReceived),
+		livedebuggingconsumer.Metrics(metricsProcessor, p.debugData, p.opts.ID, livedebugging.DirectionReceived),
+		livedebuggingconsumer.Logs(logsProcessor, p.debugData, p.opts.ID, livedebugging.DirectionReceived),
+	)
 	return nil
 }
 
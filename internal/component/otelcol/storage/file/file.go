@@ -0,0 +1,135 @@
+// Package file provides an otelcol.storage.file component.
+package file
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/component/otelcol/storage"
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/storage/filestorage"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelextension "go.opentelemetry.io/collector/extension"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:      "otelcol.storage.file",
+		Stability: featuregate.StabilityExperimental,
+		Args:      Arguments{},
+		Exports:   storage.Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			fact := filestorage.NewFactory()
+			return storage.New(opts, fact, args.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the otelcol.storage.file component.
+type Arguments struct {
+	Directory string        `river:"directory,attr,optional"`
+	Timeout   time.Duration `river:"timeout,attr,optional"`
+	FSync     bool          `river:"fsync,attr,optional"`
+
+	Compaction CompactionArguments `river:"compaction,block,optional"`
+}
+
+var _ storage.Arguments = Arguments{}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = Arguments{
+		Timeout: time.Second,
+	}
+	args.Compaction.SetToDefault()
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if args.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than zero")
+	}
+	return nil
+}
+
+// Convert implements storage.Arguments.
+func (args Arguments) Convert(dataPath string) (otelcomponent.Config, error) {
+	// Unless configured otherwise, the data is persisted in the component's
+	// data path, which outlives restarts of the process.
+	directory := args.Directory
+	if directory == "" {
+		directory = dataPath
+	}
+	compactionDirectory := args.Compaction.Directory
+	if compactionDirectory == "" {
+		compactionDirectory = directory
+	}
+
+	// The upstream extension expects the directories to exist.
+	for _, dir := range []string{directory, compactionDirectory} {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return nil, fmt.Errorf("failed to create directory %q: %w", dir, err)
+		}
+	}
+
+	return &filestorage.Config{
+		Directory: directory,
+		Timeout:   args.Timeout,
+		FSync:     args.FSync,
+		Compaction: &filestorage.CompactionConfig{
+			OnStart:                    args.Compaction.OnStart,
+			OnRebound:                  args.Compaction.OnRebound,
+			Directory:                  compactionDirectory,
+			ReboundNeededThresholdMiB:  args.Compaction.ReboundNeededThresholdMiB,
+			ReboundTriggerThresholdMiB: args.Compaction.ReboundTriggerThresholdMiB,
+			MaxTransactionSize:         args.Compaction.MaxTransactionSize,
+			CheckInterval:              args.Compaction.CheckInterval,
+		},
+	}, nil
+}
+
+// Extensions implements storage.Arguments.
+func (args Arguments) Extensions() map[otelcomponent.ID]otelextension.Extension {
+	return nil
+}
+
+// Exporters implements storage.Arguments.
+func (args Arguments) Exporters() map[otelcomponent.DataType]map[otelcomponent.ID]otelcomponent.Component {
+	return nil
+}
+
+// CompactionArguments configures the compaction of the storage database.
+type CompactionArguments struct {
+	OnStart                    bool          `river:"on_start,attr,optional"`
+	OnRebound                  bool          `river:"on_rebound,attr,optional"`
+	Directory                  string        `river:"directory,attr,optional"`
+	ReboundNeededThresholdMiB  int64         `river:"rebound_needed_threshold_mib,attr,optional"`
+	ReboundTriggerThresholdMiB int64         `river:"rebound_trigger_threshold_mib,attr,optional"`
+	MaxTransactionSize         int64         `river:"max_transaction_size,attr,optional"`
+	CheckInterval              time.Duration `river:"check_interval,attr,optional"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *CompactionArguments) SetToDefault() {
+	// Copied from upstream's defaults.
+	*args = CompactionArguments{
+		ReboundNeededThresholdMiB:  100,
+		ReboundTriggerThresholdMiB: 10,
+		MaxTransactionSize:         65536,
+		CheckInterval:              5 * time.Second,
+	}
+}
+
+// Validate implements river.Validator.
+func (args *CompactionArguments) Validate() error {
+	if args.MaxTransactionSize < 0 {
+		return fmt.Errorf("max_transaction_size cannot be less than zero")
+	}
+	if args.OnRebound && args.CheckInterval <= 0 {
+		return fmt.Errorf("check_interval must be greater than zero when on_rebound is set")
+	}
+	return nil
+}
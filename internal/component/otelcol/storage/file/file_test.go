@@ -0,0 +1,120 @@
+package file_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/internal/component/otelcol/storage"
+	"github.com/grafana/agent/internal/component/otelcol/storage/file"
+	"github.com/grafana/agent/internal/flow/componenttest"
+	"github.com/grafana/agent/internal/util"
+	"github.com/grafana/river"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/storage/filestorage"
+	"github.com/stretchr/testify/require"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	extstorage "go.opentelemetry.io/collector/extension/experimental/storage"
+)
+
+// Test performs a basic integration test which runs the otelcol.storage.file
+// component and ensures that it can be used to persist data.
+func Test(t *testing.T) {
+	ctx := componenttest.TestContext(t)
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	l := util.TestLogger(t)
+
+	// Create and run our component
+	ctrl, err := componenttest.NewControllerFromID(l, "otelcol.storage.file")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	cfg := `
+		directory = "` + dir + `"
+	`
+	var args file.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+	require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+
+	// Get the storage extension from our component and use it to persist data.
+	exports := ctrl.Exports().(storage.Exports)
+	require.NotNil(t, exports.Handler.Extension, "handler extension is nil")
+
+	ext, ok := exports.Handler.Extension.(extstorage.Extension)
+	require.True(t, ok, "handler does not implement storage.Extension")
+
+	client, err := ext.GetClient(ctx, otelcomponent.KindExporter, otelcomponent.NewID("otlp"), "traces")
+	require.NoError(t, err)
+	defer client.Close(ctx)
+
+	require.NoError(t, client.Set(ctx, "key", []byte("value")))
+	value, err := client.Get(ctx, "key")
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), value)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.NotEmpty(t, entries, "expected storage to be persisted in the configured directory")
+}
+
+func TestArguments_Convert(t *testing.T) {
+	t.Run("defaults to the data path", func(t *testing.T) {
+		var args file.Arguments
+		require.NoError(t, river.Unmarshal([]byte(``), &args))
+
+		dataPath := t.TempDir()
+		cfg, err := args.Convert(dataPath)
+		require.NoError(t, err)
+
+		fileCfg := cfg.(*filestorage.Config)
+		require.Equal(t, dataPath, fileCfg.Directory)
+		require.Equal(t, dataPath, fileCfg.Compaction.Directory)
+		require.Equal(t, time.Second, fileCfg.Timeout)
+		require.NoError(t, fileCfg.Validate())
+	})
+
+	t.Run("creates configured directories", func(t *testing.T) {
+		dir := t.TempDir() + "/storage"
+		compactionDir := t.TempDir() + "/compaction"
+
+		var args file.Arguments
+		require.NoError(t, river.Unmarshal([]byte(`
+			directory = "`+dir+`"
+			fsync     = true
+			compaction {
+				on_start  = true
+				directory = "`+compactionDir+`"
+			}
+		`), &args))
+
+		cfg, err := args.Convert(t.TempDir())
+		require.NoError(t, err)
+
+		fileCfg := cfg.(*filestorage.Config)
+		require.Equal(t, dir, fileCfg.Directory)
+		require.Equal(t, compactionDir, fileCfg.Compaction.Directory)
+		require.True(t, fileCfg.FSync)
+		require.True(t, fileCfg.Compaction.OnStart)
+		require.NoError(t, fileCfg.Validate())
+	})
+
+	t.Run("invalid compaction settings", func(t *testing.T) {
+		var args file.Arguments
+		err := river.Unmarshal([]byte(`
+			compaction {
+				on_rebound     = true
+				check_interval = "0s"
+			}
+		`), &args)
+		require.ErrorContains(t, err, "check_interval must be greater than zero")
+	})
+}
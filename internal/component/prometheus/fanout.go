@@ -60,6 +60,12 @@ func (f *Fanout) UpdateChildren(children []storage.Appendable) {
 	f.children = children
 }
 
+// SeriesStats returns the accounting of the series sent through the fanout's
+// component.
+func (f *Fanout) SeriesStats() labelstore.ComponentStats {
+	return f.ls.ComponentStats(f.componentID)
+}
+
 // Appender satisfies the Appendable interface.
 func (f *Fanout) Appender(ctx context.Context) storage.Appender {
 	f.mut.RLock()
@@ -115,6 +121,7 @@ func (a *appender) Append(ref storage.SeriesRef, l labels.Labels, t int64, v flo
 		GlobalRefID: uint64(ref),
 		Labels:      l,
 		Value:       v,
+		ComponentID: a.componentID,
 	})
 	var multiErr error
 	updated := false
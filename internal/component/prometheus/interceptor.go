@@ -24,6 +24,8 @@ type Interceptor struct {
 	next storage.Appendable
 
 	ls labelstore.LabelStore
+	// componentID is the component the series are accounted to in ls.
+	componentID string
 }
 
 var _ storage.Appendable = (*Interceptor)(nil)
@@ -44,6 +46,15 @@ func NewInterceptor(next storage.Appendable, ls labelstore.LabelStore, opts ...I
 // InterceptorOption is an option argument passed to NewInterceptor.
 type InterceptorOption func(*Interceptor)
 
+// WithComponentID returns an InterceptorOption which accounts the series
+// passing through the Interceptor to the component with the given ID in the
+// LabelStore.
+func WithComponentID(id string) InterceptorOption {
+	return func(i *Interceptor) {
+		i.componentID = id
+	}
+}
+
 // WithAppendHook returns an InterceptorOption which hooks into calls to
 // Append.
 func WithAppendHook(f func(ref storage.SeriesRef, l labels.Labels, t int64, v float64, next storage.Appender) (storage.SeriesRef, error)) InterceptorOption {
@@ -76,6 +87,16 @@ func WithHistogramHook(f func(ref storage.SeriesRef, l labels.Labels, t int64, h
 	}
 }
 
+// SeriesStats returns the accounting of the series sent through the
+// Interceptor's component. It is empty unless the Interceptor was created
+// with WithComponentID.
+func (f *Interceptor) SeriesStats() labelstore.ComponentStats {
+	if f.componentID == "" {
+		return labelstore.ComponentStats{}
+	}
+	return f.ls.ComponentStats(f.componentID)
+}
+
 // Appender satisfies the Appendable interface.
 func (f *Interceptor) Appender(ctx context.Context) storage.Appender {
 	app := &interceptappender{
@@ -107,6 +128,7 @@ func (a *interceptappender) Append(ref storage.SeriesRef, l labels.Labels, t int
 		GlobalRefID: uint64(ref),
 		Labels:      l,
 		Value:       v,
+		ComponentID: a.interceptor.componentID,
 	})
 
 	if a.interceptor.onAppend != nil {
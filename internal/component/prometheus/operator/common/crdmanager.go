@@ -224,6 +224,7 @@ func (c *crdManager) DebugInfo() interface{} {
 	if c.scrapeManager != nil {
 		info.Targets = compscrape.BuildTargetStatuses(c.scrapeManager.TargetsActive())
 	}
+	info.Series = c.ls.ComponentStats(c.opts.ID)
 	return info
 }
 
@@ -8,6 +8,7 @@ import (
 	flow_relabel "github.com/grafana/agent/internal/component/common/relabel"
 	"github.com/grafana/agent/internal/component/prometheus/scrape"
 	"github.com/grafana/agent/internal/service/cluster"
+	"github.com/grafana/agent/internal/service/labelstore"
 	"github.com/prometheus/common/model"
 	promconfig "github.com/prometheus/prometheus/config"
 	"github.com/prometheus/prometheus/storage"
@@ -70,8 +71,9 @@ func (args *Arguments) Validate() error {
 }
 
 type DebugInfo struct {
-	DiscoveredCRDs []*DiscoveredResource `river:"crds,block"`
-	Targets        []scrape.TargetStatus `river:"targets,block,optional"`
+	DiscoveredCRDs []*DiscoveredResource     `river:"crds,block"`
+	Targets        []scrape.TargetStatus     `river:"targets,block,optional"`
+	Series         labelstore.ComponentStats `river:"series,block"`
 }
 
 type DiscoveredResource struct {
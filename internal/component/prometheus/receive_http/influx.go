@@ -0,0 +1,342 @@
+package receive_http
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/internal/flow/logging/level"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage"
+)
+
+// InfluxArguments configures the Influx line protocol endpoints of the
+// component.
+type InfluxArguments struct {
+	// ValueFieldName is the name of the field whose series are named after the
+	// measurement only.
+	ValueFieldName string `river:"value_field_name,attr,optional"`
+	// DatabaseLabel is the name of the label set to the database or bucket the
+	// metrics are written to. Empty disables the label.
+	DatabaseLabel string `river:"database_label,attr,optional"`
+	// MaxRequestSize is the maximum size of the body of a write request,
+	// before and after decompression.
+	MaxRequestSize units.Base2Bytes `river:"max_request_size,attr,optional"`
+}
+
+// DefaultInfluxArguments holds default settings for InfluxArguments.
+var DefaultInfluxArguments = InfluxArguments{
+	ValueFieldName: "value",
+	MaxRequestSize: defaultMaxRequestSize,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *InfluxArguments) SetToDefault() {
+	*args = DefaultInfluxArguments
+}
+
+// Validate implements river.Validator.
+func (args *InfluxArguments) Validate() error {
+	if args.DatabaseLabel != "" && !model.LabelName(args.DatabaseLabel).IsValid() {
+		return fmt.Errorf("database_label %q is not a valid label name", args.DatabaseLabel)
+	}
+	if args.MaxRequestSize <= 0 {
+		return fmt.Errorf("max_request_size must be greater than 0")
+	}
+	return nil
+}
+
+// influxHandler accepts Influx line protocol write requests, converts every
+// numeric field to a Prometheus series and appends them to appendable.
+type influxHandler struct {
+	logger     log.Logger
+	appendable storage.Appendable
+	args       InfluxArguments
+}
+
+func newInfluxHandler(logger log.Logger, appendable storage.Appendable, args InfluxArguments) http.Handler {
+	return &influxHandler{
+		logger:     logger,
+		appendable: appendable,
+		args:       args,
+	}
+}
+
+func (h *influxHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	precision, err := influxPrecision(r.URL.Query().Get("precision"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The body is read fully before being parsed, so that a request which is
+	// too large is rejected instead of failing on its truncated last line.
+	body, err := requestBody(w, r, h.args.MaxRequestSize)
+	if err != nil {
+		http.Error(w, err.Error(), requestErrorStatus(err))
+		return
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		http.Error(w, err.Error(), requestErrorStatus(err))
+		return
+	}
+
+	// Influx v1 clients write to the db query parameter, v2 clients to the
+	// bucket query parameter.
+	var extraLabels []prompb.Label
+	if h.args.DatabaseLabel != "" {
+		db := r.URL.Query().Get("db")
+		if db == "" {
+			db = r.URL.Query().Get("bucket")
+		}
+		if db != "" {
+			extraLabels = append(extraLabels, prompb.Label{Name: h.args.DatabaseLabel, Value: db})
+		}
+	}
+
+	series, err := parseInfluxLines(bytes.NewReader(data), h.args.ValueFieldName, extraLabels, precision, time.Now())
+	if err != nil {
+		level.Error(h.logger).Log("msg", "error decoding Influx write request", "err", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := writeTimeSeries(r.Context(), h.logger, h.appendable, series, nil); err != nil {
+		level.Error(h.logger).Log("msg", "error appending Influx metrics", "err", err)
+		http.Error(w, err.Error(), writeErrorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// influxPingHandler answers the health checks of Influx clients.
+func influxPingHandler(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// influxPrecision returns the duration of a unit of the timestamps of a write
+// request with the given precision query parameter.
+func influxPrecision(precision string) (time.Duration, error) {
+	switch precision {
+	case "", "ns", "n":
+		return time.Nanosecond, nil
+	case "us", "u":
+		return time.Microsecond, nil
+	case "ms":
+		return time.Millisecond, nil
+	case "s":
+		return time.Second, nil
+	default:
+		return 0, fmt.Errorf("unsupported precision %q", precision)
+	}
+}
+
+// parseInfluxLines converts the lines of Influx line protocol in r to
+// Prometheus series. Lines without a timestamp are assigned now.
+//
+// Every numeric or boolean field of a line becomes a series named after the
+// measurement and the field, with the tags of the line and extraLabels as
+// labels. Tags take precedence over extraLabels with the same name. Fields
+// named valueField become a series named after the measurement only. String
+// fields are ignored.
+func parseInfluxLines(r io.Reader, valueField string, extraLabels []prompb.Label, precision time.Duration, now time.Time) ([]prompb.TimeSeries, error) {
+	var series []prompb.TimeSeries
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		lineSeries, err := parseInfluxLine(line, valueField, extraLabels, precision, now)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		series = append(series, lineSeries...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+func parseInfluxLine(line string, valueField string, extraLabels []prompb.Label, precision time.Duration, now time.Time) ([]prompb.TimeSeries, error) {
+	key, rest := splitUnescaped(line, ' ', false)
+	fieldSet, timestamp := splitUnescaped(strings.TrimLeft(rest, " "), ' ', true)
+	timestamp = strings.TrimSpace(timestamp)
+	if fieldSet == "" {
+		return nil, fmt.Errorf("missing fields")
+	}
+
+	ts := now.UnixMilli()
+	if timestamp != "" {
+		n, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q", timestamp)
+		}
+		ts = time.Unix(0, n*int64(precision)).UnixMilli()
+	}
+
+	measurement, tagSet := splitUnescaped(key, ',', false)
+	if measurement == "" {
+		return nil, fmt.Errorf("missing measurement")
+	}
+	measurement = unescapeInflux(measurement)
+
+	var (
+		tags    []prompb.Label
+		tagKeys = make(map[string]string)
+	)
+	for tagSet != "" {
+		var tag string
+		tag, tagSet = splitUnescaped(tagSet, ',', false)
+		k, v, err := splitKeyValue(tag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag %q: %w", tag, err)
+		}
+		name := sanitizeInfluxName(k, false)
+		// Labels starting with __ are reserved, and could override the name
+		// of the series.
+		if strings.HasPrefix(name, "__") {
+			return nil, fmt.Errorf("tag key %q is converted to the reserved label name %q", k, name)
+		}
+		if other, ok := tagKeys[name]; ok {
+			return nil, fmt.Errorf("tag keys %q and %q are both converted to the label name %q", other, k, name)
+		}
+		tagKeys[name] = k
+		tags = append(tags, prompb.Label{Name: name, Value: v})
+	}
+	for _, extra := range extraLabels {
+		if !slices.ContainsFunc(tags, func(l prompb.Label) bool { return l.Name == extra.Name }) {
+			tags = append(tags, extra)
+		}
+	}
+
+	var series []prompb.TimeSeries
+	for fieldSet != "" {
+		var field string
+		field, fieldSet = splitUnescaped(fieldSet, ',', true)
+		k, v, err := splitKeyValue(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid field %q: %w", field, err)
+		}
+		value, ok, err := parseInfluxFieldValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of field %q: %w", k, err)
+		}
+		if !ok {
+			continue
+		}
+
+		name := measurement
+		if k != valueField {
+			name += "_" + k
+		}
+		lbls := make([]prompb.Label, 0, len(tags)+1)
+		lbls = append(lbls, prompb.Label{Name: model.MetricNameLabel, Value: sanitizeInfluxName(name, true)})
+		lbls = append(lbls, tags...)
+		sort.Slice(lbls, func(i, j int) bool { return lbls[i].Name < lbls[j].Name })
+
+		series = append(series, prompb.TimeSeries{
+			Labels:  lbls,
+			Samples: []prompb.Sample{{Value: value, Timestamp: ts}},
+		})
+	}
+	return series, nil
+}
+
+// splitUnescaped splits s at the first sep which isn't escaped with a
+// backslash, nor inside a double-quoted string if quoted is true. rest is
+// empty if s doesn't contain sep.
+func splitUnescaped(s string, sep byte, quoted bool) (head, rest string) {
+	inQuotes := false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\':
+			i++
+		case c == '"' && quoted:
+			inQuotes = !inQuotes
+		case c == sep && !inQuotes:
+			return s[:i], s[i+1:]
+		}
+	}
+	return s, ""
+}
+
+func splitKeyValue(s string) (key, value string, err error) {
+	key, value = splitUnescaped(s, '=', false)
+	if key == "" || value == "" {
+		return "", "", fmt.Errorf("expected key=value")
+	}
+	return unescapeInflux(key), unescapeInflux(value), nil
+}
+
+// unescapeInflux removes the backslashes escaping special characters in
+// measurements, tag keys, tag values and field keys.
+func unescapeInflux(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(`,= \`, s[i+1]) >= 0 {
+			i++
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// parseInfluxFieldValue parses the value of a field. ok is false for string
+// fields, which can't be converted to a sample.
+func parseInfluxFieldValue(v string) (value float64, ok bool, err error) {
+	switch {
+	case strings.HasPrefix(v, `"`):
+		return 0, false, nil
+	case v == "t" || v == "T" || v == "true" || v == "True" || v == "TRUE":
+		return 1, true, nil
+	case v == "f" || v == "F" || v == "false" || v == "False" || v == "FALSE":
+		return 0, true, nil
+	case strings.HasSuffix(v, "i"):
+		n, err := strconv.ParseInt(v[:len(v)-1], 10, 64)
+		return float64(n), err == nil, err
+	case strings.HasSuffix(v, "u"):
+		n, err := strconv.ParseUint(v[:len(v)-1], 10, 64)
+		return float64(n), err == nil, err
+	default:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil, err
+	}
+}
+
+// sanitizeInfluxName replaces the characters which aren't valid in Prometheus
+// metric names (or label names if metric is false) with underscores.
+func sanitizeInfluxName(name string, metric bool) string {
+	var sb strings.Builder
+	for i, c := range name {
+		valid := c == '_' ||
+			(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+			(c >= '0' && c <= '9' && i > 0) ||
+			(c == ':' && metric)
+		if valid {
+			sb.WriteRune(c)
+		} else {
+			sb.WriteByte('_')
+		}
+	}
+	return sb.String()
+}
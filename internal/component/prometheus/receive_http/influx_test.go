@@ -0,0 +1,133 @@
+package receive_http
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInfluxLines(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+
+	tt := []struct {
+		name      string
+		input     string
+		precision time.Duration
+		args      *InfluxArguments
+		db        string
+		expect    []prompb.TimeSeries
+		expectErr string
+	}{
+		{
+			name:      "fields and tags",
+			input:     "cpu,host=a,region=eu usage=1.5,cores=4i,up=true,count=3u 1700000001000000000",
+			precision: time.Nanosecond,
+			expect: []prompb.TimeSeries{
+				influxSeries("cpu_usage", 1.5, 1700000001000, "host", "a", "region", "eu"),
+				influxSeries("cpu_cores", 4, 1700000001000, "host", "a", "region", "eu"),
+				influxSeries("cpu_up", 1, 1700000001000, "host", "a", "region", "eu"),
+				influxSeries("cpu_count", 3, 1700000001000, "host", "a", "region", "eu"),
+			},
+		},
+		{
+			name:      "value field, no timestamp, precision",
+			input:     "temperature value=21\nhumidity value=40 1700000002",
+			precision: time.Second,
+			expect: []prompb.TimeSeries{
+				influxSeries("temperature", 21, 1700000000000),
+				influxSeries("humidity", 40, 1700000002000),
+			},
+		},
+		{
+			name:      "escapes, strings, comments and sanitizing",
+			input:     "# comment\n\nmy\\ disk,mount\\ point=/var\\,log,dev.name=sda free=10,msg=\"a, b=c d\" 1700000003000",
+			precision: time.Millisecond,
+			expect: []prompb.TimeSeries{
+				influxSeries("my_disk_free", 10, 1700000003000, "dev_name", "sda", "mount_point", "/var,log"),
+			},
+		},
+		{
+			name:      "custom value field and database label",
+			input:     "temperature,db=other value=21,reading=22 1700000002\nhumidity value=40 1700000002",
+			precision: time.Second,
+			args:      &InfluxArguments{ValueFieldName: "reading", DatabaseLabel: "db"},
+			db:        "telegraf",
+			expect: []prompb.TimeSeries{
+				influxSeries("temperature_value", 21, 1700000002000, "db", "other"),
+				influxSeries("temperature", 22, 1700000002000, "db", "other"),
+				influxSeries("humidity_value", 40, 1700000002000, "db", "telegraf"),
+			},
+		},
+		{
+			name:      "invalid value",
+			input:     "cpu usage=1\ncpu usage=abc",
+			precision: time.Nanosecond,
+			expectErr: `line 2: invalid value of field "usage"`,
+		},
+		{
+			name:      "missing fields",
+			input:     "cpu,host=a",
+			precision: time.Nanosecond,
+			expectErr: "line 1: missing fields",
+		},
+		{
+			name:      "colliding tag keys",
+			input:     "cpu,a-b=1,a.b=2 usage=1",
+			precision: time.Nanosecond,
+			expectErr: `line 1: tag keys "a-b" and "a.b" are both converted to the label name "a_b"`,
+		},
+		{
+			name:      "duplicate tag keys",
+			input:     "cpu,host=a,host=b usage=1",
+			precision: time.Nanosecond,
+			expectErr: `line 1: tag keys "host" and "host" are both converted to the label name "host"`,
+		},
+		{
+			name:      "reserved tag key",
+			input:     "cpu,__name__=other usage=1",
+			precision: time.Nanosecond,
+			expectErr: `line 1: tag key "__name__" is converted to the reserved label name "__name__"`,
+		},
+		{
+			name:      "tag key sanitized to a reserved name",
+			input:     "cpu,-.address=a usage=1",
+			precision: time.Nanosecond,
+			expectErr: `line 1: tag key "-.address" is converted to the reserved label name "__address"`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			args := DefaultInfluxArguments
+			if tc.args != nil {
+				args = *tc.args
+			}
+			var extraLabels []prompb.Label
+			if tc.db != "" {
+				extraLabels = []prompb.Label{{Name: args.DatabaseLabel, Value: tc.db}}
+			}
+
+			actual, err := parseInfluxLines(strings.NewReader(tc.input), args.ValueFieldName, extraLabels, tc.precision, now)
+			if tc.expectErr != "" {
+				require.ErrorContains(t, err, tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, actual)
+		})
+	}
+}
+
+func influxSeries(name string, value float64, ts int64, lbls ...string) prompb.TimeSeries {
+	res := prompb.TimeSeries{
+		Labels:  []prompb.Label{{Name: "__name__", Value: name}},
+		Samples: []prompb.Sample{{Value: value, Timestamp: ts}},
+	}
+	for i := 0; i < len(lbls); i += 2 {
+		res.Labels = append(res.Labels, prompb.Label{Name: lbls[i], Value: lbls[i+1]})
+	}
+	return res
+}
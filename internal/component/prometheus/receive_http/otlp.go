@@ -0,0 +1,148 @@
+package receive_http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/alecthomas/units"
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/internal/flow/logging/level"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/model/textparse"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/storage/remote"
+	prometheustranslator "github.com/prometheus/prometheus/storage/remote/otlptranslator/prometheus"
+	"github.com/prometheus/prometheus/storage/remote/otlptranslator/prometheusremotewrite"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// OTLPArguments configures the OTLP endpoint of the component.
+type OTLPArguments struct {
+	// DisableTargetInfo disables the target_info series holding the resource
+	// attributes of the received metrics.
+	DisableTargetInfo bool `river:"disable_target_info,attr,optional"`
+	// MaxRequestSize is the maximum size of the body of an export request,
+	// before and after decompression.
+	MaxRequestSize units.Base2Bytes `river:"max_request_size,attr,optional"`
+}
+
+// DefaultOTLPArguments holds default settings for OTLPArguments.
+var DefaultOTLPArguments = OTLPArguments{
+	MaxRequestSize: defaultMaxRequestSize,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *OTLPArguments) SetToDefault() {
+	*args = DefaultOTLPArguments
+}
+
+// Validate implements river.Validator.
+func (args *OTLPArguments) Validate() error {
+	if args.MaxRequestSize <= 0 {
+		return fmt.Errorf("max_request_size must be greater than 0")
+	}
+	return nil
+}
+
+// otlpHandler accepts OTLP/HTTP metrics export requests, converts the metrics
+// to Prometheus series and appends them to appendable.
+type otlpHandler struct {
+	logger         log.Logger
+	appendable     storage.Appendable
+	settings       prometheusremotewrite.Settings
+	maxRequestSize units.Base2Bytes
+}
+
+func newOTLPHandler(logger log.Logger, appendable storage.Appendable, args OTLPArguments) http.Handler {
+	return &otlpHandler{
+		logger:     logger,
+		appendable: appendable,
+		settings: prometheusremotewrite.Settings{
+			DisableTargetInfo: args.DisableTargetInfo,
+		},
+		maxRequestSize: args.MaxRequestSize,
+	}
+}
+
+func (h *otlpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := requestBody(w, r, h.maxRequestSize)
+	if err != nil {
+		http.Error(w, err.Error(), requestErrorStatus(err))
+		return
+	}
+	defer body.Close()
+
+	// The body is decompressed by requestBody to bound its size.
+	decodeReq := r.Clone(r.Context())
+	decodeReq.Body = body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		decodeReq.Header.Del("Content-Encoding")
+	}
+
+	req, err := remote.DecodeOTLPWriteRequest(decodeReq)
+	if err != nil {
+		level.Error(h.logger).Log("msg", "error decoding OTLP write request", "err", err)
+		http.Error(w, err.Error(), requestErrorStatus(err))
+		return
+	}
+
+	metrics := req.Metrics()
+	seriesMap, errs := prometheusremotewrite.FromMetrics(metrics, h.settings)
+	if errs != nil {
+		level.Warn(h.logger).Log("msg", "error translating OTLP metrics to Prometheus series", "err", errs)
+	}
+
+	series := make([]prompb.TimeSeries, 0, len(seriesMap))
+	for _, ts := range seriesMap {
+		series = append(series, *ts)
+	}
+
+	if _, err := writeTimeSeries(r.Context(), h.logger, h.appendable, series, otlpMetadata(metrics, h.settings.Namespace)); err != nil {
+		level.Error(h.logger).Log("msg", "error appending OTLP metrics", "err", err)
+		http.Error(w, err.Error(), writeErrorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// otlpMetadata returns the metadata of the metrics in md, by the name of the
+// Prometheus metric family they are converted to.
+func otlpMetadata(md pmetric.Metrics, namespace string) map[string]metadata.Metadata {
+	res := make(map[string]metadata.Metadata)
+
+	resourceMetrics := md.ResourceMetrics()
+	for i := 0; i < resourceMetrics.Len(); i++ {
+		scopeMetrics := resourceMetrics.At(i).ScopeMetrics()
+		for j := 0; j < scopeMetrics.Len(); j++ {
+			metrics := scopeMetrics.At(j).Metrics()
+			for k := 0; k < metrics.Len(); k++ {
+				m := metrics.At(k)
+				res[prometheustranslator.BuildPromCompliantName(m, namespace)] = metadata.Metadata{
+					Type: otlpMetricType(m),
+					Unit: m.Unit(),
+					Help: m.Description(),
+				}
+			}
+		}
+	}
+	return res
+}
+
+func otlpMetricType(m pmetric.Metric) textparse.MetricType {
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		return textparse.MetricTypeGauge
+	case pmetric.MetricTypeSum:
+		if m.Sum().IsMonotonic() {
+			return textparse.MetricTypeCounter
+		}
+		return textparse.MetricTypeGauge
+	case pmetric.MetricTypeHistogram, pmetric.MetricTypeExponentialHistogram:
+		return textparse.MetricTypeHistogram
+	case pmetric.MetricTypeSummary:
+		return textparse.MetricTypeSummary
+	default:
+		return textparse.MetricTypeUnknown
+	}
+}
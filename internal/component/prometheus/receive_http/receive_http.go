@@ -35,6 +35,8 @@ func init() {
 type Arguments struct {
 	Server    *fnet.ServerConfig   `river:",squash"`
 	ForwardTo []storage.Appendable `river:"forward_to,attr"`
+	OTLP      *OTLPArguments       `river:"otlp,block,optional"`
+	Influx    *InfluxArguments     `river:"influx,block,optional"`
 }
 
 // SetToDefault implements river.Defaulter.
@@ -68,7 +70,7 @@ func New(opts component.Options, args Arguments) (*Component, error) {
 
 	c := &Component{
 		opts:               opts,
-		handler:            remote.NewWriteHandler(opts.Logger, opts.Registerer, fanout),
+		handler:            newWriteHandler(opts.Logger, fanout, remote.NewWriteHandler(opts.Logger, opts.Registerer, fanout)),
 		fanout:             fanout,
 		uncheckedCollector: uncheckedCollector,
 	}
@@ -100,7 +102,9 @@ func (c *Component) Update(args component.Arguments) error {
 	c.updateMut.Lock()
 	defer c.updateMut.Unlock()
 
-	serverNeedsUpdate := !reflect.DeepEqual(c.args.Server, newArgs.Server)
+	serverNeedsUpdate := !reflect.DeepEqual(c.args.Server, newArgs.Server) ||
+		!reflect.DeepEqual(c.args.OTLP, newArgs.OTLP) ||
+		!reflect.DeepEqual(c.args.Influx, newArgs.Influx)
 	if !serverNeedsUpdate {
 		c.args = newArgs
 		return nil
@@ -115,6 +119,15 @@ func (c *Component) Update(args component.Arguments) error {
 
 	err = c.server.MountAndRun(func(router *mux.Router) {
 		router.Path("/api/v1/metrics/write").Methods("POST").Handler(c.handler)
+		if newArgs.OTLP != nil {
+			router.Path("/api/v1/otlp/v1/metrics").Methods("POST").Handler(newOTLPHandler(c.opts.Logger, c.fanout, *newArgs.OTLP))
+		}
+		if newArgs.Influx != nil {
+			influx := newInfluxHandler(c.opts.Logger, c.fanout, *newArgs.Influx)
+			router.Path("/write").Methods("POST").Handler(influx)
+			router.Path("/api/v2/write").Methods("POST").Handler(influx)
+			router.Path("/ping").Methods("GET", "HEAD").HandlerFunc(influxPingHandler)
+		}
 	})
 	if err != nil {
 		return err
@@ -153,3 +166,13 @@ func (c *Component) shutdownServer() {
 		c.server = nil
 	}
 }
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	return debugInfo{Series: c.fanout.SeriesStats()}
+}
+
+// debugInfo is the debug information of the component.
+type debugInfo struct {
+	Series labelstore.ComponentStats `river:"series,block"`
+}
@@ -1,10 +1,13 @@
 package receive_http
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,6 +15,7 @@ import (
 	"github.com/grafana/agent/internal/component"
 	fnet "github.com/grafana/agent/internal/component/common/net"
 	agentprom "github.com/grafana/agent/internal/component/prometheus"
+	"github.com/grafana/agent/internal/component/prometheus/writev2"
 	"github.com/grafana/agent/internal/service/labelstore"
 	"github.com/grafana/agent/internal/util"
 	"github.com/phayes/freeport"
@@ -23,6 +27,9 @@ import (
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/storage/remote"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/protoadapt"
 )
@@ -395,3 +402,217 @@ func getFreePort(t *testing.T) int {
 	require.NoError(t, err)
 	return p
 }
+
+func TestForwardsOTLPMetrics(t *testing.T) {
+	timestamp := time.Now().Add(time.Second)
+
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	m := rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetName("requests")
+	sum := m.SetEmptySum()
+	sum.SetIsMonotonic(true)
+	sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	dp := sum.DataPoints().AppendEmpty()
+	dp.Attributes().PutStr("method", "GET")
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(timestamp))
+	dp.SetIntValue(42)
+
+	actualSamples := make(chan testSample, 100)
+	args := Arguments{
+		Server: &fnet.ServerConfig{
+			HTTP: &fnet.HTTPConfig{
+				ListenAddress: "localhost",
+				ListenPort:    getFreePort(t),
+			},
+			GRPC: testGRPCConfig(t),
+		},
+		ForwardTo: testAppendable(actualSamples),
+		OTLP:      &OTLPArguments{DisableTargetInfo: true, MaxRequestSize: 1024},
+	}
+	comp, err := New(testOptions(t), args)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go func() {
+		require.NoError(t, comp.Run(ctx))
+	}()
+	waitForServerToBeReady(t, args)
+
+	body, err := pmetricotlp.NewExportRequestFromMetrics(md).MarshalProto()
+	require.NoError(t, err)
+	resp, err := http.Post(fmt.Sprintf(
+		"http://%s:%d/api/v1/otlp/v1/metrics",
+		args.Server.HTTP.ListenAddress,
+		args.Server.HTTP.ListenPort,
+	), "application/x-protobuf", bytes.NewReader(body))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	select {
+	case actual := <-actualSamples:
+		require.Equal(t, testSample{
+			ts:  timestamp.UnixMilli(),
+			val: 42,
+			l:   labels.FromStrings("__name__", "requests_total", "method", "GET"),
+		}, actual)
+	case <-ctx.Done():
+		t.Fatalf("test timed out")
+	}
+
+	resp, err = http.Post(fmt.Sprintf(
+		"http://%s:%d/api/v1/otlp/v1/metrics",
+		args.Server.HTTP.ListenAddress,
+		args.Server.HTTP.ListenPort,
+	), "application/x-protobuf", bytes.NewReader(make([]byte, 2048)))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+}
+
+func TestForwardsInfluxMetrics(t *testing.T) {
+	timestamp := time.Now().Add(time.Second).Unix()
+
+	actualSamples := make(chan testSample, 100)
+	args := Arguments{
+		Server: &fnet.ServerConfig{
+			HTTP: &fnet.HTTPConfig{
+				ListenAddress: "localhost",
+				ListenPort:    getFreePort(t),
+			},
+			GRPC: testGRPCConfig(t),
+		},
+		ForwardTo: testAppendable(actualSamples),
+		Influx:    &InfluxArguments{ValueFieldName: "value", DatabaseLabel: "db", MaxRequestSize: 1024},
+	}
+	comp, err := New(testOptions(t), args)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go func() {
+		require.NoError(t, comp.Run(ctx))
+	}()
+	waitForServerToBeReady(t, args)
+
+	baseURL := fmt.Sprintf("http://%s:%d", args.Server.HTTP.ListenAddress, args.Server.HTTP.ListenPort)
+
+	resp, err := http.Get(baseURL + "/ping")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	body := fmt.Sprintf("cpu,host=a usage_idle=12.5,cores=4i %d\n", timestamp)
+	resp, err = http.Post(baseURL+"/write?precision=s&db=telegraf", "text/plain", strings.NewReader(body))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	expected := []testSample{
+		{ts: timestamp * 1000, val: 12.5, l: labels.FromStrings("__name__", "cpu_usage_idle", "db", "telegraf", "host", "a")},
+		{ts: timestamp * 1000, val: 4, l: labels.FromStrings("__name__", "cpu_cores", "db", "telegraf", "host", "a")},
+	}
+	for _, exp := range expected {
+		select {
+		case actual := <-actualSamples:
+			require.Equal(t, exp, actual)
+		case <-ctx.Done():
+			t.Fatalf("test timed out")
+		}
+	}
+
+	resp, err = http.Post(baseURL+"/api/v2/write", "text/plain", strings.NewReader("cpu usage_idle=oops\n"))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// The size of requests is limited before and after decompression.
+	large := strings.Repeat("cpu usage_idle=1\n", 100)
+	resp, err = http.Post(baseURL+"/write", "text/plain", strings.NewReader(large))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+	var compressed bytes.Buffer
+	gw := gzip.NewWriter(&compressed)
+	_, err = gw.Write([]byte(large))
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+	require.Less(t, compressed.Len(), 1024)
+	req, err := http.NewRequest(http.MethodPost, baseURL+"/write", &compressed)
+	require.NoError(t, err)
+	req.Header.Set("Content-Encoding", "gzip")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+}
+
+func TestForwardsRemoteWriteV2Metrics(t *testing.T) {
+	timestamp := time.Now().Add(time.Second).UnixMilli()
+
+	actualSamples := make(chan testSample, 100)
+	args := Arguments{
+		Server: &fnet.ServerConfig{
+			HTTP: &fnet.HTTPConfig{
+				ListenAddress: "localhost",
+				ListenPort:    getFreePort(t),
+			},
+			GRPC: testGRPCConfig(t),
+		},
+		ForwardTo: testAppendable(actualSamples),
+	}
+	comp, err := New(testOptions(t), args)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go func() {
+		require.NoError(t, comp.Run(ctx))
+	}()
+	waitForServerToBeReady(t, args)
+
+	req := writev2.FromV1(&prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "up"}, {Name: "job", Value: "agent"}},
+			Samples: []prompb.Sample{{Timestamp: timestamp, Value: 1}},
+		}},
+		Metadata: []prompb.MetricMetadata{{
+			Type:             prompb.MetricMetadata_GAUGE,
+			MetricFamilyName: "up",
+			Help:             "Target is up.",
+		}},
+	}, nil)
+	raw, err := req.Marshal()
+	require.NoError(t, err)
+
+	endpoint := fmt.Sprintf(
+		"http://%s:%d/api/v1/metrics/write",
+		args.Server.HTTP.ListenAddress,
+		args.Server.HTTP.ListenPort,
+	)
+	post := func(contentType string, body []byte) *http.Response {
+		httpReq, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(snappy.Encode(nil, body)))
+		require.NoError(t, err)
+		httpReq.Header.Set("Content-Type", contentType)
+		httpReq.Header.Set("Content-Encoding", "snappy")
+		resp, err := http.DefaultClient.Do(httpReq)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		return resp
+	}
+
+	resp := post(writev2.ContentType, raw)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Equal(t, "1", resp.Header.Get(writev2.SamplesWrittenHeader))
+	require.Equal(t, "0", resp.Header.Get(writev2.HistogramsWrittenHeader))
+
+	select {
+	case actual := <-actualSamples:
+		require.Equal(t, testSample{ts: timestamp, val: 1, l: labels.FromStrings("__name__", "up", "job", "agent")}, actual)
+	case <-ctx.Done():
+		t.Fatalf("test timed out")
+	}
+
+	resp = post("application/x-protobuf;proto=io.prometheus.write.v3.Request", raw)
+	require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+}
@@ -0,0 +1,168 @@
+package receive_http
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/alecthomas/units"
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/internal/flow/logging/level"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/storage/remote"
+)
+
+// writeStats counts the data written by writeTimeSeries.
+type writeStats struct {
+	Samples    int
+	Histograms int
+	Exemplars  int
+}
+
+// writeTimeSeries appends series to appendable in a single transaction, in the
+// same way the remote_write handler does. The metadata of the metric family of
+// each series is looked up in md, and appended if found.
+func writeTimeSeries(ctx context.Context, logger log.Logger, appendable storage.Appendable, series []prompb.TimeSeries, md map[string]metadata.Metadata) (stats writeStats, err error) {
+	app := appendable.Appender(ctx)
+	defer func() {
+		if err != nil {
+			_ = app.Rollback()
+			stats = writeStats{}
+			return
+		}
+		if err = app.Commit(); err != nil {
+			stats = writeStats{}
+		}
+	}()
+
+	for _, ts := range series {
+		lbls := labelProtosToLabels(ts.Labels)
+		if !lbls.IsValid() {
+			level.Warn(logger).Log("msg", "invalid metric names or labels", "got", lbls.String())
+			continue
+		}
+
+		var ref storage.SeriesRef
+		for _, s := range ts.Samples {
+			if ref, err = app.Append(ref, lbls, s.Timestamp, s.Value); err != nil {
+				return stats, err
+			}
+			stats.Samples++
+		}
+
+		for _, hp := range ts.Histograms {
+			if hp.IsFloatHistogram() {
+				_, err = app.AppendHistogram(0, lbls, hp.Timestamp, nil, remote.FloatHistogramProtoToFloatHistogram(hp))
+			} else {
+				_, err = app.AppendHistogram(0, lbls, hp.Timestamp, remote.HistogramProtoToHistogram(hp), nil)
+			}
+			if err != nil {
+				return stats, err
+			}
+			stats.Histograms++
+		}
+
+		// Like the remote_write handler, failing to append exemplars or metadata
+		// doesn't fail the request.
+		for _, ep := range ts.Exemplars {
+			e := exemplar.Exemplar{
+				Labels: labelProtosToLabels(ep.Labels),
+				Value:  ep.Value,
+				Ts:     ep.Timestamp,
+				HasTs:  ep.Timestamp != 0,
+			}
+			if _, err := app.AppendExemplar(0, lbls, e); err != nil {
+				level.Debug(logger).Log("msg", "error while appending exemplar", "series", lbls.String(), "err", err)
+				continue
+			}
+			stats.Exemplars++
+		}
+
+		if m, found := familyMetadata(md, lbls.Get(model.MetricNameLabel)); found {
+			if _, err := app.UpdateMetadata(0, lbls, m); err != nil {
+				level.Debug(logger).Log("msg", "error while updating metadata", "series", lbls.String(), "err", err)
+			}
+		}
+	}
+	return stats, nil
+}
+
+// familySuffixes are the suffixes of the series of a metric family which
+// don't share the name of the family.
+var familySuffixes = []string{"_bucket", "_sum", "_count", "_created"}
+
+// familyMetadata returns the metadata of the metric family of the series
+// named name.
+func familyMetadata(md map[string]metadata.Metadata, name string) (metadata.Metadata, bool) {
+	if m, found := md[name]; found {
+		return m, true
+	}
+	for _, suffix := range familySuffixes {
+		if family, ok := strings.CutSuffix(name, suffix); ok {
+			if m, found := md[family]; found {
+				return m, true
+			}
+		}
+	}
+	return metadata.Metadata{}, false
+}
+
+func labelProtosToLabels(lbls []prompb.Label) labels.Labels {
+	b := labels.NewScratchBuilder(len(lbls))
+	for _, l := range lbls {
+		b.Add(l.Name, l.Value)
+	}
+	b.Sort()
+	return b.Labels()
+}
+
+// defaultMaxRequestSize is the default maximum size of the body of the OTLP
+// and Influx write requests, before and after decompression.
+const defaultMaxRequestSize = 20 * units.MiB
+
+// requestBody returns the body of r, decompressed if it's gzip-encoded.
+// Reading more than maxSize bytes from the body, or from the decompressed
+// body, fails with an *http.MaxBytesError.
+func requestBody(w http.ResponseWriter, r *http.Request, maxSize units.Base2Bytes) (io.ReadCloser, error) {
+	body := http.MaxBytesReader(w, r.Body, int64(maxSize))
+	if r.Header.Get("Content-Encoding") != "gzip" {
+		return body, nil
+	}
+	gr, err := gzip.NewReader(body)
+	if err != nil {
+		return nil, err
+	}
+	return http.MaxBytesReader(w, gr, int64(maxSize)), nil
+}
+
+// requestErrorStatus returns the HTTP status code for an error reading or
+// decoding the body of a request.
+func requestErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// writeErrorStatus returns the HTTP status code for an error returned by
+// writeTimeSeries. Samples which can never be appended are reported as a bad
+// request to prevent clients from retrying them.
+func writeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrOutOfOrderSample),
+		errors.Is(err, storage.ErrOutOfBounds),
+		errors.Is(err, storage.ErrDuplicateSampleForTimestamp):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
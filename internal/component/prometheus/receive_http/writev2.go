@@ -0,0 +1,176 @@
+package receive_http
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/go-kit/log"
+	"github.com/golang/snappy"
+	"github.com/grafana/agent/internal/component/prometheus/writev2"
+	"github.com/grafana/agent/internal/flow/logging/level"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage"
+)
+
+// writeHandler accepts remote write requests. Version 1.0 requests are
+// handled by v1, and version 2.0 requests are appended to appendable.
+type writeHandler struct {
+	logger     log.Logger
+	appendable storage.Appendable
+	v1         http.Handler
+}
+
+func newWriteHandler(logger log.Logger, appendable storage.Appendable, v1 http.Handler) http.Handler {
+	return &writeHandler{
+		logger:     logger,
+		appendable: appendable,
+		v1:         v1,
+	}
+}
+
+func (h *writeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	proto, err := protoMessage(r.Header.Get("Content-Type"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	switch proto {
+	case writev2.ProtoMessageV1:
+		h.v1.ServeHTTP(w, r)
+	case writev2.ProtoMessage:
+		h.serveV2(w, r)
+	default:
+		http.Error(w, fmt.Sprintf("unsupported proto message %q", proto), http.StatusUnsupportedMediaType)
+	}
+}
+
+// protoMessage returns the protobuf message of a request with the given
+// Content-Type. Requests without a proto parameter are version 1.0 requests.
+func protoMessage(contentType string) (string, error) {
+	if contentType == "" {
+		return writev2.ProtoMessageV1, nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", err
+	}
+	if mediaType != "application/x-protobuf" {
+		return "", fmt.Errorf("unsupported content type %q", mediaType)
+	}
+	if proto, ok := params["proto"]; ok {
+		return proto, nil
+	}
+	return writev2.ProtoMessageV1, nil
+}
+
+func (h *writeHandler) serveV2(w http.ResponseWriter, r *http.Request) {
+	if enc := r.Header.Get("Content-Encoding"); enc != "" && enc != "snappy" {
+		http.Error(w, fmt.Sprintf("unsupported content encoding %q", enc), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	compressed, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	raw, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		level.Error(h.logger).Log("msg", "error decoding remote write 2.0 request", "err", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req writev2.Request
+	if err := req.Unmarshal(raw); err != nil {
+		level.Error(h.logger).Log("msg", "error decoding remote write 2.0 request", "err", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	series, md, err := fromV2(&req)
+	if err != nil {
+		level.Error(h.logger).Log("msg", "error decoding remote write 2.0 request", "err", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := writeTimeSeries(r.Context(), h.logger, h.appendable, series, md)
+	w.Header().Set(writev2.SamplesWrittenHeader, strconv.Itoa(stats.Samples))
+	w.Header().Set(writev2.HistogramsWrittenHeader, strconv.Itoa(stats.Histograms))
+	w.Header().Set(writev2.ExemplarsWrittenHeader, strconv.Itoa(stats.Exemplars))
+	if err != nil {
+		level.Error(h.logger).Log("msg", "error appending remote write 2.0 metrics", "err", err)
+		http.Error(w, err.Error(), writeErrorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// fromV2 converts the series of a remote write 2.0 request to version 1.0
+// series, and returns the metadata of the series by metric name. Created
+// timestamps are ignored.
+func fromV2(req *writev2.Request) ([]prompb.TimeSeries, map[string]metadata.Metadata, error) {
+	var (
+		b      labels.ScratchBuilder
+		series = make([]prompb.TimeSeries, 0, len(req.Timeseries))
+		md     = make(map[string]metadata.Metadata)
+	)
+
+	for _, ts := range req.Timeseries {
+		lbls, err := req.Labels(&b, ts.LabelsRefs)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		out := prompb.TimeSeries{
+			Labels:     toLabelProtos(lbls),
+			Samples:    ts.Samples,
+			Histograms: ts.Histograms,
+		}
+		for _, e := range ts.Exemplars {
+			exemplarLabels, err := req.Labels(&b, e.LabelsRefs)
+			if err != nil {
+				return nil, nil, err
+			}
+			out.Exemplars = append(out.Exemplars, prompb.Exemplar{
+				Labels:    toLabelProtos(exemplarLabels),
+				Value:     e.Value,
+				Timestamp: e.Timestamp,
+			})
+		}
+		series = append(series, out)
+
+		if ts.Metadata != (writev2.Metadata{}) {
+			help, err := req.Symbol(ts.Metadata.HelpRef)
+			if err != nil {
+				return nil, nil, err
+			}
+			unit, err := req.Symbol(ts.Metadata.UnitRef)
+			if err != nil {
+				return nil, nil, err
+			}
+			md[lbls.Get(labels.MetricName)] = metadata.Metadata{
+				Type: writev2.ToMetadataType(ts.Metadata.Type),
+				Help: help,
+				Unit: unit,
+			}
+		}
+	}
+	return series, md, nil
+}
+
+func toLabelProtos(lbls labels.Labels) []prompb.Label {
+	res := make([]prompb.Label, 0, lbls.Len())
+	lbls.Range(func(l labels.Label) {
+		res = append(res, prompb.Label{Name: l.Name, Value: l.Value})
+	})
+	return res
+}
@@ -3,13 +3,16 @@ package relabel
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"sync"
+	"time"
 
 	"github.com/grafana/agent/internal/component"
 	flow_relabel "github.com/grafana/agent/internal/component/common/relabel"
 	"github.com/grafana/agent/internal/component/prometheus"
 	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/agent/internal/service/labelstore"
+	"github.com/grafana/agent/internal/service/livedebugging"
 	lru "github.com/hashicorp/golang-lru/v2"
 	prometheus_client "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/prometheus/model/exemplar"
@@ -46,13 +49,21 @@ type Arguments struct {
 
 	// Cache size to use for LRU cache.
 	CacheSize int `river:"max_cache_size,attr,optional"`
+
+	// How long a cache entry is kept after its series was last seen. Zero
+	// disables time-based eviction.
+	CacheTTL time.Duration `river:"cache_ttl,attr,optional"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	CacheSize: 100_000,
+	CacheTTL:  10 * time.Minute,
 }
 
 // SetToDefault implements river.Defaulter.
 func (arg *Arguments) SetToDefault() {
-	*arg = Arguments{
-		CacheSize: 100_000,
-	}
+	*arg = DefaultArguments
 }
 
 // Validate implements river.Validator.
@@ -60,9 +71,28 @@ func (arg *Arguments) Validate() error {
 	if arg.CacheSize <= 0 {
 		return fmt.Errorf("max_cache_size must be greater than 0 and is %d", arg.CacheSize)
 	}
+	if arg.CacheTTL < 0 {
+		return fmt.Errorf("cache_ttl must not be negative and is %s", arg.CacheTTL)
+	}
 	return nil
 }
 
+// Reasons for cache evictions, used as the reason label of the
+// agent_prometheus_relabel_cache_evictions metric.
+const (
+	evictionReasonSize          = "size"
+	evictionReasonTTL           = "ttl"
+	evictionReasonSeriesRemoved = "series_removed"
+)
+
+// minCacheSweepInterval is the minimum interval between two sweeps of the
+// cache for expired entries.
+const minCacheSweepInterval = time.Second
+
+// sweepBatchSize is the maximum number of expired cache entries evicted while
+// holding the cache lock during a sweep.
+const sweepBatchSize = 1_000
+
 // Exports holds values which are exported by the prometheus.relabel component.
 type Exports struct {
 	Receiver storage.Appendable `river:"receiver,attr"`
@@ -81,16 +111,22 @@ type Component struct {
 	cacheMisses      prometheus_client.Counter
 	cacheSize        prometheus_client.Gauge
 	cacheDeletes     prometheus_client.Counter
+	cacheEvictions   *prometheus_client.CounterVec
 	fanout           *prometheus.Fanout
 	exited           atomic.Bool
 	ls               labelstore.LabelStore
+	debugData        livedebugging.Publisher
+	rules            []*flow_relabel.Config
+	cacheTTL         time.Duration
+	ttlChanged       chan struct{}
 
 	cacheMut sync.RWMutex
 	cache    *lru.Cache[uint64, *labelAndID]
 }
 
 var (
-	_ component.Component = (*Component)(nil)
+	_ component.Component      = (*Component)(nil)
+	_ component.DebugComponent = (*Component)(nil)
 )
 
 // New creates a new prometheus.relabel component.
@@ -104,9 +140,11 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		return nil, err
 	}
 	c := &Component{
-		opts:  o,
-		cache: cache,
-		ls:    data.(labelstore.LabelStore),
+		opts:       o,
+		cache:      cache,
+		ls:         data.(labelstore.LabelStore),
+		debugData:  livedebugging.PublisherFrom(o.GetServiceData),
+		ttlChanged: make(chan struct{}, 1),
 	}
 	c.metricsProcessed = prometheus_client.NewCounter(prometheus_client.CounterOpts{
 		Name: "agent_prometheus_relabel_metrics_processed",
@@ -132,8 +170,12 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		Name: "agent_prometheus_relabel_cache_deletes",
 		Help: "Total number of cache deletes",
 	})
+	c.cacheEvictions = prometheus_client.NewCounterVec(prometheus_client.CounterOpts{
+		Name: "agent_prometheus_relabel_cache_evictions",
+		Help: "Total number of cache evictions by reason",
+	}, []string{"reason"})
 
-	for _, metric := range []prometheus_client.Collector{c.metricsProcessed, c.metricsOutgoing, c.cacheMisses, c.cacheHits, c.cacheSize, c.cacheDeletes} {
+	for _, metric := range []prometheus_client.Collector{c.metricsProcessed, c.metricsOutgoing, c.cacheMisses, c.cacheHits, c.cacheSize, c.cacheDeletes, c.cacheEvictions} {
 		err = o.Registerer.Register(metric)
 		if err != nil {
 			return nil, err
@@ -144,15 +186,19 @@ func New(o component.Options, args Arguments) (*Component, error) {
 	c.receiver = prometheus.NewInterceptor(
 		c.fanout,
 		c.ls,
+		// The series are accounted to the component by the fanout, once
+		// relabeled, so that they aren't counted twice.
 		prometheus.WithAppendHook(func(_ storage.SeriesRef, l labels.Labels, t int64, v float64, next storage.Appender) (storage.SeriesRef, error) {
 			if c.exited.Load() {
 				return 0, fmt.Errorf("%s has exited", o.ID)
 			}
 
+			c.publishSample(livedebugging.DirectionReceived, l, t, v)
 			newLbl := c.relabel(v, l)
 			if newLbl.IsEmpty() {
 				return 0, nil
 			}
+			c.publishSample(livedebugging.DirectionEmitted, newLbl, t, v)
 			c.metricsOutgoing.Inc()
 			return next.Append(0, newLbl, t, v)
 		}),
@@ -203,12 +249,59 @@ func New(o component.Options, args Arguments) (*Component, error) {
 	return c, nil
 }
 
+// publishSample publishes a sample to the live debugging streams of the
+// component.
+func (c *Component) publishSample(dir livedebugging.Direction, l labels.Labels, t int64, v float64) {
+	if !c.debugData.IsActive(c.opts.ID) {
+		return
+	}
+	c.debugData.Publish(livedebugging.Data{
+		ComponentID: c.opts.ID,
+		Type:        livedebugging.DataTypeMetric,
+		Direction:   dir,
+		Render: func() string {
+			return fmt.Sprintf("%s %s %v", time.UnixMilli(t).UTC().Format(time.RFC3339Nano), l, v)
+		},
+	})
+}
+
 // Run implements component.Component.
 func (c *Component) Run(ctx context.Context) error {
 	defer c.exited.Store(true)
 
-	<-ctx.Done()
-	return nil
+	// Series removed from the labelstore won't be seen again under the same
+	// global ref ID, so their cache entries are dead.
+	unregister := c.ls.OnSeriesRemoved(c.evictRemovedSeries)
+	defer unregister()
+
+	sweep := time.NewTicker(time.Hour)
+	defer sweep.Stop()
+	c.resetSweep(sweep)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.ttlChanged:
+			c.resetSweep(sweep)
+		case <-sweep.C:
+			c.evictExpired(time.Now())
+		}
+	}
+}
+
+// resetSweep resets the interval of sweep to a fraction of the cache TTL, so
+// that entries don't outlive the TTL by much.
+func (c *Component) resetSweep(sweep *time.Ticker) {
+	c.mut.RLock()
+	ttl := c.cacheTTL
+	c.mut.RUnlock()
+
+	if ttl == 0 {
+		sweep.Stop()
+		return
+	}
+	sweep.Reset(max(ttl/10, minCacheSweepInterval))
 }
 
 // Update implements component.Component.
@@ -217,10 +310,25 @@ func (c *Component) Update(args component.Arguments) error {
 	defer c.mut.Unlock()
 
 	newArgs := args.(Arguments)
-	c.clearCache(newArgs.CacheSize)
+	// Cached results are only valid for the rules they were computed with, but
+	// can be kept if only the size of the cache changed.
+	if !reflect.DeepEqual(c.rules, newArgs.MetricRelabelConfigs) {
+		c.clearCache(newArgs.CacheSize)
+	} else {
+		c.resizeCache(newArgs.CacheSize)
+	}
+	c.rules = newArgs.MetricRelabelConfigs
 	c.mrc = flow_relabel.ComponentToPromRelabelConfigs(newArgs.MetricRelabelConfigs)
 	c.fanout.UpdateChildren(newArgs.ForwardTo)
 
+	if c.cacheTTL != newArgs.CacheTTL {
+		c.cacheTTL = newArgs.CacheTTL
+		select {
+		case c.ttlChanged <- struct{}{}:
+		default:
+		}
+	}
+
 	c.opts.OnStateChange(Exports{Receiver: c.receiver, Rules: newArgs.MetricRelabelConfigs})
 
 	return nil
@@ -235,24 +343,28 @@ func (c *Component) relabel(val float64, lbls labels.Labels) labels.Labels {
 		relabelled labels.Labels
 		keep       bool
 	)
+	now := time.Now()
 	newLbls, found := c.getFromCache(globalRef)
+	if found && c.cacheTTL > 0 && newLbls.lastSeen.Load() < now.Add(-c.cacheTTL).UnixNano() {
+		// The entry expired before the sweep got to it; it's replaced below.
+		c.cacheEvictions.WithLabelValues(evictionReasonTTL).Inc()
+		found = false
+	}
 	if found {
 		c.cacheHits.Inc()
-		// If newLbls is nil but cache entry was found then we want to keep the value nil, if it's not we want to reuse the labels
-		if newLbls != nil {
-			relabelled = newLbls.labels
-		}
+		newLbls.lastSeen.Store(now.UnixNano())
+		// If the series was dropped the cached labels are nil, and we want to keep the value nil.
+		relabelled = newLbls.labels
 	} else {
 		// Relabel against a copy of the labels to prevent modifying the original
 		// slice.
 		relabelled, keep = relabel.Process(lbls.Copy(), c.mrc...)
 		c.cacheMisses.Inc()
-		c.addToCache(globalRef, relabelled, keep)
+		c.addToCache(globalRef, relabelled, keep, now)
 	}
 
 	// If stale remove from the cache, the reason we don't exit early is so the stale value can propagate.
-	// TODO: (@mattdurham) This caching can leak and likely needs a timed eviction at some point, but this is simple.
-	// In the future the global ref cache may have some hooks to allow notification of when caches should be evicted.
+	// Series which disappear without a stale marker are evicted once they expire or are removed from the labelstore.
 	if value.IsStaleNaN(val) {
 		c.deleteFromCache(globalRef)
 	}
@@ -284,24 +396,99 @@ func (c *Component) clearCache(cacheSize int) {
 	c.cache = cache
 }
 
-func (c *Component) addToCache(originalID uint64, lbls labels.Labels, keep bool) {
+// resizeCache changes the size of the cache, evicting the least recently used
+// entries if it shrinks.
+func (c *Component) resizeCache(cacheSize int) {
 	c.cacheMut.Lock()
 	defer c.cacheMut.Unlock()
+	evicted := c.cache.Resize(cacheSize)
+	c.cacheEvictions.WithLabelValues(evictionReasonSize).Add(float64(evicted))
+}
 
-	if !keep {
-		c.cache.Add(originalID, nil)
+func (c *Component) addToCache(originalID uint64, lbls labels.Labels, keep bool, now time.Time) {
+	c.cacheMut.Lock()
+	defer c.cacheMut.Unlock()
+
+	entry := &labelAndID{}
+	entry.lastSeen.Store(now.UnixNano())
+	if keep {
+		entry.labels = lbls
+		entry.id = c.ls.GetOrAddGlobalRefID(lbls)
+	}
+	if evicted := c.cache.Add(originalID, entry); evicted {
+		c.cacheEvictions.WithLabelValues(evictionReasonSize).Inc()
+	}
+}
+
+// evictExpired removes the cache entries whose series weren't seen for longer
+// than the cache TTL. The cache lock is released between batches of
+// sweepBatchSize entries so that relabeling isn't blocked for the whole sweep.
+func (c *Component) evictExpired(now time.Time) {
+	c.mut.RLock()
+	ttl := c.cacheTTL
+	c.mut.RUnlock()
+	if ttl == 0 {
 		return
 	}
-	newGlobal := c.ls.GetOrAddGlobalRefID(lbls)
-	c.cache.Add(originalID, &labelAndID{
-		labels: lbls,
-		id:     newGlobal,
-	})
+
+	deadline := now.Add(-ttl).UnixNano()
+	for c.evictOldestExpired(deadline, sweepBatchSize) == sweepBatchSize {
+	}
+
+	c.cacheMut.RLock()
+	defer c.cacheMut.RUnlock()
+	c.cacheSize.Set(float64(c.cache.Len()))
+}
+
+// evictOldestExpired evicts up to limit entries last seen before deadline and
+// returns how many were evicted. Entries move to the front of the cache
+// whenever their series is seen, so the walk starts from the least recently
+// used entry and stops at the first one which isn't expired. Entries which
+// are out of order are evicted lazily when they're looked up.
+func (c *Component) evictOldestExpired(deadline int64, limit int) int {
+	c.cacheMut.Lock()
+	defer c.cacheMut.Unlock()
+
+	for evicted := 0; evicted < limit; evicted++ {
+		id, entry, found := c.cache.GetOldest()
+		if !found || entry.lastSeen.Load() >= deadline {
+			return evicted
+		}
+		c.cache.Remove(id)
+		c.cacheEvictions.WithLabelValues(evictionReasonTTL).Inc()
+	}
+	return limit
+}
+
+// evictRemovedSeries removes the cache entries of series removed from the
+// labelstore.
+func (c *Component) evictRemovedSeries(ids []uint64) {
+	c.cacheMut.Lock()
+	defer c.cacheMut.Unlock()
+
+	for _, id := range ids {
+		if c.cache.Remove(id) {
+			c.cacheEvictions.WithLabelValues(evictionReasonSeriesRemoved).Inc()
+		}
+	}
+	c.cacheSize.Set(float64(c.cache.Len()))
 }
 
 // labelAndID stores both the globalrefid for the label and the id itself. We store the id so that it doesn't have
-// to be recalculated again.
+// to be recalculated again. labels is nil if the series was dropped.
 type labelAndID struct {
 	labels labels.Labels
 	id     uint64
+	// lastSeen is the last time the series was relabeled, in nanoseconds since the Unix epoch.
+	lastSeen atomic.Int64
+}
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	return debugInfo{Series: c.fanout.SeriesStats()}
+}
+
+// debugInfo is the debug information of the component.
+type debugInfo struct {
+	Series labelstore.ComponentStats `river:"series,block"`
 }
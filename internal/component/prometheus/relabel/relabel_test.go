@@ -12,10 +12,12 @@ import (
 	flow_relabel "github.com/grafana/agent/internal/component/common/relabel"
 	"github.com/grafana/agent/internal/component/prometheus"
 	"github.com/grafana/agent/internal/flow/componenttest"
+	flow_service "github.com/grafana/agent/internal/service"
 	"github.com/grafana/agent/internal/service/labelstore"
 	"github.com/grafana/agent/internal/util"
 	"github.com/grafana/river"
 	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/prometheus/prometheus/model/value"
@@ -111,6 +113,127 @@ func TestLRUNaN(t *testing.T) {
 	require.True(t, relabeller.cache.Len() == 0)
 }
 
+func TestCacheResize(t *testing.T) {
+	relabeller := generateRelabel(t)
+	for i := 0; i < 10; i++ {
+		lbls := labels.FromStrings("__address__", "localhost", "inc", strconv.Itoa(i))
+		relabeller.relabel(0, lbls)
+	}
+	require.Equal(t, 10, relabeller.cache.Len())
+
+	// Changing only the size of the cache keeps the most recent entries.
+	args := Arguments{
+		ForwardTo:            []storage.Appendable{},
+		MetricRelabelConfigs: relabeller.rules,
+		CacheSize:            5,
+	}
+	require.NoError(t, relabeller.Update(args))
+	require.Equal(t, 5, relabeller.cache.Len())
+	require.Equal(t, 5.0, testutil.ToFloat64(relabeller.cacheEvictions.WithLabelValues(evictionReasonSize)))
+
+	args.CacheSize = 100
+	require.NoError(t, relabeller.Update(args))
+	require.Equal(t, 5, relabeller.cache.Len())
+}
+
+func TestCacheTTL(t *testing.T) {
+	relabeller := generateRelabel(t)
+	require.NoError(t, relabeller.Update(Arguments{
+		MetricRelabelConfigs: relabeller.rules,
+		CacheSize:            100_000,
+		CacheTTL:             time.Minute,
+	}))
+
+	oldLbls := labels.FromStrings("__address__", "old")
+	newLbls := labels.FromStrings("__address__", "new")
+	relabeller.relabel(0, oldLbls)
+	relabeller.relabel(0, newLbls)
+	require.Equal(t, 2, relabeller.cache.Len())
+
+	// Pretend the old series was last seen before the TTL. Peek leaves it as
+	// the least recently used entry.
+	entry, found := relabeller.cache.Peek(relabeller.ls.GetOrAddGlobalRefID(oldLbls))
+	require.True(t, found)
+	entry.lastSeen.Store(time.Now().Add(-2 * time.Minute).UnixNano())
+
+	relabeller.evictExpired(time.Now())
+	require.Equal(t, 1, relabeller.cache.Len())
+	_, found = relabeller.getFromCache(relabeller.ls.GetOrAddGlobalRefID(newLbls))
+	require.True(t, found)
+	require.Equal(t, 1.0, testutil.ToFloat64(relabeller.cacheEvictions.WithLabelValues(evictionReasonTTL)))
+}
+
+func TestCacheTTL_Lazy(t *testing.T) {
+	relabeller := generateRelabel(t)
+	require.NoError(t, relabeller.Update(Arguments{
+		MetricRelabelConfigs: relabeller.rules,
+		CacheSize:            100_000,
+		CacheTTL:             time.Minute,
+	}))
+
+	lbls := labels.FromStrings("__address__", "localhost")
+	relabeller.relabel(0, lbls)
+
+	// An expired entry which wasn't swept yet is replaced when it's looked up.
+	entry, found := relabeller.getFromCache(relabeller.ls.GetOrAddGlobalRefID(lbls))
+	require.True(t, found)
+	entry.lastSeen.Store(time.Now().Add(-2 * time.Minute).UnixNano())
+
+	relabeller.relabel(0, lbls)
+	require.Equal(t, 1.0, testutil.ToFloat64(relabeller.cacheEvictions.WithLabelValues(evictionReasonTTL)))
+	require.Equal(t, 2.0, testutil.ToFloat64(relabeller.cacheMisses))
+	newEntry, found := relabeller.getFromCache(relabeller.ls.GetOrAddGlobalRefID(lbls))
+	require.True(t, found)
+	require.NotSame(t, entry, newEntry)
+}
+
+func TestCacheTTL_Batches(t *testing.T) {
+	relabeller := generateRelabel(t)
+	require.NoError(t, relabeller.Update(Arguments{
+		MetricRelabelConfigs: relabeller.rules,
+		CacheSize:            100_000,
+		CacheTTL:             time.Minute,
+	}))
+
+	expired := time.Now().Add(-2 * time.Minute).UnixNano()
+	for i := 0; i < 2*sweepBatchSize+10; i++ {
+		lbls := labels.FromStrings("__address__", strconv.Itoa(i))
+		relabeller.relabel(0, lbls)
+		entry, _ := relabeller.cache.Peek(relabeller.ls.GetOrAddGlobalRefID(lbls))
+		entry.lastSeen.Store(expired)
+	}
+	fresh := labels.FromStrings("__address__", "fresh")
+	relabeller.relabel(0, fresh)
+
+	relabeller.evictExpired(time.Now())
+	require.Equal(t, 1, relabeller.cache.Len())
+	_, found := relabeller.getFromCache(relabeller.ls.GetOrAddGlobalRefID(fresh))
+	require.True(t, found)
+	require.Equal(t, float64(2*sweepBatchSize+10), testutil.ToFloat64(relabeller.cacheEvictions.WithLabelValues(evictionReasonTTL)))
+}
+
+func TestCacheSeriesRemoved(t *testing.T) {
+	relabeller := generateRelabel(t)
+	unregister := relabeller.ls.OnSeriesRemoved(relabeller.evictRemovedSeries)
+	defer unregister()
+
+	lbls := labels.FromStrings("__address__", "localhost")
+	relabeller.relabel(0, lbls)
+	require.Equal(t, 1, relabeller.cache.Len())
+
+	// With room for a single stale marker, marking a second series stale
+	// removes the first one from the labelstore right away.
+	require.NoError(t, relabeller.ls.(flow_service.Service).Update(labelstore.Arguments{MaxStaleMarkers: 1}))
+	other := labels.FromStrings("__address__", "other")
+	relabeller.ls.TrackStaleness([]labelstore.StalenessTracker{
+		{GlobalRefID: relabeller.ls.GetOrAddGlobalRefID(lbls), Labels: lbls, Value: math.Float64frombits(value.StaleNaN)},
+		{GlobalRefID: relabeller.ls.GetOrAddGlobalRefID(other), Labels: other, Value: math.Float64frombits(value.StaleNaN)},
+	})
+
+	require.Equal(t, 0, relabeller.cache.Len())
+	require.Equal(t, 1.0, testutil.ToFloat64(relabeller.cacheEvictions.WithLabelValues(evictionReasonSeriesRemoved)))
+}
+
 func BenchmarkCache(b *testing.B) {
 	ls := labelstore.New(nil, prom.DefaultRegisterer)
 	fanout := prometheus.NewInterceptor(nil, ls, prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, _ int64, _ float64, _ storage.Appender) (storage.SeriesRef, error) {
@@ -147,6 +270,21 @@ func BenchmarkCache(b *testing.B) {
 	app.Commit()
 }
 
+func TestSeriesStats(t *testing.T) {
+	relabeller := generateRelabel(t)
+
+	const n = 10
+	app := relabeller.receiver.Appender(context.Background())
+	for i := 0; i < n; i++ {
+		lbls := labels.FromStrings("__address__", "localhost", "inc", strconv.Itoa(i))
+		_, err := app.Append(0, lbls, time.Now().UnixMilli(), 0)
+		require.NoError(t, err)
+	}
+	require.NoError(t, app.Commit())
+
+	require.Equal(t, n, relabeller.fanout.SeriesStats().Series)
+}
+
 func generateRelabel(t *testing.T) *Component {
 	ls := labelstore.New(nil, prom.DefaultRegisterer)
 	fanout := prometheus.NewInterceptor(nil, ls, prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, _ int64, _ float64, _ storage.Appender) (storage.SeriesRef, error) {
@@ -0,0 +1,470 @@
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/grafana/agent/internal/component/prometheus/writev2"
+	"github.com/grafana/agent/internal/flow/logging/level"
+	"github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/grafana/agent/internal/util"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/prometheus/storage/remote"
+	"gopkg.in/yaml.v2"
+)
+
+// forwardServer is a local HTTP server receiving the remote write 1.0
+// requests sent by the queues of the endpoints using protocol version 2.0.
+//
+// The queues of the vendored Prometheus remote storage only send version 1.0
+// requests, and build their HTTP clients from their configuration when the
+// configuration is applied, without a way to replace them, so their requests
+// can't be converted in-process. The queue of each 2.0 endpoint is configured
+// to send them to the forwardServer instead, which converts them with the
+// forwarder of the endpoint. The metrics of the queues are relabeled by a
+// queueMetrics collector, so that they report the URL of the endpoint.
+//
+// The forwardServer only listens on the loopback interface and only accepts
+// requests carrying a random bearer token generated when it starts, so other
+// processes of the host can't send data through it.
+type forwardServer struct {
+	srv   *http.Server
+	lis   net.Listener
+	token string
+
+	mut        sync.RWMutex
+	forwarders map[string]*forwarder
+}
+
+const forwardPathPrefix = "/endpoint/"
+
+func newForwardServer(logger log.Logger) (*forwardServer, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("failed to generate remote write 2.0 forwarding token: %w", err)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for remote write 2.0 forwarding: %w", err)
+	}
+
+	s := &forwardServer{
+		lis:        lis,
+		token:      hex.EncodeToString(token),
+		forwarders: make(map[string]*forwarder),
+	}
+	s.srv = &http.Server{Handler: s}
+
+	go func() {
+		if err := s.srv.Serve(lis); err != nil && err != http.ErrServerClosed {
+			level.Error(logger).Log("msg", "remote write 2.0 forwarding server stopped", "err", err)
+		}
+	}()
+	return s, nil
+}
+
+// URL returns the URL the queue of the endpoint with the given key must send
+// requests to.
+func (s *forwardServer) URL(key string) string {
+	return "http://" + s.lis.Addr().String() + forwardPathPrefix + key
+}
+
+// Token returns the bearer token the queues must authenticate with.
+func (s *forwardServer) Token() string {
+	return s.token
+}
+
+// Forwarder returns the forwarder of the endpoint with the given key, or nil.
+func (s *forwardServer) Forwarder(key string) *forwarder {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return s.forwarders[key]
+}
+
+// SetForwarders replaces the forwarders of the endpoints by their key, and
+// returns the previous ones.
+func (s *forwardServer) SetForwarders(forwarders map[string]*forwarder) map[string]*forwarder {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	old := s.forwarders
+	s.forwarders = forwarders
+	return old
+}
+
+func (s *forwardServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	key, ok := strings.CutPrefix(r.URL.Path, forwardPathPrefix)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	f := s.Forwarder(key)
+	if f == nil {
+		// The endpoint was removed, but its queue hasn't been stopped yet.
+		http.Error(w, "unknown endpoint", http.StatusServiceUnavailable)
+		return
+	}
+	f.ServeHTTP(w, r)
+}
+
+// Close stops the server.
+func (s *forwardServer) Close() error {
+	return s.srv.Close()
+}
+
+// Once an endpoint falls back to remote write 1.0, version 2.0 is tried again
+// after a backoff, doubling from minProbeBackoff up to maxProbeBackoff as long
+// as the endpoint doesn't support it.
+const (
+	minProbeBackoff = time.Minute
+	maxProbeBackoff = time.Hour
+)
+
+// forwarder converts remote write 1.0 requests to 2.0 requests and sends them
+// to an endpoint. It falls back to sending the original 1.0 requests when the
+// endpoint shows it doesn't support version 2.0, and tries version 2.0 again
+// after a backoff.
+type forwarder struct {
+	logger   log.Logger
+	name     string // Name of the queue of the endpoint.
+	cfg      *config.RemoteWriteConfig
+	url      string
+	client   *http.Client
+	metadata writev2.MetadataFunc
+
+	protocolVersion prometheus.Gauge
+	fallbacks       prometheus.Counter
+
+	mut           sync.Mutex
+	fallbackUntil time.Time // Zero while version 2.0 is used.
+	backoff       time.Duration
+}
+
+func newForwarder(logger log.Logger, name string, cfg *config.RemoteWriteConfig, metadata writev2.MetadataFunc, metrics *forwarderMetrics) (*forwarder, error) {
+	wc, err := remote.NewWriteClient(name, &remote.ClientConfig{
+		URL:              cfg.URL,
+		Timeout:          cfg.RemoteTimeout,
+		HTTPClientConfig: cfg.HTTPClientConfig,
+		SigV4Config:      cfg.SigV4Config,
+		AzureADConfig:    cfg.AzureADConfig,
+		Headers:          cfg.Headers,
+		RetryOnRateLimit: cfg.QueueConfig.RetryOnRateLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c, ok := wc.(*remote.Client)
+	if !ok {
+		return nil, fmt.Errorf("unexpected remote write client type %T", wc)
+	}
+
+	f := &forwarder{
+		logger:   log.With(logger, "url", cfg.URL.Redacted()),
+		name:     name,
+		cfg:      cfg,
+		url:      cfg.URL.String(),
+		client:   c.Client,
+		metadata: metadata,
+
+		protocolVersion: metrics.protocolVersion.WithLabelValues(name, cfg.URL.Redacted()),
+		fallbacks:       metrics.fallbacks.WithLabelValues(name, cfg.URL.Redacted()),
+	}
+	f.protocolVersion.Set(2)
+	return f, nil
+}
+
+// ProtocolVersion returns the protocol version currently used to send
+// requests.
+func (f *forwarder) ProtocolVersion() string {
+	if f.useV2(time.Now()) {
+		return ProtocolVersion2
+	}
+	return ProtocolVersion1
+}
+
+// useV2 reports whether requests sent at now use version 2.0.
+func (f *forwarder) useV2(now time.Time) bool {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	return !now.Before(f.fallbackUntil)
+}
+
+// supportedV2 records that the endpoint accepted a version 2.0 request.
+func (f *forwarder) supportedV2() {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	if f.backoff != 0 {
+		level.Info(f.logger).Log("msg", "endpoint supports remote write 2.0 again")
+	}
+	f.fallbackUntil, f.backoff = time.Time{}, 0
+	f.protocolVersion.Set(2)
+}
+
+// fallBack records that the endpoint doesn't support version 2.0, and falls
+// back to version 1.0 until the backoff elapsed.
+func (f *forwarder) fallBack(now time.Time, status string) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	// Requests sent concurrently may all find out the endpoint doesn't
+	// support version 2.0.
+	if now.Before(f.fallbackUntil) {
+		return
+	}
+	f.backoff = min(max(2*f.backoff, minProbeBackoff), maxProbeBackoff)
+	f.fallbackUntil = now.Add(f.backoff)
+	f.protocolVersion.Set(1)
+	f.fallbacks.Inc()
+
+	level.Warn(f.logger).Log("msg", "endpoint doesn't support remote write 2.0, falling back to remote write 1.0", "status", status, "retry_in", f.backoff)
+}
+
+func (f *forwarder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if now := time.Now(); f.useV2(now) {
+		v2Body, err := f.convert(body)
+		if err != nil {
+			// The request was built by the queue, so this should never happen.
+			level.Error(f.logger).Log("msg", "failed to convert request to remote write 2.0", "err", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if v2Body == nil {
+			// Metadata is sent along with the series in version 2.0, so requests
+			// holding only metadata don't need to be sent.
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		resp, err := f.send(r.Context(), v2Body, writev2.ContentType, writev2.Version)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		if !unsupportedV2(resp) {
+			if resp.StatusCode/100 == 2 {
+				f.supportedV2()
+			}
+			copyResponse(w, resp)
+			return
+		}
+		_ = resp.Body.Close()
+		f.fallBack(now, resp.Status)
+	}
+
+	resp, err := f.send(r.Context(), body, "application/x-protobuf", writev2.VersionV1)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	copyResponse(w, resp)
+}
+
+// convert converts a snappy-compressed 1.0 request to a snappy-compressed 2.0
+// request. It returns nil if the request doesn't hold any series.
+func (f *forwarder) convert(body []byte) ([]byte, error) {
+	raw, err := snappy.Decode(nil, body)
+	if err != nil {
+		return nil, err
+	}
+	var req prompb.WriteRequest
+	if err := proto.Unmarshal(raw, &req); err != nil {
+		return nil, err
+	}
+
+	v2Req := writev2.FromV1(&req, f.metadata)
+	if len(v2Req.Timeseries) == 0 {
+		return nil, nil
+	}
+	v2Raw, err := v2Req.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return snappy.Encode(nil, v2Raw), nil
+}
+
+func (f *forwarder) send(ctx context.Context, body []byte, contentType, version string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", remote.UserAgent)
+	req.Header.Set(writev2.VersionHeader, version)
+	return f.client.Do(req)
+}
+
+// unsupportedV2 reports whether resp shows the endpoint doesn't support
+// remote write 2.0: either it rejected the content type, or it accepted the
+// request without reporting what it wrote, like 1.0 receivers which decode
+// 2.0 requests as empty 1.0 requests do.
+func unsupportedV2(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusUnsupportedMediaType {
+		return true
+	}
+	return resp.StatusCode/100 == 2 && resp.Header.Get(writev2.SamplesWrittenHeader) == ""
+}
+
+func copyResponse(w http.ResponseWriter, resp *http.Response) {
+	defer resp.Body.Close()
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
+
+// forwarderMetrics are the metrics of the forwarders, labeled like the metrics
+// of the queues.
+type forwarderMetrics struct {
+	protocolVersion *prometheus.GaugeVec
+	fallbacks       *prometheus.CounterVec
+}
+
+func newForwarderMetrics(reg prometheus.Registerer) *forwarderMetrics {
+	m := &forwarderMetrics{
+		protocolVersion: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "prometheus_remote_write_protocol_version",
+			Help: "Major version of the remote write protocol currently used to send requests to an endpoint configured with version 2.0.",
+		}, []string{queueRemoteNameLabel, queueURLLabel}),
+		fallbacks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "prometheus_remote_write_protocol_fallbacks_total",
+			Help: "Total number of times an endpoint configured with version 2.0 of the remote write protocol fell back to version 1.0.",
+		}, []string{queueRemoteNameLabel, queueURLLabel}),
+	}
+
+	if reg != nil {
+		m.protocolVersion = util.MustRegisterOrGet(reg, m.protocolVersion).(*prometheus.GaugeVec)
+		m.fallbacks = util.MustRegisterOrGet(reg, m.fallbacks).(*prometheus.CounterVec)
+	}
+	return m
+}
+
+// delete removes the metrics of the forwarder f.
+func (m *forwarderMetrics) delete(f *forwarder) {
+	m.protocolVersion.DeleteLabelValues(f.name, f.cfg.URL.Redacted())
+	m.fallbacks.DeleteLabelValues(f.name, f.cfg.URL.Redacted())
+}
+
+// Labels of the metrics of the queues of the remote storage.
+const (
+	queueRemoteNameLabel = "remote_name"
+	queueURLLabel        = "url"
+)
+
+// queueMetrics collects the metrics of the remote storage from reg, replacing
+// the address of the forwardServer in the url label of the queues of the
+// endpoints using remote write 2.0 with the URL of the endpoint.
+type queueMetrics struct {
+	reg *prometheus.Registry
+
+	mut  sync.RWMutex
+	urls map[string]string // Redacted endpoint URLs by forwardServer URL.
+}
+
+var _ prometheus.Collector = (*queueMetrics)(nil)
+
+func newQueueMetrics() *queueMetrics {
+	return &queueMetrics{reg: prometheus.NewRegistry()}
+}
+
+// SetURLs replaces the endpoint URLs reported for the forwardServer URLs.
+func (q *queueMetrics) SetURLs(urls map[string]string) {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+	q.urls = urls
+}
+
+// Describe implements prometheus.Collector. The collector is unchecked, since
+// the metrics of the queues change with the configuration.
+func (q *queueMetrics) Describe(chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector.
+func (q *queueMetrics) Collect(ch chan<- prometheus.Metric) {
+	// Gather returns the metrics it could gather along with an error.
+	families, _ := q.reg.Gather()
+
+	q.mut.RLock()
+	defer q.mut.RUnlock()
+
+	for _, mf := range families {
+		for _, m := range mf.Metric {
+			names := make([]string, 0, len(m.Label))
+			for _, l := range m.Label {
+				if l.GetName() == queueURLLabel {
+					if url, ok := q.urls[l.GetValue()]; ok {
+						l.Value = &url
+					}
+				}
+				names = append(names, l.GetName())
+			}
+			ch <- gatheredMetric{
+				desc:   prometheus.NewDesc(mf.GetName(), mf.GetHelp(), names, nil),
+				metric: m,
+			}
+		}
+	}
+}
+
+// gatheredMetric is a metric gathered from a registry.
+type gatheredMetric struct {
+	desc   *prometheus.Desc
+	metric *dto.Metric
+}
+
+func (m gatheredMetric) Desc() *prometheus.Desc { return m.desc }
+
+func (m gatheredMetric) Write(out *dto.Metric) error {
+	out.Label = m.metric.Label
+	out.Counter = m.metric.Counter
+	out.Gauge = m.metric.Gauge
+	out.Summary = m.metric.Summary
+	out.Untyped = m.metric.Untyped
+	out.Histogram = m.metric.Histogram
+	out.TimestampMs = m.metric.TimestampMs
+	return nil
+}
+
+// queueName returns the name of the queue of the endpoint cfg, which is
+// reported in the remote_name label of its metrics: its configured name, or
+// the start of the hash of its configuration, like the remote storage does.
+func queueName(cfg *config.RemoteWriteConfig) (string, error) {
+	if cfg.Name != "" {
+		return cfg.Name, nil
+	}
+	b, err := yaml.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+	hash := md5.Sum(b)
+	return hex.EncodeToString(hash[:])[:6], nil
+}
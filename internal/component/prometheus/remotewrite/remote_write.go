@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
 	"sync"
 	"time"
 
@@ -18,6 +21,8 @@ import (
 	"github.com/grafana/agent/internal/service/labelstore"
 	"github.com/grafana/agent/internal/useragent"
 	"github.com/grafana/agent/static/metrics/wal"
+	common "github.com/prometheus/common/config"
+	"github.com/prometheus/prometheus/config"
 	"github.com/prometheus/prometheus/model/exemplar"
 	"github.com/prometheus/prometheus/model/histogram"
 	"github.com/prometheus/prometheus/model/labels"
@@ -61,6 +66,12 @@ type Component struct {
 	mut sync.RWMutex
 	cfg Arguments
 
+	// forwardServer is started when the first endpoint using remote write 2.0
+	// is configured.
+	forwardServer    *forwardServer
+	forwarderMetrics *forwarderMetrics
+	queueMetrics     *queueMetrics
+
 	receiver *prometheus.Interceptor
 }
 
@@ -81,8 +92,15 @@ func New(o component.Options, c Arguments) (*Component, error) {
 		return nil, err
 	}
 
+	// The metrics of the remote storage are collected through queueMetrics,
+	// which reports the URL of the endpoints using remote write 2.0.
+	queueMetrics := newQueueMetrics()
+	if err := o.Registerer.Register(queueMetrics); err != nil {
+		return nil, err
+	}
+
 	remoteLogger := log.With(o.Logger, "subcomponent", "rw")
-	remoteStore := remote.NewStorage(remoteLogger, o.Registerer, startTime, o.DataPath, remoteFlushDeadline, nil)
+	remoteStore := remote.NewStorage(remoteLogger, queueMetrics.reg, startTime, o.DataPath, remoteFlushDeadline, nil)
 
 	service, err := o.GetServiceData(labelstore.ServiceName)
 	if err != nil {
@@ -96,10 +114,14 @@ func New(o component.Options, c Arguments) (*Component, error) {
 		walStore:    walStorage,
 		remoteStore: remoteStore,
 		storage:     storage.NewFanout(o.Logger, walStorage, remoteStore),
+
+		forwarderMetrics: newForwarderMetrics(o.Registerer),
+		queueMetrics:     queueMetrics,
 	}
 	res.receiver = prometheus.NewInterceptor(
 		res.storage,
 		ls,
+		prometheus.WithComponentID(o.ID),
 
 		// In the methods below, conversion is needed because remote_writes assume
 		// they are responsible for generating ref IDs. This means two
@@ -169,7 +191,10 @@ func New(o component.Options, c Arguments) (*Component, error) {
 
 func startTime() (int64, error) { return 0, nil }
 
-var _ component.Component = (*Component)(nil)
+var (
+	_ component.Component      = (*Component)(nil)
+	_ component.DebugComponent = (*Component)(nil)
+)
 
 // Run implements Component.
 func (c *Component) Run(ctx context.Context) error {
@@ -182,6 +207,14 @@ func (c *Component) Run(ctx context.Context) error {
 		if err != nil {
 			level.Error(c.log).Log("msg", "error when closing storage", "err", err)
 		}
+
+		// The queues flush to the forwardServer when closing the storage, so it
+		// must be closed afterwards.
+		c.mut.Lock()
+		defer c.mut.Unlock()
+		if c.forwardServer != nil {
+			_ = c.forwardServer.Close()
+		}
 	}()
 
 	// Track the last timestamp we truncated for to prevent segments from getting
@@ -264,6 +297,9 @@ func (c *Component) Update(newConfig component.Arguments) error {
 		}
 		cfg.Headers[agentseed.HeaderName] = uid
 	}
+	if err := c.applyProtocolVersions(cfg, convertedConfig); err != nil {
+		return err
+	}
 	err = c.remoteStore.ApplyConfig(convertedConfig)
 	if err != nil {
 		return err
@@ -272,3 +308,123 @@ func (c *Component) Update(newConfig component.Arguments) error {
 	c.cfg = cfg
 	return nil
 }
+
+// applyProtocolVersions routes the queues of the endpoints using remote write
+// 2.0 through the forwardServer. The configs of their queues in
+// convertedConfig are replaced with configs sending to the forwardServer,
+// while the forwarders use the original configs. The queues keep the name of
+// the original configs, and their metrics report the original URLs. It must
+// be called with c.mut held.
+func (c *Component) applyProtocolVersions(args Arguments, convertedConfig *config.Config) error {
+	var (
+		forwarders = make(map[string]*forwarder)
+		urls       = make(map[string]string)
+	)
+	for i, ep := range args.Endpoints {
+		if ep.ProtocolVersion != ProtocolVersion2 {
+			continue
+		}
+
+		if c.forwardServer == nil {
+			s, err := newForwardServer(c.log)
+			if err != nil {
+				return err
+			}
+			c.forwardServer = s
+		}
+
+		key := strconv.Itoa(i)
+		rwConfig := convertedConfig.RemoteWriteConfigs[i]
+		name, err := queueName(rwConfig)
+		if err != nil {
+			return err
+		}
+
+		// Keep the forwarder, and whether it fell back to remote write 1.0, if
+		// the endpoint didn't change.
+		f := c.forwardServer.Forwarder(key)
+		if f == nil || !reflect.DeepEqual(f.cfg, rwConfig) {
+			f, err = newForwarder(c.log, name, rwConfig, c.walStore.MetricMetadata, c.forwarderMetrics)
+			if err != nil {
+				return err
+			}
+		}
+		forwarders[key] = f
+
+		localURL, err := url.Parse(c.forwardServer.URL(key))
+		if err != nil {
+			return err
+		}
+		urls[localURL.String()] = rwConfig.URL.Redacted()
+
+		localConfig := *rwConfig
+		localConfig.Name = name
+		localConfig.URL = &common.URL{URL: localURL}
+		localConfig.HTTPClientConfig = common.DefaultHTTPClientConfig
+		localConfig.HTTPClientConfig.Authorization = &common.Authorization{
+			Type:        "Bearer",
+			Credentials: common.Secret(c.forwardServer.Token()),
+		}
+		localConfig.Headers = nil
+		localConfig.SigV4Config = nil
+		localConfig.AzureADConfig = nil
+		convertedConfig.RemoteWriteConfigs[i] = &localConfig
+	}
+
+	c.queueMetrics.SetURLs(urls)
+	if c.forwardServer == nil {
+		return nil
+	}
+
+	// Remove the metrics of the forwarders which were replaced, unless a new
+	// forwarder reports the same labels.
+	type metricLabels struct{ name, url string }
+	used := make(map[metricLabels]struct{}, len(forwarders))
+	for _, f := range forwarders {
+		used[metricLabels{f.name, f.cfg.URL.Redacted()}] = struct{}{}
+	}
+	for _, f := range c.forwardServer.SetForwarders(forwarders) {
+		if _, ok := used[metricLabels{f.name, f.cfg.URL.Redacted()}]; !ok {
+			c.forwarderMetrics.delete(f)
+		}
+	}
+	return nil
+}
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	info := debugInfo{Series: c.receiver.SeriesStats()}
+	for i, ep := range c.cfg.Endpoints {
+		version := ProtocolVersion1
+		if ep.ProtocolVersion == ProtocolVersion2 && c.forwardServer != nil {
+			if f := c.forwardServer.Forwarder(strconv.Itoa(i)); f != nil {
+				version = f.ProtocolVersion()
+			}
+		}
+		info.Endpoints = append(info.Endpoints, endpointDebugInfo{
+			Name:            ep.Name,
+			URL:             ep.URL,
+			ProtocolVersion: version,
+		})
+	}
+	return info
+}
+
+// debugInfo is the debug information of the component.
+type debugInfo struct {
+	Series    labelstore.ComponentStats `river:"series,block"`
+	Endpoints []endpointDebugInfo       `river:"endpoint,block,optional"`
+}
+
+// endpointDebugInfo is the debug information of an endpoint.
+type endpointDebugInfo struct {
+	Name string `river:"name,attr,optional"`
+	URL  string `river:"url,attr"`
+
+	// ProtocolVersion is the remote write protocol version currently used to
+	// send requests to the endpoint.
+	ProtocolVersion string `river:"protocol_version,attr"`
+}
@@ -3,16 +3,21 @@ package remotewrite_test
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/golang/snappy"
 	"github.com/grafana/agent/internal/component/prometheus/remotewrite"
+	"github.com/grafana/agent/internal/component/prometheus/writev2"
 	"github.com/grafana/agent/internal/flow/componenttest"
 	"github.com/grafana/agent/internal/util"
 	"github.com/grafana/river"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/model/textparse"
 	"github.com/prometheus/prometheus/prompb"
 	"github.com/prometheus/prometheus/storage/remote"
 	"github.com/stretchr/testify/require"
@@ -226,3 +231,117 @@ func testArgsForConfig(t *testing.T, cfg string) remotewrite.Arguments {
 	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
 	return args
 }
+
+func TestProtocolVersion2(t *testing.T) {
+	type result struct {
+		version string
+		v1      *prompb.WriteRequest
+		v2      *writev2.Request
+	}
+
+	tt := []struct {
+		name       string
+		supportsV2 bool
+	}{
+		{name: "2.0 endpoint", supportsV2: true},
+		{name: "1.0 endpoint falls back", supportsV2: false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			results := make(chan result, 10)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Content-Type") != writev2.ContentType {
+					req, err := remote.DecodeWriteRequest(r.Body)
+					if err != nil {
+						http.Error(w, err.Error(), http.StatusBadRequest)
+						return
+					}
+					results <- result{version: r.Header.Get(writev2.VersionHeader), v1: req}
+					return
+				}
+
+				if !tc.supportsV2 {
+					http.Error(w, "unsupported", http.StatusUnsupportedMediaType)
+					return
+				}
+				compressed, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				raw, err := snappy.Decode(nil, compressed)
+				require.NoError(t, err)
+				var req writev2.Request
+				require.NoError(t, req.Unmarshal(raw))
+				w.Header().Set(writev2.SamplesWrittenHeader, "1")
+				w.WriteHeader(http.StatusNoContent)
+				results <- result{version: r.Header.Get(writev2.VersionHeader), v2: &req}
+			}))
+			defer srv.Close()
+
+			args := testArgsForConfig(t, fmt.Sprintf(`
+				endpoint {
+					url              = "%s/api/v1/write"
+					remote_timeout   = "1s"
+					protocol_version = "2.0"
+
+					queue_config {
+						batch_send_deadline = "100ms"
+					}
+				}
+			`, srv.URL))
+			tc2, err := componenttest.NewControllerFromID(util.TestLogger(t), "prometheus.remote_write")
+			require.NoError(t, err)
+			go func() {
+				err = tc2.Run(componenttest.TestContext(t), args)
+				require.NoError(t, err)
+			}()
+			require.NoError(t, tc2.WaitRunning(5*time.Second))
+
+			sampleTimestamp := time.Now().Add(time.Minute).UnixMilli()
+			lset := labels.FromStrings("__name__", "up", "job", "agent")
+
+			appender := tc2.Exports().(remotewrite.Exports).Receiver.Appender(context.Background())
+			ref, err := appender.Append(0, lset, sampleTimestamp, 1)
+			require.NoError(t, err)
+			_, err = appender.UpdateMetadata(ref, lset, metadata.Metadata{Type: textparse.MetricTypeGauge, Help: "Target is up."})
+			require.NoError(t, err)
+			require.NoError(t, appender.Commit())
+
+			var res result
+			select {
+			case <-time.After(time.Minute):
+				require.FailNow(t, "timed out waiting for metrics")
+			case res = <-results:
+			}
+
+			if !tc.supportsV2 {
+				require.Equal(t, "0.1.0", res.version)
+				require.Equal(t, []prompb.TimeSeries{{
+					Labels:  []prompb.Label{{Name: "__name__", Value: "up"}, {Name: "job", Value: "agent"}},
+					Samples: []prompb.Sample{{Timestamp: sampleTimestamp, Value: 1}},
+				}}, res.v1.Timeseries)
+				return
+			}
+
+			require.Equal(t, "2.0.0", res.version)
+			require.Equal(t, &writev2.Request{
+				Symbols: []string{"", "__name__", "up", "job", "agent", "Target is up."},
+				Timeseries: []writev2.TimeSeries{{
+					LabelsRefs: []uint32{1, 2, 3, 4},
+					Samples:    []prompb.Sample{{Timestamp: sampleTimestamp, Value: 1}},
+					Metadata:   writev2.Metadata{Type: writev2.MetricTypeGauge, HelpRef: 5},
+				}},
+			}, res.v2)
+		})
+	}
+}
+
+func TestProtocolVersionValidation(t *testing.T) {
+	var args remotewrite.Arguments
+	err := river.Unmarshal([]byte(`
+		endpoint {
+			url              = "http://localhost:9009/api/v1/push"
+			protocol_version = "3.0"
+		}
+	`), &args)
+	require.ErrorContains(t, err, `unsupported protocol_version "3.0"`)
+}
@@ -51,6 +51,12 @@ var (
 	}
 )
 
+// Remote write protocol versions supported by endpoints.
+const (
+	ProtocolVersion1 = "1.0"
+	ProtocolVersion2 = "2.0"
+)
+
 // Arguments represents the input state of the prometheus.remote_write
 // component.
 type Arguments struct {
@@ -73,6 +79,7 @@ type EndpointOptions struct {
 	Headers              map[string]string       `river:"headers,attr,optional"`
 	SendExemplars        bool                    `river:"send_exemplars,attr,optional"`
 	SendNativeHistograms bool                    `river:"send_native_histograms,attr,optional"`
+	ProtocolVersion      string                  `river:"protocol_version,attr,optional"`
 	HTTPClientConfig     *types.HTTPClientConfig `river:",squash"`
 	QueueOptions         *QueueOptions           `river:"queue_config,block,optional"`
 	MetadataOptions      *MetadataOptions        `river:"metadata_config,block,optional"`
@@ -86,6 +93,7 @@ func (r *EndpointOptions) SetToDefault() {
 	*r = EndpointOptions{
 		RemoteTimeout:    30 * time.Second,
 		SendExemplars:    true,
+		ProtocolVersion:  ProtocolVersion1,
 		HTTPClientConfig: types.CloneDefaultHTTPClientConfig(),
 	}
 }
@@ -107,6 +115,12 @@ func (r *EndpointOptions) Validate() error {
 		}
 	}
 
+	switch r.ProtocolVersion {
+	case ProtocolVersion1, ProtocolVersion2:
+	default:
+		return fmt.Errorf("unsupported protocol_version %q, must be %q or %q", r.ProtocolVersion, ProtocolVersion1, ProtocolVersion2)
+	}
+
 	const tooManyAuthErr = "at most one of sigv4, azuread, basic_auth, oauth2, bearer_token & bearer_token_file must be configured"
 
 	if r.SigV4 != nil {
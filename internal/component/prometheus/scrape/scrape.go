@@ -327,7 +327,8 @@ func (c *Component) distTargets(
 
 // ScraperStatus reports the status of the scraper's jobs.
 type ScraperStatus struct {
-	TargetStatus []TargetStatus `river:"target,block,optional"`
+	TargetStatus []TargetStatus            `river:"target,block,optional"`
+	Series       labelstore.ComponentStats `river:"series,block"`
 }
 
 // TargetStatus reports on the status of the latest scrape for a target.
@@ -371,6 +372,7 @@ func BuildTargetStatuses(targets map[string][]*scrape.Target) []TargetStatus {
 func (c *Component) DebugInfo() interface{} {
 	return ScraperStatus{
 		TargetStatus: BuildTargetStatuses(c.scraper.TargetsActive()),
+		Series:       c.appendable.SeriesStats(),
 	}
 }
 
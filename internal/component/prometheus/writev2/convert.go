@@ -0,0 +1,188 @@
+package writev2
+
+import (
+	"strings"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/model/textparse"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/tsdb/record"
+)
+
+// SymbolTable interns the strings of a request. The empty string is always
+// referenced by 0.
+type SymbolTable struct {
+	symbols []string
+	refs    map[string]uint32
+}
+
+// NewSymbolTable returns an empty SymbolTable.
+func NewSymbolTable() *SymbolTable {
+	return &SymbolTable{
+		symbols: []string{""},
+		refs:    map[string]uint32{"": 0},
+	}
+}
+
+// Symbolize returns the reference to s, interning it if needed.
+func (t *SymbolTable) Symbolize(s string) uint32 {
+	if ref, ok := t.refs[s]; ok {
+		return ref
+	}
+	ref := uint32(len(t.symbols))
+	t.symbols = append(t.symbols, s)
+	t.refs[s] = ref
+	return ref
+}
+
+// SymbolizeLabels appends the references to the names and values of lbls to
+// refs.
+func (t *SymbolTable) SymbolizeLabels(lbls []prompb.Label, refs []uint32) []uint32 {
+	for _, l := range lbls {
+		refs = append(refs, t.Symbolize(l.Name), t.Symbolize(l.Value))
+	}
+	return refs
+}
+
+// Symbols returns the interned strings, in the order of their references.
+func (t *SymbolTable) Symbols() []string {
+	return t.symbols
+}
+
+// MetadataFunc returns the metadata of the series with the given metric name.
+type MetadataFunc func(name string) (metadata.Metadata, bool)
+
+// FromV1 converts a remote write 1.0 request to a 2.0 request. The metadata of
+// each series is looked up in the metadata of req, then with lookup if it's
+// not nil. The created timestamp of each series is taken from the OpenMetrics
+// _created series of its metric family in req, if any. Series without samples,
+// histograms or exemplars are dropped.
+func FromV1(req *prompb.WriteRequest, lookup MetadataFunc) *Request {
+	reqMetadata := make(map[string]prompb.MetricMetadata, len(req.Metadata))
+	for _, m := range req.Metadata {
+		reqMetadata[m.MetricFamilyName] = m
+	}
+	created := createdTimestamps(req.Timeseries)
+
+	symbols := NewSymbolTable()
+	res := &Request{Timeseries: make([]TimeSeries, 0, len(req.Timeseries))}
+
+	for _, ts := range req.Timeseries {
+		if len(ts.Samples) == 0 && len(ts.Histograms) == 0 && len(ts.Exemplars) == 0 {
+			continue
+		}
+
+		out := TimeSeries{
+			LabelsRefs: symbols.SymbolizeLabels(ts.Labels, make([]uint32, 0, 2*len(ts.Labels))),
+			Samples:    ts.Samples,
+			Histograms: ts.Histograms,
+		}
+		if len(created) > 0 {
+			name := metricName(ts.Labels)
+			if !strings.HasSuffix(name, createdSuffix) {
+				out.CreatedTimestamp = created[familyKey(familyName(name), ts.Labels)]
+			}
+		}
+		for _, e := range ts.Exemplars {
+			out.Exemplars = append(out.Exemplars, Exemplar{
+				LabelsRefs: symbols.SymbolizeLabels(e.Labels, nil),
+				Value:      e.Value,
+				Timestamp:  e.Timestamp,
+			})
+		}
+
+		name := metricName(ts.Labels)
+		if m, ok := reqMetadata[name]; ok {
+			out.Metadata = Metadata{
+				Type:    MetricType(m.Type),
+				HelpRef: symbols.Symbolize(m.Help),
+				UnitRef: symbols.Symbolize(m.Unit),
+			}
+		} else if lookup != nil {
+			if m, ok := lookup(name); ok {
+				out.Metadata = Metadata{
+					Type:    FromMetadataType(m.Type),
+					HelpRef: symbols.Symbolize(m.Help),
+					UnitRef: symbols.Symbolize(m.Unit),
+				}
+			}
+		}
+
+		res.Timeseries = append(res.Timeseries, out)
+	}
+
+	res.Symbols = symbols.Symbols()
+	return res
+}
+
+// createdSuffix is the suffix of the OpenMetrics series holding the created
+// timestamp of counters, histograms and summaries, in seconds.
+const createdSuffix = "_created"
+
+// createdTimestamps returns the latest created timestamp, in milliseconds, of
+// the _created series of series by their familyKey.
+func createdTimestamps(series []prompb.TimeSeries) map[string]int64 {
+	var created map[string]int64
+	for _, ts := range series {
+		name := metricName(ts.Labels)
+		family, ok := strings.CutSuffix(name, createdSuffix)
+		if !ok || len(ts.Samples) == 0 {
+			continue
+		}
+		if created == nil {
+			created = make(map[string]int64)
+		}
+		created[familyKey(family, ts.Labels)] = int64(ts.Samples[len(ts.Samples)-1].Value * 1000)
+	}
+	return created
+}
+
+// familyName returns the name of the metric family of a counter, histogram
+// or summary series.
+func familyName(name string) string {
+	for _, suffix := range []string{"_total", "_bucket", "_count", "_sum"} {
+		if family, ok := strings.CutSuffix(name, suffix); ok {
+			return family
+		}
+	}
+	return name
+}
+
+// familyKey identifies the series of a metric family sharing a created
+// timestamp: the family name along with the labels of the series, except the
+// metric name and the labels telling apart buckets and quantiles.
+func familyKey(family string, lbls []prompb.Label) string {
+	var b strings.Builder
+	b.WriteString(family)
+	for _, l := range lbls {
+		switch l.Name {
+		case model.MetricNameLabel, model.BucketLabel, model.QuantileLabel:
+			continue
+		}
+		b.WriteByte(0xff)
+		b.WriteString(l.Name)
+		b.WriteByte(0xff)
+		b.WriteString(l.Value)
+	}
+	return b.String()
+}
+
+func metricName(lbls []prompb.Label) string {
+	for _, l := range lbls {
+		if l.Name == model.MetricNameLabel {
+			return l.Value
+		}
+	}
+	return ""
+}
+
+// FromMetadataType converts a metric type to its remote write 2.0 value.
+func FromMetadataType(t textparse.MetricType) MetricType {
+	return MetricType(record.GetMetricType(t))
+}
+
+// ToMetadataType converts a remote write 2.0 metric type to a metric type.
+func ToMetadataType(t MetricType) textparse.MetricType {
+	return record.ToTextparseMetricType(uint8(t))
+}
@@ -0,0 +1,379 @@
+// Package writev2 implements the messages of version 2.0 of the Prometheus
+// remote write protocol, and their conversion from version 1.0 messages.
+//
+// The messages are encoded by hand since the vendored Prometheus doesn't
+// include them yet. Histograms share their encoding with version 1.0 and reuse
+// [prompb.Histogram].
+package writev2
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/prompb"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Headers and content types of remote write requests and responses.
+const (
+	ContentType   = "application/x-protobuf;proto=io.prometheus.write.v2.Request"
+	ContentTypeV1 = "application/x-protobuf;proto=prometheus.WriteRequest"
+
+	// ProtoMessage and ProtoMessageV1 are the values of the proto parameter of
+	// the content types.
+	ProtoMessage   = "io.prometheus.write.v2.Request"
+	ProtoMessageV1 = "prometheus.WriteRequest"
+
+	VersionHeader = "X-Prometheus-Remote-Write-Version"
+	Version       = "2.0.0"
+	VersionV1     = "0.1.0"
+
+	SamplesWrittenHeader    = "X-Prometheus-Remote-Write-Samples-Written"
+	HistogramsWrittenHeader = "X-Prometheus-Remote-Write-Histograms-Written"
+	ExemplarsWrittenHeader  = "X-Prometheus-Remote-Write-Exemplars-Written"
+)
+
+// Request is a remote write 2.0 request. The strings of the series are
+// interned in Symbols and referenced by their index.
+type Request struct {
+	Symbols    []string
+	Timeseries []TimeSeries
+}
+
+// TimeSeries is a series with its samples, histograms, exemplars and
+// metadata. LabelsRefs holds pairs of references to the names and values of
+// the labels.
+type TimeSeries struct {
+	LabelsRefs       []uint32
+	Samples          []prompb.Sample
+	Histograms       []prompb.Histogram
+	Exemplars        []Exemplar
+	Metadata         Metadata
+	CreatedTimestamp int64
+}
+
+// Exemplar is an exemplar of a series.
+type Exemplar struct {
+	LabelsRefs []uint32
+	Value      float64
+	Timestamp  int64
+}
+
+// Metadata is the metadata of a series.
+type Metadata struct {
+	Type    MetricType
+	HelpRef uint32
+	UnitRef uint32
+}
+
+// MetricType is the type of a series. Its values match
+// [github.com/prometheus/prometheus/tsdb/record.MetricType].
+type MetricType uint32
+
+// Metric types.
+const (
+	MetricTypeUnspecified MetricType = iota
+	MetricTypeCounter
+	MetricTypeGauge
+	MetricTypeHistogram
+	MetricTypeGaugeHistogram
+	MetricTypeSummary
+	MetricTypeInfo
+	MetricTypeStateset
+)
+
+// Labels returns the labels referenced by refs.
+func (r *Request) Labels(b *labels.ScratchBuilder, refs []uint32) (labels.Labels, error) {
+	if len(refs)%2 != 0 {
+		return labels.EmptyLabels(), fmt.Errorf("odd number of label references: %d", len(refs))
+	}
+
+	b.Reset()
+	for i := 0; i < len(refs); i += 2 {
+		name, err := r.Symbol(refs[i])
+		if err != nil {
+			return labels.EmptyLabels(), err
+		}
+		value, err := r.Symbol(refs[i+1])
+		if err != nil {
+			return labels.EmptyLabels(), err
+		}
+		b.Add(name, value)
+	}
+	b.Sort()
+	return b.Labels(), nil
+}
+
+// Symbol returns the symbol referenced by ref.
+func (r *Request) Symbol(ref uint32) (string, error) {
+	if int(ref) >= len(r.Symbols) {
+		return "", fmt.Errorf("symbol reference %d out of range, request has %d symbols", ref, len(r.Symbols))
+	}
+	return r.Symbols[ref], nil
+}
+
+// Marshal encodes r in the protobuf wire format.
+func (r *Request) Marshal() ([]byte, error) {
+	var b []byte
+	for _, s := range r.Symbols {
+		b = protowire.AppendTag(b, 4, protowire.BytesType)
+		b = protowire.AppendString(b, s)
+	}
+	for i := range r.Timeseries {
+		ts, err := r.Timeseries[i].marshal()
+		if err != nil {
+			return nil, err
+		}
+		b = protowire.AppendTag(b, 5, protowire.BytesType)
+		b = protowire.AppendBytes(b, ts)
+	}
+	return b, nil
+}
+
+func (ts *TimeSeries) marshal() ([]byte, error) {
+	var b []byte
+	b = appendPackedRefs(b, 1, ts.LabelsRefs)
+	for _, s := range ts.Samples {
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendBytes(b, marshalSample(s))
+	}
+	for i := range ts.Histograms {
+		h, err := ts.Histograms[i].Marshal()
+		if err != nil {
+			return nil, err
+		}
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		b = protowire.AppendBytes(b, h)
+	}
+	for _, e := range ts.Exemplars {
+		b = protowire.AppendTag(b, 4, protowire.BytesType)
+		b = protowire.AppendBytes(b, e.marshal())
+	}
+	if ts.Metadata != (Metadata{}) {
+		b = protowire.AppendTag(b, 5, protowire.BytesType)
+		b = protowire.AppendBytes(b, ts.Metadata.marshal())
+	}
+	if ts.CreatedTimestamp != 0 {
+		b = protowire.AppendTag(b, 6, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(ts.CreatedTimestamp))
+	}
+	return b, nil
+}
+
+func marshalSample(s prompb.Sample) []byte {
+	var b []byte
+	if s.Value != 0 {
+		b = protowire.AppendTag(b, 1, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(s.Value))
+	}
+	if s.Timestamp != 0 {
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(s.Timestamp))
+	}
+	return b
+}
+
+func (e *Exemplar) marshal() []byte {
+	var b []byte
+	b = appendPackedRefs(b, 1, e.LabelsRefs)
+	if e.Value != 0 {
+		b = protowire.AppendTag(b, 2, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(e.Value))
+	}
+	if e.Timestamp != 0 {
+		b = protowire.AppendTag(b, 3, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(e.Timestamp))
+	}
+	return b
+}
+
+func (m *Metadata) marshal() []byte {
+	var b []byte
+	if m.Type != 0 {
+		b = protowire.AppendTag(b, 1, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(m.Type))
+	}
+	if m.HelpRef != 0 {
+		b = protowire.AppendTag(b, 3, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(m.HelpRef))
+	}
+	if m.UnitRef != 0 {
+		b = protowire.AppendTag(b, 4, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(m.UnitRef))
+	}
+	return b
+}
+
+func appendPackedRefs(b []byte, num protowire.Number, refs []uint32) []byte {
+	if len(refs) == 0 {
+		return b
+	}
+	var packed []byte
+	for _, ref := range refs {
+		packed = protowire.AppendVarint(packed, uint64(ref))
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, packed)
+}
+
+// Unmarshal decodes r from the protobuf wire format. Unknown fields are
+// ignored.
+func (r *Request) Unmarshal(b []byte) error {
+	*r = Request{}
+	return unmarshalFields(b, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		switch {
+		case num == 4 && typ == protowire.BytesType:
+			r.Symbols = append(r.Symbols, string(v))
+		case num == 5 && typ == protowire.BytesType:
+			var ts TimeSeries
+			if err := ts.unmarshal(v); err != nil {
+				return fmt.Errorf("decode timeseries: %w", err)
+			}
+			r.Timeseries = append(r.Timeseries, ts)
+		}
+		return nil
+	})
+}
+
+func (ts *TimeSeries) unmarshal(b []byte) error {
+	return unmarshalFields(b, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		switch num {
+		case 1:
+			refs, err := unmarshalRefs(typ, v, ts.LabelsRefs)
+			if err != nil {
+				return err
+			}
+			ts.LabelsRefs = refs
+		case 2:
+			s, err := unmarshalSample(v)
+			if err != nil {
+				return fmt.Errorf("decode sample: %w", err)
+			}
+			ts.Samples = append(ts.Samples, s)
+		case 3:
+			var h prompb.Histogram
+			if err := h.Unmarshal(v); err != nil {
+				return fmt.Errorf("decode histogram: %w", err)
+			}
+			ts.Histograms = append(ts.Histograms, h)
+		case 4:
+			var e Exemplar
+			if err := e.unmarshal(v); err != nil {
+				return fmt.Errorf("decode exemplar: %w", err)
+			}
+			ts.Exemplars = append(ts.Exemplars, e)
+		case 5:
+			if err := ts.Metadata.unmarshal(v); err != nil {
+				return fmt.Errorf("decode metadata: %w", err)
+			}
+		case 6:
+			ts.CreatedTimestamp = int64(varintValue(v))
+		}
+		return nil
+	})
+}
+
+func unmarshalSample(b []byte) (prompb.Sample, error) {
+	var s prompb.Sample
+	err := unmarshalFields(b, func(num protowire.Number, _ protowire.Type, v []byte) error {
+		switch num {
+		case 1:
+			s.Value = math.Float64frombits(fixed64Value(v))
+		case 2:
+			s.Timestamp = int64(varintValue(v))
+		}
+		return nil
+	})
+	return s, err
+}
+
+func (e *Exemplar) unmarshal(b []byte) error {
+	return unmarshalFields(b, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		switch num {
+		case 1:
+			refs, err := unmarshalRefs(typ, v, e.LabelsRefs)
+			if err != nil {
+				return err
+			}
+			e.LabelsRefs = refs
+		case 2:
+			e.Value = math.Float64frombits(fixed64Value(v))
+		case 3:
+			e.Timestamp = int64(varintValue(v))
+		}
+		return nil
+	})
+}
+
+func (m *Metadata) unmarshal(b []byte) error {
+	return unmarshalFields(b, func(num protowire.Number, _ protowire.Type, v []byte) error {
+		switch num {
+		case 1:
+			m.Type = MetricType(varintValue(v))
+		case 3:
+			m.HelpRef = uint32(varintValue(v))
+		case 4:
+			m.UnitRef = uint32(varintValue(v))
+		}
+		return nil
+	})
+}
+
+// unmarshalFields calls f with the number, type and raw value of each field
+// encoded in b. The value of varint and fixed fields is passed undecoded.
+func unmarshalFields(b []byte, f func(num protowire.Number, typ protowire.Type, v []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var v []byte
+		if typ == protowire.BytesType {
+			v, n = protowire.ConsumeBytes(b)
+		} else {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n >= 0 {
+				v = b[:n]
+			}
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		if err := f(num, typ, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unmarshalRefs appends the references of a repeated uint32 field to refs.
+// Both the packed and unpacked encodings are accepted.
+func unmarshalRefs(typ protowire.Type, v []byte, refs []uint32) ([]uint32, error) {
+	if typ != protowire.BytesType {
+		return append(refs, uint32(varintValue(v))), nil
+	}
+	for len(v) > 0 {
+		ref, n := protowire.ConsumeVarint(v)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		refs = append(refs, uint32(ref))
+		v = v[n:]
+	}
+	return refs, nil
+}
+
+func varintValue(v []byte) uint64 {
+	x, _ := protowire.ConsumeVarint(v)
+	return x
+}
+
+func fixed64Value(v []byte) uint64 {
+	x, _ := protowire.ConsumeFixed64(v)
+	return x
+}
@@ -0,0 +1,142 @@
+package writev2
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/model/textparse"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromV1(t *testing.T) {
+	req := &prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "up"}, {Name: "job", Value: "agent"}},
+			Samples: []prompb.Sample{{Value: 1, Timestamp: 1000}},
+		}, {
+			Labels:  []prompb.Label{{Name: "__name__", Value: "requests_total"}, {Name: "job", Value: "agent"}},
+			Samples: []prompb.Sample{{Value: 42, Timestamp: 1000}},
+			Exemplars: []prompb.Exemplar{{
+				Labels:    []prompb.Label{{Name: "trace_id", Value: "abc"}},
+				Value:     1,
+				Timestamp: 900,
+			}},
+		}, {
+			Labels: []prompb.Label{{Name: "__name__", Value: "empty"}},
+		}},
+		Metadata: []prompb.MetricMetadata{{
+			Type:             prompb.MetricMetadata_GAUGE,
+			MetricFamilyName: "up",
+			Help:             "Target is up.",
+		}},
+	}
+
+	lookup := func(name string) (metadata.Metadata, bool) {
+		if name == "requests_total" {
+			return metadata.Metadata{Type: textparse.MetricTypeCounter, Help: "Total requests.", Unit: "requests"}, true
+		}
+		return metadata.Metadata{}, false
+	}
+
+	expect := &Request{
+		Symbols: []string{"", "__name__", "up", "job", "agent", "Target is up.", "requests_total", "trace_id", "abc", "Total requests.", "requests"},
+		Timeseries: []TimeSeries{{
+			LabelsRefs: []uint32{1, 2, 3, 4},
+			Samples:    []prompb.Sample{{Value: 1, Timestamp: 1000}},
+			Metadata:   Metadata{Type: MetricTypeGauge, HelpRef: 5},
+		}, {
+			LabelsRefs: []uint32{1, 6, 3, 4},
+			Samples:    []prompb.Sample{{Value: 42, Timestamp: 1000}},
+			Exemplars:  []Exemplar{{LabelsRefs: []uint32{7, 8}, Value: 1, Timestamp: 900}},
+			Metadata:   Metadata{Type: MetricTypeCounter, HelpRef: 9, UnitRef: 10},
+		}},
+	}
+	require.Equal(t, expect, FromV1(req, lookup))
+}
+
+func TestFromV1_CreatedTimestamp(t *testing.T) {
+	req := &prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "requests_total"}, {Name: "code", Value: "200"}},
+			Samples: []prompb.Sample{{Value: 42, Timestamp: 1000}},
+		}, {
+			Labels:  []prompb.Label{{Name: "__name__", Value: "requests_created"}, {Name: "code", Value: "200"}},
+			Samples: []prompb.Sample{{Value: 0.5, Timestamp: 1000}},
+		}, {
+			Labels:  []prompb.Label{{Name: "__name__", Value: "requests_total"}, {Name: "code", Value: "500"}},
+			Samples: []prompb.Sample{{Value: 1, Timestamp: 1000}},
+		}, {
+			Labels:  []prompb.Label{{Name: "__name__", Value: "latency_seconds_bucket"}, {Name: "le", Value: "+Inf"}},
+			Samples: []prompb.Sample{{Value: 3, Timestamp: 1000}},
+		}, {
+			Labels:  []prompb.Label{{Name: "__name__", Value: "latency_seconds_created"}},
+			Samples: []prompb.Sample{{Value: 0.25, Timestamp: 1000}},
+		}},
+	}
+
+	var created []int64
+	for _, ts := range FromV1(req, nil).Timeseries {
+		created = append(created, ts.CreatedTimestamp)
+	}
+	// Series without a _created series in the request have no created
+	// timestamp, and _created series themselves don't have one.
+	require.Equal(t, []int64{500, 0, 0, 250, 0}, created)
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	req := &Request{
+		Symbols: []string{"", "__name__", "latency_seconds", "trace_id", "abc", "Request latency."},
+		Timeseries: []TimeSeries{{
+			LabelsRefs: []uint32{1, 2},
+			Samples:    []prompb.Sample{{Value: 0.5, Timestamp: 1000}, {Value: 0, Timestamp: 2000}},
+			Histograms: []prompb.Histogram{{
+				Count:          &prompb.Histogram_CountInt{CountInt: 3},
+				Sum:            1.5,
+				Schema:         1,
+				ZeroCount:      &prompb.Histogram_ZeroCountInt{ZeroCountInt: 1},
+				PositiveSpans:  []prompb.BucketSpan{{Offset: 0, Length: 2}},
+				PositiveDeltas: []int64{1, 0},
+				Timestamp:      3000,
+			}},
+			Exemplars:        []Exemplar{{LabelsRefs: []uint32{3, 4}, Value: 0.7, Timestamp: 900}},
+			Metadata:         Metadata{Type: MetricTypeHistogram, HelpRef: 5},
+			CreatedTimestamp: 500,
+		}},
+	}
+
+	b, err := req.Marshal()
+	require.NoError(t, err)
+
+	var actual Request
+	require.NoError(t, actual.Unmarshal(b))
+	require.Equal(t, req.Symbols, actual.Symbols)
+	require.Len(t, actual.Timeseries, 1)
+
+	ts := actual.Timeseries[0]
+	require.Equal(t, req.Timeseries[0].LabelsRefs, ts.LabelsRefs)
+	require.Equal(t, req.Timeseries[0].Samples, ts.Samples)
+	require.Equal(t, req.Timeseries[0].Exemplars, ts.Exemplars)
+	require.Equal(t, req.Timeseries[0].Metadata, ts.Metadata)
+	require.Equal(t, req.Timeseries[0].CreatedTimestamp, ts.CreatedTimestamp)
+	require.Len(t, ts.Histograms, 1)
+	require.Equal(t, req.Timeseries[0].Histograms[0].Count, ts.Histograms[0].Count)
+	require.Equal(t, req.Timeseries[0].Histograms[0].PositiveDeltas, ts.Histograms[0].PositiveDeltas)
+	require.Equal(t, req.Timeseries[0].Histograms[0].Timestamp, ts.Histograms[0].Timestamp)
+
+	var b2 labels.ScratchBuilder
+	lbls, err := actual.Labels(&b2, ts.LabelsRefs)
+	require.NoError(t, err)
+	require.Equal(t, labels.FromStrings("__name__", "latency_seconds"), lbls)
+
+	_, err = actual.Labels(&b2, []uint32{1, 42})
+	require.ErrorContains(t, err, "symbol reference 42 out of range")
+	_, err = actual.Labels(&b2, []uint32{1})
+	require.ErrorContains(t, err, "odd number of label references")
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	var req Request
+	require.Error(t, req.Unmarshal([]byte{0x2a, 0x05, 0x01}))
+}
@@ -3,6 +3,7 @@ package write
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/grafana/agent/internal/component/pyroscope"
 	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/agent/internal/flow/logging/level"
+	"github.com/grafana/agent/internal/service/livedebugging"
 	"github.com/grafana/agent/internal/useragent"
 	"github.com/oklog/run"
 	commonconfig "github.com/prometheus/common/config"
@@ -151,9 +153,10 @@ type fanOutClient struct {
 	// The list of push clients to fan out to.
 	clients []pushv1connect.PusherServiceClient
 
-	config  Arguments
-	opts    component.Options
-	metrics *metrics
+	config    Arguments
+	opts      component.Options
+	metrics   *metrics
+	debugData livedebugging.Publisher
 }
 
 // NewFanOut creates a new fan out client that will fan out to all endpoints.
@@ -172,10 +175,11 @@ func NewFanOut(opts component.Options, config Arguments, metrics *metrics) (*fan
 		clients = append(clients, pushv1connect.NewPusherServiceClient(httpClient, endpoint.URL, WithUserAgent(userAgent)))
 	}
 	return &fanOutClient{
-		clients: clients,
-		config:  config,
-		opts:    opts,
-		metrics: metrics,
+		clients:   clients,
+		config:    config,
+		opts:      opts,
+		metrics:   metrics,
+		debugData: livedebugging.PublisherFrom(opts.GetServiceData),
 	}, nil
 }
 
@@ -277,6 +281,8 @@ func (f *fanOutClient) Appender() pyroscope.Appender {
 
 // Append implements the Appender interface.
 func (f *fanOutClient) Append(ctx context.Context, lbs labels.Labels, samples []*pyroscope.RawSample) error {
+	f.publishProfiles(lbs, samples)
+
 	// todo(ctovena): we should probably pool the label pair arrays and label builder to avoid allocs.
 	var (
 		protoLabels  = make([]*typesv1.LabelPair, 0, len(lbs)+len(f.config.ExternalLabels))
@@ -317,6 +323,25 @@ func (f *fanOutClient) Append(ctx context.Context, lbs labels.Labels, samples []
 	return err
 }
 
+// publishProfiles publishes the received profiles to the live debugging
+// streams of the component.
+func (f *fanOutClient) publishProfiles(lbs labels.Labels, samples []*pyroscope.RawSample) {
+	if !f.debugData.IsActive(f.opts.ID) {
+		return
+	}
+	for _, sample := range samples {
+		size := len(sample.RawProfile)
+		f.debugData.Publish(livedebugging.Data{
+			ComponentID: f.opts.ID,
+			Type:        livedebugging.DataTypeProfile,
+			Direction:   livedebugging.DirectionReceived,
+			Render: func() string {
+				return fmt.Sprintf("%s size=%d", lbs, size)
+			},
+		})
+	}
+}
+
 // WithUserAgent returns a `connect.ClientOption` that sets the User-Agent header on.
 func WithUserAgent(agent string) connect.ClientOption {
 	return connect.WithInterceptors(&agentInterceptor{agent})
@@ -0,0 +1,112 @@
+package secretsmanager
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/grafana/agent/internal/component"
+	common_aws "github.com/grafana/agent/internal/component/common/aws"
+	"github.com/grafana/agent/internal/component/remote/internal/secret"
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/river/rivertypes"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:      "remote.aws.secretsmanager",
+		Stability: featuregate.StabilityExperimental,
+		Args:      Arguments{},
+		Exports:   Exports{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Component reads a secret from AWS Secrets Manager.
+type Component struct {
+	opts   component.Options
+	poller *secret.Poller
+}
+
+var (
+	_ component.Component       = (*Component)(nil)
+	_ component.HealthComponent = (*Component)(nil)
+)
+
+// New initializes the remote.aws.secretsmanager component. The secret is read
+// once before New returns so that its exports are available to the
+// components that depend on it.
+func New(o component.Options, args Arguments) (*Component, error) {
+	read, err := newReadFunc(args)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Component{opts: o}
+	c.poller = secret.NewPoller(o.Logger, read, args.PollFrequency, c.export)
+	if err := c.poller.Poll(context.Background()); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	return c.poller.Run(ctx)
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+	read, err := newReadFunc(newArgs)
+	if err != nil {
+		return err
+	}
+	c.poller.Update(read, newArgs.PollFrequency)
+	return nil
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (c *Component) CurrentHealth() component.Health {
+	return c.poller.CurrentHealth()
+}
+
+func (c *Component) export(v secret.Version) {
+	c.opts.OnStateChange(Exports{
+		Value:     rivertypes.Secret(v.Value),
+		Data:      secret.Data(v.Value),
+		VersionID: v.ID,
+	})
+}
+
+// newReadFunc returns a function reading the version of the secret selected
+// by args.
+func newReadFunc(args Arguments) (secret.ReadFunc, error) {
+	cfg, err := common_aws.GenerateConfig(args.Options)
+	if err != nil {
+		return nil, err
+	}
+	client := secretsmanager.NewFromConfig(*cfg)
+
+	input := &secretsmanager.GetSecretValueInput{SecretId: aws.String(args.SecretID)}
+	if args.VersionStage != "" {
+		input.VersionStage = aws.String(args.VersionStage)
+	}
+	if args.VersionID != "" {
+		input.VersionId = aws.String(args.VersionID)
+	}
+
+	return func(ctx context.Context) (secret.Version, error) {
+		out, err := client.GetSecretValue(ctx, input)
+		if err != nil {
+			return secret.Version{}, err
+		}
+		value := aws.ToString(out.SecretString)
+		if out.SecretString == nil {
+			value = string(out.SecretBinary)
+		}
+		return secret.Version{ID: aws.ToString(out.VersionId), Value: value}, nil
+	}, nil
+}
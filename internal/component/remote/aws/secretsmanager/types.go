@@ -0,0 +1,55 @@
+package secretsmanager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/agent/internal/component/common/aws"
+	"github.com/grafana/river/rivertypes"
+)
+
+// Arguments implements the input for the remote.aws.secretsmanager component.
+type Arguments struct {
+	SecretID string `river:"secret_id,attr"`
+	// VersionStage and VersionID select the version of the secret to read.
+	// AWS Secrets Manager reads the AWSCURRENT stage if neither is set.
+	VersionStage string `river:"version_stage,attr,optional"`
+	VersionID    string `river:"version_id,attr,optional"`
+	// PollFrequency determines the frequency to check for a new version of the
+	// secret, defaults to 10m.
+	PollFrequency time.Duration `river:"poll_frequency,attr,optional"`
+	// Options allows the overriding of default settings.
+	Options aws.Client `river:"client,block,optional"`
+}
+
+// DefaultArguments sets the poll frequency
+var DefaultArguments = Arguments{
+	PollFrequency: 10 * time.Minute,
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	if a.SecretID == "" {
+		return fmt.Errorf("secret_id must not be empty")
+	}
+	if a.PollFrequency <= 0 {
+		return fmt.Errorf("poll_frequency must be greater than 0")
+	}
+	return nil
+}
+
+// Exports holds the values exported by the remote.aws.secretsmanager
+// component.
+type Exports struct {
+	// Value is the value of the secret.
+	Value rivertypes.Secret `river:"value,attr"`
+	// Data holds the keys of the secret if its value is a JSON object.
+	Data map[string]rivertypes.Secret `river:"data,attr"`
+	// VersionID is the identifier of the exported version of the secret.
+	VersionID string `river:"version_id,attr"`
+}
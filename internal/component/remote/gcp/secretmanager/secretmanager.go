@@ -0,0 +1,151 @@
+package secretmanager
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"hash/crc32"
+	"path"
+	"strings"
+
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/component/remote/internal/secret"
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/river/rivertypes"
+	"google.golang.org/api/option"
+	"google.golang.org/api/secretmanager/v1"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:      "remote.gcp.secretmanager",
+		Stability: featuregate.StabilityExperimental,
+		Args:      Arguments{},
+		Exports:   Exports{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Component reads a secret from Google Cloud Secret Manager.
+type Component struct {
+	opts   component.Options
+	poller *secret.Poller
+}
+
+var (
+	_ component.Component       = (*Component)(nil)
+	_ component.HealthComponent = (*Component)(nil)
+)
+
+// New initializes the remote.gcp.secretmanager component. The secret is read
+// once before New returns so that its exports are available to the
+// components that depend on it.
+func New(o component.Options, args Arguments) (*Component, error) {
+	read, err := newReadFunc(args)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Component{opts: o}
+	c.poller = secret.NewPoller(o.Logger, read, args.PollFrequency, c.export)
+	if err := c.poller.Poll(context.Background()); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	return c.poller.Run(ctx)
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+	read, err := newReadFunc(newArgs)
+	if err != nil {
+		return err
+	}
+	c.poller.Update(read, newArgs.PollFrequency)
+	return nil
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (c *Component) CurrentHealth() component.Health {
+	return c.poller.CurrentHealth()
+}
+
+func (c *Component) export(v secret.Version) {
+	c.opts.OnStateChange(Exports{
+		Value:   rivertypes.Secret(v.Value),
+		Data:    secret.Data(v.Value),
+		Version: v.ID,
+	})
+}
+
+// newReadFunc returns a function reading the version of the secret selected
+// by args.
+func newReadFunc(args Arguments) (secret.ReadFunc, error) {
+	service, err := secretmanager.NewService(context.Background(), clientOptions(args.Options)...)
+	if err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("projects/%s/secrets/%s/versions/%s", args.Project, args.Secret, args.Version)
+	return func(ctx context.Context) (secret.Version, error) {
+		version, value, err := accessSecretVersion(ctx, service, name)
+		if err != nil {
+			return secret.Version{}, err
+		}
+		return secret.Version{ID: version, Value: value}, nil
+	}, nil
+}
+
+// clientOptions returns the options of the Secret Manager client. The
+// credentials which aren't overridden are the application default
+// credentials.
+func clientOptions(opts Client) []option.ClientOption {
+	var res []option.ClientOption
+	if opts.Endpoint != "" {
+		// The endpoint is used as the base path of the requests, which must end
+		// with a slash.
+		res = append(res, option.WithEndpoint(strings.TrimSuffix(opts.Endpoint, "/")+"/"))
+	}
+	switch {
+	case opts.DisableAuth:
+		res = append(res, option.WithoutAuthentication())
+	case opts.CredentialsFile != "":
+		res = append(res, option.WithCredentialsFile(opts.CredentialsFile))
+	case opts.Credentials != "":
+		res = append(res, option.WithCredentialsJSON([]byte(opts.Credentials)))
+	}
+	return res
+}
+
+// accessSecretVersion returns the version number and the value of the
+// secret version with the given resource name.
+func accessSecretVersion(ctx context.Context, service *secretmanager.Service, name string) (version, value string, err error) {
+	resp, err := service.Projects.Secrets.Versions.Access(name).Context(ctx).Do()
+	if err != nil {
+		return "", "", err
+	}
+	if resp.Payload == nil {
+		return "", "", fmt.Errorf("secret version %s has no payload", resp.Name)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode secret version %s: %w", resp.Name, err)
+	}
+	if resp.Payload.DataCrc32c != 0 && int64(crc32.Checksum(data, crc32cTable)) != resp.Payload.DataCrc32c {
+		return "", "", fmt.Errorf("secret version %s is corrupted: checksum mismatch", resp.Name)
+	}
+
+	// The resource name of the response holds the version number, even if an
+	// alias was requested.
+	return path.Base(resp.Name), string(data), nil
+}
@@ -0,0 +1,212 @@
+package secretmanager
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/flow/componenttest"
+	"github.com/grafana/agent/internal/util"
+	"github.com/grafana/river"
+	"github.com/grafana/river/rivertypes"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSecretManager is a Secret Manager server holding the versions of a
+// single secret.
+type fakeSecretManager struct {
+	mut      sync.Mutex
+	versions []string
+	corrupt  bool
+}
+
+func (f *fakeSecretManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	const prefix = "/v1/projects/agent/secrets/credentials/versions/"
+	var version string
+	switch {
+	case r.Method != http.MethodGet:
+		http.Error(w, "unexpected method", http.StatusMethodNotAllowed)
+		return
+	case r.URL.Path == prefix+"latest:access":
+		version = strconv.Itoa(len(f.versions))
+	case r.URL.Path == prefix+"1:access":
+		version = "1"
+	default:
+		http.Error(w, `{"error":{"code":404,"message":"Secret not found","status":"NOT_FOUND"}}`, http.StatusNotFound)
+		return
+	}
+
+	n, _ := strconv.Atoi(version)
+	data := []byte(f.versions[n-1])
+	checksum := int64(crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli)))
+	if f.corrupt {
+		checksum++
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"name": "projects/123456/secrets/credentials/versions/" + version,
+		"payload": map[string]string{
+			"data":       base64.StdEncoding.EncodeToString(data),
+			"dataCrc32c": strconv.FormatInt(checksum, 10),
+		},
+	})
+}
+
+func (f *fakeSecretManager) AddVersion(value string) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	f.versions = append(f.versions, value)
+}
+
+func testArguments(t *testing.T, endpoint, secret, version string) Arguments {
+	cfg := fmt.Sprintf(`
+		project        = "agent"
+		secret         = "%s"
+		version        = "%s"
+		poll_frequency = "50ms"
+
+		client {
+			endpoint     = "%s"
+			disable_auth = true
+		}
+	`, secret, version, endpoint)
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+	return args
+}
+
+func Test_SecretManager(t *testing.T) {
+	fake := &fakeSecretManager{}
+	fake.AddVersion(`{"username":"agent","password":"hunter2","port":5432}`)
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(componenttest.TestContext(t))
+	defer cancel()
+
+	ctrl, err := componenttest.NewControllerFromID(util.TestLogger(t), "remote.gcp.secretmanager")
+	require.NoError(t, err)
+
+	go func() {
+		require.NoError(t, ctrl.Run(ctx, testArguments(t, srv.URL, "credentials", "latest")))
+	}()
+	require.NoError(t, ctrl.WaitRunning(time.Minute))
+
+	require.NoError(t, ctrl.WaitExports(time.Minute))
+	require.Equal(t, Exports{
+		Value: rivertypes.Secret(`{"username":"agent","password":"hunter2","port":5432}`),
+		Data: map[string]rivertypes.Secret{
+			"username": rivertypes.Secret("agent"),
+			"password": rivertypes.Secret("hunter2"),
+			"port":     rivertypes.Secret("5432"),
+		},
+		Version: "1",
+	}, ctrl.Exports().(Exports))
+
+	fake.AddVersion("plain text")
+	require.NoError(t, ctrl.WaitExports(time.Minute))
+	require.Equal(t, Exports{
+		Value:   rivertypes.Secret("plain text"),
+		Data:    map[string]rivertypes.Secret{},
+		Version: "2",
+	}, ctrl.Exports().(Exports))
+}
+
+func Test_SecretManager_PinnedVersion(t *testing.T) {
+	fake := &fakeSecretManager{}
+	fake.AddVersion("value")
+	fake.AddVersion("new value")
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	var exports Exports
+	opts := component.Options{
+		Logger:        util.TestFlowLogger(t),
+		OnStateChange: func(e component.Exports) { exports = e.(Exports) },
+	}
+	// Pinning the version ignores the versions added later.
+	_, err := New(opts, testArguments(t, srv.URL+"/", "credentials", "1"))
+	require.NoError(t, err)
+	require.Equal(t, Exports{
+		Value:   rivertypes.Secret("value"),
+		Data:    map[string]rivertypes.Secret{},
+		Version: "1",
+	}, exports)
+}
+
+func Test_SecretManager_Errors(t *testing.T) {
+	fake := &fakeSecretManager{}
+	fake.AddVersion("value")
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	opts := component.Options{
+		Logger:        util.TestFlowLogger(t),
+		OnStateChange: func(e component.Exports) {},
+	}
+	_, err := New(opts, testArguments(t, srv.URL, "missing", "latest"))
+	require.ErrorContains(t, err, "Secret not found")
+
+	fake.corrupt = true
+	_, err = New(opts, testArguments(t, srv.URL, "credentials", "latest"))
+	require.ErrorContains(t, err, "checksum mismatch")
+}
+
+func TestArguments_Validate(t *testing.T) {
+	tests := map[string]struct {
+		cfg string
+		err string
+	}{
+		"empty version": {
+			cfg: `
+				project = "agent"
+				secret  = "credentials"
+				version = ""
+			`,
+			err: "version must not be empty",
+		},
+		"both credentials": {
+			cfg: `
+				project = "agent"
+				secret  = "credentials"
+
+				client {
+					credentials_file = "/etc/gcp/credentials.json"
+					credentials      = "{}"
+				}
+			`,
+			err: "at most one of credentials_file and credentials must be set",
+		},
+		"credentials without auth": {
+			cfg: `
+				project = "agent"
+				secret  = "credentials"
+
+				client {
+					credentials_file = "/etc/gcp/credentials.json"
+					disable_auth     = true
+				}
+			`,
+			err: "credentials can't be set when disable_auth is true",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var args Arguments
+			require.ErrorContains(t, river.Unmarshal([]byte(tt.cfg), &args), tt.err)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package secretmanager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/river/rivertypes"
+)
+
+// Arguments implements the input for the remote.gcp.secretmanager component.
+type Arguments struct {
+	Project string `river:"project,attr"`
+	Secret  string `river:"secret,attr"`
+	// Version is the version of the secret to read, either a version number or
+	// an alias such as "latest".
+	Version string `river:"version,attr,optional"`
+	// PollFrequency determines the frequency to check for a new version of the
+	// secret, defaults to 10m.
+	PollFrequency time.Duration `river:"poll_frequency,attr,optional"`
+	// Options allows the overriding of default settings.
+	Options Client `river:"client,block,optional"`
+}
+
+// Client implements specific Google Cloud configuration options.
+type Client struct {
+	CredentialsFile string            `river:"credentials_file,attr,optional"`
+	Credentials     rivertypes.Secret `river:"credentials,attr,optional"`
+	Endpoint        string            `river:"endpoint,attr,optional"`
+	DisableAuth     bool              `river:"disable_auth,attr,optional"`
+}
+
+// DefaultArguments sets the version and the poll frequency.
+var DefaultArguments = Arguments{
+	Version:       "latest",
+	PollFrequency: 10 * time.Minute,
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	if a.Project == "" {
+		return fmt.Errorf("project must not be empty")
+	}
+	if a.Secret == "" {
+		return fmt.Errorf("secret must not be empty")
+	}
+	if a.Version == "" {
+		return fmt.Errorf("version must not be empty")
+	}
+	if a.PollFrequency <= 0 {
+		return fmt.Errorf("poll_frequency must be greater than 0")
+	}
+
+	if a.Options.CredentialsFile != "" && a.Options.Credentials != "" {
+		return fmt.Errorf("at most one of credentials_file and credentials must be set")
+	}
+	if a.Options.DisableAuth && (a.Options.CredentialsFile != "" || a.Options.Credentials != "") {
+		return fmt.Errorf("credentials can't be set when disable_auth is true")
+	}
+	return nil
+}
+
+// Exports holds the values exported by the remote.gcp.secretmanager
+// component.
+type Exports struct {
+	// Value is the value of the secret.
+	Value rivertypes.Secret `river:"value,attr"`
+	// Data holds the keys of the secret if its value is a JSON object.
+	Data map[string]rivertypes.Secret `river:"data,attr"`
+	// Version is the number of the exported version of the secret.
+	Version string `river:"version,attr"`
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
@@ -15,6 +16,7 @@ import (
 	common_config "github.com/grafana/agent/internal/component/common/config"
 	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/agent/internal/flow/logging/level"
+	"github.com/grafana/agent/internal/integrity"
 	"github.com/grafana/agent/internal/useragent"
 	"github.com/grafana/river/rivertypes"
 	prom_config "github.com/prometheus/common/config"
@@ -46,6 +48,8 @@ type Arguments struct {
 	Body    string            `river:"body,attr,optional"`
 
 	Client common_config.HTTPClientConfig `river:"client,block,optional"`
+
+	Verify *integrity.Arguments `river:"verify,block,optional"`
 }
 
 // DefaultArguments holds default settings for Arguments.
@@ -93,6 +97,7 @@ type Component struct {
 	mut         sync.Mutex
 	args        Arguments
 	cli         *http.Client
+	verifier    *integrity.Verifier
 	lastPoll    time.Time
 	lastExports Exports // Used for determining whether exports should be updated
 
@@ -228,6 +233,16 @@ func (c *Component) pollError() error {
 		return fmt.Errorf("unexpected status code %s", resp.Status)
 	}
 
+	// Responses which fail verification aren't exported, so that the last
+	// verified content is kept.
+	err = c.verifier.Verify(c.args.URL, bb, func(location string) ([]byte, error) {
+		return c.fetchSignature(ctx, location)
+	})
+	if err != nil {
+		level.Error(c.log).Log("msg", "failed to verify response", "err", err)
+		return err
+	}
+
 	stringContent := strings.TrimSpace(string(bb))
 
 	newExports := Exports{
@@ -246,6 +261,39 @@ func (c *Component) pollError() error {
 	return nil
 }
 
+// fetchSignature retrieves the detached signature of the response. location
+// is resolved relative to the URL of the component. fetchSignature must only
+// be called with c.mut held.
+func (c *Component) fetchSignature(ctx context.Context, location string) ([]byte, error) {
+	base, err := url.Parse(c.args.URL)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base.ResolveReference(ref).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range c.args.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := c.cli.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
 // Update updates the remote.http component. After the update completes, a
 // poll is forced.
 func (c *Component) Update(args component.Arguments) (err error) {
@@ -285,6 +333,12 @@ func (c *Component) Update(args component.Arguments) (err error) {
 	}
 	c.cli = cli
 
+	verifier, err := integrity.NewVerifier(newArgs.Verify)
+	if err != nil {
+		return err
+	}
+	c.verifier = verifier
+
 	// Send an updated event if one wasn't already read.
 	select {
 	case c.updated <- struct{}{}:
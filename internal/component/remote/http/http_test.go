@@ -2,14 +2,21 @@ package http_test
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/grafana/agent/internal/component"
 	http_component "github.com/grafana/agent/internal/component/remote/http"
 	"github.com/grafana/agent/internal/flow/componenttest"
 	"github.com/grafana/agent/internal/flow/logging/level"
@@ -177,3 +184,60 @@ func (lh *lazyHandler) SetHandler(h http.HandlerFunc) {
 
 	lh.inner = h
 }
+
+func TestVerify(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	pubBytes, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+
+	var (
+		mut       sync.Mutex
+		content   = "declare \"add\" {}\n"
+		signature = ed25519.Sign(priv, []byte(content))
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/module.river", func(w http.ResponseWriter, r *http.Request) {
+		mut.Lock()
+		defer mut.Unlock()
+		fmt.Fprint(w, content)
+	})
+	mux.HandleFunc("/module.river.sig", func(w http.ResponseWriter, r *http.Request) {
+		mut.Lock()
+		defer mut.Unlock()
+		fmt.Fprint(w, base64.StdEncoding.EncodeToString(signature))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	var exports atomic.Value
+	opts := component.Options{
+		ID:            "remote.http.test",
+		Logger:        util.TestFlowLogger(t),
+		OnStateChange: func(e component.Exports) { exports.Store(e) },
+	}
+
+	cfg := fmt.Sprintf(`
+		url = "%s/module.river"
+
+		verify {
+			public_key = %q
+		}
+	`, srv.URL, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes}))
+	var args http_component.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	c, err := http_component.New(opts, args)
+	require.NoError(t, err)
+	require.Equal(t, `declare "add" {}`, exports.Load().(http_component.Exports).Content.Value)
+
+	// Content which doesn't match its signature isn't exported.
+	mut.Lock()
+	content = "declare \"tampered\" {}\n"
+	mut.Unlock()
+
+	err = c.Update(args)
+	require.ErrorContains(t, err, "invalid signature")
+	require.Equal(t, component.HealthTypeUnhealthy, c.CurrentHealth().Health)
+	require.Equal(t, `declare "add" {}`, exports.Load().(http_component.Exports).Content.Value)
+}
@@ -0,0 +1,158 @@
+// Package secret implements the polling of secrets shared by the remote.*
+// components which read a secret from a secret manager.
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/flow/logging/level"
+	"github.com/grafana/river/rivertypes"
+)
+
+// pollTimeout is the maximum time a single read of the secret may take.
+const pollTimeout = time.Minute
+
+// Version is a version of a secret.
+type Version struct {
+	// ID identifies the version. An empty ID is considered different from
+	// all the others.
+	ID    string
+	Value string
+}
+
+// ReadFunc reads the version of the secret selected by the arguments of a
+// component.
+type ReadFunc func(ctx context.Context) (Version, error)
+
+// Poller periodically reads a secret, and calls its export function when the
+// version read changes.
+type Poller struct {
+	logger log.Logger
+	export func(Version)
+
+	mut       sync.Mutex
+	read      ReadFunc
+	frequency time.Duration
+	version   string
+	exported  bool
+	health    component.Health
+
+	updated chan struct{}
+}
+
+// NewPoller returns a Poller which reads the secret with read every
+// frequency, and calls export with the versions read.
+func NewPoller(logger log.Logger, read ReadFunc, frequency time.Duration, export func(Version)) *Poller {
+	return &Poller{
+		logger:    logger,
+		export:    export,
+		read:      read,
+		frequency: frequency,
+		updated:   make(chan struct{}, 1),
+	}
+}
+
+// Run polls the secret until ctx is canceled.
+func (p *Poller) Run(ctx context.Context) error {
+	p.mut.Lock()
+	ticker := time.NewTicker(p.frequency)
+	p.mut.Unlock()
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-p.updated:
+			p.mut.Lock()
+			ticker.Reset(p.frequency)
+			p.mut.Unlock()
+		case <-ticker.C:
+		}
+
+		if err := p.Poll(ctx); err != nil {
+			level.Error(p.logger).Log("msg", "failed to read secret", "err", err)
+		}
+	}
+}
+
+// Update replaces how and how often the secret is read, and reads it right
+// away if Run is active. The version read next is always exported.
+func (p *Poller) Update(read ReadFunc, frequency time.Duration) {
+	p.mut.Lock()
+	p.read = read
+	p.frequency = frequency
+	p.exported = false
+	p.mut.Unlock()
+
+	select {
+	case p.updated <- struct{}{}:
+	default:
+	}
+}
+
+// Poll reads the secret once, and exports it if its version changed.
+func (p *Poller) Poll(ctx context.Context) error {
+	p.mut.Lock()
+	read := p.read
+	p.mut.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, pollTimeout)
+	defer cancel()
+	v, err := read(ctx)
+
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	if err != nil {
+		p.setHealth(component.HealthTypeUnhealthy, err.Error())
+		return err
+	}
+
+	if !p.exported || v.ID == "" || v.ID != p.version {
+		p.export(v)
+		p.version, p.exported = v.ID, true
+	}
+	p.setHealth(component.HealthTypeHealthy, fmt.Sprintf("read version %q of the secret", v.ID))
+	return nil
+}
+
+// CurrentHealth returns the health of the last read of the secret.
+func (p *Poller) CurrentHealth() component.Health {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	return p.health
+}
+
+func (p *Poller) setHealth(h component.HealthType, msg string) {
+	p.health = component.Health{
+		Health:     h,
+		Message:    msg,
+		UpdateTime: time.Now(),
+	}
+}
+
+// Data returns the keys of value if it is a JSON object. Values which aren't
+// strings are exported in their JSON encoding.
+func Data(value string) map[string]rivertypes.Secret {
+	data := make(map[string]rivertypes.Secret)
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(value), &obj); err != nil {
+		return data
+	}
+	for k, raw := range obj {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			data[k] = rivertypes.Secret(s)
+			continue
+		}
+		data[k] = rivertypes.Secret(raw)
+	}
+	return data
+}
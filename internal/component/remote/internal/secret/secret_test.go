@@ -0,0 +1,131 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/util"
+	"github.com/grafana/river/rivertypes"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSecret is a secret whose versions are set by tests.
+type fakeSecret struct {
+	mut     sync.Mutex
+	reads   int
+	version Version
+	err     error
+}
+
+func (f *fakeSecret) Read(context.Context) (Version, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	f.reads++
+	return f.version, f.err
+}
+
+func (f *fakeSecret) Set(v Version, err error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	f.version, f.err = v, err
+}
+
+func (f *fakeSecret) Reads() int {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	return f.reads
+}
+
+// exportRecorder records the versions exported by a Poller.
+type exportRecorder struct {
+	mut      sync.Mutex
+	exported []Version
+}
+
+func (r *exportRecorder) Export(v Version) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.exported = append(r.exported, v)
+}
+
+func (r *exportRecorder) Exported() []Version {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return append([]Version(nil), r.exported...)
+}
+
+func TestPoller(t *testing.T) {
+	fake := &fakeSecret{}
+	fake.Set(Version{ID: "1", Value: "value"}, nil)
+	var rec exportRecorder
+
+	p := NewPoller(util.TestLogger(t), fake.Read, 10*time.Millisecond, rec.Export)
+	require.NoError(t, p.Poll(context.Background()))
+	require.Equal(t, component.HealthTypeHealthy, p.CurrentHealth().Health)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.Run(ctx)
+
+	// The secret is polled but only exported once while its version is the same.
+	require.Eventually(t, func() bool { return fake.Reads() > 3 }, time.Minute, time.Millisecond)
+	require.Equal(t, []Version{{ID: "1", Value: "value"}}, rec.Exported())
+
+	fake.Set(Version{ID: "2", Value: "new value"}, nil)
+	require.Eventually(t, func() bool { return len(rec.Exported()) == 2 }, time.Minute, time.Millisecond)
+	require.Equal(t, Version{ID: "2", Value: "new value"}, rec.Exported()[1])
+
+	// Failing reads keep the last exported version.
+	fake.Set(Version{}, errors.New("access denied"))
+	require.Eventually(t, func() bool {
+		return p.CurrentHealth().Health == component.HealthTypeUnhealthy
+	}, time.Minute, time.Millisecond)
+	require.Equal(t, "access denied", p.CurrentHealth().Message)
+	require.Len(t, rec.Exported(), 2)
+}
+
+func TestPoller_Update(t *testing.T) {
+	fake := &fakeSecret{}
+	fake.Set(Version{ID: "1", Value: "value"}, nil)
+	var rec exportRecorder
+
+	p := NewPoller(util.TestLogger(t), fake.Read, time.Hour, rec.Export)
+	require.NoError(t, p.Poll(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.Run(ctx)
+
+	// Updating the poller reads the secret right away, and exports it even if
+	// its version didn't change.
+	p.Update(fake.Read, time.Hour)
+	require.Eventually(t, func() bool { return len(rec.Exported()) == 2 }, time.Minute, time.Millisecond)
+	require.Equal(t, 2, fake.Reads())
+}
+
+func TestPoller_EmptyVersionID(t *testing.T) {
+	fake := &fakeSecret{}
+	fake.Set(Version{Value: "value"}, nil)
+	var rec exportRecorder
+
+	// Versions without an ID can't be compared, so they're always exported.
+	p := NewPoller(util.TestLogger(t), fake.Read, time.Hour, rec.Export)
+	require.NoError(t, p.Poll(context.Background()))
+	require.NoError(t, p.Poll(context.Background()))
+	require.Len(t, rec.Exported(), 2)
+}
+
+func TestData(t *testing.T) {
+	require.Equal(t, map[string]rivertypes.Secret{
+		"username": rivertypes.Secret("agent"),
+		"port":     rivertypes.Secret("5432"),
+		"tags":     rivertypes.Secret(`["a","b"]`),
+	}, Data(`{"username":"agent","port":5432,"tags":["a","b"]}`))
+
+	require.Empty(t, Data("plain text"))
+	require.Empty(t, Data(`["not", "an", "object"]`))
+}
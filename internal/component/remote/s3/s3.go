@@ -2,17 +2,14 @@ package s3
 
 import (
 	"context"
-	"crypto/tls"
-	"fmt"
-	"net/http"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	aws_config "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/grafana/agent/internal/component"
+	common_aws "github.com/grafana/agent/internal/component/common/aws"
 	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/river/rivertypes"
 	"github.com/prometheus/client_golang/prometheus"
@@ -132,58 +129,7 @@ func (s *Component) CurrentHealth() component.Health {
 }
 
 func generateS3Config(args Arguments) (*aws.Config, error) {
-	configOptions := make([]func(*aws_config.LoadOptions) error, 0)
-	// Override the endpoint.
-	if args.Options.Endpoint != "" {
-		endFunc := aws.EndpointResolverWithOptionsFunc(func(service, region string, _ ...interface{}) (aws.Endpoint, error) {
-			// The S3 compatible system used for testing with does not require signing region, so it's fine to be blank
-			// but when using a proxy to real S3 it needs to be injected.
-			return aws.Endpoint{URL: args.Options.Endpoint, SigningRegion: args.Options.SigningRegion}, nil
-		})
-		endResolver := aws_config.WithEndpointResolverWithOptions(endFunc)
-		configOptions = append(configOptions, endResolver)
-	}
-
-	// This incredibly nested option turns off SSL.
-	if args.Options.DisableSSL {
-		httpOverride := aws_config.WithHTTPClient(
-			&http.Client{
-				Transport: &http.Transport{
-					TLSClientConfig: &tls.Config{
-						InsecureSkipVerify: args.Options.DisableSSL,
-					},
-				},
-			},
-		)
-		configOptions = append(configOptions, httpOverride)
-	}
-
-	// Check to see if we need to override the credentials, else it will use the default ones.
-	// https://docs.aws.amazon.com/cli/latest/userguide/cli-configure-envvars.html
-	if args.Options.AccessKey != "" {
-		if args.Options.Secret == "" {
-			return nil, fmt.Errorf("if accesskey or secret are specified then the other must also be specified")
-		}
-		credFunc := aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
-			return aws.Credentials{
-				AccessKeyID:     args.Options.AccessKey,
-				SecretAccessKey: string(args.Options.Secret),
-			}, nil
-		})
-		credProvider := aws_config.WithCredentialsProvider(credFunc)
-		configOptions = append(configOptions, credProvider)
-	}
-
-	cfg, err := aws_config.LoadDefaultConfig(context.TODO(), configOptions...)
-	if err != nil {
-		return nil, err
-	}
-	// Set region.
-	if args.Options.Region != "" {
-		cfg.Region = args.Options.Region
-	}
-
-	return &cfg, nil
+	return common_aws.GenerateConfig(args.Options.Client)
 }
 
 // handleContentUpdate reads from the update and error channels setting as appropriate
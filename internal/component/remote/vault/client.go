@@ -14,8 +14,6 @@ type secretStore interface {
 	Read(ctx context.Context, args *Arguments) (*vault.Secret, error)
 }
 
-// TODO(rfratto): support logical stores.
-
 type kvStore struct{ c *vault.Client }
 
 func (ks *kvStore) Read(ctx context.Context, args *Arguments) (*vault.Secret, error) {
@@ -36,3 +34,31 @@ func (ks *kvStore) Read(ctx context.Context, args *Arguments) (*vault.Secret, er
 	kvSecret.Raw.Data = kvSecret.Data
 	return kvSecret.Raw, nil
 }
+
+// logicalStore reads secrets from any secrets engine through the logical
+// API, like the database, PKI or AWS engines.
+type logicalStore struct{ c *vault.Client }
+
+func (ls *logicalStore) Read(ctx context.Context, args *Arguments) (*vault.Secret, error) {
+	var (
+		secret *vault.Secret
+		err    error
+	)
+	switch args.Method {
+	case MethodWrite:
+		data := make(map[string]interface{}, len(args.WriteData))
+		for k, v := range args.WriteData {
+			data[k] = v
+		}
+		secret, err = ls.c.Logical().WriteWithContext(ctx, args.Path, data)
+	default:
+		secret, err = ls.c.Logical().ReadWithContext(ctx, args.Path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("no secret found at %q", args.Path)
+	}
+	return secret, nil
+}
@@ -0,0 +1,269 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/internal/flow/componenttest"
+	"github.com/grafana/agent/internal/util"
+	"github.com/grafana/river"
+	"github.com/grafana/river/rivertypes"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVault is a Vault server serving dynamic database credentials and PKI
+// certificates.
+type fakeVault struct {
+	mut       sync.Mutex
+	reads     int
+	writeData map[string]interface{}
+	revoked   []string
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.mut.Lock()
+	defer v.mut.Unlock()
+
+	var resp map[string]interface{}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/database/creds/readonly":
+		v.reads++
+		resp = map[string]interface{}{
+			"lease_id":       fmt.Sprintf("database/creds/readonly/%d", v.reads),
+			"lease_duration": 3600,
+			"data": map[string]interface{}{
+				"username": fmt.Sprintf("user-%d", v.reads),
+				"password": fmt.Sprintf("password-%d", v.reads),
+			},
+		}
+	case r.Method == http.MethodPut && r.URL.Path == "/v1/pki/issue/agent":
+		if err := json.NewDecoder(r.Body).Decode(&v.writeData); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp = map[string]interface{}{
+			"data": map[string]interface{}{
+				"certificate": "CERT",
+				"ca_chain":    []string{"CA1", "CA2"},
+				"expiration":  1700000000,
+				"revoked":     false,
+				"extra":       map[string]interface{}{"nested": true},
+			},
+		}
+	case r.Method == http.MethodPut && r.URL.Path == "/v1/sys/leases/revoke":
+		var req struct {
+			LeaseID string `json:"lease_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		v.revoked = append(v.revoked, req.LeaseID)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (v *fakeVault) Revoked() []string {
+	v.mut.Lock()
+	defer v.mut.Unlock()
+	return append([]string(nil), v.revoked...)
+}
+
+func (v *fakeVault) WriteData() map[string]interface{} {
+	v.mut.Lock()
+	defer v.mut.Unlock()
+	return v.writeData
+}
+
+func Test_LogicalRead(t *testing.T) {
+	fake := &fakeVault{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	cfg := fmt.Sprintf(`
+		server = "%s"
+		path   = "database/creds/readonly"
+		engine = "logical"
+
+		reread_frequency    = "100ms"
+		revoke_grace_period = "2s"
+
+		auth.token {
+			token = "secretkey"
+		}
+	`, srv.URL)
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	ctx, cancel := context.WithCancel(componenttest.TestContext(t))
+	defer cancel()
+
+	ctrl, err := componenttest.NewControllerFromID(util.TestLogger(t), "remote.vault")
+	require.NoError(t, err)
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- ctrl.Run(ctx, args)
+	}()
+	require.NoError(t, ctrl.WaitRunning(time.Minute))
+
+	// Get the initial credentials.
+	require.NoError(t, ctrl.WaitExports(time.Minute))
+	require.Equal(t, Exports{
+		Data: map[string]rivertypes.Secret{
+			"username": rivertypes.Secret("user-1"),
+			"password": rivertypes.Secret("password-1"),
+		},
+	}, ctrl.Exports().(Exports))
+
+	// Get rotated credentials, whose replaced lease is revoked once the grace
+	// period is over.
+	require.NoError(t, ctrl.WaitExports(time.Minute))
+	require.Equal(t, Exports{
+		Data: map[string]rivertypes.Secret{
+			"username": rivertypes.Secret("user-2"),
+			"password": rivertypes.Secret("password-2"),
+		},
+	}, ctrl.Exports().(Exports))
+	require.Empty(t, fake.Revoked())
+	require.Eventually(t, func() bool {
+		revoked := fake.Revoked()
+		return len(revoked) > 0 && revoked[0] == "database/creds/readonly/1"
+	}, time.Minute, 10*time.Millisecond)
+
+	// The latest lease is revoked when the component stops.
+	cancel()
+	require.NoError(t, <-runErr)
+	revoked := fake.Revoked()
+	fake.mut.Lock()
+	latest := fmt.Sprintf("database/creds/readonly/%d", fake.reads)
+	fake.mut.Unlock()
+	require.Equal(t, latest, revoked[len(revoked)-1])
+}
+
+func Test_LogicalWrite(t *testing.T) {
+	fake := &fakeVault{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	cfg := fmt.Sprintf(`
+		server     = "%s"
+		path       = "pki/issue/agent"
+		engine     = "logical"
+		method     = "write"
+		write_data = {
+			common_name = "agent.example.com",
+			ttl         = "24h",
+		}
+
+		auth.token {
+			token = "secretkey"
+		}
+	`, srv.URL)
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	ctx := componenttest.TestContext(t)
+	ctrl, err := componenttest.NewControllerFromID(util.TestLogger(t), "remote.vault")
+	require.NoError(t, err)
+
+	go func() {
+		require.NoError(t, ctrl.Run(ctx, args))
+	}()
+	require.NoError(t, ctrl.WaitRunning(time.Minute))
+	require.NoError(t, ctrl.WaitExports(time.Minute))
+
+	require.Equal(t, map[string]interface{}{
+		"common_name": "agent.example.com",
+		"ttl":         "24h",
+	}, fake.WriteData())
+	require.Equal(t, Exports{
+		Data: map[string]rivertypes.Secret{
+			"certificate": rivertypes.Secret("CERT"),
+			"ca_chain":    rivertypes.Secret("CA1\nCA2"),
+			"expiration":  rivertypes.Secret("1700000000"),
+			"revoked":     rivertypes.Secret("false"),
+		},
+	}, ctrl.Exports().(Exports))
+}
+
+func TestArguments_Validate(t *testing.T) {
+	tests := map[string]struct {
+		cfg string
+		err string
+	}{
+		"kv": {
+			cfg: `path = "secret/test"`,
+		},
+		"logical write": {
+			cfg: `
+				path       = "aws/sts/agent"
+				engine     = "logical"
+				method     = "write"
+				write_data = { ttl = "15m" }
+			`,
+		},
+		"unknown engine": {
+			cfg: `
+				path   = "secret/test"
+				engine = "kv1"
+			`,
+			err: `engine must be one of "kv" or "logical"; got "kv1"`,
+		},
+		"unknown method": {
+			cfg: `
+				path   = "secret/test"
+				engine = "logical"
+				method = "list"
+			`,
+			err: `method must be one of "read" or "write"; got "list"`,
+		},
+		"write with kv": {
+			cfg: `
+				path   = "secret/test"
+				method = "write"
+			`,
+			err: `method "write" requires the "logical" engine`,
+		},
+		"write_data with read": {
+			cfg: `
+				path       = "database/creds/readonly"
+				engine     = "logical"
+				write_data = { ttl = "15m" }
+			`,
+			err: `write_data can only be set when method is "write"`,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := fmt.Sprintf(`
+				server = "http://localhost:8200"
+				%s
+				auth.token {
+					token = "secretkey"
+				}
+			`, tt.cfg)
+
+			var args Arguments
+			err := river.Unmarshal([]byte(cfg), &args)
+			if tt.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.err)
+		})
+	}
+}
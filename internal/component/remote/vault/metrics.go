@@ -8,6 +8,8 @@ type metrics struct {
 
 	authLeaseRenewalTotal   prometheus.Counter
 	secretLeaseRenewalTotal prometheus.Counter
+
+	secretLeaseRevocationTotal prometheus.Counter
 }
 
 func newMetrics(r prometheus.Registerer) *metrics {
@@ -31,6 +33,11 @@ func newMetrics(r prometheus.Registerer) *metrics {
 		Help: "Total number of times this component renewed its secret lease",
 	})
 
+	m.secretLeaseRevocationTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "remote_vault_secret_lease_revocation_total",
+		Help: "Total number of times this component revoked a secret lease",
+	})
+
 	if r != nil {
 		r.MustRegister(
 			m.authTotal,
@@ -38,6 +45,8 @@ func newMetrics(r prometheus.Registerer) *metrics {
 
 			m.authLeaseRenewalTotal,
 			m.secretLeaseRenewalTotal,
+
+			m.secretLeaseRevocationTotal,
 		)
 	}
 	return &m
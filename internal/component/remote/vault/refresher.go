@@ -285,6 +285,10 @@ func getSecretInfo(secret *vault.Secret, updateTime time.Time) secretInfo {
 func secretExpireTime(secret *vault.Secret) time.Time {
 	ttl, err := secret.TokenTTL()
 	if err != nil || ttl == 0 {
+		// Secrets of dynamic secrets engines have a lease rather than a TTL.
+		if secret.LeaseDuration > 0 {
+			return time.Now().UTC().Add(time.Duration(secret.LeaseDuration) * time.Second)
+		}
 		return time.Time{}
 	}
 
@@ -2,7 +2,11 @@ package vault
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -36,6 +40,14 @@ type Arguments struct {
 
 	Path string `river:"path,attr"`
 
+	Engine       string            `river:"engine,attr,optional"`
+	Method       string            `river:"method,attr,optional"`
+	WriteData    map[string]string `river:"write_data,attr,optional"`
+	RevokeLeases bool              `river:"revoke_leases,attr,optional"`
+
+	// How long the lease of a replaced secret is kept before it's revoked.
+	RevokeGracePeriod time.Duration `river:"revoke_grace_period,attr,optional"`
+
 	RereadFrequency time.Duration `river:"reread_frequency,attr,optional"`
 
 	ClientOptions ClientOptions `river:"client_options,block,optional"`
@@ -48,8 +60,24 @@ type Arguments struct {
 	Auth []AuthArguments `river:"auth,enum,optional"`
 }
 
+// Secrets engines supported by remote.vault.
+const (
+	EngineKV      = "kv"
+	EngineLogical = "logical"
+)
+
+// Methods used to retrieve a secret from the logical secrets engine.
+const (
+	MethodRead  = "read"
+	MethodWrite = "write"
+)
+
 // DefaultArguments holds default settings for Arguments.
 var DefaultArguments = Arguments{
+	Engine:            EngineKV,
+	Method:            MethodRead,
+	RevokeLeases:      true,
+	RevokeGracePeriod: 5 * time.Minute,
 	ClientOptions: ClientOptions{
 		MinRetryWait: 1000 * time.Millisecond,
 		MaxRetryWait: 1500 * time.Millisecond,
@@ -95,6 +123,29 @@ func (a *Arguments) Validate() error {
 		return fmt.Errorf("client_options.timeout must be greater than 0")
 	}
 
+	if a.RevokeGracePeriod < 0 {
+		return fmt.Errorf("revoke_grace_period must not be negative")
+	}
+
+	switch a.Engine {
+	case EngineKV, EngineLogical:
+	default:
+		return fmt.Errorf("engine must be one of %q or %q; got %q", EngineKV, EngineLogical, a.Engine)
+	}
+
+	switch a.Method {
+	case MethodRead:
+		if len(a.WriteData) > 0 {
+			return fmt.Errorf("write_data can only be set when method is %q", MethodWrite)
+		}
+	case MethodWrite:
+		if a.Engine != EngineLogical {
+			return fmt.Errorf("method %q requires the %q engine", MethodWrite, EngineLogical)
+		}
+	default:
+		return fmt.Errorf("method must be one of %q or %q; got %q", MethodRead, MethodWrite, a.Method)
+	}
+
 	return nil
 }
 
@@ -106,7 +157,9 @@ func (a *Arguments) authMethod() authMethod {
 }
 
 func (a *Arguments) secretStore(cli *vault.Client) secretStore {
-	// TODO(rfratto): support different stores (like a logical store).
+	if a.Engine == EngineLogical {
+		return &logicalStore{c: cli}
+	}
 	return &kvStore{c: cli}
 }
 
@@ -121,8 +174,10 @@ type ClientOptions struct {
 // Exports is the values exported by remote.vault.
 type Exports struct {
 	// Data holds key-value pairs returned from Vault after retrieving the key.
-	// Any keys-value pairs returned from Vault which are not []byte or strings
-	// cannot be represented as secrets and are therefore ignored.
+	// Numbers and booleans are converted to strings, and lists of strings (like
+	// the CA chain of PKI certificates) are joined with newlines. Any other
+	// key-value pairs returned from Vault cannot be represented as secrets and
+	// are therefore ignored.
 	//
 	// However, it seems that most secrets engines don't actually return
 	// arbitrary data, so this limitation shouldn't cause any issues in practice.
@@ -140,8 +195,30 @@ type Component struct {
 
 	secretManager *tokenManager
 	authManager   *tokenManager
+
+	secretMut      sync.Mutex
+	exports        *Exports        // Latest exports.
+	leaseID        string          // Lease of the latest secret, if any.
+	leaseClient    *vault.Client   // Client which read the latest secret.
+	replacedLeases []replacedLease // Leases of replaced secrets waiting to be revoked.
 }
 
+// replacedLease is the lease of a replaced secret, revoked once the grace
+// period of the replacement is over.
+type replacedLease struct {
+	id       string
+	client   *vault.Client
+	revokeAt time.Time
+}
+
+// leaseRevokeTimeout is the maximum time spent revoking the lease of the
+// secret when the component stops.
+const leaseRevokeTimeout = 10 * time.Second
+
+// replacedLeaseCheckInterval is how often the leases of replaced secrets are
+// checked for revocation.
+const replacedLeaseCheckInterval = time.Second
+
 var (
 	_ component.Component       = (*Component)(nil)
 	_ component.HealthComponent = (*Component)(nil)
@@ -186,7 +263,32 @@ func (c *Component) Run(ctx context.Context) error {
 		cancel()
 	})
 
-	return rg.Run()
+	rg.Add(func() error {
+		c.runRevocations(ctx)
+		return nil
+	}, func(_ error) {
+		cancel()
+	})
+
+	err := rg.Run()
+	c.revokeLease()
+	return err
+}
+
+// runRevocations revokes the leases of replaced secrets once their grace
+// period is over, until ctx is canceled.
+func (c *Component) runRevocations(ctx context.Context) {
+	ticker := time.NewTicker(replacedLeaseCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			c.revokeReplacedLeases(ctx, now)
+		}
+	}
 }
 
 // Update updates the remote.vault component. It will try to immediately read
@@ -268,37 +370,148 @@ func (c *Component) getSecret(ctx context.Context, cli *vault.Client) (*vault.Se
 
 	// Export the secret so other components can use it.
 	c.exportSecret(secret)
+	c.replaceLease(cli, secret)
 
 	return secret, nil
 }
 
 // exportSecret converts the secret into exports and exports it to the
-// controller.
+// controller if they changed, so that dependent components are only
+// re-evaluated when the secret changes.
 func (c *Component) exportSecret(secret *vault.Secret) {
 	newExports := Exports{
 		Data: make(map[string]rivertypes.Secret),
 	}
 
 	for key, value := range secret.Data {
-		switch value := value.(type) {
-		case string:
-			newExports.Data[key] = rivertypes.Secret(value)
-		case []byte:
-			newExports.Data[key] = rivertypes.Secret(value)
-
-		default:
-			// Non-string secrets are ignored.
+		s, ok := secretValue(value)
+		if !ok {
+			// Other secrets are ignored.
 			level.Warn(c.log).Log(
 				"msg", "found field in secret which cannot be converted into a string",
 				"key", key,
 				"type", fmt.Sprintf("%T", value),
 			)
+			continue
 		}
+		newExports.Data[key] = rivertypes.Secret(s)
 	}
 
+	c.secretMut.Lock()
+	defer c.secretMut.Unlock()
+
+	if c.exports != nil && reflect.DeepEqual(*c.exports, newExports) {
+		return
+	}
+	c.exports = &newExports
 	c.opts.OnStateChange(newExports)
 }
 
+// secretValue converts a value of the data of a secret to a string.
+func secretValue(value interface{}) (string, bool) {
+	switch value := value.(type) {
+	case string:
+		return value, true
+	case []byte:
+		return string(value), true
+	case json.Number:
+		return value.String(), true
+	case bool:
+		return strconv.FormatBool(value), true
+	case []interface{}:
+		elems := make([]string, 0, len(value))
+		for _, e := range value {
+			s, ok := e.(string)
+			if !ok {
+				return "", false
+			}
+			elems = append(elems, s)
+		}
+		return strings.Join(elems, "\n"), true
+	default:
+		return "", false
+	}
+}
+
+// replaceLease records the lease of the latest secret. If revoke_leases is
+// set, the lease of the secret it replaces is revoked after
+// revoke_grace_period, so that dependent components and the connections they
+// opened with the old secret have time to move to the new one. c.mut must be
+// held.
+func (c *Component) replaceLease(cli *vault.Client, secret *vault.Secret) {
+	c.secretMut.Lock()
+	defer c.secretMut.Unlock()
+
+	oldLeaseID, oldClient := c.leaseID, c.leaseClient
+	c.leaseID, c.leaseClient = secret.LeaseID, cli
+
+	if oldLeaseID == "" || oldLeaseID == secret.LeaseID || !c.args.RevokeLeases {
+		return
+	}
+	// Leases expiring before the end of the grace period are revoked by
+	// Vault, so revoking them afterwards is a no-op.
+	c.replacedLeases = append(c.replacedLeases, replacedLease{
+		id:       oldLeaseID,
+		client:   oldClient,
+		revokeAt: time.Now().Add(c.args.RevokeGracePeriod),
+	})
+}
+
+// revokeReplacedLeases revokes the leases of replaced secrets whose grace
+// period is over at now.
+func (c *Component) revokeReplacedLeases(ctx context.Context, now time.Time) {
+	c.secretMut.Lock()
+	var due []replacedLease
+	pending := c.replacedLeases[:0]
+	for _, l := range c.replacedLeases {
+		if now.Before(l.revokeAt) {
+			pending = append(pending, l)
+		} else {
+			due = append(due, l)
+		}
+	}
+	c.replacedLeases = pending
+	c.secretMut.Unlock()
+
+	for _, l := range due {
+		c.revoke(ctx, l.client, l.id)
+	}
+}
+
+// revokeLease revokes the lease of the latest secret, and the leases of
+// replaced secrets still in their grace period, if revoke_leases is set.
+func (c *Component) revokeLease() {
+	c.mut.RLock()
+	revoke := c.args.RevokeLeases
+	c.mut.RUnlock()
+
+	c.secretMut.Lock()
+	leases := c.replacedLeases
+	if c.leaseID != "" {
+		leases = append(leases, replacedLease{id: c.leaseID, client: c.leaseClient})
+	}
+	c.leaseID, c.leaseClient, c.replacedLeases = "", nil, nil
+	c.secretMut.Unlock()
+
+	if !revoke {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), leaseRevokeTimeout)
+	defer cancel()
+	for _, l := range leases {
+		c.revoke(ctx, l.client, l.id)
+	}
+}
+
+func (c *Component) revoke(ctx context.Context, cli *vault.Client, leaseID string) {
+	if err := cli.Sys().RevokeWithContext(ctx, leaseID); err != nil {
+		level.Warn(c.log).Log("msg", "failed to revoke lease of secret", "err", err)
+		return
+	}
+	c.metrics.secretLeaseRevocationTotal.Inc()
+}
+
 // CurrentHealth returns the current health of the remote.vault component. It
 // will be healthy as long as the latest read or renewal was successful.
 func (c *Component) CurrentHealth() component.Health {
@@ -6,16 +6,20 @@ import (
 	"fmt"
 
 	"github.com/grafana/agent/internal/converter/diag"
+	"github.com/grafana/agent/internal/converter/internal/fluentbitconvert"
 	"github.com/grafana/agent/internal/converter/internal/otelcolconvert"
 	"github.com/grafana/agent/internal/converter/internal/prometheusconvert"
 	"github.com/grafana/agent/internal/converter/internal/promtailconvert"
 	"github.com/grafana/agent/internal/converter/internal/staticconvert"
+	"github.com/grafana/agent/internal/converter/internal/vectorconvert"
 )
 
 // Input represents the type of config file being fed into the converter.
 type Input string
 
 const (
+	// InputFluentBit indicates that the input file is a Fluent Bit classic or YAML file.
+	InputFluentBit Input = "fluentbit"
 	// InputOtelCol indicates that the input file is an OpenTelemetry Collector YAML file.
 	InputOtelCol Input = "otelcol"
 	// InputPrometheus indicates that the input file is a prometheus YAML file.
@@ -24,13 +28,32 @@ const (
 	InputPromtail Input = "promtail"
 	// InputStatic indicates that the input file is a grafana agent static YAML file.
 	InputStatic Input = "static"
+	// InputVector indicates that the input file is a Vector TOML, YAML or JSON file.
+	InputVector Input = "vector"
 )
 
+// Target represents the type of config file generated by the converter.
+type Target string
+
+const (
+	// TargetFlow indicates that the output file is a Grafana Agent Flow River file.
+	TargetFlow Target = "flow"
+	// TargetOtelCol indicates that the output file is an OpenTelemetry Collector YAML file.
+	TargetOtelCol Target = "otelcol"
+)
+
+var SupportedTargets = []string{
+	string(TargetFlow),
+	string(TargetOtelCol),
+}
+
 var SupportedFormats = []string{
+	string(InputFluentBit),
 	string(InputOtelCol),
 	string(InputPrometheus),
 	string(InputPromtail),
 	string(InputStatic),
+	string(InputVector),
 }
 
 // Convert generates a Grafana Agent Flow config given an input configuration
@@ -51,6 +74,8 @@ var SupportedFormats = []string{
 // error is returned alongside the resulting config.
 func Convert(in []byte, kind Input, extraArgs []string) ([]byte, diag.Diagnostics) {
 	switch kind {
+	case InputFluentBit:
+		return fluentbitconvert.Convert(in, extraArgs)
 	case InputOtelCol:
 		return otelcolconvert.Convert(in, extraArgs)
 	case InputPrometheus:
@@ -59,9 +84,28 @@ func Convert(in []byte, kind Input, extraArgs []string) ([]byte, diag.Diagnostic
 		return promtailconvert.Convert(in, extraArgs)
 	case InputStatic:
 		return staticconvert.Convert(in, extraArgs)
+	case InputVector:
+		return vectorconvert.Convert(in, extraArgs)
 	}
 
 	var diags diag.Diagnostics
 	diags.Add(diag.SeverityLevelCritical, fmt.Sprintf("unrecognized kind %q given to the config converter", kind))
 	return nil, diags
 }
+
+// Export generates a config file of the target format given a Grafana Agent
+// Flow config file. Converting to Grafana Agent Flow itself is done by
+// Convert.
+//
+// Only the components which have an equivalent in the target format are
+// exported, and the other components are reported as diagnostics.
+func Export(in []byte, target Target) ([]byte, diag.Diagnostics) {
+	switch target {
+	case TargetOtelCol:
+		return otelcolconvert.Export(in)
+	}
+
+	var diags diag.Diagnostics
+	diags.Add(diag.SeverityLevelCritical, fmt.Sprintf("unrecognized target %q given to the config exporter", target))
+	return nil, diags
+}
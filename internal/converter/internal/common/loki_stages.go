@@ -0,0 +1,166 @@
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/grafana/agent/internal/component/loki/process/stages"
+)
+
+// ExtractAll is the key of the expressions of json stages and of the mapping
+// of logfmt stages which extract every field of their source. Other log
+// shippers extract every field when parsing JSON or logfmt, while
+// loki.process needs the names of the fields to extract, which
+// ResolveExtractAll fills in.
+const ExtractAll = "*"
+
+// keepField is the extracted field which KeepMatching uses to mark the log
+// entries to drop.
+const keepField = "__converter_drop"
+
+var (
+	templateFieldRegexp = regexp.MustCompile(`index \. ("(?:[^"\\]|\\.)*")`)
+	jmespathPathRegexp  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+)
+
+// TemplateField returns the template expression reading the extracted field
+// name, which is empty if the field isn't set.
+func TemplateField(name string) string {
+	return fmt.Sprintf("(or (index . %s) \"\")", strconv.Quote(name))
+}
+
+// KeepMatching returns the stages which drop the log entries whose extracted
+// field source, or log line if source is empty, doesn't match expression.
+func KeepMatching(source string, expression string) []stages.StageConfig {
+	value := ".Entry"
+	if source != "" {
+		value = TemplateField(source)
+	}
+
+	return []stages.StageConfig{
+		{TemplateConfig: &stages.TemplateConfig{
+			Source:   keepField,
+			Template: fmt.Sprintf("{{ if not (regexMatch %s %s) }}drop{{ end }}", strconv.Quote(expression), value),
+		}},
+		{DropConfig: &stages.DropConfig{
+			Source: keepField,
+			Value:  "drop",
+		}},
+	}
+}
+
+// ResolveExtractAll replaces the ExtractAll expressions of json and logfmt
+// stages with the fields read by the stages after them. Stages for which no
+// field is read are removed.
+func ResolveExtractAll(ss []stages.StageConfig) []stages.StageConfig {
+	var (
+		res  []stages.StageConfig
+		read = map[string]struct{}{}
+	)
+	for i := len(ss) - 1; i >= 0; i-- {
+		s := ss[i]
+		switch {
+		case s.JSONConfig != nil && hasExtractAll(s.JSONConfig.Expressions):
+			if len(read) == 0 {
+				continue
+			}
+			cfg := *s.JSONConfig
+			cfg.Expressions = make(map[string]string, len(read))
+			for field := range read {
+				cfg.Expressions[field] = jmespathExpression(field)
+			}
+			s = stages.StageConfig{JSONConfig: &cfg}
+		case s.LogfmtConfig != nil && hasExtractAll(s.LogfmtConfig.Mapping):
+			if len(read) == 0 {
+				continue
+			}
+			cfg := *s.LogfmtConfig
+			cfg.Mapping = make(map[string]string, len(read))
+			for field := range read {
+				cfg.Mapping[field] = ""
+			}
+			s = stages.StageConfig{LogfmtConfig: &cfg}
+		}
+
+		for _, field := range readFields(s) {
+			read[field] = struct{}{}
+		}
+		res = append(res, s)
+	}
+
+	// The stages were appended in reverse order.
+	for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+		res[i], res[j] = res[j], res[i]
+	}
+	return res
+}
+
+// jmespathExpression returns the expression of a json stage extracting field,
+// which is empty if the name of the field is a valid expression.
+func jmespathExpression(field string) string {
+	if jmespathPathRegexp.MatchString(field) {
+		return ""
+	}
+	return strconv.Quote(field)
+}
+
+func hasExtractAll(expressions map[string]string) bool {
+	_, ok := expressions[ExtractAll]
+	return ok
+}
+
+// readFields returns the extracted fields read by the stages converted from
+// other log shippers.
+func readFields(s stages.StageConfig) []string {
+	var fields []string
+	switch {
+	case s.JSONConfig != nil && s.JSONConfig.Source != nil:
+		fields = append(fields, *s.JSONConfig.Source)
+	case s.LogfmtConfig != nil:
+		fields = append(fields, s.LogfmtConfig.Source)
+	case s.RegexConfig != nil && s.RegexConfig.Source != nil:
+		fields = append(fields, *s.RegexConfig.Source)
+	case s.TimestampConfig != nil:
+		fields = append(fields, s.TimestampConfig.Source)
+	case s.OutputConfig != nil:
+		fields = append(fields, s.OutputConfig.Source)
+	case s.DropConfig != nil:
+		fields = append(fields, s.DropConfig.Source)
+	case s.TenantConfig != nil:
+		fields = append(fields, s.TenantConfig.Source)
+	case s.TemplateConfig != nil:
+		fields = append(fields, s.TemplateConfig.Source)
+		for _, m := range templateFieldRegexp.FindAllStringSubmatch(s.TemplateConfig.Template, -1) {
+			if field, err := strconv.Unquote(m[1]); err == nil {
+				fields = append(fields, field)
+			}
+		}
+	case s.LabelsConfig != nil:
+		fields = append(fields, labelsSources(s.LabelsConfig.Values)...)
+	case s.StructuredMetadata != nil:
+		fields = append(fields, labelsSources(s.StructuredMetadata.Values)...)
+	}
+
+	res := fields[:0]
+	for _, f := range fields {
+		if f != "" && f != keepField {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+func labelsSources(values map[string]*string) []string {
+	var res []string
+	for name, source := range values {
+		if source != nil && *source != "" {
+			res = append(res, *source)
+		} else {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
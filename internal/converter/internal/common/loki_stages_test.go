@@ -0,0 +1,51 @@
+package common_test
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/internal/component/loki/process/stages"
+	"github.com/grafana/agent/internal/converter/internal/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveExtractAll(t *testing.T) {
+	level, message := "level", "message"
+	in := []stages.StageConfig{
+		{JSONConfig: &stages.JSONConfig{Expressions: map[string]string{common.ExtractAll: ""}}},
+		{LogfmtConfig: &stages.LogfmtConfig{Mapping: map[string]string{common.ExtractAll: ""}, Source: "message"}},
+		{JSONConfig: &stages.JSONConfig{Expressions: map[string]string{common.ExtractAll: ""}}},
+		{TemplateConfig: &stages.TemplateConfig{Source: "app", Template: "{{ " + common.TemplateField("kubernetes.pod-name") + " }}"}},
+		{LabelsConfig: &stages.LabelsConfig{Values: map[string]*string{"level": nil, "severity": &level}}},
+	}
+
+	require.Equal(t, []stages.StageConfig{
+		{JSONConfig: &stages.JSONConfig{Expressions: map[string]string{
+			"app":                 "",
+			"kubernetes.pod-name": `"kubernetes.pod-name"`,
+			"level":               "",
+			"message":             "",
+		}}},
+		{LogfmtConfig: &stages.LogfmtConfig{Mapping: map[string]string{
+			"app":                 "",
+			"kubernetes.pod-name": "",
+			"level":               "",
+		}, Source: message}},
+		in[3],
+		in[4],
+	}, common.ResolveExtractAll([]stages.StageConfig{in[0], in[1], in[3], in[4]}))
+
+	// Stages whose fields aren't read are removed.
+	require.Equal(t, []stages.StageConfig{in[3]}, common.ResolveExtractAll([]stages.StageConfig{in[3], in[2]}))
+}
+
+func TestKeepMatching(t *testing.T) {
+	require.Equal(t, []stages.StageConfig{
+		{TemplateConfig: &stages.TemplateConfig{
+			Source:   "__converter_drop",
+			Template: `{{ if not (regexMatch "^\\d+$" (or (index . "code") "")) }}drop{{ end }}`,
+		}},
+		{DropConfig: &stages.DropConfig{Source: "__converter_drop", Value: "drop"}},
+	}, common.KeepMatching("code", `^\d+$`))
+
+	require.Equal(t, `{{ if not (regexMatch "error" .Entry) }}drop{{ end }}`, common.KeepMatching("", "error")[0].TemplateConfig.Template)
+}
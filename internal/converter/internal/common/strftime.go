@@ -0,0 +1,98 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+// strftimeLayouts maps strftime conversion specifications to the equivalent
+// Go time layout elements.
+var strftimeLayouts = map[byte]string{
+	'a': "Mon",
+	'A': "Monday",
+	'b': "Jan",
+	'B': "January",
+	'd': "02",
+	'D': "01/02/06",
+	'e': "_2",
+	'F': "2006-01-02",
+	'h': "Jan",
+	'H': "15",
+	'I': "03",
+	'j': "002",
+	'm': "01",
+	'M': "04",
+	'n': "\n",
+	'p': "PM",
+	'R': "15:04",
+	'S': "05",
+	't': "\t",
+	'T': "15:04:05",
+	'y': "06",
+	'Y': "2006",
+	'z': "-0700",
+	'Z': "MST",
+	'%': "%",
+}
+
+// StrftimeToGoLayout converts a strftime format, as used by Fluent Bit and
+// Vector, to a time format supported by the loki.process timestamp stage.
+//
+// Fractional seconds, written as %L, %f or %N optionally preceded by a dot,
+// are dropped since Go parses them after the seconds even when the layout
+// doesn't contain them.
+func StrftimeToGoLayout(format string) (string, error) {
+	switch format {
+	case "%s":
+		return "Unix", nil
+	case "%+":
+		return "RFC3339Nano", nil
+	}
+
+	var layout strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			layout.WriteByte(format[i])
+			continue
+		}
+		if i+1 >= len(format) {
+			return "", fmt.Errorf("incomplete conversion specification at the end of %q", format)
+		}
+		i++
+
+		switch spec := format[i]; {
+		case spec == 'L' || spec == 'f' || spec == 'N':
+			trimFractionSeparator(&layout)
+		case spec == '.' && strings.HasPrefix(format[i+1:], "f"):
+			// Vector's %.f includes the separator.
+			i++
+		case spec == '.' && len(format) > i+2 && format[i+1] >= '1' && format[i+1] <= '9' && format[i+2] == 'f':
+			// Vector's %.3f also sets the number of fractional digits.
+			i += 2
+		case spec >= '1' && spec <= '9' && i+1 < len(format) && (format[i+1] == 'f' || format[i+1] == 'N'):
+			// Vector's %3f and %9N set the number of fractional digits.
+			trimFractionSeparator(&layout)
+			i++
+		case spec == ':' && i+1 < len(format) && format[i+1] == 'z':
+			layout.WriteString("-07:00")
+			i++
+		default:
+			elem, ok := strftimeLayouts[spec]
+			if !ok {
+				return "", fmt.Errorf("unsupported conversion specification %%%c in %q", spec, format)
+			}
+			layout.WriteString(elem)
+		}
+	}
+	return layout.String(), nil
+}
+
+// trimFractionSeparator removes the separator written before fractional
+// seconds from layout.
+func trimFractionSeparator(layout *strings.Builder) {
+	s := layout.String()
+	if strings.HasSuffix(s, ".") || strings.HasSuffix(s, ",") {
+		layout.Reset()
+		layout.WriteString(s[:len(s)-1])
+	}
+}
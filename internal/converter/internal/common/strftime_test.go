@@ -0,0 +1,41 @@
+package common_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/internal/converter/internal/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrftimeToGoLayout(t *testing.T) {
+	tt := []struct {
+		format   string
+		expected string
+		value    string
+	}{
+		{format: "%d/%b/%Y:%H:%M:%S %z", expected: "02/Jan/2006:15:04:05 -0700", value: "10/Oct/2023:13:55:36 -0700"},
+		{format: "%Y-%m-%dT%H:%M:%S.%L%z", expected: "2006-01-02T15:04:05-0700", value: "2023-10-10T13:55:36.123+0200"},
+		{format: "%Y-%m-%dT%H:%M:%S%.3f%:z", expected: "2006-01-02T15:04:05-07:00", value: "2023-10-10T13:55:36.123+02:00"},
+		{format: "%b %e %T", expected: "Jan _2 15:04:05", value: "Oct  1 13:55:36"},
+		{format: "%F %R %p", expected: "2006-01-02 15:04 PM", value: "2023-10-10 01:55 PM"},
+		{format: "%s", expected: "Unix"},
+		{format: "%+", expected: "RFC3339Nano"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.format, func(t *testing.T) {
+			layout, err := common.StrftimeToGoLayout(tc.format)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, layout)
+			if tc.value != "" {
+				_, err := time.Parse(layout, tc.value)
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	_, err := common.StrftimeToGoLayout("%Y-%m-%d %U")
+	require.EqualError(t, err, `unsupported conversion specification %U in "%Y-%m-%d %U"`)
+	_, err = common.StrftimeToGoLayout("%Y%")
+	require.EqualError(t, err, `incomplete conversion specification at the end of "%Y%"`)
+}
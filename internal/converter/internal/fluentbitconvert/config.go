@@ -0,0 +1,277 @@
+package fluentbitconvert
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/agent/internal/converter/diag"
+	"gopkg.in/yaml.v3"
+)
+
+// section is a section of a Fluent Bit configuration, such as an [INPUT].
+type section struct {
+	// kind is the lowercase name of the section, such as "input".
+	kind string
+	// props holds the properties of the section in order. Properties such as
+	// the rules of the grep filter can be repeated.
+	props []property
+}
+
+// property is a property of a section. Its key is lowercase since Fluent Bit
+// properties are case-insensitive.
+type property struct {
+	key   string
+	value string
+}
+
+// get returns the last value of the property key, or "" if it isn't set.
+func (s *section) get(key string) string {
+	values := s.getAll(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
+
+// getOrDefault returns the last value of the property key, or def if it
+// isn't set.
+func (s *section) getOrDefault(key string, def string) string {
+	if !s.has(key) {
+		return def
+	}
+	return s.get(key)
+}
+
+// getAll returns every value of the property key.
+func (s *section) getAll(key string) []string {
+	var values []string
+	for _, p := range s.props {
+		if p.key == key {
+			values = append(values, p.value)
+		}
+	}
+	return values
+}
+
+func (s *section) has(key string) bool {
+	return len(s.getAll(key)) > 0
+}
+
+// plugin returns the lowercase name of the plugin of the section.
+func (s *section) plugin() string {
+	return strings.ToLower(s.get("name"))
+}
+
+// config is a Fluent Bit configuration.
+type config struct {
+	inputs  []*section
+	filters []*section
+	outputs []*section
+	parsers map[string]*section
+}
+
+// parseConfig parses a Fluent Bit configuration in either the classic or the
+// YAML format.
+func parseConfig(in []byte, diags *diag.Diagnostics) (*config, error) {
+	var (
+		sections []*section
+		err      error
+	)
+	if isClassicConfig(in) {
+		sections, err = parseClassicConfig(in, diags)
+	} else {
+		sections, err = parseYAMLConfig(in)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &config{parsers: map[string]*section{}}
+	for _, s := range sections {
+		switch s.kind {
+		case "service":
+			if s.has("parsers_file") {
+				diags.Add(diag.SeverityLevelInfo, "parsers files aren't read by the converter, add the parsers used by the configuration to it to convert them")
+			}
+		case "input":
+			cfg.inputs = append(cfg.inputs, s)
+		case "filter":
+			cfg.filters = append(cfg.filters, s)
+		case "output":
+			cfg.outputs = append(cfg.outputs, s)
+		case "parser":
+			cfg.parsers[s.get("name")] = s
+		case "multiline_parser":
+			diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported multiline parser %q, use a stage.multiline block in loki.process instead", s.get("name")))
+		case "plugins", "custom":
+			diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported %s section", strings.ToUpper(s.kind)))
+		default:
+			return nil, fmt.Errorf("unknown section %q", s.kind)
+		}
+	}
+	return cfg, nil
+}
+
+// isClassicConfig reports whether the first statement of in is a section
+// header or a command of the classic configuration format.
+func isClassicConfig(in []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(in))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return strings.HasPrefix(line, "[") || strings.HasPrefix(line, "@")
+	}
+	return false
+}
+
+var variableRegexp = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// parseClassicConfig parses a configuration in the classic format, which
+// expands the variables defined with @SET.
+func parseClassicConfig(in []byte, diags *diag.Diagnostics) ([]*section, error) {
+	var (
+		sections  []*section
+		current   *section
+		variables = map[string]string{}
+		unset     = map[string]bool{}
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(in))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = variableRegexp.ReplaceAllStringFunc(line, func(ref string) string {
+			name := ref[2 : len(ref)-1]
+			if value, ok := variables[name]; ok {
+				return value
+			}
+			if !unset[name] {
+				unset[name] = true
+				diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("the environment variable %s isn't expanded, replace it with the env function in the converted configuration", name))
+			}
+			return ref
+		})
+
+		switch {
+		case strings.HasPrefix(line, "@SET "):
+			name, value, ok := strings.Cut(strings.TrimSpace(strings.TrimPrefix(line, "@SET ")), "=")
+			if !ok {
+				return nil, fmt.Errorf("line %d: invalid @SET command %q", lineNum, line)
+			}
+			variables[strings.TrimSpace(name)] = strings.TrimSpace(value)
+
+		case strings.HasPrefix(line, "@INCLUDE "):
+			diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported @INCLUDE of %s, add the included file to the converted configuration", strings.TrimSpace(strings.TrimPrefix(line, "@INCLUDE "))))
+
+		case strings.HasPrefix(line, "["):
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: invalid section header %q", lineNum, line)
+			}
+			current = &section{kind: strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))}
+			sections = append(sections, current)
+
+		default:
+			if current == nil {
+				return nil, fmt.Errorf("line %d: property %q outside of a section", lineNum, line)
+			}
+			key, value, _ := strings.Cut(line, " ")
+			current.props = append(current.props, property{
+				key:   strings.ToLower(key),
+				value: strings.TrimSpace(value),
+			})
+		}
+	}
+	return sections, scanner.Err()
+}
+
+// yamlConfig is a configuration in the YAML format. Sections are decoded as
+// nodes to keep the order of their properties.
+type yamlConfig struct {
+	Env              map[string]string `yaml:"env"`
+	Includes         []string          `yaml:"includes"`
+	Service          yaml.Node         `yaml:"service"`
+	Parsers          []yaml.Node       `yaml:"parsers"`
+	MultilineParsers []yaml.Node       `yaml:"multiline_parsers"`
+	Pipeline         struct {
+		Inputs  []yaml.Node `yaml:"inputs"`
+		Filters []yaml.Node `yaml:"filters"`
+		Outputs []yaml.Node `yaml:"outputs"`
+	} `yaml:"pipeline"`
+}
+
+// parseYAMLConfig parses a configuration in the YAML format.
+func parseYAMLConfig(in []byte) ([]*section, error) {
+	var cfg yamlConfig
+	dec := yaml.NewDecoder(bytes.NewReader(in))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	if len(cfg.Includes) > 0 {
+		return nil, fmt.Errorf("unsupported includes of %s, add the included files to the converted configuration", strings.Join(cfg.Includes, ", "))
+	}
+
+	expand := func(s string) string {
+		return variableRegexp.ReplaceAllStringFunc(s, func(ref string) string {
+			if value, ok := cfg.Env[ref[2:len(ref)-1]]; ok {
+				return value
+			}
+			return ref
+		})
+	}
+
+	var sections []*section
+	add := func(kind string, nodes ...yaml.Node) error {
+		for _, node := range nodes {
+			if node.Kind == 0 {
+				// The section isn't set.
+				continue
+			}
+			if node.Kind != yaml.MappingNode {
+				return fmt.Errorf("line %d: %s must be a map", node.Line, kind)
+			}
+
+			s := &section{kind: kind}
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := strings.ToLower(node.Content[i].Value), node.Content[i+1]
+				switch value.Kind {
+				case yaml.ScalarNode:
+					s.props = append(s.props, property{key: key, value: expand(value.Value)})
+				case yaml.SequenceNode:
+					for _, elem := range value.Content {
+						if elem.Kind != yaml.ScalarNode {
+							return fmt.Errorf("line %d: unsupported %s property %q", elem.Line, kind, key)
+						}
+						s.props = append(s.props, property{key: key, value: expand(elem.Value)})
+					}
+				default:
+					return fmt.Errorf("line %d: unsupported %s property %q", value.Line, kind, key)
+				}
+			}
+			sections = append(sections, s)
+		}
+		return nil
+	}
+
+	for _, err := range []error{
+		add("service", cfg.Service),
+		add("parser", cfg.Parsers...),
+		add("multiline_parser", cfg.MultilineParsers...),
+		add("input", cfg.Pipeline.Inputs...),
+		add("filter", cfg.Pipeline.Filters...),
+		add("output", cfg.Pipeline.Outputs...),
+	} {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sections, nil
+}
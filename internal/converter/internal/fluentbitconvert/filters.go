@@ -0,0 +1,191 @@
+package fluentbitconvert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/agent/internal/component/loki/process/stages"
+	"github.com/grafana/agent/internal/converter/diag"
+	"github.com/grafana/agent/internal/converter/internal/common"
+)
+
+// convertFilter converts the filter section s for records whose log line is
+// stored under lineKey.
+func (c *converter) convertFilter(s *section, lineKey string) []stages.StageConfig {
+	switch s.plugin() {
+	case "parser":
+		return c.convertParserFilter(s, lineKey)
+	case "grep":
+		return c.convertGrepFilter(s, lineKey)
+	case "modify":
+		return c.convertModifyFilter(s, lineKey)
+	case "record_modifier":
+		return c.convertRecordModifierFilter(s, lineKey)
+	default:
+		c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported filter %q", s.plugin()))
+		return nil
+	}
+}
+
+// convertParserFilter converts a parser filter, which parses a field of the
+// records.
+func (c *converter) convertParserFilter(s *section, lineKey string) []stages.StageConfig {
+	c.checkProperties(s, "key_name", "parser", "reserve_data", "preserve_key", "unescape_key")
+
+	key, ok := c.recordKey(s, s.get("key_name"))
+	if !ok {
+		return nil
+	}
+	source := key
+	if key == lineKey {
+		source = ""
+	}
+
+	parsers := s.getAll("parser")
+	if len(parsers) > 1 {
+		c.diags.Add(diag.SeverityLevelWarn, "the parser filter is converted to the stages of all its parsers instead of only the first one matching the record")
+	}
+	var res []stages.StageConfig
+	for _, parser := range parsers {
+		res = append(res, c.parserStages(parser, source)...)
+	}
+	return res
+}
+
+// convertGrepFilter converts a grep filter, which drops the records whose
+// fields don't match its Regex rules or match its Exclude rules.
+func (c *converter) convertGrepFilter(s *section, lineKey string) []stages.StageConfig {
+	c.checkProperties(s, "regex", "exclude", "logical_op")
+	if op := strings.ToLower(s.getOrDefault("logical_op", "legacy")); op != "legacy" {
+		c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported logical_op %q of the grep filter", op))
+		return nil
+	}
+
+	var res []stages.StageConfig
+	for _, p := range s.props {
+		if p.key != "regex" && p.key != "exclude" {
+			continue
+		}
+		key, expression, _ := strings.Cut(p.value, " ")
+		key, ok := c.recordKey(s, key)
+		if !ok {
+			continue
+		}
+		source := key
+		if key == lineKey {
+			source = ""
+		}
+		expression = strings.TrimSpace(expression)
+
+		if p.key == "regex" {
+			res = append(res, common.KeepMatching(source, expression)...)
+		} else {
+			res = append(res, stages.StageConfig{DropConfig: &stages.DropConfig{
+				Source:     source,
+				Expression: expression,
+			}})
+		}
+	}
+	return res
+}
+
+// convertModifyFilter converts a modify filter, which sets, renames and
+// copies the fields of the records.
+func (c *converter) convertModifyFilter(s *section, lineKey string) []stages.StageConfig {
+	var (
+		res     []stages.StageConfig
+		removed bool
+	)
+	for _, p := range s.props {
+		if isSupportedProperty(p.key, commonProperties) {
+			continue
+		}
+
+		args := strings.Fields(p.value)
+
+		switch p.key {
+		case "set", "add", "rename", "hard_rename", "copy", "hard_copy":
+			if len(args) != 2 {
+				c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("invalid %s rule %q of the modify filter", p.key, p.value))
+				continue
+			}
+			if args[0] == lineKey || args[1] == lineKey {
+				c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported %s rule %q of the modify filter, the log line can't be modified", p.key, p.value))
+				continue
+			}
+
+			var cfg stages.TemplateConfig
+			switch p.key {
+			case "set":
+				cfg = stages.TemplateConfig{Source: args[0], Template: templateLiteral(args[1])}
+			case "add":
+				cfg = stages.TemplateConfig{Source: args[0], Template: fmt.Sprintf("{{ or .Value %s }}", strconv.Quote(args[1]))}
+			case "rename", "copy":
+				// The field is only set if it doesn't exist.
+				cfg = stages.TemplateConfig{Source: args[1], Template: fmt.Sprintf("{{ or .Value (index . %s) \"\" }}", strconv.Quote(args[0]))}
+			case "hard_rename", "hard_copy":
+				cfg = stages.TemplateConfig{Source: args[1], Template: fmt.Sprintf("{{ or (index . %s) .Value \"\" }}", strconv.Quote(args[0]))}
+			}
+			res = append(res, stages.StageConfig{TemplateConfig: &cfg})
+
+		case "remove", "remove_wildcard", "remove_regex":
+			removed = true
+
+		default:
+			c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported property %q of the modify filter", p.key))
+		}
+	}
+
+	if removed {
+		c.diags.Add(diag.SeverityLevelInfo, "the remove rules of the modify filter aren't converted, the extracted fields aren't sent by loki.write")
+	}
+	return res
+}
+
+// convertRecordModifierFilter converts a record_modifier filter, which sets
+// fields of the records.
+func (c *converter) convertRecordModifierFilter(s *section, lineKey string) []stages.StageConfig {
+	c.checkProperties(s, "record", "remove_key", "allowlist_key", "whitelist_key")
+
+	var res []stages.StageConfig
+	for _, record := range s.getAll("record") {
+		key, value, _ := strings.Cut(record, " ")
+		if key == lineKey {
+			c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported record %q of the record_modifier filter, the log line can't be modified", record))
+			continue
+		}
+		res = append(res, stages.StageConfig{TemplateConfig: &stages.TemplateConfig{
+			Source:   key,
+			Template: templateLiteral(strings.TrimSpace(value)),
+		}})
+	}
+
+	if s.has("remove_key") || s.has("allowlist_key") || s.has("whitelist_key") {
+		c.diags.Add(diag.SeverityLevelInfo, "the removed keys of the record_modifier filter aren't converted, the extracted fields aren't sent by loki.write")
+	}
+	return res
+}
+
+// recordKey returns the key of a record field referenced by a plugin
+// property. Only top-level keys are supported.
+func (c *converter) recordKey(s *section, key string) (string, bool) {
+	if key == "" {
+		c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("missing record key in the %s %s", s.plugin(), s.kind))
+		return "", false
+	}
+	key = strings.TrimPrefix(key, "$")
+	if strings.ContainsAny(key, "[]'") {
+		c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported record accessor %q of the %s %s, only top-level keys are supported", key, s.plugin(), s.kind))
+		return "", false
+	}
+	return key, true
+}
+
+// templateLiteral returns a template rendering value.
+func templateLiteral(value string) string {
+	if strings.Contains(value, "{{") {
+		return fmt.Sprintf("{{ %s }}", strconv.Quote(value))
+	}
+	return value
+}
@@ -0,0 +1,338 @@
+// Package fluentbitconvert converts Fluent Bit configurations to Grafana Agent
+// Flow configurations.
+package fluentbitconvert
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/agent/internal/component/common/loki"
+	"github.com/grafana/agent/internal/component/loki/process"
+	"github.com/grafana/agent/internal/component/loki/process/stages"
+	"github.com/grafana/agent/internal/converter/diag"
+	"github.com/grafana/agent/internal/converter/internal/common"
+	"github.com/grafana/river/scanner"
+	"github.com/grafana/river/token/builder"
+)
+
+// Convert implements a Fluent Bit config converter. Both the classic and the
+// YAML configuration formats are supported.
+//
+// extraArgs are supported to mirror the other converter params due to shared
+// testing code but they should be passed empty to this converter.
+func Convert(in []byte, extraArgs []string) ([]byte, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if len(extraArgs) > 0 {
+		diags.Add(diag.SeverityLevelCritical, fmt.Sprintf("extra arguments are not supported for the fluentbit converter: %s", extraArgs))
+		return nil, diags
+	}
+
+	cfg, err := parseConfig(in, &diags)
+	if err != nil {
+		diags.Add(diag.SeverityLevelCritical, fmt.Sprintf("failed to parse Fluent Bit config: %s", err))
+		return nil, diags
+	}
+
+	f := builder.NewFile()
+	diags = appendAll(f, cfg, diags)
+	diags.AddAll(common.ValidateNodes(f))
+
+	var buf bytes.Buffer
+	if _, err := f.WriteTo(&buf); err != nil {
+		diags.Add(diag.SeverityLevelCritical, fmt.Sprintf("failed to render Flow config: %s", err.Error()))
+		return nil, diags
+	}
+
+	if len(buf.Bytes()) == 0 {
+		return nil, diags
+	}
+
+	prettyByte, newDiags := common.PrettyPrint(buf.Bytes())
+	diags.AddAll(newDiags)
+	return prettyByte, diags
+}
+
+// input is a converted Fluent Bit input.
+type input struct {
+	label string
+	// tags are the tags of the records of the input. The wildcards in the tags
+	// of tail inputs are expanded to the watched paths.
+	tags []string
+	// lineKey is the key of the log line in the records of the input.
+	lineKey string
+	// stages convert the records of the input, such as with its parser.
+	stages []stages.StageConfig
+	// appendBlocks appends the components reading the input to the file.
+	appendBlocks func(forwardTo []loki.LogsReceiver)
+}
+
+// filter is a converted Fluent Bit filter.
+type filter struct {
+	section *section
+	match   func(tag string) bool
+	// stages holds the stages of the filter for each line key, since filters
+	// read the log line from the extracted fields unless they use its key.
+	stages map[string][]stages.StageConfig
+}
+
+// output is a converted Fluent Bit output.
+type output struct {
+	label    string
+	match    func(tag string) bool
+	stages   []stages.StageConfig
+	block    *builder.Block
+	receiver loki.LogsReceiver
+}
+
+type converter struct {
+	f      *builder.File
+	diags  *diag.Diagnostics
+	cfg    *config
+	labels map[string]bool
+	// checkedParsers holds the parsers whose properties were checked, to only
+	// report them once.
+	checkedParsers map[string]bool
+}
+
+// appendAll analyzes the entire Fluent Bit config in memory and transforms it
+// into Flow components. It then appends each argument to the file builder.
+//
+// Fluent Bit routes records between plugins with tags, while Flow components
+// are wired explicitly. The filters and outputs matching each input are
+// converted to a loki.process component per output, since extracted fields
+// aren't sent between components.
+func appendAll(f *builder.File, cfg *config, diags diag.Diagnostics) diag.Diagnostics {
+	c := &converter{
+		f:              f,
+		diags:          &diags,
+		cfg:            cfg,
+		labels:         map[string]bool{},
+		checkedParsers: map[string]bool{},
+	}
+
+	var inputs []*input
+	pluginIndexes := map[string]int{}
+	for _, s := range cfg.inputs {
+		index := pluginIndexes[s.plugin()]
+		pluginIndexes[s.plugin()]++
+		if in := c.convertInput(s, index); in != nil {
+			inputs = append(inputs, in)
+		}
+	}
+
+	var filters []*filter
+	for _, s := range cfg.filters {
+		filters = append(filters, &filter{
+			section: s,
+			match:   c.newMatcher(s),
+			stages:  map[string][]stages.StageConfig{},
+		})
+	}
+
+	var outputs []*output
+	pluginIndexes = map[string]int{}
+	for _, s := range cfg.outputs {
+		index := pluginIndexes[s.plugin()]
+		pluginIndexes[s.plugin()]++
+		if out := c.convertOutput(s, index); out != nil {
+			outputs = append(outputs, out)
+		}
+	}
+
+	for _, in := range inputs {
+		c.appendInput(in, filters, outputs)
+	}
+	for _, out := range outputs {
+		f.Body().AppendBlock(out.block)
+	}
+
+	return diags
+}
+
+// appendInput appends the components reading the input and the loki.process
+// components routing its logs to the matching outputs.
+func (c *converter) appendInput(in *input, filters []*filter, outputs []*output) {
+	var matchedOutputs []*output
+	for _, out := range outputs {
+		if matchesAny(out.match, in.tags) {
+			matchedOutputs = append(matchedOutputs, out)
+		}
+	}
+	if len(matchedOutputs) == 0 {
+		c.diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("no output matches the tag of the %s input, its logs are dropped", in.label))
+	}
+
+	var filterStages []stages.StageConfig
+	for _, flt := range filters {
+		if matchesAny(flt.match, in.tags) {
+			filterStages = append(filterStages, c.filterStages(flt, in.lineKey)...)
+		}
+	}
+
+	var (
+		forwardTo []loki.LogsReceiver
+		blocks    []*builder.Block
+	)
+	for _, out := range matchedOutputs {
+		var ss []stages.StageConfig
+		ss = append(ss, in.stages...)
+		ss = append(ss, filterStages...)
+		ss = append(ss, out.stages...)
+		ss = common.ResolveExtractAll(ss)
+		if len(ss) == 0 {
+			forwardTo = append(forwardTo, out.receiver)
+			continue
+		}
+
+		label := in.label
+		if len(matchedOutputs) > 1 {
+			label = c.uniqueLabel(common.LabelForParts(in.label, out.label))
+		}
+		blocks = append(blocks, common.NewBlockWithOverride([]string{"loki", "process"}, label, process.Arguments{
+			ForwardTo: []loki.LogsReceiver{out.receiver},
+			Stages:    ss,
+		}))
+		forwardTo = append(forwardTo, common.ConvertLogsReceiver{
+			Expr: fmt.Sprintf("loki.process.%s.receiver", label),
+		})
+	}
+
+	in.appendBlocks(forwardTo)
+	for _, block := range blocks {
+		c.f.Body().AppendBlock(block)
+	}
+}
+
+// filterStages returns the stages of flt for records whose log line is
+// stored under lineKey. The diagnostics of the filter are only reported the
+// first time it's converted.
+func (c *converter) filterStages(flt *filter, lineKey string) []stages.StageConfig {
+	if ss, ok := flt.stages[lineKey]; ok {
+		return ss
+	}
+
+	diags := c.diags
+	if len(flt.stages) > 0 {
+		c.diags = &diag.Diagnostics{}
+	}
+	ss := c.convertFilter(flt.section, lineKey)
+	c.diags = diags
+
+	flt.stages[lineKey] = ss
+	return ss
+}
+
+// newMatcher returns the function matching the tags of the records routed to
+// the filter or output s.
+func (c *converter) newMatcher(s *section) func(tag string) bool {
+	if pattern := s.get("match_regex"); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("invalid Match_Regex of the %s %s: %s", s.plugin(), s.kind, err))
+			return func(string) bool { return false }
+		}
+		return re.MatchString
+	}
+
+	pattern := s.get("match")
+	if pattern == "" {
+		c.diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("the %s %s doesn't set Match, no records are routed to it", s.plugin(), s.kind))
+		return func(string) bool { return false }
+	}
+	// Match patterns only support the * wildcard.
+	re := regexp.MustCompile("^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$")
+	return re.MatchString
+}
+
+func matchesAny(match func(tag string) bool, tags []string) bool {
+	for _, tag := range tags {
+		if match(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// componentLabel returns a unique label for the component converted from the
+// section s, which is its alias if it's set.
+func (c *converter) componentLabel(s *section, index int) string {
+	label := s.get("alias")
+	if label == "" {
+		label = common.LabelWithIndex(index, s.plugin())
+	}
+	sanitized, err := scanner.SanitizeIdentifier(label)
+	if err != nil {
+		c.diags.Add(diag.SeverityLevelCritical, fmt.Sprintf("failed to sanitize label %q: %s", label, err))
+	}
+	return c.uniqueLabel(sanitized)
+}
+
+// uniqueLabel returns label, suffixed with an index if it's already used.
+func (c *converter) uniqueLabel(label string) string {
+	res := label
+	for i := 1; c.labels[res]; i++ {
+		res = common.LabelWithIndex(i, label)
+	}
+	c.labels[res] = true
+	return res
+}
+
+// checkProperties reports the properties of s which aren't supported. Keys
+// prefixed with a dot match every property with that prefix.
+func (c *converter) checkProperties(s *section, supported ...string) {
+	reported := map[string]bool{}
+	for _, p := range s.props {
+		if reported[p.key] || isSupportedProperty(p.key, commonProperties) || isSupportedProperty(p.key, supported) {
+			continue
+		}
+		reported[p.key] = true
+		c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported property %q of the %s %s", p.key, s.plugin(), s.kind))
+	}
+}
+
+// commonProperties are the properties shared by plugins which don't affect
+// the conversion, such as the tuning of buffers and retries.
+var commonProperties = []string{
+	"name", "alias", "tag", "match", "match_regex", "log_level", "log_suppress_interval",
+	"mem_buf_limit", "buffer_chunk_size", "buffer_max_size", "threaded", "routable",
+	"retry_limit", "workers", "storage.", "net.",
+}
+
+func isSupportedProperty(key string, supported []string) bool {
+	for _, s := range supported {
+		if key == s || (strings.HasSuffix(s, ".") && strings.HasPrefix(key, s)) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseBool parses a Fluent Bit boolean.
+func parseBool(s *section, key string, def bool) bool {
+	switch strings.ToLower(s.get(key)) {
+	case "":
+		return def
+	case "on", "true", "yes", "1":
+		return true
+	default:
+		return false
+	}
+}
+
+// parseInt parses a Fluent Bit integer.
+func (c *converter) parseInt(s *section, key string, def int) int {
+	value := s.get(key)
+	if value == "" {
+		return def
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("invalid %s of the %s %s: %s", key, s.plugin(), s.kind, err))
+		return def
+	}
+	return i
+}
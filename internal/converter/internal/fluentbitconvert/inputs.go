@@ -0,0 +1,237 @@
+package fluentbitconvert
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	commoncfg "github.com/grafana/agent/internal/component/common/config"
+	"github.com/grafana/agent/internal/component/common/kubernetes"
+	"github.com/grafana/agent/internal/component/common/loki"
+	"github.com/grafana/agent/internal/component/discovery"
+	filematch "github.com/grafana/agent/internal/component/local/file_match"
+	"github.com/grafana/agent/internal/component/loki/process/stages"
+	lokisourcefile "github.com/grafana/agent/internal/component/loki/source/file"
+	"github.com/grafana/agent/internal/component/loki/source/kubernetes_events"
+	"github.com/grafana/agent/internal/component/loki/source/syslog"
+	"github.com/grafana/agent/internal/converter/diag"
+	"github.com/grafana/agent/internal/converter/internal/common"
+)
+
+// convertInput converts the input section s. It returns nil if the input
+// isn't supported.
+func (c *converter) convertInput(s *section, index int) *input {
+	label := c.componentLabel(s, index)
+
+	tag := s.get("tag")
+	if tag == "" {
+		tag = s.get("alias")
+	}
+	if tag == "" {
+		tag = fmt.Sprintf("%s.%d", s.plugin(), index)
+	}
+
+	switch s.plugin() {
+	case "tail":
+		return c.convertTail(s, label, tag)
+	case "syslog":
+		return c.convertSyslog(s, label, tag)
+	case "kubernetes_events":
+		return c.convertKubernetesEvents(s, label, tag)
+	default:
+		c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported input %q", s.plugin()))
+		return nil
+	}
+}
+
+// convertTail converts a tail input to local.file_match and
+// loki.source.file components.
+func (c *converter) convertTail(s *section, label string, tag string) *input {
+	c.checkProperties(s,
+		"path", "exclude_path", "refresh_interval", "read_from_head", "parser", "key",
+		"multiline.parser", "path_key", "db", "db.", "skip_long_lines", "skip_empty_lines",
+		"rotate_wait", "inotify_watcher", "docker_mode", "multiline", "parser_firstline",
+	)
+	for _, key := range []string{"docker_mode", "multiline", "parser_firstline"} {
+		if s.has(key) {
+			c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported property %q of the tail input, use the multiline.parser property instead", key))
+		}
+	}
+	if s.has("path_key") {
+		c.diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("the path_key property of the tail input isn't converted, the path of the files read by loki.source.file.%s is set in the filename label", label))
+	}
+	if s.has("db") {
+		c.diags.Add(diag.SeverityLevelInfo, "the database of the tail input isn't converted, loki.source.file stores the read positions in the data directory of the agent")
+	}
+
+	excludes := splitList(s.get("exclude_path"))
+	var (
+		targets []discovery.Target
+		tags    []string
+	)
+	for _, path := range splitList(s.get("path")) {
+		target := discovery.Target{"__path__": path}
+		switch len(excludes) {
+		case 0:
+		case 1:
+			target["__path_exclude__"] = excludes[0]
+		default:
+			target["__path_exclude__"] = "{" + strings.Join(excludes, ",") + "}"
+		}
+		targets = append(targets, target)
+
+		// The wildcard in the tag of tail inputs is replaced with the path of
+		// the file.
+		tags = append(tags, strings.Replace(tag, "*", strings.ReplaceAll(strings.TrimPrefix(path, "/"), "/", "."), 1))
+	}
+	if len(targets) == 0 {
+		c.diags.Add(diag.SeverityLevelError, "the tail input doesn't set Path")
+		return nil
+	}
+
+	in := &input{
+		label:   label,
+		tags:    tags,
+		lineKey: s.getOrDefault("key", "log"),
+	}
+
+	for _, parser := range splitList(s.get("multiline.parser")) {
+		switch parser {
+		case "docker":
+			in.stages = append(in.stages, stages.StageConfig{DockerConfig: &stages.DockerConfig{}})
+		case "cri":
+			cri := stages.DefaultCRIConfig
+			in.stages = append(in.stages, stages.StageConfig{CRIConfig: &cri})
+		default:
+			c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported multiline parser %q of the tail input, use a stage.multiline block in loki.process instead", parser))
+		}
+	}
+	if parser := s.get("parser"); parser != "" {
+		in.stages = append(in.stages, c.parserStages(parser, "")...)
+	}
+
+	fileMatchArgs := filematch.Arguments{
+		PathTargets: targets,
+		SyncPeriod:  time.Duration(c.parseInt(s, "refresh_interval", 60)) * time.Second,
+	}
+	in.appendBlocks = func(forwardTo []loki.LogsReceiver) {
+		c.f.Body().AppendBlock(common.NewBlockWithOverride([]string{"local", "file_match"}, label, fileMatchArgs))
+
+		args := lokisourcefile.DefaultArguments
+		args.ForwardTo = forwardTo
+		args.TailFromEnd = !parseBool(s, "read_from_head", false)
+		overrideHook := func(val interface{}) interface{} {
+			if _, ok := val.([]discovery.Target); ok {
+				return common.CustomTokenizer{Expr: fmt.Sprintf("local.file_match.%s.targets", label)}
+			}
+			return val
+		}
+		c.f.Body().AppendBlock(common.NewBlockWithOverrideFn([]string{"loki", "source", "file"}, label, args, overrideHook))
+	}
+	return in
+}
+
+// convertSyslog converts a syslog input to a loki.source.syslog component.
+func (c *converter) convertSyslog(s *section, label string, tag string) *input {
+	c.checkProperties(s, "mode", "listen", "port", "path", "unix_perm", "parser", "receive_buffer_size", "tls", "tls.")
+
+	mode := strings.ToLower(s.getOrDefault("mode", "unix_udp"))
+	if mode != "tcp" && mode != "udp" {
+		c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported mode %q of the syslog input, loki.source.syslog only listens on TCP and UDP", mode))
+		return nil
+	}
+	if parser := s.get("parser"); parser != "" && parser != "syslog-rfc5424" {
+		c.diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("the parser %q of the syslog input isn't converted, loki.source.syslog parses RFC5424 messages", parser))
+	}
+
+	listener := syslog.DefaultListenerConfig
+	listener.ListenAddress = net.JoinHostPort(s.getOrDefault("listen", "0.0.0.0"), s.getOrDefault("port", "5140"))
+	listener.ListenProtocol = mode
+	if parseBool(s, "tls", false) {
+		listener.TLSConfig = commoncfg.TLSConfig{
+			CAFile:             s.get("tls.ca_file"),
+			CertFile:           s.get("tls.crt_file"),
+			KeyFile:            s.get("tls.key_file"),
+			InsecureSkipVerify: !parseBool(s, "tls.verify", true),
+		}
+	}
+
+	return &input{
+		label:   label,
+		tags:    []string{tag},
+		lineKey: "message",
+		appendBlocks: func(forwardTo []loki.LogsReceiver) {
+			args := syslog.Arguments{
+				SyslogListeners: []syslog.ListenerConfig{listener},
+				ForwardTo:       forwardTo,
+			}
+			c.f.Body().AppendBlock(common.NewBlockWithOverride([]string{"loki", "source", "syslog"}, label, args))
+		},
+	}
+}
+
+const (
+	defaultKubeURL       = "https://kubernetes.default.svc"
+	defaultKubeCAFile    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+	defaultKubeTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+// convertKubernetesEvents converts a kubernetes_events input to a
+// loki.source.kubernetes_events component.
+func (c *converter) convertKubernetesEvents(s *section, label string, tag string) *input {
+	c.checkProperties(s,
+		"kube_url", "kube_ca_file", "kube_token_file", "kube_namespace", "tls.verify",
+		"interval_sec", "interval_nsec", "kube_request_limit", "kube_retention_time", "db", "db.",
+	)
+
+	args := kubernetes_events.DefaultArguments
+	// Fluent Bit records the events as maps, which are closer to JSON lines.
+	args.LogFormat = "json"
+	if namespace := s.get("kube_namespace"); namespace != "" {
+		args.Namespaces = []string{namespace}
+	}
+
+	var (
+		kubeURL   = s.getOrDefault("kube_url", defaultKubeURL)
+		caFile    = s.getOrDefault("kube_ca_file", defaultKubeCAFile)
+		tokenFile = s.getOrDefault("kube_token_file", defaultKubeTokenFile)
+		verify    = parseBool(s, "tls.verify", true)
+	)
+	// The in-cluster configuration is used by default.
+	if kubeURL != defaultKubeURL || caFile != defaultKubeCAFile || tokenFile != defaultKubeTokenFile || !verify {
+		apiServer, err := url.Parse(kubeURL)
+		if err != nil {
+			c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("invalid kube_url of the kubernetes_events input: %s", err))
+		}
+		args.Client = kubernetes.ClientArguments{
+			APIServer:        commoncfg.URL{URL: apiServer},
+			HTTPClientConfig: *commoncfg.CloneDefaultHTTPClientConfig(),
+		}
+		args.Client.HTTPClientConfig.BearerTokenFile = tokenFile
+		args.Client.HTTPClientConfig.TLSConfig.CAFile = caFile
+		args.Client.HTTPClientConfig.TLSConfig.InsecureSkipVerify = !verify
+	}
+
+	return &input{
+		label:   label,
+		tags:    []string{tag},
+		lineKey: "message",
+		appendBlocks: func(forwardTo []loki.LogsReceiver) {
+			args.ForwardTo = forwardTo
+			c.f.Body().AppendBlock(common.NewBlockWithOverride([]string{"loki", "source", "kubernetes_events"}, label, args))
+		},
+	}
+}
+
+// splitList splits a comma separated list of values.
+func splitList(s string) []string {
+	var res []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
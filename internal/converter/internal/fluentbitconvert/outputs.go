@@ -0,0 +1,192 @@
+package fluentbitconvert
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	commoncfg "github.com/grafana/agent/internal/component/common/config"
+	"github.com/grafana/agent/internal/component/loki/process/stages"
+	lokiwrite "github.com/grafana/agent/internal/component/loki/write"
+	"github.com/grafana/agent/internal/converter/diag"
+	"github.com/grafana/agent/internal/converter/internal/common"
+	"github.com/grafana/river/rivertypes"
+)
+
+// convertOutput converts the output section s. It returns nil if the output
+// isn't supported.
+func (c *converter) convertOutput(s *section, index int) *output {
+	label := c.componentLabel(s, index)
+
+	var out *output
+	switch s.plugin() {
+	case "loki":
+		out = c.convertLokiOutput(s, label)
+	case "http":
+		out = c.convertHTTPOutput(s, label)
+	default:
+		c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported output %q", s.plugin()))
+		return nil
+	}
+
+	out.label = label
+	out.match = c.newMatcher(s)
+	out.receiver = common.ConvertLogsReceiver{
+		Expr: fmt.Sprintf("loki.write.%s.receiver", label),
+	}
+	return out
+}
+
+// convertLokiOutput converts a loki output to a loki.write component.
+func (c *converter) convertLokiOutput(s *section, label string) *output {
+	c.checkProperties(s,
+		"host", "port", "uri", "tls", "tls.", "http_user", "http_passwd", "bearer_token",
+		"header", "tenant_id", "tenant_id_key", "labels", "label_keys", "structured_metadata",
+		"remove_keys", "line_format", "drop_single_key", "auto_kubernetes_labels", "compress",
+	)
+	if parseBool(s, "auto_kubernetes_labels", false) {
+		c.diags.Add(diag.SeverityLevelError, "unsupported auto_kubernetes_labels of the loki output, use a stage.labels block in loki.process instead")
+	}
+	if s.has("remove_keys") {
+		c.diags.Add(diag.SeverityLevelInfo, "the remove_keys property of the loki output isn't converted, the extracted fields aren't sent by loki.write")
+	}
+	if format := strings.ToLower(s.getOrDefault("line_format", "json")); format != "json" || !parseBool(s, "drop_single_key", false) {
+		c.diags.Add(diag.SeverityLevelInfo, fmt.Sprintf("loki.write.%s sends the log lines instead of the records encoded in the %s format of the loki output", label, format))
+	}
+
+	endpoint := c.endpointOptions(s, "3100", "/loki/api/v1/push")
+	endpoint.TenantID = s.get("tenant_id")
+
+	out := &output{}
+	args := lokiwrite.Arguments{
+		Endpoints: []lokiwrite.EndpointOptions{endpoint},
+	}
+	externalLabels := map[string]string{}
+
+	labels := map[string]*string{}
+	for _, l := range splitList(s.get("labels")) {
+		name, value, ok := strings.Cut(l, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		switch {
+		case !ok:
+			// A record key whose name is used as the label name.
+			if key, ok := c.recordKey(s, name); ok {
+				labels[key] = labelSource(key, key)
+			}
+		case strings.HasPrefix(value, "$"):
+			if key, ok := c.recordKey(s, value); ok {
+				labels[name] = labelSource(name, key)
+			}
+		default:
+			externalLabels[name] = value
+		}
+	}
+	for _, l := range splitList(s.get("label_keys")) {
+		if key, ok := c.recordKey(s, l); ok {
+			labels[key] = labelSource(key, key)
+		}
+	}
+	if !s.has("labels") && !s.has("label_keys") {
+		externalLabels["job"] = "fluent-bit"
+	}
+	if len(externalLabels) > 0 {
+		args.ExternalLabels = externalLabels
+	}
+	if len(labels) > 0 {
+		out.stages = append(out.stages, stages.StageConfig{LabelsConfig: &stages.LabelsConfig{Values: labels}})
+	}
+
+	metadata := map[string]*string{}
+	for _, m := range splitList(s.get("structured_metadata")) {
+		name, value, ok := strings.Cut(m, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || !strings.HasPrefix(value, "$") {
+			c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported structured metadata %q of the loki output, only record keys are supported", m))
+			continue
+		}
+		if key, ok := c.recordKey(s, value); ok {
+			metadata[name] = labelSource(name, key)
+		}
+	}
+	if len(metadata) > 0 {
+		out.stages = append(out.stages, stages.StageConfig{StructuredMetadata: &stages.LabelsConfig{Values: metadata}})
+	}
+
+	if key := s.get("tenant_id_key"); key != "" {
+		out.stages = append(out.stages, stages.StageConfig{TenantConfig: &stages.TenantConfig{Source: key}})
+	}
+
+	out.block = common.NewBlockWithOverride([]string{"loki", "write"}, label, args)
+	return out
+}
+
+// convertHTTPOutput converts an http output to a loki.write component. The
+// server it sends to must accept Loki push requests.
+func (c *converter) convertHTTPOutput(s *section, label string) *output {
+	c.checkProperties(s,
+		"host", "port", "uri", "tls", "tls.", "http_user", "http_passwd", "bearer_token",
+		"header", "format", "json_date_key", "json_date_format", "compress",
+	)
+	c.diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("the http output is converted to loki.write.%s, which sends Loki push requests instead of the records encoded in the %s format", label, s.getOrDefault("format", "msgpack")))
+
+	args := lokiwrite.Arguments{
+		Endpoints: []lokiwrite.EndpointOptions{c.endpointOptions(s, "80", "/")},
+	}
+	return &output{
+		block: common.NewBlockWithOverride([]string{"loki", "write"}, label, args),
+	}
+}
+
+// endpointOptions converts the properties of the HTTP client of the output s.
+func (c *converter) endpointOptions(s *section, defaultPort string, defaultURI string) lokiwrite.EndpointOptions {
+	tls := parseBool(s, "tls", false)
+	u := url.URL{
+		Scheme: "http",
+		Host:   net.JoinHostPort(s.getOrDefault("host", "127.0.0.1"), s.getOrDefault("port", defaultPort)),
+		Path:   s.getOrDefault("uri", defaultURI),
+	}
+	if tls {
+		u.Scheme = "https"
+	}
+
+	endpoint := lokiwrite.GetDefaultEndpointOptions()
+	endpoint.URL = u.String()
+
+	if user := s.get("http_user"); user != "" {
+		endpoint.HTTPClientConfig.BasicAuth = &commoncfg.BasicAuth{
+			Username: user,
+			Password: rivertypes.Secret(s.get("http_passwd")),
+		}
+	}
+	if token := s.get("bearer_token"); token != "" {
+		endpoint.HTTPClientConfig.BearerToken = rivertypes.Secret(token)
+	}
+	if tls {
+		endpoint.HTTPClientConfig.TLSConfig = commoncfg.TLSConfig{
+			CAFile:             s.get("tls.ca_file"),
+			CertFile:           s.get("tls.crt_file"),
+			KeyFile:            s.get("tls.key_file"),
+			ServerName:         s.get("tls.vhost"),
+			InsecureSkipVerify: !parseBool(s, "tls.verify", true),
+		}
+	}
+
+	for _, header := range s.getAll("header") {
+		name, value, _ := strings.Cut(header, " ")
+		if endpoint.Headers == nil {
+			endpoint.Headers = map[string]string{}
+		}
+		endpoint.Headers[name] = strings.TrimSpace(value)
+	}
+	return endpoint
+}
+
+// labelSource returns the source of a label set from the record key, which
+// is empty if it's the name of the label.
+func labelSource(name string, key string) *string {
+	if name == key {
+		key = ""
+	}
+	return &key
+}
@@ -0,0 +1,172 @@
+package fluentbitconvert
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/agent/internal/component/loki/process/stages"
+	"github.com/grafana/agent/internal/converter/diag"
+	"github.com/grafana/agent/internal/converter/internal/common"
+)
+
+// builtinParsers are the parsers defined in the parsers.conf file shipped
+// with Fluent Bit, which most configurations load.
+const builtinParsers = `
+[PARSER]
+    Name        apache
+    Format      regex
+    Regex       ^(?<host>[^ ]*) [^ ]* (?<user>[^ ]*) \[(?<time>[^\]]*)\] "(?<method>\S+)(?: +(?<path>[^\"]*?)(?: +\S*)?)?" (?<code>[^ ]*) (?<size>[^ ]*)(?: "(?<referer>[^\"]*)" "(?<agent>[^\"]*)")?$
+    Time_Key    time
+    Time_Format %d/%b/%Y:%H:%M:%S %z
+
+[PARSER]
+    Name        nginx
+    Format      regex
+    Regex       ^(?<remote>[^ ]*) (?<host>[^ ]*) (?<user>[^ ]*) \[(?<time>[^\]]*)\] "(?<method>\S+)(?: +(?<path>[^\"]*?)(?: +\S*)?)?" (?<code>[^ ]*) (?<size>[^ ]*)(?: "(?<referer>[^\"]*)" "(?<agent>[^\"]*)")
+    Time_Key    time
+    Time_Format %d/%b/%Y:%H:%M:%S %z
+
+[PARSER]
+    Name        json
+    Format      json
+    Time_Key    time
+    Time_Format %d/%b/%Y:%H:%M:%S %z
+
+[PARSER]
+    Name        logfmt
+    Format      logfmt
+
+[PARSER]
+    Name        docker
+    Format      json
+    Time_Key    time
+    Time_Format %Y-%m-%dT%H:%M:%S.%L
+    Time_Keep   On
+
+[PARSER]
+    Name        cri
+    Format      regex
+    Regex       ^(?<time>[^ ]+) (?<stream>stdout|stderr) (?<logtag>[^ ]*) (?<message>.*)$
+    Time_Key    time
+    Time_Format %Y-%m-%dT%H:%M:%S.%L%z
+
+[PARSER]
+    Name        syslog-rfc5424
+    Format      regex
+    Regex       ^\<(?<pri>[0-9]{1,5})\>1 (?<time>[^ ]+) (?<host>[^ ]+) (?<ident>[^ ]+) (?<pid>[-0-9]+) (?<msgid>[^ ]+) (?<extradata>(\[(.*?)\]|-)) (?<message>.+)$
+    Time_Key    time
+    Time_Format %Y-%m-%dT%H:%M:%S.%L%z
+    Time_Keep   On
+
+[PARSER]
+    Name        syslog-rfc3164
+    Format      regex
+    Regex       ^\<(?<pri>[0-9]+)\>(?<time>[^ ]* {1,2}[^ ]* [^ ]*) (?<host>[^ ]*) (?<ident>[a-zA-Z0-9_\/\.\-]*)(?:\[(?<pid>[0-9]+)\])?(?:[^\:]*\:)? *(?<message>.*)$
+    Time_Key    time
+    Time_Format %b %d %H:%M:%S
+    Time_Keep   On
+`
+
+var builtinParserSections = func() map[string]*section {
+	sections, err := parseClassicConfig([]byte(builtinParsers), &diag.Diagnostics{})
+	if err != nil {
+		panic(err)
+	}
+	res := map[string]*section{}
+	for _, s := range sections {
+		res[s.get("name")] = s
+	}
+	return res
+}()
+
+// parserStages returns the stages parsing the extracted field source, or the
+// log line if source is empty, with the parser name.
+func (c *converter) parserStages(name string, source string) []stages.StageConfig {
+	s, ok := c.cfg.parsers[name]
+	if !ok {
+		s, ok = builtinParserSections[name]
+		if !ok {
+			c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("undefined parser %q", name))
+			return nil
+		}
+
+		// The docker and cri parsers of the log line are converted to their
+		// stages, which also set the timestamp and the stream of the entries.
+		if source == "" {
+			switch name {
+			case "docker":
+				return []stages.StageConfig{{DockerConfig: &stages.DockerConfig{}}}
+			case "cri":
+				cri := stages.DefaultCRIConfig
+				return []stages.StageConfig{{CRIConfig: &cri}}
+			}
+		}
+	}
+
+	if !c.checkedParsers[name] {
+		c.checkedParsers[name] = true
+		c.checkParserProperties(s)
+	}
+
+	var sourcePtr *string
+	if source != "" {
+		sourcePtr = &source
+	}
+
+	var res []stages.StageConfig
+	switch format := strings.ToLower(s.get("format")); format {
+	case "regex":
+		expression := s.get("regex")
+		if _, err := regexp.Compile(expression); err != nil {
+			c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("failed to convert the regex of the parser %q: %s", name, err))
+			return nil
+		}
+		res = append(res, stages.StageConfig{RegexConfig: &stages.RegexConfig{
+			Expression: expression,
+			Source:     sourcePtr,
+		}})
+	case "json":
+		res = append(res, stages.StageConfig{JSONConfig: &stages.JSONConfig{
+			Expressions: map[string]string{common.ExtractAll: ""},
+			Source:      sourcePtr,
+		}})
+	case "logfmt":
+		res = append(res, stages.StageConfig{LogfmtConfig: &stages.LogfmtConfig{
+			Mapping: map[string]string{common.ExtractAll: ""},
+			Source:  source,
+		}})
+	default:
+		c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported format %q of the parser %q", format, name))
+		return nil
+	}
+
+	if timeFormat := s.get("time_format"); timeFormat != "" {
+		layout, err := common.StrftimeToGoLayout(timeFormat)
+		if err != nil {
+			c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("failed to convert the time format of the parser %q: %s", name, err))
+			return res
+		}
+		res = append(res, stages.StageConfig{TimestampConfig: &stages.TimestampConfig{
+			Source: s.getOrDefault("time_key", "time"),
+			Format: layout,
+		}})
+	}
+	return res
+}
+
+// checkParserProperties reports the properties of the parser s which aren't
+// supported.
+func (c *converter) checkParserProperties(s *section) {
+	for _, p := range s.props {
+		switch {
+		case p.key == "name", p.key == "format", p.key == "regex", p.key == "time_key",
+			p.key == "time_format", p.key == "time_keep", p.key == "time_strict",
+			p.key == "skip_empty_values", p.key == "types":
+		case strings.HasPrefix(p.key, "decode_field"):
+			c.diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("the %s property of the parser %q isn't converted, the decoded fields must be parsed with additional stages", p.key, s.get("name")))
+		default:
+			c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported property %q of the parser %q", p.key, s.get("name")))
+		}
+	}
+}
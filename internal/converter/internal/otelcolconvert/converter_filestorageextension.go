@@ -0,0 +1,62 @@
+package otelcolconvert
+
+import (
+	"fmt"
+
+	"github.com/grafana/agent/internal/component/otelcol/storage/file"
+	"github.com/grafana/agent/internal/converter/diag"
+	"github.com/grafana/agent/internal/converter/internal/common"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/storage/filestorage"
+	"go.opentelemetry.io/collector/component"
+)
+
+func init() {
+	converters = append(converters, fileStorageExtensionConverter{})
+}
+
+type fileStorageExtensionConverter struct{}
+
+func (fileStorageExtensionConverter) Factory() component.Factory {
+	return filestorage.NewFactory()
+}
+
+func (fileStorageExtensionConverter) InputComponentName() string { return "otelcol.storage.file" }
+
+func (fileStorageExtensionConverter) ConvertAndAppend(state *State, id component.InstanceID, cfg component.Config) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	label := state.FlowComponentLabel()
+
+	args := toFileStorageExtension(cfg.(*filestorage.Config))
+	block := common.NewBlockWithOverride([]string{"otelcol", "storage", "file"}, label, args)
+
+	diags.Add(
+		diag.SeverityLevelInfo,
+		fmt.Sprintf("Converted %s into %s", StringifyInstanceID(id), StringifyBlock(block)),
+	)
+
+	state.Body().AppendBlock(block)
+	return diags
+}
+
+func toFileStorageExtension(cfg *filestorage.Config) *file.Arguments {
+	args := &file.Arguments{
+		Directory: cfg.Directory,
+		Timeout:   cfg.Timeout,
+		FSync:     cfg.FSync,
+	}
+	args.Compaction.SetToDefault()
+
+	if cfg.Compaction != nil {
+		args.Compaction = file.CompactionArguments{
+			OnStart:                    cfg.Compaction.OnStart,
+			OnRebound:                  cfg.Compaction.OnRebound,
+			Directory:                  cfg.Compaction.Directory,
+			ReboundNeededThresholdMiB:  cfg.Compaction.ReboundNeededThresholdMiB,
+			ReboundTriggerThresholdMiB: cfg.Compaction.ReboundTriggerThresholdMiB,
+			MaxTransactionSize:         cfg.Compaction.MaxTransactionSize,
+			CheckInterval:              cfg.Compaction.CheckInterval,
+		}
+	}
+	return args
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/grafana/agent/internal/component/otelcol"
 	"github.com/grafana/agent/internal/component/otelcol/auth"
 	"github.com/grafana/agent/internal/component/otelcol/exporter/otlp"
+	"github.com/grafana/agent/internal/component/otelcol/storage"
 	"github.com/grafana/agent/internal/converter/diag"
 	"github.com/grafana/agent/internal/converter/internal/common"
 	"go.opentelemetry.io/collector/component"
@@ -40,6 +41,9 @@ func (otlpExporterConverter) ConvertAndAppend(state *State, id component.Instanc
 		case auth.Handler:
 			ext := state.LookupExtension(cfg.(*otlpexporter.Config).Auth.AuthenticatorID)
 			return common.CustomTokenizer{Expr: fmt.Sprintf("%s.%s.handler", strings.Join(ext.Name, "."), ext.Label)}
+		case storage.Handler:
+			ext := state.LookupExtension(*cfg.(*otlpexporter.Config).QueueConfig.StorageID)
+			return common.CustomTokenizer{Expr: fmt.Sprintf("%s.%s.handler", strings.Join(ext.Name, "."), ext.Label)}
 		}
 		return val
 	}
@@ -70,10 +74,16 @@ func toOtelcolExporterOTLP(cfg *otlpexporter.Config) *otlp.Arguments {
 }
 
 func toQueueArguments(cfg exporterhelper.QueueSettings) otelcol.QueueArguments {
+	var s *storage.Handler
+	if cfg.StorageID != nil {
+		s = &storage.Handler{}
+	}
+
 	return otelcol.QueueArguments{
 		Enabled:      cfg.Enabled,
 		NumConsumers: cfg.NumConsumers,
 		QueueSize:    cfg.QueueSize,
+		Storage:      s,
 	}
 }
 
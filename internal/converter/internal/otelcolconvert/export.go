@@ -0,0 +1,340 @@
+package otelcolconvert
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	flowcomponent "github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/component/otelcol"
+	"github.com/grafana/agent/internal/component/otelcol/auth"
+	"github.com/grafana/agent/internal/component/otelcol/storage"
+	"github.com/grafana/agent/internal/converter/diag"
+	"github.com/grafana/river/ast"
+	"github.com/grafana/river/parser"
+	"github.com/grafana/river/vm"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/connector"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/extension"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/processor"
+	"go.opentelemetry.io/collector/receiver"
+	"gopkg.in/yaml.v3"
+)
+
+// Export implements a Grafana Agent Flow config exporter, which converts the
+// otelcol components of a Flow config into an equivalent OpenTelemetry
+// Collector config.
+//
+// Only otelcol components which can be converted from an OpenTelemetry
+// Collector config are supported, and other components are reported as
+// diagnostics. Calls to the env function are exported as references to
+// environment variables which are expanded by the OpenTelemetry Collector.
+func Export(in []byte) ([]byte, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	file, err := parser.ParseFile("", in)
+	if err != nil {
+		diags.Add(diag.SeverityLevelCritical, fmt.Sprintf("failed to parse Flow config: %s", err))
+		return nil, diags
+	}
+
+	e := newExporter(&diags)
+	e.collect(file.Body)
+	e.evaluate()
+
+	cfg := e.config()
+	if cfg.empty() {
+		return nil, diags
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg); err != nil {
+		diags.Add(diag.SeverityLevelCritical, fmt.Sprintf("failed to render OpenTelemetry Collector config: %s", err))
+		return nil, diags
+	}
+	return buf.Bytes(), diags
+}
+
+// exportFactory is the OpenTelemetry Collector component factory of a Flow
+// component.
+type exportFactory struct {
+	kind    component.Kind
+	factory component.Factory
+}
+
+// exportFactories maps the names of the Flow components which can be
+// exported to their OpenTelemetry Collector factories.
+func exportFactories() map[string]exportFactory {
+	res := make(map[string]exportFactory, len(converters))
+	for _, converter := range converters {
+		var (
+			fact = converter.Factory()
+			kind component.Kind
+		)
+		switch fact.(type) {
+		case receiver.Factory:
+			kind = component.KindReceiver
+		case processor.Factory:
+			kind = component.KindProcessor
+		case exporter.Factory:
+			kind = component.KindExporter
+		case extension.Factory:
+			kind = component.KindExtension
+		case connector.Factory:
+			kind = component.KindConnector
+		default:
+			panic(fmt.Sprintf("unknown component factory type %T", fact))
+		}
+
+		// Receivers don't receive data from other components, but they follow
+		// the same naming convention.
+		name := converter.InputComponentName()
+		if name == "" && kind == component.KindReceiver {
+			name = "otelcol.receiver." + string(fact.Type())
+		}
+		if name != "" {
+			res[name] = exportFactory{kind: kind, factory: fact}
+		}
+	}
+	return res
+}
+
+// exportComponent is an otelcol component of the Flow config being
+// exported.
+type exportComponent struct {
+	block   *ast.BlockStmt
+	name    string
+	factory *exportFactory // nil if the component can't be exported.
+	id      component.ID
+
+	// consumer identifies the component in the arguments of the components
+	// sending data to it.
+	consumer *exportConsumer
+	// cfg is the encoded OpenTelemetry Collector config, which is nil until
+	// the arguments of the component are evaluated.
+	cfg  map[string]interface{}
+	next *otelcol.ConsumerArguments
+	// connectorType is the type of connector components, which determines the
+	// telemetry signal they receive.
+	connectorType int
+}
+
+// String returns the Flow ID of the component.
+func (c *exportComponent) String() string {
+	return fmt.Sprintf("%s.%s", c.name, c.block.Label)
+}
+
+// exportConsumer is the consumer exported by otelcol components in the scope
+// used to evaluate the arguments of other components. It only identifies the
+// component and never consumes data.
+type exportConsumer struct {
+	component *exportComponent
+}
+
+var _ otelcol.Consumer = (*exportConsumer)(nil)
+
+func (*exportConsumer) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{}
+}
+
+func (*exportConsumer) ConsumeTraces(context.Context, ptrace.Traces) error    { return nil }
+func (*exportConsumer) ConsumeMetrics(context.Context, pmetric.Metrics) error { return nil }
+func (*exportConsumer) ConsumeLogs(context.Context, plog.Logs) error          { return nil }
+
+type flowExporter struct {
+	diags      *diag.Diagnostics
+	factories  map[string]exportFactory
+	components []*exportComponent
+	// reported are the diagnostics of the pipelines, which are found once per
+	// receiver.
+	reported map[string]bool
+}
+
+func newExporter(diags *diag.Diagnostics) *flowExporter {
+	return &flowExporter{
+		diags:     diags,
+		factories: exportFactories(),
+		reported:  map[string]bool{},
+	}
+}
+
+// reportOnce adds the diagnostic message unless it was already reported.
+func (e *flowExporter) reportOnce(severity diag.Severity, message string) {
+	if e.reported[message] {
+		return
+	}
+	e.reported[message] = true
+	e.diags.Add(severity, message)
+}
+
+// configBlocks are the blocks of Flow configs which configure Grafana Agent
+// Flow itself rather than components.
+var configBlocks = map[string]bool{
+	"logging": true,
+	"tracing": true,
+	"http":    true,
+}
+
+// collect collects the otelcol components of body, and reports the other
+// statements.
+func (e *flowExporter) collect(body ast.Body) {
+	for _, stmt := range body {
+		block, ok := stmt.(*ast.BlockStmt)
+		if !ok {
+			e.diags.Add(diag.SeverityLevelError, "unsupported top-level attribute, only otelcol components are exported")
+			continue
+		}
+
+		name := strings.Join(block.Name, ".")
+		switch {
+		case configBlocks[name]:
+			e.diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("the %s block isn't exported, configure the OpenTelemetry Collector service instead", name))
+			continue
+		case !strings.HasPrefix(name, "otelcol."):
+			e.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported component %s, only otelcol components are exported", flowID(block)))
+			continue
+		}
+
+		c := &exportComponent{block: block, name: name}
+		c.consumer = &exportConsumer{component: c}
+		if fact, ok := e.factories[name]; ok {
+			c.factory = &fact
+			c.id = component.NewIDWithName(fact.factory.Type(), block.Label)
+			if block.Label == "default" {
+				c.id = component.NewID(fact.factory.Type())
+			}
+		} else {
+			e.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported component %s, it has no OpenTelemetry Collector equivalent", c))
+		}
+		e.components = append(e.components, c)
+	}
+}
+
+// evaluate evaluates the arguments of the components which can be exported
+// and converts them to OpenTelemetry Collector configs.
+func (e *flowExporter) evaluate() {
+	scope := e.scope()
+	for _, c := range e.components {
+		if c.factory == nil {
+			continue
+		}
+		reg, ok := flowcomponent.Get(c.name)
+		if !ok {
+			e.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported component %s, it isn't registered", c))
+			c.factory = nil
+			continue
+		}
+
+		args := reg.CloneArguments()
+		if err := vm.New(c.block.Body).Evaluate(scope, args); err != nil {
+			e.diags.Add(diag.SeverityLevelError, fmt.Sprintf("failed to evaluate the arguments of %s: %s", c, err))
+			c.factory = nil
+			continue
+		}
+
+		converter, ok := args.(interface {
+			Convert() (component.Config, error)
+		})
+		if !ok {
+			// Storage extensions create their directories when their arguments
+			// are converted.
+			e.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported component %s, its arguments can't be converted outside of Grafana Agent Flow", c))
+			c.factory = nil
+			continue
+		}
+		cfg, err := converter.Convert()
+		if err != nil {
+			e.diags.Add(diag.SeverityLevelError, fmt.Sprintf("failed to convert the arguments of %s: %s", c, err))
+			c.factory = nil
+			continue
+		}
+
+		var exact bool
+		if c.cfg, exact = minimalConfig(c.factory.factory, cfg); !exact {
+			e.diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("the exported config of %s may differ from its arguments, since some of them can't be represented in YAML", c))
+		}
+		if err := component.ValidateConfig(cfg); err != nil {
+			e.diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("the exported config of %s isn't valid in the OpenTelemetry Collector: %s", c, err))
+		}
+		if next, ok := args.(interface {
+			NextConsumers() *otelcol.ConsumerArguments
+		}); ok {
+			c.next = next.NextConsumers()
+		}
+		if conn, ok := args.(interface{ ConnectorType() int }); ok {
+			c.connectorType = conn.ConnectorType()
+		}
+
+		e.diags.Add(diag.SeverityLevelInfo, fmt.Sprintf("Exported %s into %s/%s", c, StringifyKind(c.factory.kind), c.id))
+	}
+}
+
+// scope returns the scope used to evaluate the arguments of components. It
+// contains the exports of the otelcol components, and replaces the env
+// function so that environment variables are expanded by the OpenTelemetry
+// Collector.
+func (e *flowExporter) scope() *vm.Scope {
+	vars := map[string]interface{}{
+		"env": func(name string) string {
+			return fmt.Sprintf("${env:%s}", name)
+		},
+	}
+
+	for _, c := range e.components {
+		// Most otelcol components export a consumer, which is assumed for the
+		// components which aren't registered.
+		exportsType := reflect.TypeOf(otelcol.ConsumerExports{})
+		if reg, ok := flowcomponent.Get(c.name); ok {
+			if reg.Exports == nil {
+				continue
+			}
+			exportsType = reflect.TypeOf(reg.Exports)
+		}
+
+		exports := reflect.New(exportsType).Elem()
+		for i := 0; i < exports.NumField(); i++ {
+			field := exports.Field(i)
+			if !field.CanSet() {
+				continue
+			}
+			switch field.Type() {
+			case reflect.TypeOf((*otelcol.Consumer)(nil)).Elem():
+				field.Set(reflect.ValueOf(c.consumer))
+			case reflect.TypeOf(auth.Handler{}):
+				field.Set(reflect.ValueOf(auth.Handler{ID: c.id}))
+			case reflect.TypeOf(storage.Handler{}):
+				field.Set(reflect.ValueOf(storage.Handler{ID: c.id}))
+			}
+		}
+
+		m := vars
+		for _, part := range c.block.Name {
+			next, ok := m[part].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				m[part] = next
+			}
+			m = next
+		}
+		m[c.block.Label] = exports.Interface()
+	}
+
+	return &vm.Scope{Variables: vars}
+}
+
+func flowID(block *ast.BlockStmt) string {
+	name := strings.Join(block.Name, ".")
+	if block.Label == "" {
+		return name
+	}
+	return fmt.Sprintf("%s.%s", name, block.Label)
+}
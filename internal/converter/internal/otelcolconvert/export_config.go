@@ -0,0 +1,493 @@
+package otelcolconvert
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/grafana/agent/internal/component/otelcol"
+	flowconnector "github.com/grafana/agent/internal/component/otelcol/connector"
+	"github.com/grafana/agent/internal/converter/diag"
+	"github.com/grafana/agent/internal/converter/internal/common"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configopaque"
+	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/processor"
+)
+
+// exportedConfig is an OpenTelemetry Collector config. Its fields are
+// ordered like in the OpenTelemetry Collector documentation.
+type exportedConfig struct {
+	Receivers  map[string]interface{} `yaml:"receivers,omitempty"`
+	Processors map[string]interface{} `yaml:"processors,omitempty"`
+	Exporters  map[string]interface{} `yaml:"exporters,omitempty"`
+	Connectors map[string]interface{} `yaml:"connectors,omitempty"`
+	Extensions map[string]interface{} `yaml:"extensions,omitempty"`
+	Service    exportedService        `yaml:"service"`
+}
+
+type exportedService struct {
+	Extensions []string                     `yaml:"extensions,omitempty"`
+	Pipelines  map[string]*exportedPipeline `yaml:"pipelines"`
+}
+
+type exportedPipeline struct {
+	Receivers  []string `yaml:"receivers"`
+	Processors []string `yaml:"processors,omitempty"`
+	Exporters  []string `yaml:"exporters"`
+}
+
+func (cfg *exportedConfig) empty() bool {
+	return len(cfg.Receivers) == 0 && len(cfg.Processors) == 0 && len(cfg.Exporters) == 0 &&
+		len(cfg.Connectors) == 0 && len(cfg.Extensions) == 0
+}
+
+// config returns the OpenTelemetry Collector config of the exported
+// components.
+func (e *flowExporter) config() *exportedConfig {
+	cfg := &exportedConfig{
+		Service: exportedService{Pipelines: e.pipelines()},
+	}
+
+	for _, c := range e.components {
+		if c.cfg == nil {
+			continue
+		}
+
+		var section *map[string]interface{}
+		switch c.factory.kind {
+		case component.KindReceiver:
+			section = &cfg.Receivers
+		case component.KindProcessor:
+			section = &cfg.Processors
+		case component.KindExporter:
+			section = &cfg.Exporters
+		case component.KindConnector:
+			section = &cfg.Connectors
+		case component.KindExtension:
+			section = &cfg.Extensions
+			cfg.Service.Extensions = append(cfg.Service.Extensions, c.id.String())
+		}
+		if *section == nil {
+			*section = map[string]interface{}{}
+		}
+		(*section)[c.id.String()] = c.cfg
+	}
+
+	if !cfg.empty() && len(cfg.Service.Pipelines) == 0 {
+		e.diags.Add(diag.SeverityLevelError, "the exported config has no pipelines, no otelcol receiver sends data to an otelcol exporter")
+	}
+	return cfg
+}
+
+// exportedPath is a path from a receiver or connector to an exporter or
+// connector through a chain of processors.
+type exportedPath struct {
+	processors []*exportComponent
+	exporter   *exportComponent
+}
+
+// pipelines returns the pipelines of the exported components.
+//
+// Flow components form a graph, while OpenTelemetry Collector pipelines
+// connect a set of receivers to a set of exporters through a single chain of
+// processors. A pipeline is created for each chain of processors and set of
+// exporters the receivers send data through, so processors which send data
+// to multiple components appear in multiple pipelines.
+func (e *flowExporter) pipelines() map[string]*exportedPipeline {
+	res := map[string]*exportedPipeline{}
+
+	for _, signal := range []component.DataType{component.DataTypeMetrics, component.DataTypeLogs, component.DataTypeTraces} {
+		type pipeline struct {
+			receivers  []*exportComponent
+			processors []*exportComponent
+			exporters  []*exportComponent
+		}
+		var (
+			keys  []string
+			byKey = map[string]*pipeline{}
+		)
+
+		for _, c := range e.components {
+			if c.cfg == nil || c.next == nil {
+				continue
+			}
+			if kind := c.factory.kind; kind != component.KindReceiver && kind != component.KindConnector {
+				continue
+			}
+
+			// Group the paths of the receiver by chain of processors.
+			var (
+				chains     []string
+				processors = map[string][]*exportComponent{}
+				exporters  = map[string][]*exportComponent{}
+			)
+			for _, path := range e.paths(signal, nil, nextConsumers(c.next, signal)) {
+				chain := joinIDs(path.processors)
+				if _, ok := processors[chain]; !ok {
+					chains = append(chains, chain)
+					processors[chain] = path.processors
+				}
+				if !containsComponent(exporters[chain], path.exporter) {
+					exporters[chain] = append(exporters[chain], path.exporter)
+				}
+			}
+
+			for _, chain := range chains {
+				key := chain + "|" + joinIDs(exporters[chain])
+				p, ok := byKey[key]
+				if !ok {
+					p = &pipeline{processors: processors[chain], exporters: exporters[chain]}
+					byKey[key] = p
+					keys = append(keys, key)
+				}
+				p.receivers = append(p.receivers, c)
+			}
+		}
+
+		names := map[string]bool{}
+		for _, key := range keys {
+			p := byKey[key]
+
+			// Pipelines are named after their first processor or exporter if
+			// there are multiple pipelines of the signal.
+			id := string(signal)
+			if len(keys) > 1 {
+				first := p.exporters[0]
+				if len(p.processors) > 0 {
+					first = p.processors[0]
+				}
+				label := strings.ReplaceAll(first.id.String(), "/", "_")
+				name := label
+				for i := 1; names[name]; i++ {
+					name = common.LabelWithIndex(i, label)
+				}
+				names[name] = true
+				id += "/" + name
+			}
+
+			res[id] = &exportedPipeline{
+				Receivers:  componentIDs(p.receivers),
+				Processors: componentIDs(p.processors),
+				Exporters:  componentIDs(p.exporters),
+			}
+		}
+	}
+
+	return res
+}
+
+// paths returns the paths of signal from the consumers, which follow the
+// chain of processors.
+func (e *flowExporter) paths(signal component.DataType, processors []*exportComponent, consumers []otelcol.Consumer) []exportedPath {
+	var res []exportedPath
+	for _, consumer := range consumers {
+		ec, ok := consumer.(*exportConsumer)
+		if !ok || ec.component.cfg == nil {
+			// Components which can't be exported are already reported.
+			continue
+		}
+
+		c := ec.component
+		if !supportsSignal(c.factory.factory, signal) {
+			e.reportOnce(diag.SeverityLevelWarn, fmt.Sprintf("%s doesn't support %s, which aren't exported to it", c, signal))
+			continue
+		}
+		switch c.factory.kind {
+		case component.KindProcessor:
+			if c.next == nil {
+				continue
+			}
+			chain := append(append([]*exportComponent{}, processors...), c)
+			res = append(res, e.paths(signal, chain, nextConsumers(c.next, signal))...)
+		case component.KindConnector:
+			if input := connectorInput(c.connectorType); input != signal {
+				e.reportOnce(diag.SeverityLevelError, fmt.Sprintf("%s receives %s, but %s are sent to it", c, input, signal))
+				continue
+			}
+			res = append(res, exportedPath{processors: processors, exporter: c})
+		case component.KindExporter:
+			res = append(res, exportedPath{processors: processors, exporter: c})
+		}
+	}
+	return res
+}
+
+func nextConsumers(next *otelcol.ConsumerArguments, signal component.DataType) []otelcol.Consumer {
+	switch signal {
+	case component.DataTypeMetrics:
+		return next.Metrics
+	case component.DataTypeLogs:
+		return next.Logs
+	case component.DataTypeTraces:
+		return next.Traces
+	default:
+		panic(fmt.Sprintf("otelcolconvert: unknown data type %q", signal))
+	}
+}
+
+// supportsSignal returns whether the processor or exporter factory fact
+// supports signal. Other components are assumed to support it.
+func supportsSignal(fact component.Factory, signal component.DataType) bool {
+	var stability map[component.DataType]component.StabilityLevel
+	switch fact := fact.(type) {
+	case processor.Factory:
+		stability = map[component.DataType]component.StabilityLevel{
+			component.DataTypeMetrics: fact.MetricsProcessorStability(),
+			component.DataTypeLogs:    fact.LogsProcessorStability(),
+			component.DataTypeTraces:  fact.TracesProcessorStability(),
+		}
+	case exporter.Factory:
+		stability = map[component.DataType]component.StabilityLevel{
+			component.DataTypeMetrics: fact.MetricsExporterStability(),
+			component.DataTypeLogs:    fact.LogsExporterStability(),
+			component.DataTypeTraces:  fact.TracesExporterStability(),
+		}
+	default:
+		return true
+	}
+	return stability[signal] != component.StabilityLevelUndefined
+}
+
+// connectorInput returns the signal received by connectors of the connector
+// type t.
+func connectorInput(t int) component.DataType {
+	switch t {
+	case flowconnector.ConnectorTracesToTraces, flowconnector.ConnectorTracesToMetrics, flowconnector.ConnectorTracesToLogs:
+		return component.DataTypeTraces
+	case flowconnector.ConnectorMetricsToTraces, flowconnector.ConnectorMetricsToMetrics, flowconnector.ConnectorMetricsToLogs:
+		return component.DataTypeMetrics
+	default:
+		return component.DataTypeLogs
+	}
+}
+
+func containsComponent(components []*exportComponent, c *exportComponent) bool {
+	for _, other := range components {
+		if other == c {
+			return true
+		}
+	}
+	return false
+}
+
+func componentIDs(components []*exportComponent) []string {
+	res := make([]string, 0, len(components))
+	for _, c := range components {
+		res = append(res, c.id.String())
+	}
+	return res
+}
+
+func joinIDs(components []*exportComponent) string {
+	return strings.Join(componentIDs(components), ",")
+}
+
+var opaqueType = reflect.TypeOf(configopaque.String(""))
+
+// encodeConfig encodes the OpenTelemetry Collector config v to a map
+// following its mapstructure tags. Unlike confmap, opaque values aren't
+// redacted.
+func encodeConfig(v reflect.Value) map[string]interface{} {
+	m, _ := encodeValue(v).(map[string]interface{})
+	if m == nil {
+		m = map[string]interface{}{}
+	}
+	return m
+}
+
+func encodeValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	switch v.Type() {
+	case opaqueType:
+		return v.String()
+	}
+	if v.Kind() != reflect.Pointer && v.Kind() != reflect.Interface {
+		if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+			text, err := m.MarshalText()
+			if err == nil {
+				return string(text)
+			}
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return encodeValue(v.Elem())
+
+	case reflect.Struct:
+		res := map[string]interface{}{}
+		encodeStruct(v, res)
+		if len(res) == 0 {
+			return nil
+		}
+		return res
+
+	case reflect.Map:
+		if v.Len() == 0 {
+			return nil
+		}
+		res := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			res[fmt.Sprint(encodeValue(iter.Key()))] = encodeValue(iter.Value())
+		}
+		return res
+
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			return nil
+		}
+		res := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			res = append(res, encodeValue(v.Index(i)))
+		}
+		return res
+
+	default:
+		return v.Interface()
+	}
+}
+
+func encodeStruct(v reflect.Value, res map[string]interface{}) {
+	for i := 0; i < v.NumField(); i++ {
+		// The exported fields of unexported embedded structs are decoded too.
+		field := v.Type().Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "-" {
+			continue
+		}
+		fv := v.Field(i)
+		if strings.Contains(opts, "squash") || (field.Anonymous && name == "") {
+			for fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					break
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				encodeStruct(fv, res)
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if value := encodeValue(fv); value != nil {
+			res[name] = value
+		}
+	}
+}
+
+// maxSectionDepth is the maximum depth of the sections of the configs of
+// components.
+const maxSectionDepth = 8
+
+// minimalConfig encodes cfg without the values equal to the default config of
+// factory. Some components detect disabled sections from their sibling
+// sections being set, so sections equal to their default are kept as empty
+// maps, from the top of the config down, until the config decodes the same.
+// It returns false if no encoding of the config decodes the same.
+func minimalConfig(factory component.Factory, cfg component.Config) (map[string]interface{}, bool) {
+	var (
+		full     = encodeConfig(reflect.ValueOf(cfg))
+		def      = encodeConfig(reflect.ValueOf(factory.CreateDefaultConfig()))
+		expected = pruneDefaults(copyMap(full), def, maxSectionDepth)
+	)
+	for depth := 0; depth <= maxSectionDepth; depth++ {
+		m := pruneDefaults(copyMap(full), def, depth)
+		if decodesTo(factory, m, def, expected) {
+			return m, true
+		}
+	}
+	return pruneDefaults(copyMap(full), def, 0), false
+}
+
+// decodesTo returns whether the encoded config m is decoded by the
+// OpenTelemetry Collector to the config whose values which differ from the
+// default config def are expected.
+func decodesTo(factory component.Factory, m map[string]interface{}, def map[string]interface{}, expected map[string]interface{}) bool {
+	cfg := factory.CreateDefaultConfig()
+	if err := component.UnmarshalConfig(confmap.NewFromStringMap(copyMap(m)), cfg); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(pruneDefaults(encodeConfig(reflect.ValueOf(cfg)), def, maxSectionDepth), expected)
+}
+
+// pruneDefaults removes the values of cfg which are equal to the values of
+// the default config def. Zero values which aren't set in def are removed
+// too, since they're decoded the same. Sections which only contain default
+// values are kept as empty maps up to keepDepth levels deep.
+func pruneDefaults(cfg map[string]interface{}, def map[string]interface{}, keepDepth int) map[string]interface{} {
+	for key, value := range cfg {
+		defValue := def[key]
+
+		m, isMap := value.(map[string]interface{})
+		if isMap {
+			defMap, _ := defValue.(map[string]interface{})
+			pruneDefaults(m, defMap, keepDepth-1)
+			if len(m) == 0 && keepDepth <= 0 {
+				delete(cfg, key)
+			}
+			continue
+		}
+
+		if list, isList := value.([]interface{}); isList && !reflect.DeepEqual(value, defValue) {
+			// Sections of lists don't have defaults, so only their zero values
+			// are removed.
+			for _, elem := range list {
+				if m, ok := elem.(map[string]interface{}); ok {
+					pruneDefaults(m, nil, 0)
+				}
+			}
+			continue
+		}
+
+		if defValue == nil {
+			if _, isSlice := value.([]interface{}); !isSlice && reflect.ValueOf(value).IsZero() {
+				delete(cfg, key)
+			}
+		} else if reflect.DeepEqual(value, defValue) {
+			delete(cfg, key)
+		}
+	}
+	return cfg
+}
+
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		res[k] = copyValue(v)
+	}
+	return res
+}
+
+func copyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return copyMap(v)
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, elem := range v {
+			res[i] = copyValue(elem)
+		}
+		return res
+	default:
+		return v
+	}
+}
@@ -0,0 +1,115 @@
+package otelcolconvert_test
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grafana/agent/internal/converter/diag"
+	"github.com/grafana/agent/internal/converter/internal/otelcolconvert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExport tests exporting the Flow configs of testdata/export. The
+// exported configs are compared to the matching .yaml files, and the non-Info
+// diagnostics to the matching .diags files.
+func TestExport(t *testing.T) {
+	paths, err := filepath.Glob("testdata/export/*.river")
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+
+	for _, path := range paths {
+		path := path
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			in, err := os.ReadFile(path)
+			require.NoError(t, err)
+
+			out, diags := otelcolconvert.Export(in)
+			diags.RemoveDiagsBySeverity(diag.SeverityLevelInfo)
+
+			expectedDiags := readExpectedDiags(t, strings.TrimSuffix(path, ".river")+".diags")
+			actualDiags := make([]string, 0, len(diags))
+			for _, d := range diags {
+				actualDiags = append(actualDiags, d.String())
+			}
+			require.Equal(t, expectedDiags, actualDiags)
+
+			expected, err := os.ReadFile(strings.TrimSuffix(path, ".river") + ".yaml")
+			require.NoError(t, err)
+			require.Equal(t, string(expected), string(out))
+		})
+	}
+}
+
+// TestExportRoundTrip tests that the Flow configs converted from
+// OpenTelemetry Collector configs are converted back to the same Flow configs
+// once exported.
+func TestExportRoundTrip(t *testing.T) {
+	// Labelled components aren't tested, since their converted labels are
+	// prefixed with the name of their pipelines.
+	tt := []string{
+		"batch",
+		"inconsistent_processor",
+		"jaeger",
+		"jaegerremotesampling",
+		"k8sattributes",
+		"kafka",
+		"opencensus",
+		"otlp",
+		"otlphttp",
+		"probabilistic_sampler",
+		"servicegraph",
+		"span",
+		"span_full",
+		"spanmetrics",
+		"transform",
+		"vcenterreceiver",
+		"zipkin",
+	}
+
+	for _, name := range tt {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			in, err := os.ReadFile(filepath.Join("testdata", name+".river"))
+			require.NoError(t, err)
+
+			out, diags := otelcolconvert.Export(in)
+			require.False(t, hasErrors(diags), "failed to export the config: %s", diags.Error())
+
+			// The converter expands environment variables in the ${NAME} form.
+			out = bytes.ReplaceAll(out, []byte("${env:"), []byte("${"))
+			actual, diags := otelcolconvert.Convert(out, nil)
+			require.False(t, hasErrors(diags), "failed to convert the exported config: %s", diags.Error())
+			require.Equal(t, string(bytes.TrimSpace(in)), string(bytes.TrimSpace(actual)))
+		})
+	}
+}
+
+func readExpectedDiags(t *testing.T, path string) []string {
+	res := []string{}
+	bb, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return res
+	}
+	require.NoError(t, err)
+
+	s := bufio.NewScanner(bytes.NewReader(bb))
+	for s.Scan() {
+		if line := s.Text(); line != "" {
+			res = append(res, line)
+		}
+	}
+	return res
+}
+
+func hasErrors(diags diag.Diagnostics) bool {
+	for _, d := range diags {
+		if d.Severity >= diag.SeverityLevelError {
+			return true
+		}
+	}
+	return false
+}
@@ -37,6 +37,7 @@ func toRelabelArguments(relabelConfigs []*prom_relabel.Config, forwardTo []stora
 		ForwardTo:            forwardTo,
 		MetricRelabelConfigs: ToFlowRelabelConfigs(relabelConfigs),
 		CacheSize:            100_000,
+		CacheTTL:             relabel.DefaultArguments.CacheTTL,
 	}
 }
 
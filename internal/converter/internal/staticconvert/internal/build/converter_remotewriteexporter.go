@@ -98,5 +98,6 @@ func toremotewriteexporterConfig(cfg *remotewriteexporter.Config, forwardTo []st
 		ForwardTo:                     forwardTo,
 		AddMetricSuffixes:             defaultArgs.AddMetricSuffixes,
 		ResourceToTelemetryConversion: defaultArgs.ResourceToTelemetryConversion,
+		DeltaToCumulativeTTL:          defaultArgs.DeltaToCumulativeTTL,
 	}
 }
@@ -0,0 +1,238 @@
+package vectorconvert
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/grafana/agent/internal/converter/diag"
+	"gopkg.in/yaml.v3"
+)
+
+// component is a source, transform or sink of a Vector configuration.
+type component struct {
+	// kind is either "source", "transform" or "sink".
+	kind string
+	name string
+	opts map[string]interface{}
+}
+
+// typ returns the type of the component, such as "file".
+func (c *component) typ() string {
+	return c.getString("type")
+}
+
+// get returns the option at path, whose elements are separated by dots, or
+// nil if it isn't set.
+func (c *component) get(path string) interface{} {
+	var v interface{} = c.opts
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
+
+func (c *component) has(path string) bool {
+	return c.get(path) != nil
+}
+
+// getString returns the option at path formatted as a string.
+func (c *component) getString(path string) string {
+	return c.getStringOrDefault(path, "")
+}
+
+func (c *component) getStringOrDefault(path string, def string) string {
+	switch v := c.get(path).(type) {
+	case nil:
+		return def
+	case string:
+		return v
+	case []interface{}, map[string]interface{}:
+		return def
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// getInt returns the option at path as an integer, or def if it isn't set
+// or isn't an integer.
+func (c *component) getInt(path string, def int) int {
+	switch v := c.get(path).(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case string:
+		if i, err := strconv.Atoi(v); err == nil {
+			return i
+		}
+	}
+	return def
+}
+
+// getFloat returns the option at path as a float, or def if it isn't set or
+// isn't a number.
+func (c *component) getFloat(path string, def float64) float64 {
+	switch v := c.get(path).(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return def
+}
+
+func (c *component) getBool(path string, def bool) bool {
+	if v, ok := c.get(path).(bool); ok {
+		return v
+	}
+	return def
+}
+
+// getStrings returns the option at path as a list of strings. A single
+// string is returned as a list of one element.
+func (c *component) getStrings(path string) []string {
+	switch v := c.get(path).(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		res := make([]string, 0, len(v))
+		for _, elem := range v {
+			res = append(res, fmt.Sprint(elem))
+		}
+		return res
+	}
+	return nil
+}
+
+// getMap returns the option at path as a map of strings.
+func (c *component) getMap(path string) map[string]string {
+	m, ok := c.get(path).(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	res := make(map[string]string, len(m))
+	for k, v := range m {
+		res[k] = fmt.Sprint(v)
+	}
+	return res
+}
+
+// keys returns the sorted keys of the options at path.
+func (c *component) keys(path string) []string {
+	var m map[string]interface{}
+	if path == "" {
+		m = c.opts
+	} else {
+		m, _ = c.get(path).(map[string]interface{})
+	}
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// config is a Vector configuration. The components of each kind are sorted
+// by name.
+type config struct {
+	sources    []*component
+	transforms []*component
+	sinks      []*component
+}
+
+// topLevelOptions are the global options of Vector configurations which don't
+// affect the conversion.
+var topLevelOptions = map[string]bool{
+	"data_dir":            true,
+	"api":                 true,
+	"healthchecks":        true,
+	"acknowledgements":    true,
+	"log_schema":          true,
+	"schema":              true,
+	"timezone":            true,
+	"expire_metrics_secs": true,
+}
+
+var variableRegexp = regexp.MustCompile(`\$\{?([A-Za-z_][A-Za-z0-9_]*)`)
+
+// parseConfig parses a Vector configuration in the TOML, YAML or JSON
+// format.
+func parseConfig(in []byte, diags *diag.Diagnostics) (*config, error) {
+	// Vector interpolates environment variables before parsing the
+	// configuration.
+	reported := map[string]bool{}
+	for _, m := range variableRegexp.FindAllSubmatch(in, -1) {
+		name := string(m[1])
+		if !reported[name] {
+			reported[name] = true
+			diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("the environment variable %s isn't expanded, replace it with the env function in the converted configuration", name))
+		}
+	}
+
+	raw := map[string]interface{}{}
+	if _, tomlErr := toml.NewDecoder(bytes.NewReader(in)).Decode(&raw); tomlErr != nil {
+		// JSON configurations are also valid YAML.
+		raw = map[string]interface{}{}
+		if yamlErr := yaml.Unmarshal(in, &raw); yamlErr != nil {
+			return nil, fmt.Errorf("the configuration is neither valid TOML (%s) nor YAML (%s)", tomlErr, yamlErr)
+		}
+	}
+
+	cfg := &config{}
+	for key, value := range raw {
+		var components *[]*component
+		switch key {
+		case "sources":
+			components = &cfg.sources
+		case "transforms":
+			components = &cfg.transforms
+		case "sinks":
+			components = &cfg.sinks
+		case "secret", "enrichment_tables":
+			diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported %s option", key))
+			continue
+		default:
+			if !topLevelOptions[key] {
+				return nil, fmt.Errorf("unknown option %q", key)
+			}
+			continue
+		}
+
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s must be a map", key)
+		}
+		for name, opts := range m {
+			optsMap, ok := opts.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s %q must be a map", key, name)
+			}
+			*components = append(*components, &component{
+				kind: strings.TrimSuffix(key, "s"),
+				name: name,
+				opts: optsMap,
+			})
+		}
+	}
+
+	for _, components := range [][]*component{cfg.sources, cfg.transforms, cfg.sinks} {
+		sort.Slice(components, func(i, j int) bool {
+			return components[i].name < components[j].name
+		})
+	}
+	return cfg, nil
+}
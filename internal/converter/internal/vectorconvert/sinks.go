@@ -0,0 +1,177 @@
+package vectorconvert
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/units"
+	commoncfg "github.com/grafana/agent/internal/component/common/config"
+	"github.com/grafana/agent/internal/component/loki/process/stages"
+	lokiwrite "github.com/grafana/agent/internal/component/loki/write"
+	"github.com/grafana/agent/internal/converter/diag"
+	"github.com/grafana/agent/internal/converter/internal/common"
+	"github.com/grafana/river/rivertypes"
+	"github.com/prometheus/common/model"
+)
+
+// convertSink converts the sink comp. It returns nil if the sink isn't
+// supported.
+func (c *converter) convertSink(comp *component) *sink {
+	snk := &sink{
+		component: comp,
+		label:     c.componentLabel(comp),
+	}
+
+	switch comp.typ() {
+	case "loki":
+		c.convertLokiSink(snk)
+	case "http":
+		c.convertHTTPSink(snk)
+	default:
+		c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported sink %q of type %q", comp.name, comp.typ()))
+		return nil
+	}
+
+	snk.receiver = common.ConvertLogsReceiver{
+		Expr: fmt.Sprintf("loki.write.%s.receiver", snk.label),
+	}
+	return snk
+}
+
+// convertLokiSink converts a loki sink to a loki.write component.
+func (c *converter) convertLokiSink(snk *sink) {
+	comp := snk.component
+	c.checkOptions(comp,
+		"endpoint", "path", "labels", "tenant_id", "auth", "tls", "request", "batch", "encoding",
+		"structured_metadata", "out_of_order_action", "remove_label_fields", "remove_timestamp",
+		"remove_structured_metadata_fields", "compression",
+	)
+	if codec := comp.getStringOrDefault("encoding.codec", "text"); codec != "text" {
+		c.diags.Add(diag.SeverityLevelInfo, fmt.Sprintf("loki.write.%s sends the log lines instead of the events encoded with the %s codec of the sink %q", snk.label, codec, comp.name))
+	}
+
+	endpoint := c.endpointOptions(comp, strings.TrimSuffix(comp.getString("endpoint"), "/")+comp.getStringOrDefault("path", "/loki/api/v1/push"))
+
+	if tenant := comp.getString("tenant_id"); tenant != "" {
+		if field, ok := c.templateField(comp, "tenant_id", tenant); ok {
+			snk.stages = append(snk.stages, stages.StageConfig{TenantConfig: &stages.TenantConfig{Source: field}})
+		} else if !strings.Contains(tenant, "{{") {
+			endpoint.TenantID = tenant
+		}
+	}
+
+	args := lokiwrite.Arguments{
+		Endpoints: []lokiwrite.EndpointOptions{endpoint},
+	}
+	snk.labels = map[string]string{}
+	externalLabels := map[string]string{}
+	for _, name := range comp.keys("labels") {
+		value := comp.getString("labels." + name)
+		if !model.LabelName(name).IsValid() {
+			c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported label %q of the sink %q, label names must be valid Prometheus label names", name, comp.name))
+			continue
+		}
+		if field, ok := c.templateField(comp, "label "+name, value); ok {
+			snk.labels[name] = field
+		} else if !strings.Contains(value, "{{") {
+			externalLabels[name] = value
+		}
+	}
+	if len(externalLabels) > 0 {
+		args.ExternalLabels = externalLabels
+	}
+
+	metadata := map[string]*string{}
+	for _, name := range comp.keys("structured_metadata") {
+		value := comp.getString("structured_metadata." + name)
+		field, ok := c.templateField(comp, "structured metadata "+name, value)
+		if !ok {
+			if !strings.Contains(value, "{{") {
+				c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported structured metadata %s of the sink %q, only fields of the events are supported", name, comp.name))
+			}
+			continue
+		}
+		source := field
+		if field == name {
+			source = ""
+		}
+		metadata[name] = &source
+	}
+	if len(metadata) > 0 {
+		snk.stages = append(snk.stages, stages.StageConfig{StructuredMetadata: &stages.LabelsConfig{Values: metadata}})
+	}
+
+	snk.block = common.NewBlockWithOverride([]string{"loki", "write"}, snk.label, args)
+}
+
+// convertHTTPSink converts an http sink to a loki.write component. The
+// server it sends to must accept Loki push requests.
+func (c *converter) convertHTTPSink(snk *sink) {
+	comp := snk.component
+	c.checkOptions(comp, "uri", "method", "auth", "tls", "request", "batch", "encoding", "framing", "compression", "payload_prefix", "payload_suffix")
+	c.diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("the sink %q is converted to loki.write.%s, which sends Loki push requests instead of the events encoded with the %s codec", comp.name, snk.label, comp.getString("encoding.codec")))
+
+	args := lokiwrite.Arguments{
+		Endpoints: []lokiwrite.EndpointOptions{c.endpointOptions(comp, comp.getString("uri"))},
+	}
+	snk.block = common.NewBlockWithOverride([]string{"loki", "write"}, snk.label, args)
+}
+
+// endpointOptions converts the options of the HTTP client of the sink comp.
+func (c *converter) endpointOptions(comp *component, rawURL string) lokiwrite.EndpointOptions {
+	// Environment variables are reported when the configuration is parsed.
+	if _, err := url.Parse(rawURL); err != nil && !variableRegexp.MatchString(rawURL) {
+		c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("invalid endpoint of the sink %q: %s", comp.name, err))
+	}
+
+	endpoint := lokiwrite.GetDefaultEndpointOptions()
+	endpoint.URL = rawURL
+
+	switch strategy := comp.getString("auth.strategy"); strategy {
+	case "":
+	case "basic":
+		endpoint.HTTPClientConfig.BasicAuth = &commoncfg.BasicAuth{
+			Username: comp.getString("auth.user"),
+			Password: rivertypes.Secret(comp.getString("auth.password")),
+		}
+	case "bearer":
+		endpoint.HTTPClientConfig.BearerToken = rivertypes.Secret(comp.getString("auth.token"))
+	default:
+		c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported auth strategy %q of the sink %q", strategy, comp.name))
+	}
+
+	if comp.has("tls") {
+		endpoint.HTTPClientConfig.TLSConfig = c.tlsConfig(comp)
+	}
+	if headers := comp.getMap("request.headers"); len(headers) > 0 {
+		endpoint.Headers = headers
+	}
+	if timeout := comp.getInt("request.timeout_secs", 0); timeout > 0 {
+		endpoint.RemoteTimeout = time.Duration(timeout) * time.Second
+	}
+	if maxBytes := comp.getInt("batch.max_bytes", 0); maxBytes > 0 {
+		endpoint.BatchSize = units.Base2Bytes(maxBytes)
+	}
+	if timeout := comp.getFloat("batch.timeout_secs", 0); timeout > 0 {
+		endpoint.BatchWait = time.Duration(timeout * float64(time.Second))
+	}
+	return endpoint
+}
+
+var templateFieldRegexp = regexp.MustCompile(`^\{\{\s*(\.?[A-Za-z0-9_.]+)\s*\}\}$`)
+
+// templateField returns the field rendered by the template of the option
+// name of comp. It returns false if the value isn't a template, and reports
+// templates which aren't supported.
+func (c *converter) templateField(comp *component, name string, value string) (string, bool) {
+	if m := templateFieldRegexp.FindStringSubmatch(strings.TrimSpace(value)); m != nil {
+		return fieldName(m[1]), true
+	}
+	if strings.Contains(value, "{{") {
+		c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported template %q of the %s of the sink %q, only templates of a single field are supported", value, name, comp.name))
+	}
+	return "", false
+}
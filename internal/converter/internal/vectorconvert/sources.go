@@ -0,0 +1,228 @@
+package vectorconvert
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	commoncfg "github.com/grafana/agent/internal/component/common/config"
+	"github.com/grafana/agent/internal/component/common/loki"
+	flow_relabel "github.com/grafana/agent/internal/component/common/relabel"
+	"github.com/grafana/agent/internal/component/discovery"
+	"github.com/grafana/agent/internal/component/discovery/kubernetes"
+	"github.com/grafana/agent/internal/component/discovery/relabel"
+	filematch "github.com/grafana/agent/internal/component/local/file_match"
+	"github.com/grafana/agent/internal/component/loki/process/stages"
+	lokisourcefile "github.com/grafana/agent/internal/component/loki/source/file"
+	lokisourcekubernetes "github.com/grafana/agent/internal/component/loki/source/kubernetes"
+	"github.com/grafana/agent/internal/component/loki/source/syslog"
+	"github.com/grafana/agent/internal/converter/diag"
+	"github.com/grafana/agent/internal/converter/internal/common"
+	"github.com/grafana/river/token/builder"
+)
+
+// convertSource converts the source comp. It returns nil if the source isn't
+// supported.
+func (c *converter) convertSource(comp *component) *source {
+	src := &source{
+		component: comp,
+		label:     c.componentLabel(comp),
+	}
+
+	switch comp.typ() {
+	case "file":
+		c.convertFileSource(src)
+	case "syslog":
+		if !c.convertSyslogSource(src) {
+			return nil
+		}
+	case "kubernetes_logs":
+		c.convertKubernetesLogsSource(src)
+	default:
+		c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported source %q of type %q", comp.name, comp.typ()))
+		return nil
+	}
+	return src
+}
+
+// convertFileSource converts a file source to local.file_match and
+// loki.source.file components.
+func (c *converter) convertFileSource(src *source) {
+	comp := src.component
+	c.checkOptions(comp,
+		"include", "exclude", "read_from", "ignore_checkpoints", "glob_minimum_cooldown_ms",
+		"encoding", "multiline", "data_dir", "fingerprint", "max_line_bytes", "file_key",
+		"host_key", "offset_key", "line_delimiter",
+	)
+	if comp.has("data_dir") {
+		c.diags.Add(diag.SeverityLevelInfo, fmt.Sprintf("the data_dir of the source %q isn't converted, loki.source.file stores the read positions in the data directory of the agent", comp.name))
+	}
+
+	excludes := comp.getStrings("exclude")
+	var targets []discovery.Target
+	for _, path := range comp.getStrings("include") {
+		target := discovery.Target{"__path__": path}
+		switch len(excludes) {
+		case 0:
+		case 1:
+			target["__path_exclude__"] = excludes[0]
+		default:
+			target["__path_exclude__"] = "{" + strings.Join(excludes, ",") + "}"
+		}
+		targets = append(targets, target)
+	}
+	if len(targets) == 0 {
+		c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("the source %q doesn't include any file", comp.name))
+	}
+
+	if comp.has("multiline") {
+		if mode := comp.getString("multiline.mode"); mode != "halt_before" || comp.getString("multiline.start_pattern") != comp.getString("multiline.condition_pattern") {
+			c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported multiline mode %q of the source %q, only lines starting with the start_pattern in the halt_before mode are supported", mode, comp.name))
+		} else {
+			cfg := stages.DefaultMultilineConfig
+			cfg.Expression = comp.getString("multiline.start_pattern")
+			cfg.MaxWaitTime = time.Duration(comp.getInt("multiline.timeout_ms", 1000)) * time.Millisecond
+			src.stages = append(src.stages, stages.StageConfig{MultilineConfig: &cfg})
+		}
+	}
+
+	// loki.source.file sets the path of the files in the filename label.
+	src.fieldLabels = map[string]string{comp.getStringOrDefault("file_key", "file"): "filename"}
+
+	fileMatchArgs := filematch.Arguments{
+		PathTargets: targets,
+		SyncPeriod:  time.Duration(comp.getInt("glob_minimum_cooldown_ms", 1000)) * time.Millisecond,
+	}
+	src.appendBlocks = func(forwardTo []loki.LogsReceiver) {
+		c.f.Body().AppendBlock(common.NewBlockWithOverride([]string{"local", "file_match"}, src.label, fileMatchArgs))
+
+		args := lokisourcefile.DefaultArguments
+		args.ForwardTo = forwardTo
+		args.Encoding = comp.getString("encoding.charset")
+		args.TailFromEnd = comp.getString("read_from") == "end"
+		overrideHook := func(val interface{}) interface{} {
+			if _, ok := val.([]discovery.Target); ok {
+				return common.CustomTokenizer{Expr: fmt.Sprintf("local.file_match.%s.targets", src.label)}
+			}
+			return val
+		}
+		c.f.Body().AppendBlock(common.NewBlockWithOverrideFn([]string{"loki", "source", "file"}, src.label, args, overrideHook))
+	}
+}
+
+// convertSyslogSource converts a syslog source to a loki.source.syslog
+// component. It returns false if the source isn't supported.
+func (c *converter) convertSyslogSource(src *source) bool {
+	comp := src.component
+	c.checkOptions(comp, "mode", "address", "max_length", "tls", "keepalive", "receive_buffer_bytes", "connection_limit", "host_key", "path", "socket_file_mode")
+
+	mode := comp.getStringOrDefault("mode", "tcp")
+	if mode != "tcp" && mode != "udp" {
+		c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported mode %q of the source %q, loki.source.syslog only listens on TCP and UDP", mode, comp.name))
+		return false
+	}
+
+	listener := syslog.DefaultListenerConfig
+	listener.ListenAddress = comp.getString("address")
+	listener.ListenProtocol = mode
+	listener.MaxMessageLength = comp.getInt("max_length", listener.MaxMessageLength)
+	if comp.getBool("tls.enabled", false) {
+		listener.TLSConfig = c.tlsConfig(comp)
+	}
+
+	src.appendBlocks = func(forwardTo []loki.LogsReceiver) {
+		args := syslog.Arguments{
+			SyslogListeners: []syslog.ListenerConfig{listener},
+			ForwardTo:       forwardTo,
+		}
+		c.f.Body().AppendBlock(common.NewBlockWithOverride([]string{"loki", "source", "syslog"}, src.label, args))
+	}
+	return true
+}
+
+// convertKubernetesLogsSource converts a kubernetes_logs source, which reads
+// the logs of the pods of its node, to discovery.kubernetes,
+// discovery.relabel and loki.source.kubernetes components.
+func (c *converter) convertKubernetesLogsSource(src *source) {
+	comp := src.component
+	c.checkOptions(comp,
+		"self_node_name", "extra_label_selector", "extra_field_selector", "kube_config_file",
+		"auto_partial_merge", "glob_minimum_cooldown_ms", "data_dir", "max_line_bytes",
+		"max_read_bytes", "ingestion_timestamp_field", "timezone", "oldest_first",
+		"delay_deletion_ms", "use_apiserver_cache", "read_from",
+	)
+
+	// The source reads the logs of the pods of the node Vector runs on, whose
+	// name is usually set in an environment variable.
+	nodeName := comp.getStringOrDefault("self_node_name", "${VECTOR_SELF_NODE_NAME}")
+	nodeSelector := fmt.Sprintf("%q", "spec.nodeName="+nodeName)
+	if m := variableRegexp.FindStringSubmatch(nodeName); m != nil && m[0] == strings.TrimSuffix(nodeName, "}") {
+		nodeSelector = fmt.Sprintf(`"spec.nodeName=" + env(%q)`, m[1])
+	}
+	if field := comp.getString("extra_field_selector"); field != "" {
+		nodeSelector += fmt.Sprintf(" + %q", ","+field)
+	}
+
+	// The relabeled labels are the fields of the events set in labels.
+	rules := []struct{ source, label, field string }{
+		{"__meta_kubernetes_namespace", "namespace", "kubernetes.pod_namespace"},
+		{"__meta_kubernetes_pod_name", "pod", "kubernetes.pod_name"},
+		{"__meta_kubernetes_pod_container_name", "container", "kubernetes.container_name"},
+		{"__meta_kubernetes_pod_node_name", "node", "kubernetes.pod_node_name"},
+	}
+	src.fieldLabels = map[string]string{}
+	var relabelConfigs []*flow_relabel.Config
+	for _, r := range rules {
+		cfg := flow_relabel.DefaultRelabelConfig
+		cfg.SourceLabels = []string{r.source}
+		cfg.TargetLabel = r.label
+		relabelConfigs = append(relabelConfigs, &cfg)
+		src.fieldLabels[r.field] = r.label
+	}
+
+	src.appendBlocks = func(forwardTo []loki.LogsReceiver) {
+		discoveryArgs := kubernetes.DefaultConfig
+		discoveryArgs.Role = "pod"
+		discoveryArgs.KubeConfig = comp.getString("kube_config_file")
+		block := common.NewBlockWithOverride([]string{"discovery", "kubernetes"}, src.label, discoveryArgs)
+		selectors := builder.NewBlock([]string{"selectors"}, "")
+		selectors.Body().SetAttributeValue("role", "pod")
+		selectors.Body().SetAttributeValue("field", common.CustomTokenizer{Expr: nodeSelector})
+		if label := comp.getString("extra_label_selector"); label != "" {
+			selectors.Body().SetAttributeValue("label", label)
+		}
+		block.Body().AppendBlock(selectors)
+		c.f.Body().AppendBlock(block)
+
+		relabelArgs := relabel.Arguments{RelabelConfigs: relabelConfigs}
+		c.f.Body().AppendBlock(common.NewBlockWithOverrideFn([]string{"discovery", "relabel"}, src.label, relabelArgs, targetsOverride(fmt.Sprintf("discovery.kubernetes.%s.targets", src.label))))
+
+		args := lokisourcekubernetes.DefaultArguments
+		args.ForwardTo = forwardTo
+		if kubeConfig := comp.getString("kube_config_file"); kubeConfig != "" {
+			args.Client.KubeConfig = kubeConfig
+		}
+		c.f.Body().AppendBlock(common.NewBlockWithOverrideFn([]string{"loki", "source", "kubernetes"}, src.label, args, targetsOverride(fmt.Sprintf("discovery.relabel.%s.output", src.label))))
+	}
+}
+
+// targetsOverride returns a hook overriding targets with expr.
+func targetsOverride(expr string) builder.ValueOverrideHook {
+	return func(val interface{}) interface{} {
+		if _, ok := val.([]discovery.Target); ok {
+			return common.CustomTokenizer{Expr: expr}
+		}
+		return val
+	}
+}
+
+// tlsConfig converts the tls options of comp.
+func (c *converter) tlsConfig(comp *component) commoncfg.TLSConfig {
+	return commoncfg.TLSConfig{
+		CAFile:             comp.getString("tls.ca_file"),
+		CertFile:           comp.getString("tls.crt_file"),
+		KeyFile:            comp.getString("tls.key_file"),
+		ServerName:         comp.getString("tls.server_name"),
+		InsecureSkipVerify: !comp.getBool("tls.verify_certificate", true),
+	}
+}
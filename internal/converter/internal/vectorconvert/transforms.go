@@ -0,0 +1,322 @@
+package vectorconvert
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/agent/internal/component/loki/process/stages"
+	"github.com/grafana/agent/internal/converter/diag"
+	"github.com/grafana/agent/internal/converter/internal/common"
+)
+
+// convertTransform returns the stages of the transform comp.
+func (c *converter) convertTransform(comp *component) []stages.StageConfig {
+	switch comp.typ() {
+	case "remap":
+		return c.convertRemapTransform(comp)
+	case "filter":
+		return c.convertFilterTransform(comp)
+	case "sample":
+		return c.convertSampleTransform(comp)
+	case "throttle":
+		return c.convertThrottleTransform(comp)
+	default:
+		c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported transform %q of type %q", comp.name, comp.typ()))
+		return nil
+	}
+}
+
+// convertRemapTransform converts a remap transform whose VRL program only
+// uses the statements supported by convertVRLStatement.
+func (c *converter) convertRemapTransform(comp *component) []stages.StageConfig {
+	c.checkOptions(comp, "source", "drop_on_error", "drop_on_abort", "reroute_dropped", "timezone", "metric_tag_values")
+	if comp.has("file") || comp.has("files") {
+		c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported VRL program file of the transform %q, add the program to the source option to convert it", comp.name))
+	}
+
+	var res []stages.StageConfig
+	for _, stmt := range splitVRL(comp.getString("source")) {
+		ss, ok := convertVRLStatement(stmt)
+		if !ok {
+			c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported VRL statement %q of the transform %q", stmt, comp.name))
+			continue
+		}
+		res = append(res, ss...)
+	}
+	return res
+}
+
+// splitVRL splits a VRL program into its statements. Comments are removed.
+func splitVRL(program string) []string {
+	var res []string
+	for _, line := range strings.Split(program, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, stmt := range strings.Split(line, ";") {
+			if stmt = strings.TrimSpace(stmt); stmt != "" {
+				res = append(res, stmt)
+			}
+		}
+	}
+	return res
+}
+
+const (
+	vrlPath   = `\.[A-Za-z0-9_.]*`
+	vrlString = `(?:"(?:[^"\\]|\\.)*"|'[^']*')`
+)
+
+var (
+	// The fields parsed by the functions must be merged in the event.
+	vrlParseRegexp = regexp.MustCompile(`^\.\s*(?:=|\|=)\s*(?:merge\(\s*\.\s*,\s*)?(parse_json|parse_logfmt|parse_key_value)!?\((` + vrlPath + `)\)\)?$`)
+	vrlRegexRegexp = regexp.MustCompile(`^\.\s*(?:=|\|=)\s*(?:merge\(\s*\.\s*,\s*)?parse_regex!?\((` + vrlPath + `)\s*,\s*r(` + vrlString + `)\)\)?$`)
+	vrlTimeRegexp  = regexp.MustCompile(`^\.timestamp\s*=\s*parse_timestamp!?\((` + vrlPath + `)\s*,\s*(?:format:\s*)?(` + vrlString + `)\)$`)
+	vrlSetRegexp   = regexp.MustCompile(`^(` + vrlPath + `)\s*=\s*(` + vrlString + `|` + vrlPath + `)$`)
+	vrlDelRegexp   = regexp.MustCompile(`^del\((` + vrlPath + `)\)$`)
+)
+
+// convertVRLStatement converts a VRL statement parsing or setting fields of
+// the events. It returns false if the statement isn't supported.
+func convertVRLStatement(stmt string) ([]stages.StageConfig, bool) {
+	if m := vrlParseRegexp.FindStringSubmatch(stmt); m != nil {
+		source := vrlSource(m[2])
+		switch m[1] {
+		case "parse_json":
+			return []stages.StageConfig{{JSONConfig: &stages.JSONConfig{
+				Expressions: map[string]string{common.ExtractAll: ""},
+				Source:      stringPtr(source),
+			}}}, true
+		default:
+			return []stages.StageConfig{{LogfmtConfig: &stages.LogfmtConfig{
+				Mapping: map[string]string{common.ExtractAll: ""},
+				Source:  source,
+			}}}, true
+		}
+	}
+
+	if m := vrlRegexRegexp.FindStringSubmatch(stmt); m != nil {
+		expression, err := vrlUnquote(m[2])
+		if err != nil {
+			return nil, false
+		}
+		if _, err := regexp.Compile(expression); err != nil {
+			return nil, false
+		}
+		return []stages.StageConfig{{RegexConfig: &stages.RegexConfig{
+			Expression: expression,
+			Source:     stringPtr(vrlSource(m[1])),
+		}}}, true
+	}
+
+	if m := vrlTimeRegexp.FindStringSubmatch(stmt); m != nil {
+		format, err := vrlUnquote(m[2])
+		if err != nil {
+			return nil, false
+		}
+		layout, err := common.StrftimeToGoLayout(format)
+		if err != nil {
+			return nil, false
+		}
+		return []stages.StageConfig{{TimestampConfig: &stages.TimestampConfig{
+			Source: fieldName(m[1]),
+			Format: layout,
+		}}}, true
+	}
+
+	if m := vrlSetRegexp.FindStringSubmatch(stmt); m != nil {
+		target, value := fieldName(m[1]), m[2]
+		if target == "" {
+			return nil, false
+		}
+		if strings.HasPrefix(value, ".") {
+			source := fieldName(value)
+			if target == "message" {
+				// The log line is replaced with the field.
+				return []stages.StageConfig{{OutputConfig: &stages.OutputConfig{Source: source}}}, source != ""
+			}
+			if source == "" || source == "message" {
+				return nil, false
+			}
+			return []stages.StageConfig{{TemplateConfig: &stages.TemplateConfig{
+				Source:   target,
+				Template: fmt.Sprintf("{{ %s }}", common.TemplateField(source)),
+			}}}, true
+		}
+
+		literal, err := vrlUnquote(value)
+		if err != nil || target == "message" {
+			return nil, false
+		}
+		return []stages.StageConfig{{TemplateConfig: &stages.TemplateConfig{
+			Source:   target,
+			Template: templateLiteral(literal),
+		}}}, true
+	}
+
+	if vrlDelRegexp.MatchString(stmt) {
+		// Fields of the events which aren't set as labels or as the log line
+		// aren't sent by loki.write.
+		return nil, true
+	}
+
+	return nil, false
+}
+
+// convertFilterTransform converts a filter transform whose condition only
+// uses the expressions supported by convertVRLCondition.
+func (c *converter) convertFilterTransform(comp *component) []stages.StageConfig {
+	c.checkOptions(comp, "condition")
+
+	condition := comp.getString("condition")
+	if comp.has("condition.source") {
+		if typ := comp.getStringOrDefault("condition.type", "vrl"); typ != "vrl" {
+			c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported condition type %q of the transform %q", typ, comp.name))
+			return nil
+		}
+		condition = comp.getString("condition.source")
+	}
+
+	var res []stages.StageConfig
+	for _, expr := range strings.Split(condition, "&&") {
+		ss, ok := convertVRLCondition(strings.TrimSpace(expr))
+		if !ok {
+			c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported condition %q of the transform %q", strings.TrimSpace(expr), comp.name))
+			continue
+		}
+		res = append(res, ss...)
+	}
+	return res
+}
+
+var (
+	vrlCompareRegexp  = regexp.MustCompile(`^(` + vrlPath + `)\s*(==|!=)\s*(` + vrlString + `)$`)
+	vrlFunctionRegexp = regexp.MustCompile(`^(!?)\s*(match|contains|starts_with|ends_with)!?\((` + vrlPath + `)\s*,\s*(r?` + vrlString + `)\)$`)
+)
+
+// convertVRLCondition converts a VRL condition comparing or matching fields of
+// the events to stages dropping the events which don't match it. It returns
+// false if the condition isn't supported.
+func convertVRLCondition(expr string) ([]stages.StageConfig, bool) {
+	var (
+		field      string
+		expression string
+		negate     bool
+	)
+	if m := vrlCompareRegexp.FindStringSubmatch(expr); m != nil {
+		value, err := vrlUnquote(m[3])
+		if err != nil {
+			return nil, false
+		}
+		field = m[1]
+		expression = "^" + regexp.QuoteMeta(value) + "$"
+		negate = m[2] == "!="
+	} else if m := vrlFunctionRegexp.FindStringSubmatch(expr); m != nil {
+		arg := strings.TrimPrefix(m[4], "r")
+		value, err := vrlUnquote(arg)
+		if err != nil {
+			return nil, false
+		}
+		if (m[2] == "match") != strings.HasPrefix(m[4], "r") {
+			// match expects a regex, and the other functions a string.
+			return nil, false
+		}
+		field = m[3]
+		negate = m[1] == "!"
+		switch m[2] {
+		case "match":
+			expression = value
+		case "contains":
+			expression = regexp.QuoteMeta(value)
+		case "starts_with":
+			expression = "^" + regexp.QuoteMeta(value)
+		case "ends_with":
+			expression = regexp.QuoteMeta(value) + "$"
+		}
+	} else {
+		return nil, false
+	}
+	if _, err := regexp.Compile(expression); err != nil {
+		return nil, false
+	}
+
+	source := vrlSource(field)
+	if negate {
+		return []stages.StageConfig{{DropConfig: &stages.DropConfig{
+			Source:     source,
+			Expression: expression,
+		}}}, true
+	}
+	return common.KeepMatching(source, expression), true
+}
+
+// convertSampleTransform converts a sample transform, which keeps 1 out of
+// rate events.
+func (c *converter) convertSampleTransform(comp *component) []stages.StageConfig {
+	c.checkOptions(comp, "rate")
+
+	rate := comp.getInt("rate", 0)
+	if rate <= 0 {
+		c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("invalid rate of the transform %q", comp.name))
+		return nil
+	}
+	var cfg stages.SamplingConfig
+	cfg.SetToDefault()
+	cfg.SamplingRate = 1 / float64(rate)
+	return []stages.StageConfig{{SamplingConfig: &cfg}}
+}
+
+// convertThrottleTransform converts a throttle transform, which drops the
+// events exceeding threshold per window.
+func (c *converter) convertThrottleTransform(comp *component) []stages.StageConfig {
+	c.checkOptions(comp, "threshold", "window_secs", "internal_metrics")
+
+	threshold := comp.getInt("threshold", 0)
+	window := comp.getFloat("window_secs", 0)
+	if threshold <= 0 || window <= 0 {
+		c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("invalid threshold or window_secs of the transform %q", comp.name))
+		return nil
+	}
+	return []stages.StageConfig{{LimitConfig: &stages.LimitConfig{
+		Rate:  float64(threshold) / window,
+		Burst: threshold,
+		Drop:  true,
+	}}}
+}
+
+// vrlSource returns the source of a stage reading the VRL path, which is
+// empty for the log line.
+func vrlSource(path string) string {
+	field := fieldName(path)
+	if field == "message" {
+		return ""
+	}
+	return field
+}
+
+// vrlUnquote unquotes a VRL string literal. Raw strings are quoted with
+// single quotes.
+func vrlUnquote(s string) (string, error) {
+	if strings.HasPrefix(s, "'") {
+		return strings.TrimSuffix(strings.TrimPrefix(s, "'"), "'"), nil
+	}
+	return strconv.Unquote(s)
+}
+
+func stringPtr(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// templateLiteral returns a template rendering value.
+func templateLiteral(value string) string {
+	if strings.Contains(value, "{{") {
+		return fmt.Sprintf("{{ %s }}", strconv.Quote(value))
+	}
+	return value
+}
@@ -0,0 +1,344 @@
+// Package vectorconvert converts Vector configurations to Grafana Agent Flow
+// configurations.
+package vectorconvert
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/grafana/agent/internal/component/common/loki"
+	"github.com/grafana/agent/internal/component/loki/process"
+	"github.com/grafana/agent/internal/component/loki/process/stages"
+	"github.com/grafana/agent/internal/converter/diag"
+	"github.com/grafana/agent/internal/converter/internal/common"
+	"github.com/grafana/river/scanner"
+	"github.com/grafana/river/token/builder"
+)
+
+// Convert implements a Vector config converter. Configurations in the TOML,
+// YAML and JSON formats are supported.
+//
+// extraArgs are supported to mirror the other converter params due to shared
+// testing code but they should be passed empty to this converter.
+func Convert(in []byte, extraArgs []string) ([]byte, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if len(extraArgs) > 0 {
+		diags.Add(diag.SeverityLevelCritical, fmt.Sprintf("extra arguments are not supported for the vector converter: %s", extraArgs))
+		return nil, diags
+	}
+
+	cfg, err := parseConfig(in, &diags)
+	if err != nil {
+		diags.Add(diag.SeverityLevelCritical, fmt.Sprintf("failed to parse Vector config: %s", err))
+		return nil, diags
+	}
+
+	f := builder.NewFile()
+	diags = appendAll(f, cfg, diags)
+	diags.AddAll(common.ValidateNodes(f))
+
+	var buf bytes.Buffer
+	if _, err := f.WriteTo(&buf); err != nil {
+		diags.Add(diag.SeverityLevelCritical, fmt.Sprintf("failed to render Flow config: %s", err.Error()))
+		return nil, diags
+	}
+
+	if len(buf.Bytes()) == 0 {
+		return nil, diags
+	}
+
+	prettyByte, newDiags := common.PrettyPrint(buf.Bytes())
+	diags.AddAll(newDiags)
+	return prettyByte, diags
+}
+
+// source is a converted Vector source.
+type source struct {
+	component *component
+	label     string
+	// stages convert the events of the source, such as merging multiline
+	// events.
+	stages []stages.StageConfig
+	// fieldLabels maps the fields of the events of the source which are set
+	// as labels by the converted components to the name of the labels.
+	fieldLabels map[string]string
+	// appendBlocks appends the components reading the source to the file.
+	appendBlocks func(forwardTo []loki.LogsReceiver)
+}
+
+// transform is a converted Vector transform.
+type transform struct {
+	component *component
+	stages    []stages.StageConfig
+}
+
+// sink is a converted Vector sink.
+type sink struct {
+	component *component
+	label     string
+	// labels maps the names of the labels set from fields of the events to
+	// the fields.
+	labels   map[string]string
+	stages   []stages.StageConfig
+	block    *builder.Block
+	receiver loki.LogsReceiver
+}
+
+type converter struct {
+	f      *builder.File
+	diags  *diag.Diagnostics
+	labels map[string]bool
+}
+
+// appendAll analyzes the entire Vector config in memory and transforms it
+// into Flow components. It then appends each argument to the file builder.
+//
+// The transforms on the path from each source to each sink are converted to
+// a loki.process component, since fields of the events aren't sent between
+// Flow components.
+func appendAll(f *builder.File, cfg *config, diags diag.Diagnostics) diag.Diagnostics {
+	c := &converter{
+		f:      f,
+		diags:  &diags,
+		labels: map[string]bool{},
+	}
+
+	var sources []*source
+	for _, comp := range cfg.sources {
+		if src := c.convertSource(comp); src != nil {
+			sources = append(sources, src)
+		}
+	}
+
+	transforms := map[string]*transform{}
+	for _, comp := range cfg.transforms {
+		transforms[comp.name] = &transform{
+			component: comp,
+			stages:    c.convertTransform(comp),
+		}
+	}
+
+	var sinks []*sink
+	for _, comp := range cfg.sinks {
+		if snk := c.convertSink(comp); snk != nil {
+			sinks = append(sinks, snk)
+		}
+	}
+
+	g := newGraph(c, cfg)
+	for _, src := range sources {
+		c.appendSource(src, g, transforms, sinks)
+	}
+	for _, snk := range sinks {
+		f.Body().AppendBlock(snk.block)
+	}
+
+	return diags
+}
+
+// appendSource appends the components reading the source and the
+// loki.process components routing its events to the sinks it's connected
+// to.
+func (c *converter) appendSource(src *source, g *graph, transforms map[string]*transform, sinks []*sink) {
+	var (
+		connected []*sink
+		paths     [][]string
+	)
+	for _, snk := range sinks {
+		switch p := g.paths(src.component.name, snk.component.name); len(p) {
+		case 0:
+		case 1:
+			connected = append(connected, snk)
+			paths = append(paths, p[0])
+		default:
+			c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported multiple paths from the source %q to the sink %q", src.component.name, snk.component.name))
+		}
+	}
+	if len(connected) == 0 {
+		c.diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("the source %q isn't connected to any sink, its events are dropped", src.component.name))
+	}
+
+	var (
+		forwardTo []loki.LogsReceiver
+		blocks    []*builder.Block
+	)
+	for i, snk := range connected {
+		var ss []stages.StageConfig
+		ss = append(ss, src.stages...)
+		for _, name := range paths[i] {
+			ss = append(ss, transforms[name].stages...)
+		}
+		ss = append(ss, c.labelStages(src, snk)...)
+		ss = append(ss, snk.stages...)
+		ss = common.ResolveExtractAll(ss)
+		if len(ss) == 0 {
+			forwardTo = append(forwardTo, snk.receiver)
+			continue
+		}
+
+		label := src.label
+		if len(connected) > 1 {
+			label = c.uniqueLabel(common.LabelForParts(src.label, snk.label))
+		}
+		blocks = append(blocks, common.NewBlockWithOverride([]string{"loki", "process"}, label, process.Arguments{
+			ForwardTo: []loki.LogsReceiver{snk.receiver},
+			Stages:    ss,
+		}))
+		forwardTo = append(forwardTo, common.ConvertLogsReceiver{
+			Expr: fmt.Sprintf("loki.process.%s.receiver", label),
+		})
+	}
+
+	src.appendBlocks(forwardTo)
+	for _, block := range blocks {
+		c.f.Body().AppendBlock(block)
+	}
+}
+
+// labelStages returns the stages setting the labels of the sink snk from the
+// fields of the events of the source src.
+func (c *converter) labelStages(src *source, snk *sink) []stages.StageConfig {
+	values := map[string]*string{}
+	for name, field := range snk.labels {
+		if label, ok := src.fieldLabels[field]; ok {
+			if label != name {
+				c.diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("the label %s of the sink %q is set from the field %s, which the converted source %q sets in the %s label instead", name, snk.component.name, field, src.component.name, label))
+			}
+			continue
+		}
+
+		source := field
+		if name == field {
+			source = ""
+		}
+		values[name] = &source
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return []stages.StageConfig{{LabelsConfig: &stages.LabelsConfig{Values: values}}}
+}
+
+// componentLabel returns a unique label for the component comp.
+func (c *converter) componentLabel(comp *component) string {
+	label, err := scanner.SanitizeIdentifier(comp.name)
+	if err != nil {
+		c.diags.Add(diag.SeverityLevelCritical, fmt.Sprintf("failed to sanitize label %q: %s", comp.name, err))
+	}
+	return c.uniqueLabel(label)
+}
+
+// uniqueLabel returns label, suffixed with an index if it's already used.
+func (c *converter) uniqueLabel(label string) string {
+	res := label
+	for i := 1; c.labels[res]; i++ {
+		res = common.LabelWithIndex(i, label)
+	}
+	c.labels[res] = true
+	return res
+}
+
+// checkOptions reports the options of comp which aren't supported.
+func (c *converter) checkOptions(comp *component, supported ...string) {
+	for _, key := range comp.keys("") {
+		if key == "type" || key == "inputs" || commonOptions[key] {
+			continue
+		}
+		found := false
+		for _, s := range supported {
+			if key == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported option %q of the %s %q", key, comp.kind, comp.name))
+		}
+	}
+}
+
+// commonOptions are the options shared by components which don't affect the
+// conversion, such as the tuning of buffers and retries.
+var commonOptions = map[string]bool{
+	"buffer":           true,
+	"healthcheck":      true,
+	"acknowledgements": true,
+	"graph":            true,
+}
+
+// graph is the topology of the components of a Vector configuration.
+type graph struct {
+	// inputs maps the names of transforms and sinks to the names of the
+	// components they read from.
+	inputs map[string][]string
+}
+
+// newGraph resolves the inputs of the transforms and sinks of cfg, which may
+// be glob patterns.
+func newGraph(c *converter, cfg *config) *graph {
+	var names []string
+	for _, comps := range [][]*component{cfg.sources, cfg.transforms} {
+		for _, comp := range comps {
+			names = append(names, comp.name)
+		}
+	}
+
+	g := &graph{inputs: map[string][]string{}}
+	for _, comps := range [][]*component{cfg.transforms, cfg.sinks} {
+		for _, comp := range comps {
+			for _, pattern := range comp.getStrings("inputs") {
+				matched := false
+				for _, name := range names {
+					if ok, _ := path.Match(pattern, name); ok && name != comp.name {
+						g.inputs[comp.name] = append(g.inputs[comp.name], name)
+						matched = true
+					}
+				}
+				if !matched {
+					c.diags.Add(diag.SeverityLevelError, fmt.Sprintf("unsupported input %q of the %s %q, it doesn't match a supported source or transform", pattern, comp.kind, comp.name))
+				}
+			}
+		}
+	}
+	return g
+}
+
+// paths returns the names of the transforms on each path from the source
+// from to the sink to, in the order events go through them.
+func (g *graph) paths(from string, to string) [][]string {
+	var (
+		res   [][]string
+		visit func(name string, transforms []string, seen map[string]bool)
+	)
+	visit = func(name string, transforms []string, seen map[string]bool) {
+		for _, input := range g.inputs[name] {
+			if seen[input] {
+				continue
+			}
+			if input == from {
+				path := make([]string, len(transforms))
+				// The transforms were collected from the sink.
+				for i, t := range transforms {
+					path[len(transforms)-1-i] = t
+				}
+				res = append(res, path)
+				continue
+			}
+			seen[input] = true
+			visit(input, append(transforms, input), seen)
+			delete(seen, input)
+		}
+	}
+	visit(to, nil, map[string]bool{})
+	return res
+}
+
+// fieldName returns the name of the field of a VRL path or template field
+// such as .kubernetes.pod_name, which is also the key of the field extracted
+// by loki.process stages.
+func fieldName(path string) string {
+	return strings.TrimPrefix(strings.TrimSpace(path), ".")
+}
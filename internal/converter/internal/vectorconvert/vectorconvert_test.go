@@ -0,0 +1,15 @@
+//go:build linux
+
+package vectorconvert_test
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/internal/converter/internal/test_common"
+	"github.com/grafana/agent/internal/converter/internal/vectorconvert"
+)
+
+func TestConvert(t *testing.T) {
+	test_common.TestDirectory(t, "testdata", ".toml", true, []string{}, vectorconvert.Convert)
+	test_common.TestDirectory(t, "testdata", ".yaml", true, []string{}, vectorconvert.Convert)
+}
@@ -51,6 +51,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/grafana/agent/internal/component"
 	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/agent/internal/flow/internal/controller"
 	"github.com/grafana/agent/internal/flow/internal/worker"
@@ -123,6 +124,12 @@ type Flow struct {
 
 	loadMut    sync.RWMutex
 	loadedOnce atomic.Bool
+
+	// Most recently loaded source and its options, used as the base for
+	// runtime edits. Guarded by loadMut.
+	source     *Source
+	sourceArgs map[string]any
+	registry   *controller.CustomComponentRegistry
 }
 
 // New creates a new, unstarted Flow controller. Call Run to run the controller.
@@ -135,6 +142,43 @@ func New(o Options) *Flow {
 	})
 }
 
+// NewWithComponents creates a new, unstarted Flow controller which can use the
+// components in extra in addition to the registered components. Components in
+// extra aren't subject to the minimum stability level of the controller, and
+// take precedence over registered components with the same name.
+//
+// NewWithComponents allows to provide components which mustn't be available
+// to every controller, such as the components used by tests of River
+// configurations.
+func NewWithComponents(o Options, extra []component.Registration) *Flow {
+	return newController(controllerOptions{
+		Options: o,
+		ComponentRegistry: extraComponentRegistry{
+			ComponentRegistry: controller.NewDefaultComponentRegistry(o.MinStability),
+			extra:             extra,
+		},
+		ModuleRegistry: newModuleRegistry(),
+		IsModule:       false, // We are creating a new root controller.
+		WorkerPool:     worker.NewDefaultWorkerPool(),
+	})
+}
+
+// extraComponentRegistry is a [controller.ComponentRegistry] which looks up
+// components in extra before the wrapped registry.
+type extraComponentRegistry struct {
+	controller.ComponentRegistry
+	extra []component.Registration
+}
+
+func (r extraComponentRegistry) Get(name string) (component.Registration, error) {
+	for _, reg := range r.extra {
+		if reg.Name == name {
+			return reg, nil
+		}
+	}
+	return r.ComponentRegistry.Get(name)
+}
+
 // controllerOptions are internal options used to create both root Flow
 // controller and controllers for modules.
 type controllerOptions struct {
@@ -314,6 +358,7 @@ func (f *Flow) loadSource(source *Source, args map[string]any, customComponentRe
 		return diags
 	}
 	f.loadedOnce.Store(true)
+	f.source, f.sourceArgs, f.registry = source, args, customComponentRegistry
 
 	select {
 	case f.loadFinished <- struct{}{}:
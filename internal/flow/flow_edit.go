@@ -0,0 +1,163 @@
+package flow
+
+import (
+	"errors"
+
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/flow/internal/controller"
+	"github.com/grafana/agent/internal/flow/logging/level"
+	"github.com/grafana/river/ast"
+	"github.com/grafana/river/diag"
+	"github.com/grafana/river/parser"
+)
+
+// editSourceName is the name used for reporting the positions of errors in
+// River fragments passed to the edit methods.
+const editSourceName = "<request>"
+
+// UpdateComponent replaces the arguments of the component with the given ID
+// in the root module. args is a River fragment holding the new body of the
+// component block.
+//
+// Edits are validated and applied the same way as a reload of the whole
+// configuration. If the edited configuration can't be loaded, the previous
+// configuration is restored and the returned error holds the
+// [diag.Diagnostics] describing the failure.
+//
+// Edits only live in memory; they are discarded the next time the
+// configuration is loaded from its source.
+func (f *Flow) UpdateComponent(id string, args []byte) error {
+	file, err := parser.ParseFile(editSourceName, args)
+	if err != nil {
+		return err
+	}
+
+	return f.editComponents(func(blocks []*ast.BlockStmt) ([]*ast.BlockStmt, error) {
+		idx := findComponentBlock(blocks, id)
+		if idx == -1 {
+			return nil, component.ErrComponentNotFound
+		}
+
+		updated := *blocks[idx]
+		updated.Body = file.Body
+
+		res := append([]*ast.BlockStmt(nil), blocks...)
+		res[idx] = &updated
+		return res, nil
+	})
+}
+
+// AddComponent adds a component to the root module. block is a River
+// fragment holding a single component block. See [Flow.UpdateComponent] for
+// how edits are applied.
+func (f *Flow) AddComponent(block []byte) error {
+	file, err := parser.ParseFile(editSourceName, block)
+	if err != nil {
+		return err
+	}
+
+	fragment, err := sourceFromBody(file.Body)
+	if err != nil {
+		return err
+	}
+	if len(fragment.components) != 1 || len(fragment.configBlocks) > 0 || len(fragment.declareBlocks) > 0 {
+		return diag.Diagnostics{{
+			Severity: diag.SeverityLevelError,
+			StartPos: ast.StartPos(file).Position(),
+			EndPos:   ast.EndPos(file).Position(),
+			Message:  "expected exactly one component block",
+		}}
+	}
+	newBlock := fragment.components[0]
+
+	return f.editComponents(func(blocks []*ast.BlockStmt) ([]*ast.BlockStmt, error) {
+		if findComponentBlock(blocks, controller.BlockComponentID(newBlock).String()) != -1 {
+			return nil, component.ErrComponentExists
+		}
+
+		res := append([]*ast.BlockStmt(nil), blocks...)
+		return append(res, newBlock), nil
+	})
+}
+
+// RemoveComponent removes the component with the given ID from the root
+// module. See [Flow.UpdateComponent] for how edits are applied.
+func (f *Flow) RemoveComponent(id string) error {
+	return f.editComponents(func(blocks []*ast.BlockStmt) ([]*ast.BlockStmt, error) {
+		idx := findComponentBlock(blocks, id)
+		if idx == -1 {
+			return nil, component.ErrComponentNotFound
+		}
+
+		res := make([]*ast.BlockStmt, 0, len(blocks)-1)
+		res = append(res, blocks[:idx]...)
+		return append(res, blocks[idx+1:]...), nil
+	})
+}
+
+// editComponents applies the list of component blocks returned by edit,
+// restoring the previous configuration if the result fails to load.
+func (f *Flow) editComponents(edit func(blocks []*ast.BlockStmt) ([]*ast.BlockStmt, error)) error {
+	f.loadMut.Lock()
+	defer f.loadMut.Unlock()
+
+	prev := f.source
+	if prev == nil {
+		return errors.New("no configuration has been loaded")
+	}
+
+	components, err := edit(prev.components)
+	if err != nil {
+		return err
+	}
+
+	// The edited source no longer matches the raw configuration it was parsed
+	// from, so it doesn't carry it over.
+	edited := &Source{
+		components:    components,
+		configBlocks:  prev.configBlocks,
+		declareBlocks: prev.declareBlocks,
+	}
+
+	if diags := f.applySource(edited); diags.HasErrors() {
+		// The previous configuration may have had evaluation errors of its own,
+		// so errors from restoring it are only logged.
+		if restoreDiags := f.applySource(prev); restoreDiags.HasErrors() {
+			level.Warn(f.log).Log("msg", "errors restoring the configuration after a failed edit", "err", restoreDiags)
+		}
+		return diags
+	}
+
+	f.source = edited
+	return nil
+}
+
+// applySource applies source with the options of the most recent load. It
+// must be called with loadMut held.
+func (f *Flow) applySource(source *Source) diag.Diagnostics {
+	diags := f.loader.Apply(controller.ApplyOptions{
+		Args:                    f.sourceArgs,
+		ComponentBlocks:         source.components,
+		ConfigBlocks:            source.configBlocks,
+		DeclareBlocks:           source.declareBlocks,
+		CustomComponentRegistry: f.registry,
+	})
+
+	select {
+	case f.loadFinished <- struct{}{}:
+	default:
+		// A refresh is already scheduled
+	}
+	return diags
+}
+
+// findComponentBlock returns the index of the block defining the component
+// with the given ID, or -1 if there is none.
+func findComponentBlock(blocks []*ast.BlockStmt, id string) int {
+	for i, b := range blocks {
+		if controller.BlockComponentID(b).String() == id {
+			return i
+		}
+	}
+	return -1
+}
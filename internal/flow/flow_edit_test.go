@@ -0,0 +1,98 @@
+package flow
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/flow/internal/testcomponents"
+	"github.com/grafana/river/diag"
+	"github.com/stretchr/testify/require"
+)
+
+func TestController_EditComponents(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+	ctrl := New(testOptions(t))
+	defer cleanUpController(ctrl)
+
+	f, err := ParseSource(t.Name(), []byte(testFile))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadSource(f, nil))
+
+	t.Run("update", func(t *testing.T) {
+		err := ctrl.UpdateComponent("testcomponents.passthrough.static", []byte(`input = "updated"`))
+		require.NoError(t, err)
+
+		in, out := getFields(t, ctrl.loader.Graph(), "testcomponents.passthrough.static")
+		require.Equal(t, "updated", in.(testcomponents.PassthroughConfig).Input)
+		require.Equal(t, "updated", out.(testcomponents.PassthroughExports).Output)
+	})
+
+	t.Run("invalid update is rolled back", func(t *testing.T) {
+		err := ctrl.UpdateComponent("testcomponents.passthrough.static", []byte(`input = [1, 2]`))
+
+		var diags diag.Diagnostics
+		require.ErrorAs(t, err, &diags)
+		require.Equal(t, 1, diags[0].StartPos.Line)
+		require.Equal(t, editSourceName, diags[0].StartPos.Filename)
+
+		in, _ := getFields(t, ctrl.loader.Graph(), "testcomponents.passthrough.static")
+		require.Equal(t, "updated", in.(testcomponents.PassthroughConfig).Input)
+	})
+
+	t.Run("update of missing component", func(t *testing.T) {
+		err := ctrl.UpdateComponent("testcomponents.passthrough.missing", []byte(`input = "x"`))
+		require.ErrorIs(t, err, component.ErrComponentNotFound)
+	})
+
+	t.Run("add", func(t *testing.T) {
+		err := ctrl.AddComponent([]byte(`
+			testcomponents.passthrough "added" {
+				input = testcomponents.passthrough.static.output
+			}
+		`))
+		require.NoError(t, err)
+		require.Len(t, ctrl.loader.Components(), 5)
+
+		_, out := getFields(t, ctrl.loader.Graph(), "testcomponents.passthrough.added")
+		require.Equal(t, "updated", out.(testcomponents.PassthroughExports).Output)
+	})
+
+	t.Run("add existing component", func(t *testing.T) {
+		err := ctrl.AddComponent([]byte(`testcomponents.passthrough "added" { input = "x" }`))
+		require.ErrorIs(t, err, component.ErrComponentExists)
+	})
+
+	t.Run("add invalid reference is rolled back", func(t *testing.T) {
+		err := ctrl.AddComponent([]byte(`testcomponents.passthrough "broken" { input = testcomponents.passthrough.missing.output }`))
+
+		var diags diag.Diagnostics
+		require.ErrorAs(t, err, &diags)
+		require.Len(t, ctrl.loader.Components(), 5)
+	})
+
+	t.Run("add multiple blocks", func(t *testing.T) {
+		err := ctrl.AddComponent([]byte(`
+			testcomponents.passthrough "a" { input = "a" }
+			testcomponents.passthrough "b" { input = "b" }
+		`))
+
+		var diags diag.Diagnostics
+		require.ErrorAs(t, err, &diags)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		require.NoError(t, ctrl.RemoveComponent("testcomponents.passthrough.added"))
+		require.Len(t, ctrl.loader.Components(), 4)
+
+		err := ctrl.RemoveComponent("testcomponents.passthrough.added")
+		require.ErrorIs(t, err, component.ErrComponentNotFound)
+	})
+
+	t.Run("remove referenced component is rolled back", func(t *testing.T) {
+		err := ctrl.RemoveComponent("testcomponents.passthrough.ticker")
+
+		var diags diag.Diagnostics
+		require.ErrorAs(t, err, &diags)
+		require.Len(t, ctrl.loader.Components(), 4)
+	})
+}
@@ -3,6 +3,7 @@ package flow
 import (
 	"context"
 
+	"github.com/grafana/agent/internal/component"
 	"github.com/grafana/agent/internal/flow/internal/controller"
 	"github.com/grafana/agent/internal/flow/internal/dag"
 	"github.com/grafana/agent/internal/flow/internal/worker"
@@ -102,3 +103,6 @@ func (sc serviceController) LoadSource(b []byte, args map[string]any) error {
 	return sc.f.LoadSource(source, args)
 }
 func (sc serviceController) Ready() bool { return sc.f.Ready() }
+func (sc serviceController) ListComponents(moduleID string, opts component.InfoOptions) ([]*component.Info, error) {
+	return sc.f.ListComponents(moduleID, opts)
+}
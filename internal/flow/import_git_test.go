@@ -6,6 +6,10 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -287,6 +291,79 @@ testImport.add "cc" {
 	}, 5*time.Second, 100*time.Millisecond)
 }
 
+func TestPullUpdatingVerified(t *testing.T) {
+	testRepo := t.TempDir()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	pubBytes, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes}), 0666)
+	require.NoError(t, err)
+
+	main := `
+import.git "testImport" {
+	repository = "` + testRepo + `"
+	path = "math.river"
+	pull_frequency = "100ms"
+
+	verify {
+		public_key_file = "` + keyFile + `"
+	}
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+	runGit(t, testRepo, "init", testRepo)
+
+	math := filepath.Join(testRepo, "math.river")
+	commit := func(content string, signature []byte) {
+		require.NoError(t, os.WriteFile(math, []byte(content), 0666))
+		require.NoError(t, os.WriteFile(math+".sig", signature, 0666))
+		runGit(t, testRepo, "add", ".")
+		runGit(t, testRepo, "commit", "-m", "update")
+	}
+	commit(contents, ed25519.Sign(priv, []byte(contents)))
+
+	ctrl, f := setup(t, main)
+	err = ctrl.LoadSource(f, nil)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ctrl.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 100*time.Millisecond)
+
+	// Content which doesn't match its signature isn't loaded.
+	commit(contentsMore, ed25519.Sign(priv, []byte(contents)))
+	time.Sleep(500 * time.Millisecond)
+	export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+	require.Equal(t, 2, export["sum"])
+
+	commit(contentsMore, ed25519.Sign(priv, []byte(contentsMore)))
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 3
+	}, 5*time.Second, 100*time.Millisecond)
+}
+
 func runGit(t *testing.T, dir string, args ...string) {
 	exe := exec.Command("git", args...)
 	var stdErr bytes.Buffer
@@ -0,0 +1,159 @@
+//go:build linux
+
+package flow_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/grafana/agent/internal/oci"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportOCI(t *testing.T) {
+	srv := httptest.NewServer(registry.New())
+	defer srv.Close()
+
+	ref := strings.TrimPrefix(srv.URL, "http://") + "/modules/math:latest"
+	pushModule(t, ref, contents)
+
+	main := `
+import.oci "testImport" {
+	reference      = "` + ref + `"
+	path           = "math.river"
+	pull_frequency = "100ms"
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+	ctrl, f := setup(t, main)
+	err := ctrl.LoadSource(f, nil)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ctrl.Run(ctx)
+	}()
+
+	// Check for initial condition
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 100*time.Millisecond)
+
+	// Move the tag to a new version of the module.
+	pushModule(t, ref, contentsMore)
+
+	// Check for final condition.
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 3
+	}, 5*time.Second, 100*time.Millisecond)
+}
+
+func TestImportOCIVerify(t *testing.T) {
+	srv := httptest.NewServer(registry.New())
+	defer srv.Close()
+
+	ref := strings.TrimPrefix(srv.URL, "http://") + "/modules/math:latest"
+	pushModule(t, ref, contents)
+
+	tt := []struct {
+		name      string
+		sha256    string
+		expectErr string
+	}{
+		{
+			name:   "matching checksum",
+			sha256: fmt.Sprintf("%x", sha256.Sum256([]byte(contents))),
+		},
+		{
+			name:      "mismatching checksum",
+			sha256:    fmt.Sprintf("%x", sha256.Sum256([]byte(contentsMore))),
+			expectErr: `verification of "math.river" failed`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			main := `
+import.oci "testImport" {
+	reference = "` + ref + `"
+	path      = "math.river"
+
+	verify {
+		sha256 = "` + tc.sha256 + `"
+	}
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+			ctrl, f := setup(t, main)
+			err := ctrl.LoadSource(f, nil)
+			ctx, cancel := context.WithCancel(context.Background())
+
+			var wg sync.WaitGroup
+			defer func() {
+				cancel()
+				wg.Wait()
+			}()
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctrl.Run(ctx)
+			}()
+
+			if tc.expectErr != "" {
+				require.ErrorContains(t, err, tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Eventually(t, func() bool {
+				export := getExport[map[string]interface{}](t, ctrl, "", "testImport.add.cc")
+				return export["sum"] == 2
+			}, 5*time.Second, 100*time.Millisecond)
+		})
+	}
+}
+
+// pushModule pushes an artifact holding the math.river file, as pushed by
+// `oras push REFERENCE math.river`.
+func pushModule(t *testing.T, ref string, module string) {
+	t.Helper()
+
+	img, err := mutate.Append(mutate.MediaType(empty.Image, types.OCIManifestSchema1), mutate.Addendum{
+		Layer:       static.NewLayer([]byte(module), types.OCILayer),
+		Annotations: map[string]string{oci.AnnotationTitle: "math.river"},
+	})
+	require.NoError(t, err)
+	tag, err := name.ParseReference(ref)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(tag, img))
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/grafana/river/vm"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/atomic"
 )
 
 // ComponentID is a fully-qualified name of a component. Each element in
@@ -88,16 +89,19 @@ type BuiltinComponentNode struct {
 	nodeID            string // Cached from id.String() to avoid allocating new strings every time NodeID is called.
 	reg               component.Registration
 	managedOpts       component.Options
-	registry          *prometheus.Registry
+	registry          atomic.Pointer[prometheus.Registry] // Registry of the current managed component.
+	restarts          prometheus.Counter
 	exportsType       reflect.Type
 	moduleController  ModuleController
 	OnBlockNodeUpdate func(cn BlockNode) // Informs controller that we need to reevaluate
 
-	mut     sync.RWMutex
-	block   *ast.BlockStmt // Current River block to derive args from
-	eval    *vm.Evaluator
-	managed component.Component // Inner managed component
-	args    component.Arguments // Evaluated arguments for the managed component
+	mut      sync.RWMutex
+	block    *ast.BlockStmt // Current River block to derive args from
+	eval     *vm.Evaluator
+	policies []*ast.BlockStmt    // restart_policy blocks removed from block
+	managed  component.Component // Inner managed component
+	args     component.Arguments // Evaluated arguments for the managed component
+	policy   RestartPolicy       // Evaluated restart policy for the managed component
 
 	// NOTE(rfratto): health and exports have their own mutex because they may be
 	// set asynchronously while mut is still being held (i.e., when calling Evaluate
@@ -137,6 +141,8 @@ func NewBuiltinComponentNode(globals ComponentGlobals, reg component.Registratio
 		globalID = path.Join(globals.ControllerID, nodeID)
 	}
 
+	body, policies := splitRestartPolicy(b.Body)
+
 	cn := &BuiltinComponentNode{
 		id:                id,
 		globalID:          globalID,
@@ -147,33 +153,33 @@ func NewBuiltinComponentNode(globals ComponentGlobals, reg component.Registratio
 		exportsType:       getExportsType(reg),
 		moduleController:  globals.NewModuleController(globalID),
 		OnBlockNodeUpdate: globals.OnBlockNodeUpdate,
+		restarts:          newRestartsCounter(),
 
-		block: b,
-		eval:  vm.New(b.Body),
+		block:    b,
+		eval:     vm.New(body),
+		policies: policies,
 
 		// Prepopulate arguments and exports with their zero values.
 		args:    reg.Args,
 		exports: reg.Exports,
+		policy:  DefaultRestartPolicy,
 
 		evalHealth: initHealth,
 		runHealth:  initHealth,
 	}
 	cn.managedOpts = getManagedOptions(globals, cn)
+	cn.managedOpts.Registerer.MustRegister(cn.restarts)
 
 	return cn
 }
 
 func getManagedOptions(globals ComponentGlobals, cn *BuiltinComponentNode) component.Options {
-	cn.registry = prometheus.NewRegistry()
 	parent, id := splitPath(cn.globalID)
 	return component.Options{
-		ID:     cn.globalID,
-		Logger: log.With(globals.Logger, "component_path", parent, "component_id", id),
-		Registerer: prometheus.WrapRegistererWith(prometheus.Labels{
-			"component_path": parent,
-			"component_id":   id,
-		}, cn.registry),
-		Tracer: tracing.WrapTracer(globals.TraceProvider, cn.globalID),
+		ID:         cn.globalID,
+		Logger:     log.With(globals.Logger, "component_path", parent, "component_id", id),
+		Registerer: cn.newRegisterer(),
+		Tracer:     tracing.WrapTracer(globals.TraceProvider, cn.globalID),
 
 		DataPath: filepath.Join(globals.DataPath, cn.globalID),
 
@@ -186,6 +192,20 @@ func getManagedOptions(globals ComponentGlobals, cn *BuiltinComponentNode) compo
 	}
 }
 
+// newRegisterer replaces the registry of the node with an empty one, and
+// returns the Registerer of the managed component registering to it.
+func (cn *BuiltinComponentNode) newRegisterer() prometheus.Registerer {
+	registry := prometheus.NewRegistry()
+	parent, id := splitPath(cn.globalID)
+	registerer := prometheus.WrapRegistererWith(prometheus.Labels{
+		"component_path": parent,
+		"component_id":   id,
+	}, registry)
+
+	cn.registry.Store(registry)
+	return registerer
+}
+
 func getExportsType(reg component.Registration) reflect.Type {
 	if reg.Exports != nil {
 		return reflect.TypeOf(reg.Exports)
@@ -231,8 +251,10 @@ func (cn *BuiltinComponentNode) UpdateBlock(b *ast.BlockStmt) {
 
 	cn.mut.Lock()
 	defer cn.mut.Unlock()
+	body, policies := splitRestartPolicy(b.Body)
 	cn.block = b
-	cn.eval = vm.New(b.Body)
+	cn.eval = vm.New(body)
+	cn.policies = policies
 }
 
 // Evaluate implements BlockNode and updates the arguments for the managed component
@@ -258,10 +280,16 @@ func (cn *BuiltinComponentNode) evaluate(scope *vm.Scope) error {
 	cn.mut.Lock()
 	defer cn.mut.Unlock()
 
+	policy, err := cn.evaluatePolicy(scope)
+	if err != nil {
+		return err
+	}
+
 	argsPointer := cn.reg.CloneArguments()
 	if err := cn.eval.Evaluate(scope, argsPointer); err != nil {
 		return fmt.Errorf("decoding River: %w", err)
 	}
+	cn.policy = policy
 
 	// args is always a pointer to the args type, so we want to deference it since
 	// components expect a non-pointer.
@@ -295,12 +323,37 @@ func (cn *BuiltinComponentNode) evaluate(scope *vm.Scope) error {
 	return nil
 }
 
+// evaluatePolicy evaluates the restart_policy block of the component, if any.
+func (cn *BuiltinComponentNode) evaluatePolicy(scope *vm.Scope) (RestartPolicy, error) {
+	policy := DefaultRestartPolicy
+
+	switch len(cn.policies) {
+	case 0:
+		return policy, nil
+	case 1:
+		b := cn.policies[0]
+		if b.Label != "" {
+			return policy, fmt.Errorf("%s block must not have a label", restartPolicyBlockName)
+		}
+		if err := vm.New(b.Body).Evaluate(scope, &policy); err != nil {
+			return policy, fmt.Errorf("decoding %s block: %w", restartPolicyBlockName, err)
+		}
+		return policy, nil
+	default:
+		return policy, fmt.Errorf("%s block may only be specified once", restartPolicyBlockName)
+	}
+}
+
 // Run runs the managed component in the calling goroutine until ctx is
 // canceled. Evaluate must have been called at least once without returning an
 // error before calling Run.
 //
+// If the managed component exits before ctx is canceled, it is restarted
+// according to its restart policy.
+//
 // Run will immediately return ErrUnevaluated if Evaluate has never been called
-// successfully. Otherwise, Run will return nil.
+// successfully. Otherwise, Run will return the error the managed component
+// last exited with.
 func (cn *BuiltinComponentNode) Run(ctx context.Context) error {
 	cn.mut.RLock()
 	managed := cn.managed
@@ -310,21 +363,84 @@ func (cn *BuiltinComponentNode) Run(ctx context.Context) error {
 		return ErrUnevaluated
 	}
 
-	cn.setRunHealth(component.HealthTypeHealthy, "started component")
-	err := cn.managed.Run(ctx)
+	var (
+		logger = cn.managedOpts.Logger
+
+		// restarts is the number of consecutive restarts of the managed
+		// component. It is reset once the component runs for longer than the
+		// maximum backoff of its restart policy.
+		restarts int
+	)
+
+	for {
+		cn.setRunHealth(component.HealthTypeHealthy, "started component")
+		startTime := time.Now()
+		err := managed.Run(ctx)
+
+		var exitMsg string
+		if err != nil {
+			level.Error(logger).Log("msg", "component exited with error", "err", err)
+			exitMsg = fmt.Sprintf("component shut down with error: %s", err)
+		} else {
+			level.Info(logger).Log("msg", "component exited")
+			exitMsg = "component shut down normally"
+		}
+
+		for {
+			cn.mut.RLock()
+			policy := cn.policy
+			cn.mut.RUnlock()
+
+			if time.Since(startTime) >= policy.MaxBackoff {
+				restarts = 0
+			}
+			if ctx.Err() != nil || !policy.shouldRestart(err, restarts) {
+				cn.setRunHealth(component.HealthTypeExited, exitMsg)
+				return err
+			}
+
+			delay := policy.backoff(restarts)
+			restarts++
+
+			level.Warn(logger).Log("msg", "restarting component", "restarts", restarts, "backoff", delay)
+			cn.setRunHealth(component.HealthTypeUnhealthy, fmt.Sprintf("%s, restarting in %s", exitMsg, delay))
+
+			select {
+			case <-ctx.Done():
+				cn.setRunHealth(component.HealthTypeExited, exitMsg)
+				return err
+			case <-time.After(delay):
+			}
+			cn.restarts.Inc()
+
+			// Components may tear down their state when Run returns, so each
+			// restart runs a new instance built from the current arguments.
+			startTime = time.Now()
+			managed, err = cn.rebuild()
+			if err == nil {
+				break
+			}
+			level.Error(logger).Log("msg", "failed to rebuild component", "err", err)
+			exitMsg = fmt.Sprintf("component failed to restart: %s", err)
+		}
+	}
+}
+
+// rebuild replaces the managed component with a new instance built from the
+// current arguments.
+func (cn *BuiltinComponentNode) rebuild() (component.Component, error) {
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
 
-	var exitMsg string
-	logger := cn.managedOpts.Logger
+	opts := cn.managedOpts
+	opts.Registerer = cn.newRegisterer()
+	opts.Registerer.MustRegister(cn.restarts)
+	managed, err := cn.reg.Build(opts, cn.args)
 	if err != nil {
-		level.Error(logger).Log("msg", "component exited with error", "err", err)
-		exitMsg = fmt.Sprintf("component shut down with error: %s", err)
-	} else {
-		level.Info(logger).Log("msg", "component exited")
-		exitMsg = "component shut down normally"
+		return nil, fmt.Errorf("building component: %w", err)
 	}
-
-	cn.setRunHealth(component.HealthTypeExited, exitMsg)
-	return err
+	cn.managed = managed
+	return managed, nil
 }
 
 // ErrUnevaluated is returned if BuiltinComponentNode.Run is called before a managed
@@ -393,6 +509,9 @@ func (cn *BuiltinComponentNode) setExports(e component.Exports) {
 //  2. Health from the last call to Evaluate().
 //  3. Health reported from the component.
 func (cn *BuiltinComponentNode) CurrentHealth() component.Health {
+	// The managed component is replaced when it's restarted.
+	managed := cn.Component()
+
 	cn.healthMut.RLock()
 	defer cn.healthMut.RUnlock()
 
@@ -401,7 +520,7 @@ func (cn *BuiltinComponentNode) CurrentHealth() component.Health {
 		evalHealth = cn.evalHealth
 	)
 
-	if hc, ok := cn.managed.(component.HealthComponent); ok {
+	if hc, ok := managed.(component.HealthComponent); ok {
 		componentHealth := hc.CurrentHealth()
 		return component.LeastHealthy(runHealth, evalHealth, componentHealth)
 	}
@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/river"
+	"github.com/grafana/river/ast"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// restartPolicyBlockName is the name of the block which configures the
+// restart policy of a component inside its River block. The block is removed
+// from the body of the component before its arguments are decoded.
+const restartPolicyBlockName = "restart_policy"
+
+// RestartMode determines when a component is restarted after its Run method
+// exits.
+type RestartMode string
+
+// Supported restart modes.
+const (
+	// RestartModeNever never restarts a component.
+	RestartModeNever RestartMode = "never"
+	// RestartModeOnFailure restarts a component which exited with an error.
+	RestartModeOnFailure RestartMode = "on-failure"
+	// RestartModeAlways restarts a component whenever it exits.
+	RestartModeAlways RestartMode = "always"
+)
+
+var (
+	_ encoding.TextMarshaler   = RestartModeNever
+	_ encoding.TextUnmarshaler = (*RestartMode)(nil)
+)
+
+// MarshalText implements encoding.TextMarshaler, returning the raw bytes of
+// the RestartMode.
+func (m RestartMode) MarshalText() (text []byte, err error) {
+	return []byte(m), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. UnmarshalText returns an
+// error if the text is not recognized as a valid RestartMode.
+func (m *RestartMode) UnmarshalText(text []byte) error {
+	switch RestartMode(text) {
+	case RestartModeNever, RestartModeOnFailure, RestartModeAlways:
+		*m = RestartMode(text)
+		return nil
+	default:
+		return fmt.Errorf("unsupported mode %q, must be one of %q, %q or %q", text, RestartModeNever, RestartModeOnFailure, RestartModeAlways)
+	}
+}
+
+// RestartPolicy configures how a component is restarted after its Run method
+// exits before the component is shut down.
+type RestartPolicy struct {
+	Mode       RestartMode   `river:"mode,attr"`
+	MinBackoff time.Duration `river:"min_backoff,attr,optional"`
+	MaxBackoff time.Duration `river:"max_backoff,attr,optional"`
+	// MaxRestarts is the maximum number of consecutive restarts, or 0 for no
+	// limit.
+	MaxRestarts int `river:"max_restarts,attr,optional"`
+}
+
+var (
+	_ river.Defaulter = (*RestartPolicy)(nil)
+	_ river.Validator = (*RestartPolicy)(nil)
+)
+
+// DefaultRestartPolicy is the restart policy of components without a
+// restart_policy block. Components are never restarted by default.
+var DefaultRestartPolicy = RestartPolicy{
+	Mode:       RestartModeNever,
+	MinBackoff: time.Second,
+	MaxBackoff: time.Minute,
+}
+
+// SetToDefault implements river.Defaulter.
+func (p *RestartPolicy) SetToDefault() {
+	*p = DefaultRestartPolicy
+}
+
+// Validate implements river.Validator.
+func (p *RestartPolicy) Validate() error {
+	if p.MinBackoff <= 0 {
+		return errors.New("min_backoff must be greater than 0")
+	}
+	if p.MaxBackoff < p.MinBackoff {
+		return errors.New("max_backoff must be greater than or equal to min_backoff")
+	}
+	if p.MaxRestarts < 0 {
+		return errors.New("max_restarts must not be negative")
+	}
+	return nil
+}
+
+// shouldRestart returns whether a component which exited with err after
+// restarts consecutive restarts must be restarted.
+func (p *RestartPolicy) shouldRestart(err error, restarts int) bool {
+	if p.MaxRestarts > 0 && restarts >= p.MaxRestarts {
+		return false
+	}
+
+	switch p.Mode {
+	case RestartModeAlways:
+		return true
+	case RestartModeOnFailure:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+// backoff returns the delay before the restart following restarts
+// consecutive restarts. The delay doubles with each restart, up to
+// MaxBackoff.
+func (p *RestartPolicy) backoff(restarts int) time.Duration {
+	delay := p.MinBackoff
+	for i := 0; i < restarts && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay
+}
+
+// splitRestartPolicy returns body without its restart_policy blocks, and the
+// restart_policy blocks.
+func splitRestartPolicy(body ast.Body) (ast.Body, []*ast.BlockStmt) {
+	var (
+		rest     = make(ast.Body, 0, len(body))
+		policies []*ast.BlockStmt
+	)
+	for _, stmt := range body {
+		if b, ok := stmt.(*ast.BlockStmt); ok && len(b.Name) == 1 && b.Name[0] == restartPolicyBlockName {
+			policies = append(policies, b)
+			continue
+		}
+		rest = append(rest, stmt)
+	}
+	return rest, policies
+}
+
+// newRestartsCounter returns the counter of the restarts of a component.
+func newRestartsCounter() prometheus.Counter {
+	return prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "agent_component_restarts_total",
+		Help: "Total number of times the component was restarted after it exited.",
+	})
+}
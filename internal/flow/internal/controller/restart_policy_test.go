@@ -0,0 +1,300 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/flow/logging"
+	"github.com/grafana/river/ast"
+	"github.com/grafana/river/parser"
+	"github.com/grafana/river/vm"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestRestartPolicy_Validate(t *testing.T) {
+	tt := []struct {
+		name     string
+		policy   func(p *RestartPolicy)
+		errorMsg string
+	}{
+		{name: "default", policy: func(p *RestartPolicy) {}},
+		{name: "on-failure", policy: func(p *RestartPolicy) { p.Mode = RestartModeOnFailure }},
+		{
+			name:     "zero min_backoff",
+			policy:   func(p *RestartPolicy) { p.MinBackoff = 0 },
+			errorMsg: "min_backoff must be greater than 0",
+		},
+		{
+			name:     "max_backoff lower than min_backoff",
+			policy:   func(p *RestartPolicy) { p.MaxBackoff = p.MinBackoff / 2 },
+			errorMsg: "max_backoff must be greater than or equal to min_backoff",
+		},
+		{
+			name:     "negative max_restarts",
+			policy:   func(p *RestartPolicy) { p.MaxRestarts = -1 },
+			errorMsg: "max_restarts must not be negative",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			p := DefaultRestartPolicy
+			tc.policy(&p)
+
+			err := p.Validate()
+			if tc.errorMsg != "" {
+				require.ErrorContains(t, err, tc.errorMsg)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRestartPolicy_ShouldRestart(t *testing.T) {
+	errExit := errors.New("exit")
+
+	tt := []struct {
+		mode        RestartMode
+		maxRestarts int
+		err         error
+		restarts    int
+		expect      bool
+	}{
+		{mode: RestartModeNever, err: errExit, expect: false},
+		{mode: RestartModeNever, err: nil, expect: false},
+		{mode: RestartModeOnFailure, err: errExit, expect: true},
+		{mode: RestartModeOnFailure, err: nil, expect: false},
+		{mode: RestartModeAlways, err: errExit, expect: true},
+		{mode: RestartModeAlways, err: nil, expect: true},
+		{mode: RestartModeAlways, maxRestarts: 3, restarts: 2, expect: true},
+		{mode: RestartModeAlways, maxRestarts: 3, restarts: 3, expect: false},
+	}
+
+	for _, tc := range tt {
+		p := RestartPolicy{Mode: tc.mode, MaxRestarts: tc.maxRestarts}
+		require.Equal(t, tc.expect, p.shouldRestart(tc.err, tc.restarts), "%+v", tc)
+	}
+}
+
+func TestRestartPolicy_Backoff(t *testing.T) {
+	p := RestartPolicy{MinBackoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	var actual []time.Duration
+	for i := 0; i < 5; i++ {
+		actual = append(actual, p.backoff(i))
+	}
+	expect := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	require.Equal(t, expect, actual)
+}
+
+func TestBuiltinComponentNode_RestartPolicy(t *testing.T) {
+	errExit := errors.New("exit")
+
+	tt := []struct {
+		name         string
+		policy       string
+		expectRuns   int
+		expectErr    error
+		expectHealth component.HealthType
+	}{
+		{
+			name:         "no policy",
+			expectRuns:   1,
+			expectErr:    errExit,
+			expectHealth: component.HealthTypeExited,
+		},
+		{
+			name: "max restarts",
+			policy: `
+				restart_policy {
+					mode         = "on-failure"
+					min_backoff  = "1ms"
+					max_backoff  = "1ms"
+					max_restarts = 3
+				}
+			`,
+			expectRuns:   4,
+			expectErr:    errExit,
+			expectHealth: component.HealthTypeExited,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var runs int
+			reg := exitingRegistration(func() error {
+				runs++
+				return errExit
+			})
+
+			cn := newTestComponentNode(t, reg, tc.policy)
+			require.NoError(t, cn.Evaluate(&vm.Scope{}))
+
+			err := cn.Run(context.Background())
+			require.ErrorIs(t, err, tc.expectErr)
+			require.Equal(t, tc.expectRuns, runs)
+			require.Equal(t, tc.expectHealth, cn.CurrentHealth().Health)
+			require.Equal(t, float64(tc.expectRuns-1), testutil.ToFloat64(cn.restarts))
+		})
+	}
+}
+
+func TestBuiltinComponentNode_RestartPolicyCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reg := exitingRegistration(func() error {
+		cancel()
+		return nil
+	})
+	cn := newTestComponentNode(t, reg, `
+		restart_policy {
+			mode        = "always"
+			min_backoff = "1h"
+			max_backoff = "1h"
+		}
+	`)
+	require.NoError(t, cn.Evaluate(&vm.Scope{}))
+
+	// The component must not be restarted once ctx is canceled, even though
+	// its policy always restarts it.
+	require.NoError(t, cn.Run(ctx))
+	require.Equal(t, component.HealthTypeExited, cn.CurrentHealth().Health)
+	require.Equal(t, float64(0), testutil.ToFloat64(cn.restarts))
+}
+
+func TestBuiltinComponentNode_RestartRebuilds(t *testing.T) {
+	var (
+		errExit   = errors.New("exit")
+		errClosed = errors.New("run after teardown")
+		builds    int
+		runErrs   []error
+	)
+
+	reg := component.Registration{
+		Name: "testcomponents.exiting",
+		Args: struct{}{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			builds++
+			// Components register their metrics when they're built.
+			opts.Registerer.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{Name: "test_builds_total"}))
+			return &teardownComponent{errExit: errExit, errClosed: errClosed, runErrs: &runErrs}, nil
+		},
+	}
+	cn := newTestComponentNode(t, reg, `
+		restart_policy {
+			mode         = "on-failure"
+			min_backoff  = "1ms"
+			max_backoff  = "1ms"
+			max_restarts = 2
+		}
+	`)
+	require.NoError(t, cn.Evaluate(&vm.Scope{}))
+	first := cn.Component()
+
+	require.ErrorIs(t, cn.Run(context.Background()), errExit)
+
+	// Each restart ran a new instance instead of the one which was torn down.
+	require.Equal(t, 3, builds)
+	require.Equal(t, []error{errExit, errExit, errExit}, runErrs)
+	require.NotSame(t, first, cn.Component())
+	require.Equal(t, float64(2), testutil.ToFloat64(cn.restarts))
+}
+
+// teardownComponent tears down its state when Run returns, so it can't be run
+// again.
+type teardownComponent struct {
+	errExit, errClosed error
+	runErrs            *[]error
+	closed             bool
+}
+
+func (c *teardownComponent) Run(ctx context.Context) error {
+	err := c.errExit
+	if c.closed {
+		err = c.errClosed
+	}
+	c.closed = true
+	*c.runErrs = append(*c.runErrs, err)
+	return err
+}
+
+func (c *teardownComponent) Update(component.Arguments) error { return nil }
+
+func TestBuiltinComponentNode_RestartPolicyInvalid(t *testing.T) {
+	tt := []struct {
+		name     string
+		policy   string
+		errorMsg string
+	}{
+		{
+			name:     "missing mode",
+			policy:   `restart_policy {}`,
+			errorMsg: `missing required attribute "mode"`,
+		},
+		{
+			name:     "unsupported mode",
+			policy:   `restart_policy { mode = "sometimes" }`,
+			errorMsg: `unsupported mode "sometimes"`,
+		},
+		{
+			name: "duplicate block",
+			policy: `
+				restart_policy { mode = "always" }
+				restart_policy { mode = "never" }
+			`,
+			errorMsg: "restart_policy block may only be specified once",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			reg := exitingRegistration(func() error { return nil })
+			cn := newTestComponentNode(t, reg, tc.policy)
+			require.ErrorContains(t, cn.Evaluate(&vm.Scope{}), tc.errorMsg)
+		})
+	}
+}
+
+type exitingComponent struct {
+	run func() error
+}
+
+func (c *exitingComponent) Run(ctx context.Context) error    { return c.run() }
+func (c *exitingComponent) Update(component.Arguments) error { return nil }
+
+func exitingRegistration(run func() error) component.Registration {
+	return component.Registration{
+		Name: "testcomponents.exiting",
+		Args: struct{}{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return &exitingComponent{run: run}, nil
+		},
+	}
+}
+
+func newTestComponentNode(t *testing.T, reg component.Registration, body string) *BuiltinComponentNode {
+	t.Helper()
+
+	file, err := parser.ParseFile(t.Name(), []byte(`testcomponents.exiting "test" {`+body+`}`))
+	require.NoError(t, err)
+
+	l, err := logging.New(os.Stderr, logging.DefaultOptions)
+	require.NoError(t, err)
+
+	globals := ComponentGlobals{
+		Logger:              l,
+		TraceProvider:       noop.NewTracerProvider(),
+		DataPath:            t.TempDir(),
+		NewModuleController: func(id string) ModuleController { return nil },
+	}
+	return NewBuiltinComponentNode(globals, reg, file.Body[0].(*ast.BlockStmt))
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/grafana/agent/internal/component"
 	"github.com/grafana/agent/internal/flow/logging/level"
+	"github.com/grafana/agent/internal/integrity"
 	"github.com/grafana/agent/internal/vcs"
 	"github.com/grafana/river/vm"
 )
@@ -27,13 +28,11 @@ type ImportGit struct {
 	mut             sync.RWMutex
 	repo            *vcs.GitRepo
 	repoOpts        vcs.GitRepoOptions
+	verifier        *integrity.Verifier
 	args            GitArguments
 	onContentChange func(map[string]string)
 
-	argsChanged chan struct{}
-
-	healthMut sync.RWMutex
-	health    component.Health
+	poller
 }
 
 var (
@@ -48,6 +47,8 @@ type GitArguments struct {
 	Path          string            `river:"path,attr"`
 	PullFrequency time.Duration     `river:"pull_frequency,attr,optional"`
 	GitAuthConfig vcs.GitAuthConfig `river:",squash"`
+
+	Verify *integrity.Arguments `river:"verify,block,optional"`
 }
 
 var DefaultGitArguments = GitArguments{
@@ -65,8 +66,8 @@ func NewImportGit(managedOpts component.Options, eval *vm.Evaluator, onContentCh
 		opts:            managedOpts,
 		log:             managedOpts.Logger,
 		eval:            eval,
-		argsChanged:     make(chan struct{}, 1),
 		onContentChange: onContentChange,
+		poller:          newPoller(managedOpts.Logger, "repository", level.Info),
 	}
 }
 
@@ -86,83 +87,21 @@ func (im *ImportGit) Evaluate(scope *vm.Scope) error {
 	return nil
 }
 
+// Run implements component.Component.
 func (im *ImportGit) Run(ctx context.Context) error {
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
-
-	var (
-		ticker  *time.Ticker
-		tickerC <-chan time.Time
-	)
-
-	for {
-		select {
-		case <-ctx.Done():
-			// TODO: should we stope the ticker here?
-			return nil
-
-		case <-im.argsChanged:
-			im.mut.Lock()
-			pullFrequency := im.args.PullFrequency
-			im.mut.Unlock()
-			ticker, tickerC = im.updateTicker(pullFrequency, ticker, tickerC)
-
-		case <-tickerC:
-			level.Info(im.log).Log("msg", "updating repository")
-			im.tickPollFile(ctx)
-		}
-	}
+	return im.run(ctx, im.pullFrequency, im.tickPollFile)
 }
 
-func (im *ImportGit) updateTicker(pullFrequency time.Duration, ticker *time.Ticker, tickerC <-chan time.Time) (*time.Ticker, <-chan time.Time) {
-	level.Info(im.log).Log("msg", "updating repository pull frequency, next pull attempt will be done according to the pullFrequency", "new_frequency", pullFrequency)
-
-	if pullFrequency > 0 {
-		if ticker == nil {
-			ticker = time.NewTicker(pullFrequency)
-			tickerC = ticker.C
-		} else {
-			ticker.Reset(pullFrequency)
-		}
-		return ticker, tickerC
-	}
-
-	if ticker != nil {
-		ticker.Stop()
-	}
-	return nil, nil
+func (im *ImportGit) pullFrequency() time.Duration {
+	im.mut.RLock()
+	defer im.mut.RUnlock()
+	return im.args.PullFrequency
 }
 
-func (im *ImportGit) tickPollFile(ctx context.Context) {
+func (im *ImportGit) tickPollFile(ctx context.Context) error {
 	im.mut.Lock()
-	err := im.pollFile(ctx, im.args)
-	pullFrequency := im.args.PullFrequency
-	im.mut.Unlock()
-
-	im.updateHealth(err)
-
-	if err != nil {
-		level.Error(im.log).Log("msg", "failed to update repository", "pullFrequency", pullFrequency, "err", err)
-	}
-}
-
-func (im *ImportGit) updateHealth(err error) {
-	im.healthMut.Lock()
-	defer im.healthMut.Unlock()
-
-	if err != nil {
-		im.health = component.Health{
-			Health:     component.HealthTypeUnhealthy,
-			Message:    err.Error(),
-			UpdateTime: time.Now(),
-		}
-	} else {
-		im.health = component.Health{
-			Health:     component.HealthTypeHealthy,
-			Message:    "module updated",
-			UpdateTime: time.Now(),
-		}
-	}
+	defer im.mut.Unlock()
+	return im.pollFile(ctx, im.args)
 }
 
 // Update implements component.Component.
@@ -178,6 +117,12 @@ func (im *ImportGit) Update(args component.Arguments) (err error) {
 
 	newArgs := args.(GitArguments)
 
+	verifier, err := integrity.NewVerifier(newArgs.Verify)
+	if err != nil {
+		return err
+	}
+	im.verifier = verifier
+
 	// TODO(rfratto): store in a repo-specific directory so changing repositories
 	// doesn't risk break the module loader if there's a SHA collision between
 	// the two different repositories.
@@ -217,10 +162,7 @@ func (im *ImportGit) Update(args component.Arguments) (err error) {
 	}
 
 	// Schedule an update for handling the changed arguments.
-	select {
-	case im.argsChanged <- struct{}{}:
-	default:
-	}
+	im.scheduleUpdate()
 
 	im.args = newArgs
 	return nil
@@ -247,6 +189,10 @@ func (im *ImportGit) pollFile(ctx context.Context, args GitArguments) error {
 }
 
 func (im *ImportGit) handleDirectory(path string) error {
+	if !im.verifier.CanVerifyDirectories() {
+		return fmt.Errorf("%q is a directory: only signatures stored next to each file can be used to verify directories", path)
+	}
+
 	filesInfo, err := im.repo.ReadDir(path)
 	if err != nil {
 		return err
@@ -257,10 +203,14 @@ func (im *ImportGit) handleDirectory(path string) error {
 		if fi.IsDir() || !strings.HasSuffix(fi.Name(), ".river") {
 			continue
 		}
-		bb, err := im.repo.ReadFile(filepath.Join(path, fi.Name()))
+		filePath := filepath.Join(path, fi.Name())
+		bb, err := im.repo.ReadFile(filePath)
 		if err != nil {
 			return err
 		}
+		if err := im.verifier.Verify(filePath, bb, im.repo.ReadFile); err != nil {
+			return err
+		}
 		content[fi.Name()] = string(bb)
 	}
 	im.onContentChange(content)
@@ -272,17 +222,13 @@ func (im *ImportGit) handleFile(path string) error {
 	if err != nil {
 		return err
 	}
+	if err := im.verifier.Verify(path, bb, im.repo.ReadFile); err != nil {
+		return err
+	}
 	im.onContentChange(map[string]string{path: string(bb)})
 	return nil
 }
 
-// CurrentHealth implements component.HealthComponent.
-func (im *ImportGit) CurrentHealth() component.Health {
-	im.healthMut.RLock()
-	defer im.healthMut.RUnlock()
-	return im.health
-}
-
 // Update the evaluator.
 func (im *ImportGit) SetEval(eval *vm.Evaluator) {
 	im.eval = eval
@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/agent/internal/component"
 	common_config "github.com/grafana/agent/internal/component/common/config"
 	remote_http "github.com/grafana/agent/internal/component/remote/http"
+	"github.com/grafana/agent/internal/integrity"
 	"github.com/grafana/river/vm"
 )
 
@@ -45,6 +46,8 @@ type HTTPArguments struct {
 	Body    string            `river:"body,attr,optional"`
 
 	Client common_config.HTTPClientConfig `river:"client,block,optional"`
+
+	Verify *integrity.Arguments `river:"verify,block,optional"`
 }
 
 // DefaultHTTPArguments holds default settings for HTTPArguments.
@@ -60,6 +63,21 @@ func (args *HTTPArguments) SetToDefault() {
 	*args = DefaultHTTPArguments
 }
 
+// remoteHTTPArguments converts the arguments to the arguments of the managed
+// remote.http component.
+func (args HTTPArguments) remoteHTTPArguments() remote_http.Arguments {
+	return remote_http.Arguments{
+		URL:           args.URL,
+		PollFrequency: args.PollFrequency,
+		PollTimeout:   args.PollTimeout,
+		Method:        args.Method,
+		Headers:       args.Headers,
+		Body:          args.Body,
+		Client:        args.Client,
+		Verify:        args.Verify,
+	}
+}
+
 func (im *ImportHTTP) Evaluate(scope *vm.Scope) error {
 	var arguments HTTPArguments
 	if err := im.eval.Evaluate(scope, &arguments); err != nil {
@@ -67,15 +85,7 @@ func (im *ImportHTTP) Evaluate(scope *vm.Scope) error {
 	}
 	if im.managedRemoteHTTP == nil {
 		var err error
-		im.managedRemoteHTTP, err = remote_http.New(im.managedOpts, remote_http.Arguments{
-			URL:           arguments.URL,
-			PollFrequency: arguments.PollFrequency,
-			PollTimeout:   arguments.PollTimeout,
-			Method:        arguments.Method,
-			Headers:       arguments.Headers,
-			Body:          arguments.Body,
-			Client:        arguments.Client,
-		})
+		im.managedRemoteHTTP, err = remote_http.New(im.managedOpts, arguments.remoteHTTPArguments())
 		if err != nil {
 			return fmt.Errorf("creating http component: %w", err)
 		}
@@ -87,7 +97,7 @@ func (im *ImportHTTP) Evaluate(scope *vm.Scope) error {
 	}
 
 	// Update the existing managed component
-	if err := im.managedRemoteHTTP.Update(arguments); err != nil {
+	if err := im.managedRemoteHTTP.Update(arguments.remoteHTTPArguments()); err != nil {
 		return fmt.Errorf("updating component: %w", err)
 	}
 	im.arguments = arguments
@@ -0,0 +1,244 @@
+package importsource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/flow/logging/level"
+	"github.com/grafana/agent/internal/integrity"
+	"github.com/grafana/agent/internal/oci"
+	"github.com/grafana/river/vm"
+)
+
+// ImportOCI imports a module from an artifact stored in an OCI registry.
+type ImportOCI struct {
+	opts            component.Options
+	log             log.Logger
+	eval            *vm.Evaluator
+	mut             sync.RWMutex
+	repo            *oci.Repository
+	repoOpts        oci.RepositoryOptions
+	verifier        *integrity.Verifier
+	args            OCIArguments
+	onContentChange func(map[string]string)
+
+	poller
+}
+
+var (
+	_ ImportSource              = (*ImportOCI)(nil)
+	_ component.Component       = (*ImportOCI)(nil)
+	_ component.HealthComponent = (*ImportOCI)(nil)
+)
+
+type OCIArguments struct {
+	Reference     string         `river:"reference,attr"`
+	Path          string         `river:"path,attr,optional"`
+	PullFrequency time.Duration  `river:"pull_frequency,attr,optional"`
+	PlainHTTP     bool           `river:"plain_http,attr,optional"`
+	Insecure      bool           `river:"insecure,attr,optional"`
+	Auth          oci.AuthConfig `river:",squash"`
+
+	Verify *integrity.Arguments `river:"verify,block,optional"`
+}
+
+var DefaultOCIArguments = OCIArguments{
+	Path:          ".",
+	PullFrequency: time.Minute,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *OCIArguments) SetToDefault() {
+	*args = DefaultOCIArguments
+}
+
+// Validate implements river.Validator.
+func (args *OCIArguments) Validate() error {
+	if args.PullFrequency < 0 {
+		return fmt.Errorf("pull_frequency must not be negative")
+	}
+	return args.Auth.Validate()
+}
+
+func NewImportOCI(managedOpts component.Options, eval *vm.Evaluator, onContentChange func(map[string]string)) *ImportOCI {
+	return &ImportOCI{
+		opts:            managedOpts,
+		log:             managedOpts.Logger,
+		eval:            eval,
+		onContentChange: onContentChange,
+		poller:          newPoller(managedOpts.Logger, "artifact", level.Debug),
+	}
+}
+
+func (im *ImportOCI) Evaluate(scope *vm.Scope) error {
+	var arguments OCIArguments
+	if err := im.eval.Evaluate(scope, &arguments); err != nil {
+		return fmt.Errorf("decoding River: %w", err)
+	}
+
+	if reflect.DeepEqual(im.args, arguments) {
+		return nil
+	}
+
+	if err := im.Update(arguments); err != nil {
+		return fmt.Errorf("updating component: %w", err)
+	}
+	return nil
+}
+
+// Run implements component.Component.
+func (im *ImportOCI) Run(ctx context.Context) error {
+	return im.run(ctx, im.pullFrequency, im.tickPollFile)
+}
+
+func (im *ImportOCI) pullFrequency() time.Duration {
+	im.mut.RLock()
+	defer im.mut.RUnlock()
+	return im.args.PullFrequency
+}
+
+func (im *ImportOCI) tickPollFile(ctx context.Context) error {
+	im.mut.Lock()
+	defer im.mut.Unlock()
+	return im.pollFile(ctx, im.args)
+}
+
+// Update implements component.Component.
+// An oci.UpdateFailedError means that a previously downloaded artifact is
+// cached on disk: the cached module is loaded, the import is reported as
+// unhealthy and the update is retried on the next poll.
+func (im *ImportOCI) Update(args component.Arguments) (err error) {
+	// updateErr is reported in the health of the import when the module is
+	// loaded from a cached artifact.
+	var updateErr error
+	defer func() {
+		if err != nil {
+			im.updateHealth(err)
+		} else {
+			im.updateHealth(updateErr)
+		}
+	}()
+	im.mut.Lock()
+	defer im.mut.Unlock()
+
+	newArgs := args.(OCIArguments)
+
+	verifier, err := integrity.NewVerifier(newArgs.Verify)
+	if err != nil {
+		return err
+	}
+	im.verifier = verifier
+
+	repoOpts := oci.RepositoryOptions{
+		Reference:          newArgs.Reference,
+		PlainHTTP:          newArgs.PlainHTTP,
+		InsecureSkipVerify: newArgs.Insecure,
+		Auth:               newArgs.Auth,
+	}
+
+	if im.repo == nil || !reflect.DeepEqual(repoOpts, im.repoOpts) {
+		r, err := oci.NewRepository(context.Background(), filepath.Join(im.opts.DataPath, "oci"), repoOpts)
+		if err != nil {
+			if !errors.As(err, &oci.UpdateFailedError{}) {
+				return err
+			}
+			level.Error(im.log).Log("msg", "failed to update artifact, using cached artifact", "err", err)
+			updateErr = err
+		}
+		im.repo = r
+		im.repoOpts = repoOpts
+		if err := im.loadContent(newArgs.Path); err != nil {
+			return err
+		}
+	} else if err := im.pollFile(context.Background(), newArgs); err != nil {
+		if !errors.As(err, &oci.UpdateFailedError{}) {
+			return err
+		}
+		level.Error(im.log).Log("msg", "failed to update artifact", "err", err)
+		updateErr = err
+	}
+
+	// Schedule an update for handling the changed arguments.
+	im.scheduleUpdate()
+
+	im.args = newArgs
+	return nil
+}
+
+// pollFile checks the registry for a new version of the artifact and updates
+// the controller. pollFile must only be called with im.mut held.
+func (im *ImportOCI) pollFile(ctx context.Context, args OCIArguments) error {
+	if err := im.repo.Update(ctx); err != nil {
+		return err
+	}
+	return im.loadContent(args.Path)
+}
+
+// loadContent reads the module at path from the artifact. loadContent must
+// only be called with im.mut held.
+func (im *ImportOCI) loadContent(path string) error {
+	info, err := im.repo.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return im.handleDirectory(path)
+	}
+	return im.handleFile(path)
+}
+
+func (im *ImportOCI) handleDirectory(path string) error {
+	if !im.verifier.CanVerifyDirectories() {
+		return fmt.Errorf("%q is a directory: only signatures stored next to each file can be used to verify directories", path)
+	}
+
+	entries, err := im.repo.ReadDir(path)
+	if err != nil {
+		return err
+	}
+
+	content := make(map[string]string)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".river") {
+			continue
+		}
+		filePath := filepath.Join(path, e.Name())
+		bb, err := im.repo.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		if err := im.verifier.Verify(filePath, bb, im.repo.ReadFile); err != nil {
+			return err
+		}
+		content[e.Name()] = string(bb)
+	}
+	im.onContentChange(content)
+	return nil
+}
+
+func (im *ImportOCI) handleFile(path string) error {
+	bb, err := im.repo.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := im.verifier.Verify(path, bb, im.repo.ReadFile); err != nil {
+		return err
+	}
+	im.onContentChange(map[string]string{path: string(bb)})
+	return nil
+}
+
+// Update the evaluator.
+func (im *ImportOCI) SetEval(eval *vm.Evaluator) {
+	im.eval = eval
+}
@@ -0,0 +1,126 @@
+package importsource
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/flow/logging/level"
+)
+
+// poller holds the logic shared by the import sources which pull a module
+// from a remote source on a frequency: the pull loop and the health of the
+// import.
+type poller struct {
+	log log.Logger
+	// source describes what is pulled in log messages, for example
+	// "repository".
+	source string
+	// updateLevel is the level of the messages logged when pulling and when
+	// the pull frequency changes, for example level.Info.
+	updateLevel func(log.Logger) log.Logger
+
+	argsChanged chan struct{}
+
+	healthMut sync.RWMutex
+	health    component.Health
+}
+
+func newPoller(l log.Logger, source string, updateLevel func(log.Logger) log.Logger) poller {
+	return poller{
+		log:         l,
+		source:      source,
+		updateLevel: updateLevel,
+		argsChanged: make(chan struct{}, 1),
+	}
+}
+
+// run pulls every pullFrequency until ctx is canceled, reporting the result
+// of each pull in the health of the import. The pull frequency is read again
+// every time scheduleUpdate is called. A pull frequency of 0 disables
+// pulling.
+func (p *poller) run(ctx context.Context, pullFrequency func() time.Duration, pull func(context.Context) error) error {
+	var (
+		ticker  *time.Ticker
+		tickerC <-chan time.Time
+	)
+	defer func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-p.argsChanged:
+			ticker, tickerC = p.updateTicker(pullFrequency(), ticker, tickerC)
+
+		case <-tickerC:
+			p.updateLevel(p.log).Log("msg", "updating "+p.source)
+			err := pull(ctx)
+			p.updateHealth(err)
+			if err != nil {
+				level.Error(p.log).Log("msg", "failed to update "+p.source, "err", err)
+			}
+		}
+	}
+}
+
+func (p *poller) updateTicker(pullFrequency time.Duration, ticker *time.Ticker, tickerC <-chan time.Time) (*time.Ticker, <-chan time.Time) {
+	p.updateLevel(p.log).Log("msg", "updating "+p.source+" pull frequency, next pull attempt will be done according to the pullFrequency", "new_frequency", pullFrequency)
+
+	if pullFrequency > 0 {
+		if ticker == nil {
+			ticker = time.NewTicker(pullFrequency)
+			tickerC = ticker.C
+		} else {
+			ticker.Reset(pullFrequency)
+		}
+		return ticker, tickerC
+	}
+
+	if ticker != nil {
+		ticker.Stop()
+	}
+	return nil, nil
+}
+
+// scheduleUpdate makes run read the pull frequency again.
+func (p *poller) scheduleUpdate() {
+	select {
+	case p.argsChanged <- struct{}{}:
+	default:
+	}
+}
+
+func (p *poller) updateHealth(err error) {
+	p.healthMut.Lock()
+	defer p.healthMut.Unlock()
+
+	if err != nil {
+		p.health = component.Health{
+			Health:     component.HealthTypeUnhealthy,
+			Message:    err.Error(),
+			UpdateTime: time.Now(),
+		}
+	} else {
+		p.health = component.Health{
+			Health:     component.HealthTypeHealthy,
+			Message:    "module updated",
+			UpdateTime: time.Now(),
+		}
+	}
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (p *poller) CurrentHealth() component.Health {
+	p.healthMut.RLock()
+	defer p.healthMut.RUnlock()
+	return p.health
+}
@@ -0,0 +1,151 @@
+package testcomponents
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/component/common/loki"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/common/model"
+)
+
+// LogsSourceArguments configures a LogsSource.
+type LogsSourceArguments struct {
+	ForwardTo []loki.LogsReceiver `river:"forward_to,attr"`
+	Entries   []LogEntry          `river:"entry,block,optional"`
+}
+
+// LogEntry is a log entry sent by a LogsSource.
+type LogEntry struct {
+	Line      string            `river:"line,attr"`
+	Labels    map[string]string `river:"labels,attr,optional"`
+	Timestamp time.Time         `river:"timestamp,attr,optional"`
+}
+
+// LogsSource is a component which sends its entries to the receivers in
+// forward_to once it starts running, and then calls its done callback.
+// LogsSource does not register itself as a component and must be provided in
+// a custom registry.
+type LogsSource struct {
+	done func()
+
+	mut  sync.RWMutex
+	args LogsSourceArguments
+}
+
+var _ component.Component = (*LogsSource)(nil)
+
+// NewLogsSource creates a new LogsSource. done is called once all entries
+// were sent.
+func NewLogsSource(args LogsSourceArguments, done func()) *LogsSource {
+	return &LogsSource{done: done, args: args}
+}
+
+// Run implements Component.
+func (c *LogsSource) Run(ctx context.Context) error {
+	c.mut.RLock()
+	args := c.args
+	c.mut.RUnlock()
+
+	for _, e := range args.Entries {
+		entry := loki.Entry{
+			Labels: make(model.LabelSet, len(e.Labels)),
+			Entry: logproto.Entry{
+				Timestamp: e.Timestamp,
+				Line:      e.Line,
+			},
+		}
+		if entry.Timestamp.IsZero() {
+			entry.Timestamp = time.Now()
+		}
+		for k, v := range e.Labels {
+			entry.Labels[model.LabelName(k)] = model.LabelValue(v)
+		}
+
+		for _, receiver := range args.ForwardTo {
+			select {
+			case <-ctx.Done():
+				return nil
+			case receiver.Chan() <- entry.Clone():
+			}
+		}
+	}
+	c.done()
+
+	<-ctx.Done()
+	return nil
+}
+
+// Update implements Component.
+func (c *LogsSource) Update(args component.Arguments) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.args = args.(LogsSourceArguments)
+	return nil
+}
+
+// LogsReceiverExports describes exported fields of a LogsReceiver.
+type LogsReceiverExports struct {
+	Receiver loki.LogsReceiver `river:"receiver,attr"`
+	Entries  []ReceivedEntry   `river:"entries,attr"`
+}
+
+// ReceivedEntry is a log entry received by a LogsReceiver.
+type ReceivedEntry struct {
+	Line   string            `river:"line,attr"`
+	Labels map[string]string `river:"labels,attr"`
+}
+
+// LogsReceiver is a component which exports the log entries it received.
+// LogsReceiver does not register itself as a component and must be provided
+// in a custom registry.
+type LogsReceiver struct {
+	opts     component.Options
+	receiver loki.LogsReceiver
+	entries  []ReceivedEntry
+}
+
+var _ component.Component = (*LogsReceiver)(nil)
+
+// NewLogsReceiver creates a new LogsReceiver.
+func NewLogsReceiver(o component.Options) *LogsReceiver {
+	c := &LogsReceiver{opts: o, receiver: loki.NewLogsReceiver()}
+	c.export()
+	return c
+}
+
+// Run implements Component.
+func (c *LogsReceiver) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case entry := <-c.receiver.Chan():
+			received := ReceivedEntry{
+				Line:   entry.Line,
+				Labels: make(map[string]string, len(entry.Labels)),
+			}
+			for k, v := range entry.Labels {
+				received.Labels[string(k)] = string(v)
+			}
+			c.entries = append(c.entries, received)
+			c.export()
+		}
+	}
+}
+
+func (c *LogsReceiver) export() {
+	c.opts.OnStateChange(LogsReceiverExports{
+		Receiver: c.receiver,
+		Entries:  append(make([]ReceivedEntry, 0, len(c.entries)), c.entries...),
+	})
+}
+
+// Update implements Component.
+func (c *LogsReceiver) Update(component.Arguments) error { return nil }
+
+// ReceiverArguments configures the receivers, which don't support any
+// argument.
+type ReceiverArguments struct{}
@@ -0,0 +1,189 @@
+package testcomponents
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/component/prometheus"
+	"github.com/grafana/agent/internal/flow/logging/level"
+	"github.com/grafana/agent/internal/service/labelstore"
+	"github.com/grafana/dskit/backoff"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+)
+
+// MetricsSourceArguments configures a MetricsSource.
+type MetricsSourceArguments struct {
+	ForwardTo []storage.Appendable `river:"forward_to,attr"`
+	Samples   []Sample             `river:"sample,block,optional"`
+}
+
+// Sample is a sample sent by a MetricsSource.
+type Sample struct {
+	Labels    map[string]string `river:"labels,attr"`
+	Value     float64           `river:"value,attr"`
+	Timestamp time.Time         `river:"timestamp,attr,optional"`
+}
+
+// MetricsSource is a component which appends its samples to the receivers in
+// forward_to once it starts running, and then calls its done callback.
+// MetricsSource does not register itself as a component and must be provided
+// in a custom registry.
+type MetricsSource struct {
+	opts component.Options
+	ls   labelstore.LabelStore
+	done func()
+
+	mut  sync.RWMutex
+	args MetricsSourceArguments
+}
+
+var _ component.Component = (*MetricsSource)(nil)
+
+// NewMetricsSource creates a new MetricsSource. done is called once all
+// samples were appended.
+func NewMetricsSource(o component.Options, args MetricsSourceArguments, done func()) (*MetricsSource, error) {
+	data, err := o.GetServiceData(labelstore.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+	return &MetricsSource{
+		opts: o,
+		ls:   data.(labelstore.LabelStore),
+		done: done,
+		args: args,
+	}, nil
+}
+
+// Run implements Component.
+func (c *MetricsSource) Run(ctx context.Context) error {
+	c.mut.RLock()
+	args := c.args
+	c.mut.RUnlock()
+
+	fanout := prometheus.NewFanout(args.ForwardTo, c.opts.ID, c.opts.Registerer, c.ls)
+	ok := retry(ctx, c.opts.Logger, func() error {
+		app := fanout.Appender(ctx)
+		for _, s := range args.Samples {
+			ts := s.Timestamp
+			if ts.IsZero() {
+				ts = time.Now()
+			}
+			if _, err := app.Append(0, labels.FromMap(s.Labels), ts.UnixMilli(), s.Value); err != nil {
+				_ = app.Rollback()
+				return err
+			}
+		}
+		return app.Commit()
+	})
+	if !ok {
+		return nil
+	}
+	c.done()
+
+	<-ctx.Done()
+	return nil
+}
+
+// Update implements Component.
+func (c *MetricsSource) Update(args component.Arguments) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.args = args.(MetricsSourceArguments)
+	return nil
+}
+
+// MetricsReceiverExports describes exported fields of a MetricsReceiver.
+type MetricsReceiverExports struct {
+	Receiver storage.Appendable `river:"receiver,attr"`
+	Samples  []ReceivedSample   `river:"samples,attr"`
+}
+
+// ReceivedSample is a sample received by a MetricsReceiver.
+type ReceivedSample struct {
+	Labels map[string]string `river:"labels,attr"`
+	Value  float64           `river:"value,attr"`
+}
+
+// MetricsReceiver is a component which exports the samples it received.
+// MetricsReceiver does not register itself as a component and must be
+// provided in a custom registry.
+type MetricsReceiver struct {
+	opts     component.Options
+	receiver storage.Appendable
+
+	mut     sync.Mutex
+	samples []ReceivedSample
+}
+
+var _ component.Component = (*MetricsReceiver)(nil)
+
+// NewMetricsReceiver creates a new MetricsReceiver.
+func NewMetricsReceiver(o component.Options) (*MetricsReceiver, error) {
+	data, err := o.GetServiceData(labelstore.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &MetricsReceiver{opts: o}
+	c.receiver = prometheus.NewInterceptor(
+		nil,
+		data.(labelstore.LabelStore),
+		prometheus.WithComponentID(o.ID),
+		prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, _ int64, v float64, _ storage.Appender) (storage.SeriesRef, error) {
+			c.append(ReceivedSample{Labels: l.Map(), Value: v})
+			return ref, nil
+		}),
+	)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.export()
+	return c, nil
+}
+
+func (c *MetricsReceiver) append(s ReceivedSample) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.samples = append(c.samples, s)
+	c.export()
+}
+
+// export must be called with mut held, so that exports are updated in the
+// order samples are received.
+func (c *MetricsReceiver) export() {
+	c.opts.OnStateChange(MetricsReceiverExports{
+		Receiver: c.receiver,
+		Samples:  append(make([]ReceivedSample, 0, len(c.samples)), c.samples...),
+	})
+}
+
+// Run implements Component.
+func (c *MetricsReceiver) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+// Update implements Component.
+func (c *MetricsReceiver) Update(component.Arguments) error { return nil }
+
+// retry calls f until it succeeds or ctx is canceled. It returns whether f
+// succeeded.
+func retry(ctx context.Context, logger log.Logger, f func() error) bool {
+	bo := backoff.New(ctx, backoff.Config{
+		MinBackoff: 10 * time.Millisecond,
+		MaxBackoff: time.Second,
+	})
+	for bo.Ongoing() {
+		err := f()
+		if err == nil {
+			return true
+		}
+		level.Warn(logger).Log("msg", "failed to send data, retrying", "err", err)
+		bo.Wait()
+	}
+	return false
+}
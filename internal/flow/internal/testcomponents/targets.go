@@ -0,0 +1,52 @@
+package testcomponents
+
+import (
+	"context"
+
+	"github.com/grafana/agent/internal/component"
+)
+
+// TargetsSourceArguments configures a TargetsSource.
+type TargetsSourceArguments struct {
+	Targets []map[string]string `river:"targets,attr,optional"`
+}
+
+// TargetsSourceExports describes exported fields of a TargetsSource.
+type TargetsSourceExports struct {
+	Targets []map[string]string `river:"targets,attr"`
+}
+
+// TargetsSource is a component which exports its targets the same way
+// discovery components do, so that they can be passed to the components
+// under test. TargetsSource does not register itself as a component and must
+// be provided in a custom registry.
+//
+// Targets are plain maps rather than discovery.Target, as the discovery
+// package depends on the Flow controller.
+type TargetsSource struct {
+	opts component.Options
+}
+
+var _ component.Component = (*TargetsSource)(nil)
+
+// NewTargetsSource creates a new TargetsSource.
+func NewTargetsSource(o component.Options, args TargetsSourceArguments) *TargetsSource {
+	c := &TargetsSource{opts: o}
+	_ = c.Update(args)
+	return c
+}
+
+// Run implements Component.
+func (c *TargetsSource) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+// Update implements Component.
+func (c *TargetsSource) Update(args component.Arguments) error {
+	targets := args.(TargetsSourceArguments).Targets
+	c.opts.OnStateChange(TargetsSourceExports{
+		Targets: append(make([]map[string]string, 0, len(targets)), targets...),
+	})
+	return nil
+}
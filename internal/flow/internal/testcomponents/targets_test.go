@@ -0,0 +1,77 @@
+package testcomponents_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/component/common/loki"
+	"github.com/grafana/agent/internal/flow/componenttest"
+	"github.com/grafana/agent/internal/flow/internal/testcomponents"
+	"github.com/grafana/agent/internal/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+func TestTargetsSource(t *testing.T) {
+	ctrl := componenttest.NewControllerFromReg(util.TestLogger(t), component.Registration{
+		Name:    "testing.targets.source",
+		Args:    testcomponents.TargetsSourceArguments{},
+		Exports: testcomponents.TargetsSourceExports{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return testcomponents.NewTargetsSource(opts, args.(testcomponents.TargetsSourceArguments)), nil
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	targets := []map[string]string{{"__address__": "localhost:9090"}}
+	go func() {
+		err := ctrl.Run(ctx, testcomponents.TargetsSourceArguments{Targets: targets})
+		require.NoError(t, err)
+	}()
+	require.NoError(t, ctrl.WaitRunning(time.Second))
+	require.NoError(t, ctrl.WaitExports(time.Second))
+	require.Equal(t, targets, ctrl.Exports().(testcomponents.TargetsSourceExports).Targets)
+
+	targets = append(targets, map[string]string{"__address__": "localhost:12345"})
+	require.NoError(t, ctrl.Update(testcomponents.TargetsSourceArguments{Targets: targets}))
+	require.NoError(t, ctrl.WaitExports(time.Second))
+	require.Equal(t, targets, ctrl.Exports().(testcomponents.TargetsSourceExports).Targets)
+}
+
+func TestLogsSource(t *testing.T) {
+	var done atomic.Bool
+	ctrl := componenttest.NewControllerFromReg(util.TestLogger(t), component.Registration{
+		Name: "testing.logs.source",
+		Args: testcomponents.LogsSourceArguments{},
+		Build: func(_ component.Options, args component.Arguments) (component.Component, error) {
+			return testcomponents.NewLogsSource(args.(testcomponents.LogsSourceArguments), func() { done.Store(true) }), nil
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	receiver := loki.NewLogsReceiver()
+	go func() {
+		err := ctrl.Run(ctx, testcomponents.LogsSourceArguments{
+			ForwardTo: []loki.LogsReceiver{receiver},
+			Entries:   []testcomponents.LogEntry{{Line: "hello", Labels: map[string]string{"level": "info"}}},
+		})
+		require.NoError(t, err)
+	}()
+	require.NoError(t, ctrl.WaitRunning(time.Second))
+
+	select {
+	case entry := <-receiver.Chan():
+		require.Equal(t, "hello", entry.Line)
+		require.Equal(t, "info", string(entry.Labels["level"]))
+		require.False(t, entry.Timestamp.IsZero())
+	case <-time.After(time.Second):
+		require.FailNow(t, "timed out waiting for the log entry")
+	}
+	require.Eventually(t, done.Load, time.Second, 10*time.Millisecond)
+}
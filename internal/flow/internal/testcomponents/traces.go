@@ -0,0 +1,221 @@
+package testcomponents
+
+import (
+	"context"
+	"crypto/rand"
+	"sync"
+	"time"
+
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/component/otelcol"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// TracesSourceArguments configures a TracesSource.
+type TracesSourceArguments struct {
+	ForwardTo []otelcol.Consumer `river:"forward_to,attr"`
+	Spans     []Span             `river:"span,block,optional"`
+}
+
+// Span is a span sent by a TracesSource.
+type Span struct {
+	Name               string            `river:"name,attr"`
+	Attributes         map[string]string `river:"attributes,attr,optional"`
+	ResourceAttributes map[string]string `river:"resource_attributes,attr,optional"`
+}
+
+// TracesSource is a component which sends its spans to the consumers in
+// forward_to once it starts running, and then calls its done callback.
+// TracesSource does not register itself as a component and must be provided
+// in a custom registry.
+type TracesSource struct {
+	opts component.Options
+	done func()
+
+	mut  sync.RWMutex
+	args TracesSourceArguments
+}
+
+var _ component.Component = (*TracesSource)(nil)
+
+// NewTracesSource creates a new TracesSource. done is called once all spans
+// were sent.
+func NewTracesSource(o component.Options, args TracesSourceArguments, done func()) *TracesSource {
+	return &TracesSource{opts: o, done: done, args: args}
+}
+
+// Run implements Component.
+func (c *TracesSource) Run(ctx context.Context) error {
+	c.mut.RLock()
+	args := c.args
+	c.mut.RUnlock()
+
+	for _, s := range args.Spans {
+		td := newTraces(s)
+		for _, consumer := range args.ForwardTo {
+			// otelcol components only accept data once they're started, so
+			// sending is retried until they are.
+			ok := retry(ctx, c.opts.Logger, func() error {
+				return consumer.ConsumeTraces(ctx, td)
+			})
+			if !ok {
+				return nil
+			}
+		}
+	}
+	c.done()
+
+	<-ctx.Done()
+	return nil
+}
+
+// newTraces returns traces holding the span s.
+func newTraces(s Span) ptrace.Traces {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	for k, v := range s.ResourceAttributes {
+		rs.Resource().Attributes().PutStr(k, v)
+	}
+
+	span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetName(s.Name)
+	for k, v := range s.Attributes {
+		span.Attributes().PutStr(k, v)
+	}
+
+	var (
+		traceID pcommon.TraceID
+		spanID  pcommon.SpanID
+	)
+	_, _ = rand.Read(traceID[:])
+	_, _ = rand.Read(spanID[:])
+	span.SetTraceID(traceID)
+	span.SetSpanID(spanID)
+
+	now := time.Now()
+	span.SetStartTimestamp(pcommon.NewTimestampFromTime(now))
+	span.SetEndTimestamp(pcommon.NewTimestampFromTime(now))
+	return td
+}
+
+// Update implements Component.
+func (c *TracesSource) Update(args component.Arguments) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.args = args.(TracesSourceArguments)
+	return nil
+}
+
+// TracesReceiverExports describes exported fields of a TracesReceiver.
+type TracesReceiverExports struct {
+	Input otelcol.Consumer `river:"input,attr"`
+	Spans []ReceivedSpan   `river:"spans,attr"`
+}
+
+// ReceivedSpan is a span received by a TracesReceiver.
+type ReceivedSpan struct {
+	Name               string            `river:"name,attr"`
+	Attributes         map[string]string `river:"attributes,attr"`
+	ResourceAttributes map[string]string `river:"resource_attributes,attr"`
+}
+
+// TracesReceiver is a component which exports the spans it received.
+// TracesReceiver does not register itself as a component and must be
+// provided in a custom registry.
+type TracesReceiver struct {
+	opts component.Options
+
+	mut   sync.Mutex
+	spans []ReceivedSpan
+}
+
+var _ component.Component = (*TracesReceiver)(nil)
+
+// NewTracesReceiver creates a new TracesReceiver.
+func NewTracesReceiver(o component.Options) *TracesReceiver {
+	c := &TracesReceiver{opts: o}
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.export()
+	return c
+}
+
+func (c *TracesReceiver) consume(td ptrace.Traces) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		rs := td.ResourceSpans().At(i)
+		resourceAttributes := attributesMap(rs.Resource().Attributes())
+
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			spans := rs.ScopeSpans().At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				c.spans = append(c.spans, ReceivedSpan{
+					Name:               spans.At(k).Name(),
+					Attributes:         attributesMap(spans.At(k).Attributes()),
+					ResourceAttributes: resourceAttributes,
+				})
+			}
+		}
+	}
+	c.export()
+}
+
+// export must be called with mut held, so that exports are updated in the
+// order spans are received.
+func (c *TracesReceiver) export() {
+	c.opts.OnStateChange(TracesReceiverExports{
+		Input: tracesConsumer{receiver: c},
+		Spans: append(make([]ReceivedSpan, 0, len(c.spans)), c.spans...),
+	})
+}
+
+// Run implements Component.
+func (c *TracesReceiver) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+// Update implements Component.
+func (c *TracesReceiver) Update(component.Arguments) error { return nil }
+
+// tracesConsumer is the otelcol.Consumer exported by TracesReceiver. It's
+// kept separate from TracesReceiver as exported values are copied when
+// they're evaluated.
+type tracesConsumer struct {
+	receiver *TracesReceiver
+}
+
+var _ otelcol.Consumer = tracesConsumer{}
+
+// Capabilities implements otelcol.Consumer.
+func (c tracesConsumer) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: false}
+}
+
+// ConsumeTraces implements otelcol.Consumer.
+func (c tracesConsumer) ConsumeTraces(_ context.Context, td ptrace.Traces) error {
+	c.receiver.consume(td)
+	return nil
+}
+
+// ConsumeMetrics implements otelcol.Consumer. Metrics are ignored.
+func (c tracesConsumer) ConsumeMetrics(context.Context, pmetric.Metrics) error { return nil }
+
+// ConsumeLogs implements otelcol.Consumer. Logs are ignored.
+func (c tracesConsumer) ConsumeLogs(context.Context, plog.Logs) error { return nil }
+
+// attributesMap returns attrs with their values converted to strings.
+func attributesMap(attrs pcommon.Map) map[string]string {
+	m := make(map[string]string, attrs.Len())
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		m[k] = v.AsString()
+		return true
+	})
+	return m
+}
@@ -0,0 +1,135 @@
+package rivertest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/river/ast"
+	"github.com/grafana/river/parser"
+	"github.com/grafana/river/token/builder"
+	"github.com/grafana/river/vm"
+)
+
+// AssertArguments holds values which are used to configure the
+// testing.assert component.
+type AssertArguments struct {
+	Actual   any    `river:"actual,attr"`
+	Expected any    `river:"expected,attr"`
+	Message  string `river:"message,attr,optional"`
+}
+
+// assertComponent implements the testing.assert component. It reports to
+// the tracker of its test whether actual equals expected every time it's
+// evaluated.
+type assertComponent struct {
+	opts    component.Options
+	tracker *tracker
+
+	healthMut sync.RWMutex
+	health    component.Health
+}
+
+var _ component.HealthComponent = (*assertComponent)(nil)
+
+func newAssertRegistration(t *tracker) component.Registration {
+	return component.Registration{
+		Name:      "testing.assert",
+		Stability: featuregate.StabilityExperimental,
+		Args:      AssertArguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			c := &assertComponent{opts: opts, tracker: t}
+			if err := c.Update(args); err != nil {
+				return nil, err
+			}
+			return c, nil
+		},
+	}
+}
+
+// Run implements component.Component.
+func (c *assertComponent) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+// Update implements component.Component.
+func (c *assertComponent) Update(args component.Arguments) error {
+	newArgs := args.(AssertArguments)
+
+	equal, err := riverEqual(newArgs.Actual, newArgs.Expected)
+	if err != nil {
+		return err
+	}
+
+	var failure string
+	if !equal {
+		var sb strings.Builder
+		if newArgs.Message != "" {
+			fmt.Fprintf(&sb, "%s\n", newArgs.Message)
+		}
+		fmt.Fprintf(&sb, "expected: %s\n", formatValue(newArgs.Expected))
+		fmt.Fprintf(&sb, "actual:   %s", formatValue(newArgs.Actual))
+		failure = sb.String()
+	}
+	c.tracker.setAssert(c.opts.ID, failure)
+
+	health := component.Health{
+		Health:     component.HealthTypeHealthy,
+		Message:    "assertion holds",
+		UpdateTime: time.Now(),
+	}
+	if failure != "" {
+		health.Health = component.HealthTypeUnhealthy
+		health.Message = "assertion doesn't hold"
+	}
+
+	c.healthMut.Lock()
+	c.health = health
+	c.healthMut.Unlock()
+	return nil
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (c *assertComponent) CurrentHealth() component.Health {
+	c.healthMut.RLock()
+	defer c.healthMut.RUnlock()
+	return c.health
+}
+
+// equalExpr compares values with the equality operator of River, so that for
+// example 1 equals 1.0 and objects equal structs with the same fields.
+var equalExpr = func() ast.Expr {
+	expr, err := parser.ParseExpression("actual == expected")
+	if err != nil {
+		panic(err)
+	}
+	return expr
+}()
+
+func riverEqual(actual, expected any) (bool, error) {
+	scope := &vm.Scope{
+		Variables: map[string]any{
+			"actual":   actual,
+			"expected": expected,
+		},
+	}
+
+	var equal bool
+	if err := vm.New(equalExpr).Evaluate(scope, &equal); err != nil {
+		return false, fmt.Errorf("comparing actual and expected: %w", err)
+	}
+	return equal, nil
+}
+
+// formatValue returns v formatted as a River expression.
+func formatValue(v any) string {
+	expr := builder.NewExpr()
+	expr.SetValue(v)
+	return string(expr.Bytes())
+}
@@ -0,0 +1,105 @@
+package rivertest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// WriteJUnit writes the results of suites to w as a JUnit XML report.
+func WriteJUnit(w io.Writer, suites []Suite) error {
+	report := junitTestSuites{}
+
+	for _, s := range suites {
+		js := junitTestSuite{Name: s.File}
+
+		// A file which couldn't be loaded is reported as a single errored
+		// test case, as JUnit test suites can't hold errors themselves.
+		if s.Err != nil {
+			js.Tests, js.Errors = 1, 1
+			js.TestCases = append(js.TestCases, junitTestCase{
+				Name:      s.File,
+				ClassName: s.File,
+				Error: &junitMessage{
+					Message: "failed to load file",
+					Content: s.Err.Error(),
+				},
+			})
+		}
+
+		var total time.Duration
+		for _, t := range s.Tests {
+			total += t.Duration
+			js.Tests++
+
+			tc := junitTestCase{
+				Name:      t.Name,
+				ClassName: s.File,
+				Time:      junitTime(t.Duration),
+				SystemOut: t.Output,
+			}
+			if t.Failure != "" {
+				js.Failures++
+				tc.Failure = &junitMessage{
+					Message: "test failed",
+					Content: t.Failure,
+				}
+			}
+			js.TestCases = append(js.TestCases, tc)
+		}
+		js.Time = junitTime(total)
+
+		report.Tests += js.Tests
+		report.Failures += js.Failures
+		report.Errors += js.Errors
+		report.TestSuites = append(report.TestSuites, js)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Errors     int              `xml:"errors,attr"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Content string `xml:",chardata"`
+}
+
+// junitTime formats d as a number of seconds.
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,348 @@
+// Package rivertest runs tests of River configurations, such as modules and
+// declare blocks.
+//
+// Tests are defined by test blocks in *_test.river files. The body of a test
+// block is loaded as a Flow configuration, together with the statements of
+// the file which are outside of test blocks. In addition to the registered
+// components, tests can use test-only components to send synthetic data to
+// the components under test, to receive the data they send, and to assert on
+// values:
+//
+//   - testing.logs.source, testing.metrics.source and testing.traces.source
+//     send log entries, samples and spans to their forward_to receivers once
+//     they start running.
+//   - testing.logs.receiver, testing.metrics.receiver and
+//     testing.traces.receiver export the data they received.
+//   - testing.targets.source exports synthetic targets, like discovery
+//     components do.
+//   - testing.assert compares its actual and expected values.
+//
+// The components sending and receiving data are implemented in the
+// testcomponents package.
+//
+// A test passes once all its sources sent their data and all its assertions
+// hold. It fails if it can't be loaded, or if it doesn't pass before its
+// timeout.
+package rivertest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/agent/internal/flow"
+	"github.com/grafana/agent/internal/flow/logging"
+	"github.com/grafana/agent/internal/service"
+	"github.com/grafana/river/ast"
+	"github.com/grafana/river/diag"
+	"github.com/grafana/river/parser"
+	"github.com/grafana/river/vm"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// testBlockName is the name of the blocks defining tests.
+const testBlockName = "test"
+
+// DefaultTimeout is the default timeout of a test.
+const DefaultTimeout = 10 * time.Second
+
+// Options configures how tests are run.
+type Options struct {
+	// MinStability is the minimum stability level of the components which can
+	// be used by tests.
+	MinStability featuregate.Stability
+
+	// Timeout is the timeout of tests which don't define their own. Defaults
+	// to DefaultTimeout.
+	Timeout time.Duration
+
+	// Run only runs the tests whose name matches Run, if set.
+	Run *regexp.Regexp
+
+	// NewServices returns the services to run with the controller of a test.
+	// If NewServices is nil, tests run without services.
+	NewServices func(logger log.Logger) ([]service.Service, error)
+}
+
+// Suite holds the results of the tests of a file.
+type Suite struct {
+	File  string
+	Tests []Result
+
+	// Err is set if the file couldn't be loaded, in which case none of its
+	// tests ran.
+	Err error
+}
+
+// Failed returns whether the file couldn't be loaded, or whether any of its
+// tests failed.
+func (s Suite) Failed() bool {
+	if s.Err != nil {
+		return true
+	}
+	for _, t := range s.Tests {
+		if t.Failure != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Result is the result of a test.
+type Result struct {
+	Name     string
+	Duration time.Duration
+
+	// Failure describes why the test failed. It's empty if the test passed.
+	Failure string
+
+	// Output holds the logs of the components of the test.
+	Output string
+}
+
+// testArguments holds the attributes of test blocks.
+type testArguments struct {
+	Timeout time.Duration `river:"timeout,attr,optional"`
+}
+
+// RunFile runs the tests of the River file at path.
+func RunFile(ctx context.Context, path string, opts Options) Suite {
+	suite := Suite{File: path}
+
+	bb, err := os.ReadFile(path)
+	if err != nil {
+		suite.Err = err
+		return suite
+	}
+
+	shared, tests, err := parseFile(path, bb)
+	if err != nil {
+		suite.Err = formatError(path, bb, err)
+		return suite
+	}
+
+	for _, test := range tests {
+		if opts.Run != nil && !opts.Run.MatchString(test.Label) {
+			continue
+		}
+		suite.Tests = append(suite.Tests, runTest(ctx, path, bb, shared, test, opts))
+	}
+	return suite
+}
+
+// parseFile returns the statements of the file which are outside of test
+// blocks, and the test blocks.
+func parseFile(path string, bb []byte) (shared ast.Body, tests []*ast.BlockStmt, err error) {
+	file, err := parser.ParseFile(path, bb)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		diags diag.Diagnostics
+		names = make(map[string]bool)
+	)
+	for _, stmt := range file.Body {
+		b, ok := stmt.(*ast.BlockStmt)
+		if !ok || len(b.Name) != 1 || b.Name[0] != testBlockName {
+			shared = append(shared, stmt)
+			continue
+		}
+
+		switch {
+		case b.Label == "":
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				StartPos: ast.StartPos(b).Position(),
+				EndPos:   ast.EndPos(b).Position(),
+				Message:  "test blocks must have a label",
+			})
+		case names[b.Label]:
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				StartPos: ast.StartPos(b).Position(),
+				EndPos:   ast.EndPos(b).Position(),
+				Message:  fmt.Sprintf("test %q is defined more than once", b.Label),
+			})
+		default:
+			names[b.Label] = true
+			tests = append(tests, b)
+		}
+	}
+
+	if diags.HasErrors() {
+		return nil, nil, diags
+	}
+	return shared, tests, nil
+}
+
+func runTest(ctx context.Context, path string, bb []byte, shared ast.Body, test *ast.BlockStmt, opts Options) Result {
+	var (
+		start  = time.Now()
+		output syncBuffer
+	)
+
+	err := runTestController(ctx, path, bb, shared, test, opts, &output)
+	res := Result{
+		Name:     test.Label,
+		Duration: time.Since(start),
+		Output:   output.String(),
+	}
+	if err != nil {
+		res.Failure = formatError(path, bb, err).Error()
+	}
+	return res
+}
+
+// runTestController runs a controller for test until the test passes or
+// times out. Logs of the controller are written to output.
+func runTestController(ctx context.Context, path string, bb []byte, shared ast.Body, test *ast.BlockStmt, opts Options, output io.Writer) error {
+	// Attributes of the test block configure the test, and its blocks are
+	// loaded along with the shared statements of the file.
+	var (
+		attrs ast.Body
+		body  = append(ast.Body{}, shared...)
+	)
+	for _, stmt := range test.Body {
+		if _, ok := stmt.(*ast.AttributeStmt); ok {
+			attrs = append(attrs, stmt)
+		} else {
+			body = append(body, stmt)
+		}
+	}
+
+	var args testArguments
+	if err := vm.New(attrs).Evaluate(nil, &args); err != nil {
+		return err
+	}
+	if args.Timeout == 0 {
+		args.Timeout = opts.Timeout
+	}
+	if args.Timeout == 0 {
+		args.Timeout = DefaultTimeout
+	}
+
+	source, err := flow.ParseSourceBody(path, bb, body)
+	if err != nil {
+		return err
+	}
+
+	dataPath, err := os.MkdirTemp("", "agent-test-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dataPath)
+
+	logger, err := logging.New(output, logging.DefaultOptions)
+	if err != nil {
+		return err
+	}
+
+	var services []service.Service
+	if opts.NewServices != nil {
+		services, err = opts.NewServices(logger)
+		if err != nil {
+			return err
+		}
+	}
+
+	t := newTracker()
+	f := flow.NewWithComponents(flow.Options{
+		Logger:       logger,
+		DataPath:     dataPath,
+		Reg:          prometheus.NewRegistry(),
+		MinStability: opts.MinStability,
+		Services:     services,
+	}, t.components())
+
+	ctx, cancel := context.WithTimeout(ctx, args.Timeout)
+	defer cancel()
+
+	loadErr := f.LoadSource(source, nil)
+
+	// The controller is run even if loading failed, so that it releases the
+	// resources of the components which were created.
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		f.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-exited
+	}()
+
+	if loadErr != nil {
+		return loadErr
+	}
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		pendingSources, failures := t.status()
+		if len(pendingSources) == 0 && len(failures) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			msg := []string{fmt.Sprintf("test didn't pass after %s", args.Timeout)}
+			if len(pendingSources) > 0 {
+				msg = append(msg, "sources which didn't send all their data: "+strings.Join(pendingSources, ", "))
+			}
+			msg = append(msg, failures...)
+			return errors.New(strings.Join(msg, "\n"))
+		}
+	}
+}
+
+// formatError formats River diagnostics in err with the content of the file
+// they refer to.
+func formatError(path string, bb []byte, err error) error {
+	var diags diag.Diagnostics
+	if !errors.As(err, &diags) {
+		var d diag.Diagnostic
+		if !errors.As(err, &d) {
+			return err
+		}
+		diags = diag.Diagnostics{d}
+	}
+
+	var sb strings.Builder
+	p := diag.NewPrinter(diag.PrinterConfig{
+		ContextLinesBefore: 1,
+		ContextLinesAfter:  1,
+	})
+	_ = p.Fprint(&sb, map[string][]byte{path: bb}, diags)
+	return errors.New(strings.TrimSpace(sb.String()))
+}
+
+// syncBuffer is a bytes.Buffer which can be written to and read from
+// concurrently.
+type syncBuffer struct {
+	mut sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	return b.buf.String()
+}
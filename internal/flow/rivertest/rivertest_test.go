@@ -0,0 +1,111 @@
+package rivertest
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	_ "github.com/grafana/agent/internal/component/discovery/relabel"
+	_ "github.com/grafana/agent/internal/component/loki/process"
+	_ "github.com/grafana/agent/internal/component/otelcol/processor/attributes"
+	_ "github.com/grafana/agent/internal/component/prometheus/relabel"
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/agent/internal/service"
+	"github.com/grafana/agent/internal/service/labelstore"
+	otel_service "github.com/grafana/agent/internal/service/otel"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func testOptions() Options {
+	return Options{
+		MinStability: featuregate.StabilityExperimental,
+		Timeout:      5 * time.Second,
+		NewServices: func(l log.Logger) ([]service.Service, error) {
+			return []service.Service{
+				otel_service.New(l),
+				labelstore.New(l, prometheus.NewRegistry()),
+			}, nil
+		},
+	}
+}
+
+func TestRunFile(t *testing.T) {
+	suite := RunFile(context.Background(), "testdata/pipeline_test.river", testOptions())
+	require.NoError(t, suite.Err)
+	require.True(t, suite.Failed())
+	require.Len(t, suite.Tests, 3)
+
+	for _, passed := range suite.Tests[:2] {
+		require.Empty(t, passed.Failure, passed.Name)
+	}
+	require.Equal(t, "drops_debug_logs", suite.Tests[0].Name)
+	require.Equal(t, "relabels_targets", suite.Tests[1].Name)
+
+	failed := suite.Tests[2]
+	require.Equal(t, "fails", failed.Name)
+	require.Equal(t, `test didn't pass after 1s
+testing.assert.wrong: arithmetic is broken
+expected: 3
+actual:   2`, failed.Failure)
+}
+
+func TestRunFile_Run(t *testing.T) {
+	opts := testOptions()
+	opts.Run = regexp.MustCompile("^drops_")
+
+	suite := RunFile(context.Background(), "testdata/pipeline_test.river", opts)
+	require.NoError(t, suite.Err)
+	require.False(t, suite.Failed())
+	require.Len(t, suite.Tests, 1)
+	require.Equal(t, "drops_debug_logs", suite.Tests[0].Name)
+}
+
+func TestRunFile_Invalid(t *testing.T) {
+	suite := RunFile(context.Background(), "testdata/invalid_test.river", testOptions())
+	require.ErrorContains(t, suite.Err, `test "duplicate" is defined more than once`)
+	require.True(t, suite.Failed())
+	require.Empty(t, suite.Tests)
+}
+
+func TestWriteJUnit(t *testing.T) {
+	suites := []Suite{
+		{
+			File: "a_test.river",
+			Tests: []Result{
+				{Name: "passes", Duration: 1500 * time.Millisecond},
+				{Name: "fails", Duration: time.Second, Failure: "expected: 1", Output: "some logs"},
+			},
+		},
+		{
+			File: "b_test.river",
+			Err:  errContains("unexpected token"),
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteJUnit(&buf, suites))
+	require.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="3" failures="1" errors="1">
+  <testsuite name="a_test.river" tests="2" failures="1" errors="0" time="2.500">
+    <testcase name="passes" classname="a_test.river" time="1.500"></testcase>
+    <testcase name="fails" classname="a_test.river" time="1.000">
+      <failure message="test failed">expected: 1</failure>
+      <system-out>some logs</system-out>
+    </testcase>
+  </testsuite>
+  <testsuite name="b_test.river" tests="1" failures="0" errors="1" time="0.000">
+    <testcase name="b_test.river" classname="b_test.river" time="">
+      <error message="failed to load file">unexpected token</error>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, buf.String())
+}
+
+type errContains string
+
+func (e errContains) Error() string { return string(e) }
@@ -0,0 +1,148 @@
+package rivertest
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/agent/internal/flow/internal/testcomponents"
+)
+
+// tracker tracks the progress of a test: the sources which haven't sent all
+// their data yet, and the assertions which don't hold.
+type tracker struct {
+	mut      sync.Mutex
+	sources  map[string]bool   // Whether each source sent all its data.
+	failures map[string]string // Failure of each assertion, empty if it holds.
+}
+
+func newTracker() *tracker {
+	return &tracker{
+		sources:  make(map[string]bool),
+		failures: make(map[string]string),
+	}
+}
+
+// components returns the test-only components. The components sending data
+// report to t once they sent all of it.
+func (t *tracker) components() []component.Registration {
+	return []component.Registration{
+		newAssertRegistration(t),
+		{
+			Name:      "testing.logs.source",
+			Stability: featuregate.StabilityExperimental,
+			Args:      testcomponents.LogsSourceArguments{},
+
+			Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+				return testcomponents.NewLogsSource(args.(testcomponents.LogsSourceArguments), t.addSource(opts.ID)), nil
+			},
+		},
+		{
+			Name:      "testing.logs.receiver",
+			Stability: featuregate.StabilityExperimental,
+			Args:      testcomponents.ReceiverArguments{},
+			Exports:   testcomponents.LogsReceiverExports{},
+
+			Build: func(opts component.Options, _ component.Arguments) (component.Component, error) {
+				return testcomponents.NewLogsReceiver(opts), nil
+			},
+		},
+		{
+			Name:      "testing.metrics.source",
+			Stability: featuregate.StabilityExperimental,
+			Args:      testcomponents.MetricsSourceArguments{},
+
+			Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+				return testcomponents.NewMetricsSource(opts, args.(testcomponents.MetricsSourceArguments), t.addSource(opts.ID))
+			},
+		},
+		{
+			Name:      "testing.metrics.receiver",
+			Stability: featuregate.StabilityExperimental,
+			Args:      testcomponents.ReceiverArguments{},
+			Exports:   testcomponents.MetricsReceiverExports{},
+
+			Build: func(opts component.Options, _ component.Arguments) (component.Component, error) {
+				return testcomponents.NewMetricsReceiver(opts)
+			},
+		},
+		{
+			Name:      "testing.traces.source",
+			Stability: featuregate.StabilityExperimental,
+			Args:      testcomponents.TracesSourceArguments{},
+
+			Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+				return testcomponents.NewTracesSource(opts, args.(testcomponents.TracesSourceArguments), t.addSource(opts.ID)), nil
+			},
+		},
+		{
+			Name:      "testing.traces.receiver",
+			Stability: featuregate.StabilityExperimental,
+			Args:      testcomponents.ReceiverArguments{},
+			Exports:   testcomponents.TracesReceiverExports{},
+
+			Build: func(opts component.Options, _ component.Arguments) (component.Component, error) {
+				return testcomponents.NewTracesReceiver(opts), nil
+			},
+		},
+		{
+			Name:      "testing.targets.source",
+			Stability: featuregate.StabilityExperimental,
+			Args:      testcomponents.TargetsSourceArguments{},
+			Exports:   testcomponents.TargetsSourceExports{},
+
+			Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+				return testcomponents.NewTargetsSource(opts, args.(testcomponents.TargetsSourceArguments)), nil
+			},
+		},
+	}
+}
+
+// addSource tracks the source with the given ID, and returns the function it
+// calls once it sent all its data.
+func (t *tracker) addSource(id string) (done func()) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	t.sources[id] = false
+	return func() { t.sourceDone(id) }
+}
+
+func (t *tracker) sourceDone(id string) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	t.sources[id] = true
+}
+
+func (t *tracker) setAssert(id string, failure string) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	t.failures[id] = failure
+}
+
+// status returns the sources which haven't sent all their data yet and the
+// failures of the assertions which don't hold, sorted by component ID. The
+// test passes once both are empty.
+func (t *tracker) status() (pendingSources []string, failures []string) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	for id, done := range t.sources {
+		if !done {
+			pendingSources = append(pendingSources, id)
+		}
+	}
+	sort.Strings(pendingSources)
+
+	ids := make([]string, 0, len(t.failures))
+	for id, failure := range t.failures {
+		if failure != "" {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		failures = append(failures, id+": "+t.failures[id])
+	}
+	return pendingSources, failures
+}
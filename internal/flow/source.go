@@ -46,6 +46,22 @@ func ParseSource(name string, bb []byte) (*Source, error) {
 	return source, nil
 }
 
+// ParseSourceBody creates a Source from body, which holds statements parsed
+// from the River file name whose content is bb. It allows to load a subset
+// of a file, for example the body of a block, while reporting errors against
+// the original file.
+//
+// bb must not be modified after passing to ParseSourceBody.
+func ParseSourceBody(name string, bb []byte, body ast.Body) (*Source, error) {
+	source, err := sourceFromBody(body)
+	if err != nil {
+		return nil, err
+	}
+	source.sourceMap = map[string][]byte{name: bb}
+	source.hash = sha256.Sum256(bb)
+	return source, nil
+}
+
 // sourceFromBody creates a Source from an existing AST. This must only be used
 // internally as there will be no sourceMap or hash.
 func sourceFromBody(body ast.Body) (*Source, error) {
@@ -75,7 +91,7 @@ func sourceFromBody(body ast.Body) (*Source, error) {
 			switch fullName {
 			case "declare":
 				declares = append(declares, stmt)
-			case "logging", "tracing", "argument", "export", "import.file", "import.string", "import.http", "import.git":
+			case "logging", "tracing", "argument", "export", "import.file", "import.string", "import.http", "import.git", "import.oci":
 				configs = append(configs, stmt)
 			default:
 				components = append(components, stmt)
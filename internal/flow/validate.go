@@ -0,0 +1,131 @@
+package flow
+
+import (
+	"context"
+	"io"
+
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/agent/internal/flow/internal/controller"
+	"github.com/grafana/agent/internal/flow/internal/worker"
+	"github.com/grafana/agent/internal/flow/logging"
+	"github.com/grafana/agent/internal/service"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ValidateOptions configures [Validate].
+type ValidateOptions struct {
+	// Logger for the controller used for validation. Logs are discarded if
+	// Logger is nil.
+	Logger *logging.Logger
+
+	// DataPath is the directory where imports may store data while the source
+	// is validated.
+	DataPath string
+
+	// MinStability is the minimum stability level of features that can be
+	// used by the source.
+	MinStability featuregate.Stability
+
+	// Services whose configuration blocks may be used by the source. Only
+	// their definitions are used; they are never updated or run.
+	Services []service.Service
+}
+
+// Validate loads source the same way [Flow.LoadSource] does, without building
+// or running any components.
+//
+// The arguments of every component are evaluated and validated against the
+// component's registered Arguments type, and every component exports the zero
+// value of its registered Exports type. Imports are resolved and custom
+// components declared with declare blocks are loaded, so the components they
+// define are validated too.
+//
+// Validate returns a [diag.Diagnostics] error holding every problem found.
+func Validate(source *Source, opts ValidateOptions) error {
+	logger := opts.Logger
+	if logger == nil {
+		var err error
+		logger, err = logging.New(io.Discard, logging.DefaultOptions)
+		if err != nil {
+			return err
+		}
+	}
+
+	services := make([]service.Service, 0, len(opts.Services))
+	for _, svc := range opts.Services {
+		services = append(services, validationService{def: svc.Definition()})
+	}
+
+	f := newController(controllerOptions{
+		Options: Options{
+			Logger:       logger,
+			DataPath:     opts.DataPath,
+			MinStability: opts.MinStability,
+			Reg:          prometheus.NewRegistry(),
+			Services:     services,
+		},
+		ComponentRegistry: validationRegistry{
+			ComponentRegistry: controller.NewDefaultComponentRegistry(opts.MinStability),
+		},
+		ModuleRegistry: newModuleRegistry(),
+		WorkerPool:     worker.NewDefaultWorkerPool(),
+	})
+
+	err := f.LoadSource(source, nil)
+
+	// Running the controller with a canceled context releases the resources
+	// held by nodes created while loading.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f.Run(ctx)
+
+	return err
+}
+
+// validationRegistry is a [controller.ComponentRegistry] which replaces the
+// Build function of components with one creating a no-op component.
+type validationRegistry struct {
+	controller.ComponentRegistry
+}
+
+func (r validationRegistry) Get(name string) (component.Registration, error) {
+	reg, err := r.ComponentRegistry.Get(name)
+	if err != nil {
+		return reg, err
+	}
+
+	reg.Build = func(component.Options, component.Arguments) (component.Component, error) {
+		return validationComponent{}, nil
+	}
+	return reg, nil
+}
+
+// validationComponent is a no-op component. Its exports are left at the
+// zero value of the registered Exports type.
+type validationComponent struct{}
+
+func (validationComponent) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (validationComponent) Update(component.Arguments) error { return nil }
+
+// validationService is a no-op service which only exposes the definition of
+// the service it stands in for, so that its configuration block is decoded
+// and validated against the service's ConfigType.
+type validationService struct {
+	def service.Definition
+}
+
+func (s validationService) Definition() service.Definition { return s.def }
+
+func (validationService) Run(ctx context.Context, _ service.Host) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (validationService) Update(any) error { return nil }
+
+func (validationService) Data() any { return nil }
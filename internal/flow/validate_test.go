@@ -0,0 +1,134 @@
+package flow
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/river/diag"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	tt := []struct {
+		name   string
+		source string
+		errors []string
+	}{
+		{
+			name:   "valid",
+			source: testFile,
+		},
+		{
+			name: "valid declare",
+			source: `
+				declare "add" {
+					argument "input" {}
+
+					testcomponents.passthrough "inner" {
+						input = argument.input.value
+					}
+
+					export "output" {
+						value = testcomponents.passthrough.inner.output
+					}
+				}
+
+				add "example" {
+					input = "hello"
+				}
+
+				testcomponents.passthrough "outer" {
+					input = add.example.output
+				}
+			`,
+		},
+		{
+			name: "invalid arguments",
+			source: `
+				testcomponents.tick "ticker" {
+					frequency = "not-a-duration"
+				}
+
+				testcomponents.passthrough "static" {
+					input = [1, 2]
+				}
+			`,
+			errors: []string{
+				`"not-a-duration" time: invalid duration "not-a-duration"`,
+				"[1, 2] should be string, got array",
+			},
+		},
+		{
+			name: "unknown component",
+			source: `
+				testcomponents.missing "example" {}
+			`,
+			errors: []string{`cannot find the definition of component name "testcomponents.missing"`},
+		},
+		{
+			name: "invalid reference",
+			source: `
+				testcomponents.passthrough "example" {
+					input = testcomponents.passthrough.missing.output
+				}
+			`,
+			errors: []string{`component "testcomponents.passthrough.missing.output" does not exist or is out of scope`},
+		},
+		{
+			name: "invalid declare",
+			source: `
+				declare "add" {
+					argument "input" {}
+
+					testcomponents.passthrough "inner" {
+						input = [argument.input.value]
+					}
+				}
+
+				add "example" {
+					input = "hello"
+				}
+			`,
+			errors: []string{"should be string, got array"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			defer verifyNoGoroutineLeaks(t)
+
+			source, err := ParseSource(t.Name(), []byte(tc.source))
+			require.NoError(t, err)
+
+			err = Validate(source, ValidateOptions{
+				DataPath:     t.TempDir(),
+				MinStability: featuregate.StabilityBeta,
+			})
+			if len(tc.errors) == 0 {
+				require.NoError(t, err)
+				return
+			}
+
+			var diags diag.Diagnostics
+			require.ErrorAs(t, err, &diags)
+			require.Len(t, diags, len(tc.errors))
+
+			// Independent components are evaluated in no particular order.
+			var messages []string
+			for _, d := range diags {
+				messages = append(messages, d.Message)
+			}
+			for _, msg := range tc.errors {
+				require.Condition(t, func() bool {
+					for _, m := range messages {
+						if strings.Contains(m, msg) {
+							return true
+						}
+					}
+					return false
+				}, "missing diagnostic %q in %q", msg, messages)
+			}
+		})
+	}
+}
@@ -20,6 +20,7 @@ func convertCommand() *cobra.Command {
 	f := &flowConvert{
 		output:       "",
 		sourceFormat: "",
+		target:       string(converter.TargetFlow),
 		bypassErrors: false,
 		extraArgs:    "",
 	}
@@ -41,6 +42,10 @@ provided, no report is generated.
 
 The -f flag can be used to specify the format we are converting from.
 
+The -t flag can be used to specify the format we are converting to. When
+the target is not flow, the file must be a River configuration file and the
+-f flag must not be provided.
+
 The -b flag can be used to bypass errors. Errors are defined as 
 non-critical issues identified during the conversion where an
 output can still be generated.
@@ -76,6 +81,7 @@ by separating them with a space.`,
 	cmd.Flags().StringVarP(&f.output, "output", "o", f.output, "The filepath and filename where the output is written.")
 	cmd.Flags().StringVarP(&f.report, "report", "r", f.report, "The filepath and filename where the report is written.")
 	cmd.Flags().StringVarP(&f.sourceFormat, "source-format", "f", f.sourceFormat, fmt.Sprintf("The format of the source file. Supported formats: %s.", supportedFormatsList()))
+	cmd.Flags().StringVarP(&f.target, "target", "t", f.target, fmt.Sprintf("The format of the output file. Supported formats: %s.", supportedTargetsList()))
 	cmd.Flags().BoolVarP(&f.bypassErrors, "bypass-errors", "b", f.bypassErrors, "Enable bypassing errors when converting")
 	cmd.Flags().StringVarP(&f.extraArgs, "extra-args", "e", f.extraArgs, "Extra arguments from the original format used by the converter. Multiple arguments can be passed by separating them with a space.")
 	return cmd
@@ -85,13 +91,18 @@ type flowConvert struct {
 	output       string
 	report       string
 	sourceFormat string
+	target       string
 	bypassErrors bool
 	extraArgs    string
 }
 
 func (fc *flowConvert) Run(configFile string) error {
-	if fc.sourceFormat == "" {
-		return fmt.Errorf("source-format is a required flag")
+	if fc.target == string(converter.TargetFlow) {
+		if fc.sourceFormat == "" {
+			return fmt.Errorf("source-format is a required flag")
+		}
+	} else if fc.sourceFormat != "" || fc.extraArgs != "" {
+		return fmt.Errorf("source-format and extra-args can't be used with target %q, the file must be a River configuration", fc.target)
 	}
 
 	if configFile == "-" {
@@ -120,12 +131,19 @@ func convert(r io.Reader, fc *flowConvert) error {
 		return err
 	}
 
-	ea, err := parseExtraArgs(fc.extraArgs)
-	if err != nil {
-		return err
+	var (
+		outputBytes []byte
+		diags       convert_diag.Diagnostics
+	)
+	if fc.target == string(converter.TargetFlow) {
+		ea, err := parseExtraArgs(fc.extraArgs)
+		if err != nil {
+			return err
+		}
+		outputBytes, diags = converter.Convert(inputBytes, converter.Input(fc.sourceFormat), ea)
+	} else {
+		outputBytes, diags = converter.Export(inputBytes, converter.Target(fc.target))
 	}
-
-	riverBytes, diags := converter.Convert(inputBytes, converter.Input(fc.sourceFormat), ea)
 	err = generateConvertReport(diags, fc)
 	if err != nil {
 		return err
@@ -138,7 +156,7 @@ func convert(r io.Reader, fc *flowConvert) error {
 	}
 
 	var buf bytes.Buffer
-	buf.WriteString(string(riverBytes))
+	buf.WriteString(string(outputBytes))
 
 	if fc.output == "" {
 		_, err := io.Copy(os.Stdout, &buf)
@@ -188,6 +206,14 @@ func supportedFormatsList() string {
 	return strings.Join(ret, ", ")
 }
 
+func supportedTargetsList() string {
+	var ret = make([]string, len(converter.SupportedTargets))
+	for i, t := range converter.SupportedTargets {
+		ret[i] = fmt.Sprintf("%q", t)
+	}
+	return strings.Join(ret, ", ")
+}
+
 func parseExtraArgs(extraArgs string) ([]string, error) {
 	var result []string
 	if extraArgs == "" {
@@ -30,6 +30,7 @@ import (
 	"github.com/grafana/agent/internal/service"
 	httpservice "github.com/grafana/agent/internal/service/http"
 	"github.com/grafana/agent/internal/service/labelstore"
+	"github.com/grafana/agent/internal/service/livedebugging"
 	otel_service "github.com/grafana/agent/internal/service/otel"
 	remotecfgservice "github.com/grafana/agent/internal/service/remotecfg"
 	uiservice "github.com/grafana/agent/internal/service/ui"
@@ -106,6 +107,8 @@ depending on the nature of the reload error.
 	cmd.Flags().StringVar(&r.uiPrefix, "server.http.ui-path-prefix", r.uiPrefix, "Prefix to serve the HTTP UI at")
 	cmd.Flags().
 		BoolVar(&r.enablePprof, "server.http.enable-pprof", r.enablePprof, "Enable /debug/pprof profiling endpoints.")
+	cmd.Flags().
+		StringVar(&r.apiWriteTokenFile, "server.http.api-write-token-file", r.apiWriteTokenFile, "File holding the bearer token for the write endpoints of the HTTP API. The write endpoints are disabled if unset.")
 
 	// Cluster flags
 	cmd.Flags().
@@ -145,6 +148,7 @@ type flowRun struct {
 	storagePath                  string
 	minStability                 featuregate.Stability
 	uiPrefix                     string
+	apiWriteTokenFile            string
 	enablePprof                  bool
 	disableReporting             bool
 	clusterEnabled               bool
@@ -257,13 +261,31 @@ func (fr *flowRun) Run(configPath string) error {
 	remoteCfgService, err := remotecfgservice.New(remotecfgservice.Options{
 		Logger:      log.With(l, "service", "remotecfg"),
 		StoragePath: fr.storagePath,
+		Metrics:     reg,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create the remotecfg service: %w", err)
 	}
 
+	var apiWriteToken string
+	if fr.apiWriteTokenFile != "" {
+		bb, err := os.ReadFile(fr.apiWriteTokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read the API write token: %w", err)
+		}
+		apiWriteToken = strings.TrimSpace(string(bb))
+		if apiWriteToken == "" {
+			return fmt.Errorf("API write token file %q is empty", fr.apiWriteTokenFile)
+		}
+	}
+
 	uiService := uiservice.New(uiservice.Options{
-		UIPrefix: fr.uiPrefix,
+		UIPrefix:   fr.uiPrefix,
+		WriteToken: apiWriteToken,
+		ReloadFunc: func() error {
+			_, err := reload()
+			return err
+		},
 	})
 
 	otelService := otel_service.New(l)
@@ -272,6 +294,7 @@ func (fr *flowRun) Run(configPath string) error {
 	}
 
 	labelService := labelstore.New(l, reg)
+	liveDebuggingService := livedebugging.New()
 	agentseed.Init(fr.storagePath, l)
 
 	f := flow.New(flow.Options{
@@ -286,6 +309,7 @@ func (fr *flowRun) Run(configPath string) error {
 			clusterService,
 			otelService,
 			labelService,
+			liveDebuggingService,
 			remoteCfgService,
 		},
 	})
@@ -0,0 +1,223 @@
+package flowmode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/agent/internal/flow/rivertest"
+	"github.com/grafana/agent/internal/service"
+	httpservice "github.com/grafana/agent/internal/service/http"
+	"github.com/grafana/agent/internal/service/labelstore"
+	"github.com/grafana/agent/internal/service/livedebugging"
+	otel_service "github.com/grafana/agent/internal/service/otel"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// testFileSuffix is the suffix of the files holding tests.
+const testFileSuffix = "_test.river"
+
+func testCommand() *cobra.Command {
+	t := &flowTest{
+		minStability: featuregate.StabilityExperimental,
+		timeout:      rivertest.DefaultTimeout,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "test [flags] path...",
+		Short: "Run the tests of River configurations",
+		Long: `The test subcommand runs the tests defined in *_test.river files.
+
+Each path is either a test file, or a directory which is searched recursively
+for test files. Each test block of a test file is loaded as a configuration
+along with the statements of the file outside of test blocks, typically
+declare and import blocks defining the modules under test.
+
+Tests use testing.* components to send synthetic log entries, samples and
+spans to the components under test, to receive the data they send, and to
+assert on values. A test passes once all its data was sent and all its
+assertions hold, and fails if it doesn't pass before its timeout.
+
+test exits with a non-zero exit code if any test fails.`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return t.Run(cmd.Context(), args)
+		},
+	}
+
+	cmd.Flags().StringVar(&t.run, "run", t.run, "Only run the tests whose name matches this regular expression.")
+	cmd.Flags().DurationVar(&t.timeout, "timeout", t.timeout, "Timeout of the tests which don't define their own.")
+	cmd.Flags().StringVar(&t.junitReport, "report.junit", t.junitReport, "The filepath where a JUnit XML report of the tests is written.")
+	return cmd
+}
+
+type flowTest struct {
+	minStability featuregate.Stability
+	run          string
+	timeout      time.Duration
+	junitReport  string
+}
+
+func (ft *flowTest) Run(ctx context.Context, paths []string) error {
+	opts := rivertest.Options{
+		MinStability: ft.minStability,
+		Timeout:      ft.timeout,
+		NewServices:  testServices,
+	}
+	if ft.run != "" {
+		re, err := regexp.Compile(ft.run)
+		if err != nil {
+			return fmt.Errorf("invalid run expression: %w", err)
+		}
+		opts.Run = re
+	}
+
+	files, err := findTestFiles(paths)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no %s files found", testFileSuffix)
+	}
+
+	var (
+		suites []rivertest.Suite
+		failed bool
+	)
+	for _, file := range files {
+		suite := rivertest.RunFile(ctx, file, opts)
+		printSuite(os.Stdout, suite)
+
+		suites = append(suites, suite)
+		failed = failed || suite.Failed()
+	}
+
+	if ft.junitReport != "" {
+		if err := writeJUnitReport(ft.junitReport, suites); err != nil {
+			return fmt.Errorf("writing JUnit report: %w", err)
+		}
+	}
+
+	if failed {
+		return errors.New("tests failed")
+	}
+	return nil
+}
+
+// findTestFiles returns the test files at paths, sorted by path.
+func findTestFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.HasSuffix(d.Name(), testFileSuffix) {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// printSuite prints the results of suite in a format similar to go test.
+func printSuite(w io.Writer, suite rivertest.Suite) {
+	if suite.Err != nil {
+		fmt.Fprintf(w, "FAIL\t%s\n%s\n", suite.File, indent(suite.Err.Error()))
+		return
+	}
+
+	var total time.Duration
+	for _, t := range suite.Tests {
+		total += t.Duration
+		if t.Failure == "" {
+			fmt.Fprintf(w, "--- PASS: %s (%.2fs)\n", t.Name, t.Duration.Seconds())
+			continue
+		}
+		fmt.Fprintf(w, "--- FAIL: %s (%.2fs)\n%s\n", t.Name, t.Duration.Seconds(), indent(t.Failure))
+	}
+
+	if suite.Failed() {
+		fmt.Fprintf(w, "FAIL\t%s\t%.3fs\n", suite.File, total.Seconds())
+	} else {
+		fmt.Fprintf(w, "ok\t%s\t%.3fs\n", suite.File, total.Seconds())
+	}
+}
+
+func indent(s string) string {
+	return "    " + strings.ReplaceAll(s, "\n", "\n    ")
+}
+
+func writeJUnitReport(path string, suites []rivertest.Suite) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := rivertest.WriteJUnit(f, suites); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// testServices returns the services run along with tests. The HTTP service
+// listens on a random port so that tests don't collide with a running agent
+// or with each other. Clustering is disabled, so the cluster service doesn't
+// listen on its address either.
+func testServices(l log.Logger) ([]service.Service, error) {
+	reg := prometheus.NewRegistry()
+
+	clusterService, err := buildClusterService(clusterOptions{
+		Log:           l,
+		Metrics:       reg,
+		Tracer:        noop.NewTracerProvider(),
+		ListenAddress: "127.0.0.1:0",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []service.Service{
+		httpservice.New(httpservice.Options{
+			Logger:           l,
+			Tracer:           noop.NewTracerProvider(),
+			Gatherer:         reg,
+			ReadyFunc:        func() bool { return true },
+			HTTPListenAddr:   "127.0.0.1:0",
+			MemoryListenAddr: "agent.internal:12345",
+		}),
+		clusterService,
+		otel_service.New(l),
+		labelstore.New(l, reg),
+		livedebugging.New(),
+	}, nil
+}
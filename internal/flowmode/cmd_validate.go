@@ -0,0 +1,144 @@
+package flowmode
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/agent/internal/flow"
+	"github.com/grafana/agent/internal/service"
+	httpservice "github.com/grafana/agent/internal/service/http"
+	"github.com/grafana/agent/internal/service/labelstore"
+	"github.com/grafana/agent/internal/service/livedebugging"
+	otel_service "github.com/grafana/agent/internal/service/otel"
+	remotecfgservice "github.com/grafana/agent/internal/service/remotecfg"
+	uiservice "github.com/grafana/agent/internal/service/ui"
+	"github.com/grafana/river/diag"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/cobra"
+)
+
+func validateCommand() *cobra.Command {
+	v := &flowValidate{
+		minStability: featuregate.StabilityExperimental,
+		configFormat: "flow",
+	}
+
+	cmd := &cobra.Command{
+		Use:   "validate [flags] path",
+		Short: "Validate a configuration without running it",
+		Long: `The validate subcommand checks a configuration dir/file-path for errors
+without running it.
+
+The configuration is loaded the same way as by the run subcommand: component
+arguments are evaluated and validated, references between components are
+resolved, and imports and declare blocks are loaded. Components are never
+started; instead, every component exports the zero value of its exports.
+
+All diagnostics found are written to stderr. validate exits with a non-zero
+exit code if the configuration contains errors.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+
+		RunE: func(_ *cobra.Command, args []string) error {
+			return v.Run(args[0])
+		},
+	}
+
+	cmd.Flags().StringVar(&v.configFormat, "config.format", v.configFormat, fmt.Sprintf("The format of the source file. Supported formats: %s.", supportedFormatsList()))
+	cmd.Flags().BoolVar(&v.configBypassConversionErrors, "config.bypass-conversion-errors", v.configBypassConversionErrors, "Enable bypassing errors when converting")
+	cmd.Flags().StringVar(&v.configExtraArgs, "config.extra-args", v.configExtraArgs, "Extra arguments from the original format used by the converter. Multiple arguments can be passed by separating them with a space.")
+	return cmd
+}
+
+type flowValidate struct {
+	minStability                 featuregate.Stability
+	configFormat                 string
+	configBypassConversionErrors bool
+	configExtraArgs              string
+}
+
+func (fv *flowValidate) Run(configPath string) error {
+	source, err := loadFlowSource(configPath, fv.configFormat, fv.configBypassConversionErrors, fv.configExtraArgs)
+	if err == nil {
+		err = fv.validate(source)
+	}
+
+	var diags diag.Diagnostics
+	if errors.As(err, &diags) {
+		p := diag.NewPrinter(diag.PrinterConfig{
+			Color:              !color.NoColor,
+			ContextLinesBefore: 1,
+			ContextLinesAfter:  1,
+		})
+		_ = p.Fprint(os.Stderr, source.RawConfigs(), diags)
+
+		if !diags.HasErrors() {
+			return nil
+		}
+		return fmt.Errorf("configuration contains errors")
+	}
+	return err
+}
+
+func (fv *flowValidate) validate(source *flow.Source) error {
+	// Imports may store data while they are resolved, so they get a directory
+	// which is thrown away afterwards.
+	dataPath, err := os.MkdirTemp("", "agent-validate-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dataPath)
+
+	services, err := validationServices(dataPath)
+	if err != nil {
+		return err
+	}
+
+	return flow.Validate(source, flow.ValidateOptions{
+		DataPath:     dataPath,
+		MinStability: fv.minStability,
+		Services:     services,
+	})
+}
+
+// validationServices returns the services available to the run subcommand,
+// so that their configuration blocks are validated too. The services are
+// never run.
+func validationServices(dataPath string) ([]service.Service, error) {
+	var (
+		l   = log.NewNopLogger()
+		reg = prometheus.NewRegistry()
+	)
+
+	clusterService, err := buildClusterService(clusterOptions{
+		Log:           l,
+		Metrics:       reg,
+		ListenAddress: "127.0.0.1:12345",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	remoteCfgService, err := remotecfgservice.New(remotecfgservice.Options{
+		Logger:      l,
+		StoragePath: dataPath,
+		Metrics:     reg,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []service.Service{
+		httpservice.New(httpservice.Options{Logger: l}),
+		uiservice.New(uiservice.Options{}),
+		clusterService,
+		otel_service.New(l),
+		labelstore.New(l, reg),
+		livedebugging.New(),
+		remoteCfgService,
+	}, nil
+}
@@ -0,0 +1,168 @@
+// Package integrity verifies the integrity of imported content against a
+// pinned SHA-256 checksum or a detached signature.
+package integrity
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Arguments configures the verification of imported content. Exactly one of
+// SHA256, PublicKey and PublicKeyFile must be set.
+type Arguments struct {
+	SHA256        string `river:"sha256,attr,optional"`
+	PublicKey     string `river:"public_key,attr,optional"`
+	PublicKeyFile string `river:"public_key_file,attr,optional"`
+	Signature     string `river:"signature,attr,optional"`
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	var set int
+	for _, v := range []string{args.SHA256, args.PublicKey, args.PublicKeyFile} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("exactly one of sha256, public_key and public_key_file must be set")
+	}
+
+	if args.SHA256 != "" {
+		if _, err := parseSHA256(args.SHA256); err != nil {
+			return err
+		}
+		if args.Signature != "" {
+			return errors.New("signature can only be set with public_key or public_key_file")
+		}
+	}
+	return nil
+}
+
+func parseSHA256(s string) ([]byte, error) {
+	sum, err := hex.DecodeString(strings.TrimPrefix(s, "sha256:"))
+	if err != nil || len(sum) != sha256.Size {
+		return nil, fmt.Errorf("sha256 must be a hex-encoded SHA-256 checksum, got %q", s)
+	}
+	return sum, nil
+}
+
+// VerificationFailedError represents content which failed verification.
+type VerificationFailedError struct {
+	Location string
+	Inner    error
+}
+
+// Error returns the error string, denoting the content which failed
+// verification.
+func (err VerificationFailedError) Error() string {
+	return fmt.Sprintf("verification of %q failed: %s", err.Location, err.Inner)
+}
+
+// Unwrap returns the inner error.
+func (err VerificationFailedError) Unwrap() error { return err.Inner }
+
+// Verifier verifies content. A nil *Verifier accepts any content.
+type Verifier struct {
+	sha256    []byte
+	key       publicKey
+	signature string
+}
+
+// NewVerifier creates a Verifier from args. It returns nil if args is nil.
+func NewVerifier(args *Arguments) (*Verifier, error) {
+	if args == nil {
+		return nil, nil
+	}
+	if err := args.Validate(); err != nil {
+		return nil, err
+	}
+
+	if args.SHA256 != "" {
+		sum, err := parseSHA256(args.SHA256)
+		if err != nil {
+			return nil, err
+		}
+		return &Verifier{sha256: sum}, nil
+	}
+
+	keyData := []byte(args.PublicKey)
+	if args.PublicKeyFile != "" {
+		var err error
+		keyData, err = os.ReadFile(args.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading public key: %w", err)
+		}
+	}
+	key, err := parsePublicKey(keyData)
+	if err != nil {
+		return nil, err
+	}
+	return &Verifier{key: key, signature: args.Signature}, nil
+}
+
+// NeedsSignature returns true if the Verifier verifies detached signatures.
+func (v *Verifier) NeedsSignature() bool {
+	return v != nil && v.key != nil
+}
+
+// CanVerifyDirectories returns true if the Verifier can verify each file of a
+// directory: a pinned checksum or a single configured signature only apply to
+// one file.
+func (v *Verifier) CanVerifyDirectories() bool {
+	return v == nil || (v.key != nil && v.signature == "")
+}
+
+// SignatureLocation returns the location of the detached signature of the
+// content at location: the configured signature if set, or location with a
+// .sig suffix. The suffix of HTTP(S) URLs is added to their path, so that
+// their query string and fragment are kept.
+func (v *Verifier) SignatureLocation(location string) string {
+	if v != nil && v.signature != "" {
+		return v.signature
+	}
+	if u, err := url.Parse(location); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		u.Path += ".sig"
+		if u.RawPath != "" {
+			u.RawPath += ".sig"
+		}
+		return u.String()
+	}
+	return location + ".sig"
+}
+
+// Verify verifies content retrieved from location. If the Verifier verifies
+// detached signatures, the signature is read by calling readSignature with
+// the signature location. Verify returns a VerificationFailedError if the
+// content fails verification.
+func (v *Verifier) Verify(location string, content []byte, readSignature func(location string) ([]byte, error)) error {
+	if v == nil {
+		return nil
+	}
+
+	var err error
+	if v.key == nil {
+		if sum := sha256.Sum256(content); !bytes.Equal(sum[:], v.sha256) {
+			err = fmt.Errorf("sha256 checksum %x doesn't match the pinned checksum %x", sum, v.sha256)
+		}
+	} else {
+		var signature []byte
+		signature, err = readSignature(v.SignatureLocation(location))
+		if err != nil {
+			err = fmt.Errorf("reading signature: %w", err)
+		} else {
+			err = v.key.verify(content, signature)
+		}
+	}
+
+	if err != nil {
+		return VerificationFailedError{Location: location, Inner: err}
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package integrity_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/agent/internal/integrity"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+)
+
+var content = []byte("declare \"add\" {}\n")
+
+func TestArguments_Validate(t *testing.T) {
+	sum := sha256.Sum256(content)
+
+	tt := []struct {
+		name        string
+		args        integrity.Arguments
+		expectError string
+	}{
+		{
+			name: "sha256",
+			args: integrity.Arguments{SHA256: hex.EncodeToString(sum[:])},
+		},
+		{
+			name: "prefixed sha256",
+			args: integrity.Arguments{SHA256: "sha256:" + hex.EncodeToString(sum[:])},
+		},
+		{
+			name:        "nothing set",
+			args:        integrity.Arguments{},
+			expectError: "exactly one of sha256, public_key and public_key_file must be set",
+		},
+		{
+			name:        "sha256 and public key",
+			args:        integrity.Arguments{SHA256: hex.EncodeToString(sum[:]), PublicKey: "key"},
+			expectError: "exactly one of sha256, public_key and public_key_file must be set",
+		},
+		{
+			name:        "invalid sha256",
+			args:        integrity.Arguments{SHA256: "abcd"},
+			expectError: `sha256 must be a hex-encoded SHA-256 checksum, got "abcd"`,
+		},
+		{
+			name:        "signature with sha256",
+			args:        integrity.Arguments{SHA256: hex.EncodeToString(sum[:]), Signature: "module.sig"},
+			expectError: "signature can only be set with public_key or public_key_file",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.args.Validate()
+			if tc.expectError == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectError)
+			}
+		})
+	}
+}
+
+func TestVerifier_Nil(t *testing.T) {
+	v, err := integrity.NewVerifier(nil)
+	require.NoError(t, err)
+	require.Nil(t, v)
+	require.False(t, v.NeedsSignature())
+	require.NoError(t, v.Verify("module.river", content, nil))
+}
+
+func TestVerifier_SHA256(t *testing.T) {
+	sum := sha256.Sum256(content)
+	v, err := integrity.NewVerifier(&integrity.Arguments{SHA256: hex.EncodeToString(sum[:])})
+	require.NoError(t, err)
+	require.False(t, v.NeedsSignature())
+
+	require.NoError(t, v.Verify("module.river", content, nil))
+
+	err = v.Verify("module.river", []byte("tampered"), nil)
+	require.ErrorAs(t, err, &integrity.VerificationFailedError{})
+	require.ErrorContains(t, err, `verification of "module.river" failed: sha256 checksum`)
+}
+
+func TestVerifier_Signatures(t *testing.T) {
+	ecdsaPriv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ed25519Pub, ed25519Priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	rsaPriv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tt := []struct {
+		name      string
+		publicKey string
+		sign      func(content []byte) []byte
+	}{
+		{
+			// Signatures written by `cosign sign-blob` are encoded in base64.
+			name:      "cosign ecdsa",
+			publicKey: pemPublicKey(t, &ecdsaPriv.PublicKey),
+			sign: func(content []byte) []byte {
+				digest := sha256.Sum256(content)
+				sig, err := ecdsa.SignASN1(rand.Reader, ecdsaPriv, digest[:])
+				require.NoError(t, err)
+				return []byte(base64.StdEncoding.EncodeToString(sig))
+			},
+		},
+		{
+			name:      "raw ed25519",
+			publicKey: pemPublicKey(t, ed25519Pub),
+			sign: func(content []byte) []byte {
+				return ed25519.Sign(ed25519Priv, content)
+			},
+		},
+		{
+			name:      "rsa",
+			publicKey: pemPublicKey(t, &rsaPriv.PublicKey),
+			sign: func(content []byte) []byte {
+				digest := sha256.Sum256(content)
+				sig, err := rsa.SignPKCS1v15(rand.Reader, rsaPriv, crypto.SHA256, digest[:])
+				require.NoError(t, err)
+				return sig
+			},
+		},
+		{
+			name:      "minisign",
+			publicKey: minisignPublicKey(ed25519Pub),
+			sign: func(content []byte) []byte {
+				return minisignSign(ed25519Priv, content, false)
+			},
+		},
+		{
+			name:      "minisign prehashed",
+			publicKey: minisignPublicKey(ed25519Pub),
+			sign: func(content []byte) []byte {
+				return minisignSign(ed25519Priv, content, true)
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := integrity.NewVerifier(&integrity.Arguments{PublicKey: tc.publicKey})
+			require.NoError(t, err)
+			require.True(t, v.NeedsSignature())
+
+			signature := tc.sign(content)
+			readSignature := func(location string) ([]byte, error) {
+				require.Equal(t, "module.river.sig", location)
+				return signature, nil
+			}
+			require.NoError(t, v.Verify("module.river", content, readSignature))
+
+			err = v.Verify("module.river", []byte("tampered"), readSignature)
+			require.ErrorAs(t, err, &integrity.VerificationFailedError{})
+		})
+	}
+}
+
+func TestVerifier_SignatureLocation(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	keyFile := filepath.Join(t.TempDir(), "key.pub")
+	require.NoError(t, os.WriteFile(keyFile, []byte(minisignPublicKey(pub)), 0644))
+
+	v, err := integrity.NewVerifier(&integrity.Arguments{PublicKeyFile: keyFile, Signature: "signatures/module.minisig"})
+	require.NoError(t, err)
+	require.Equal(t, "signatures/module.minisig", v.SignatureLocation("module.river"))
+
+	err = v.Verify("module.river", content, func(location string) ([]byte, error) {
+		require.Equal(t, "signatures/module.minisig", location)
+		return minisignSign(priv, content, false), nil
+	})
+	require.NoError(t, err)
+
+	err = v.Verify("module.river", content, func(string) ([]byte, error) {
+		return nil, errors.New("not found")
+	})
+	require.EqualError(t, err, `verification of "module.river" failed: reading signature: not found`)
+}
+
+func TestVerifier_DefaultSignatureLocation(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	v, err := integrity.NewVerifier(&integrity.Arguments{PublicKey: minisignPublicKey(pub)})
+	require.NoError(t, err)
+
+	tests := map[string]string{
+		"modules/math.river":                                   "modules/math.river.sig",
+		"https://example.com/math.river":                       "https://example.com/math.river.sig",
+		"https://example.com/math.river?token=x":               "https://example.com/math.river.sig?token=x",
+		"http://example.com/math.river?a=1&b=2#section":        "http://example.com/math.river.sig?a=1&b=2#section",
+		"https://example.com/modules%2Fmath.river?token=a%2Fb": "https://example.com/modules%2Fmath.river.sig?token=a%2Fb",
+	}
+	for location, expected := range tests {
+		require.Equal(t, expected, v.SignatureLocation(location), location)
+	}
+}
+
+func TestVerifier_MinisignOtherKey(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	v, err := integrity.NewVerifier(&integrity.Arguments{PublicKey: minisignPublicKey(pub)})
+	require.NoError(t, err)
+
+	// Both keys share the same key ID, so the signature itself is checked.
+	signature := minisignSign(otherPriv, content, false)
+	err = v.Verify("module.river", content, func(string) ([]byte, error) { return signature, nil })
+	require.EqualError(t, err, `verification of "module.river" failed: invalid signature`)
+}
+
+func TestNewVerifier_InvalidKey(t *testing.T) {
+	_, err := integrity.NewVerifier(&integrity.Arguments{PublicKey: "not a key"})
+	require.EqualError(t, err, "public key must be either a PEM-encoded public key or a minisign public key")
+}
+
+func pemPublicKey(t *testing.T, key any) string {
+	bb, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: bb}))
+}
+
+var minisignKeyID = []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+func minisignPublicKey(pub ed25519.PublicKey) string {
+	bb := append([]byte("Ed"), minisignKeyID...)
+	bb = append(bb, pub...)
+	return "untrusted comment: minisign public key 0807060504030201\n" + base64.StdEncoding.EncodeToString(bb) + "\n"
+}
+
+func minisignSign(priv ed25519.PrivateKey, content []byte, prehashed bool) []byte {
+	alg, message := "Ed", content
+	if prehashed {
+		sum := blake2b.Sum512(content)
+		alg, message = "ED", sum[:]
+	}
+	sig := ed25519.Sign(priv, message)
+	trustedComment := "timestamp:1700000000\tfile:module.river"
+	globalSig := ed25519.Sign(priv, append(append([]byte{}, sig...), trustedComment...))
+
+	bb := append([]byte(alg), minisignKeyID...)
+	bb = append(bb, sig...)
+	return []byte(fmt.Sprintf(
+		"untrusted comment: signature from minisign secret key\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(bb), trustedComment, base64.StdEncoding.EncodeToString(globalSig),
+	))
+}
@@ -0,0 +1,195 @@
+package integrity
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+type publicKey interface {
+	verify(content, signature []byte) error
+}
+
+var errInvalidSignature = errors.New("invalid signature")
+
+// parsePublicKey parses either a PEM-encoded public key, as generated by
+// `cosign generate-key-pair`, or a minisign public key.
+func parsePublicKey(data []byte) (publicKey, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		if block.Type != "PUBLIC KEY" {
+			return nil, fmt.Errorf("unsupported PEM block %q, expected PUBLIC KEY", block.Type)
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing public key: %w", err)
+		}
+		switch key := key.(type) {
+		case *ecdsa.PublicKey:
+			return ecdsaKey{key}, nil
+		case ed25519.PublicKey:
+			return ed25519Key{key}, nil
+		case *rsa.PublicKey:
+			return rsaKey{key}, nil
+		default:
+			return nil, fmt.Errorf("unsupported public key type %T", key)
+		}
+	}
+	return parseMinisignKey(data)
+}
+
+// decodeSignature decodes a signature encoded in base64, as written by
+// `cosign sign-blob`, or returns the raw signature.
+func decodeSignature(signature []byte) []byte {
+	if sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature))); err == nil {
+		return sig
+	}
+	return signature
+}
+
+type ecdsaKey struct{ key *ecdsa.PublicKey }
+
+func (k ecdsaKey) verify(content, signature []byte) error {
+	var h hash.Hash
+	switch k.key.Curve {
+	case elliptic.P384():
+		h = sha512.New384()
+	case elliptic.P521():
+		h = sha512.New()
+	default:
+		h = sha256.New()
+	}
+	h.Write(content)
+	if !ecdsa.VerifyASN1(k.key, h.Sum(nil), decodeSignature(signature)) {
+		return errInvalidSignature
+	}
+	return nil
+}
+
+type ed25519Key struct{ key ed25519.PublicKey }
+
+func (k ed25519Key) verify(content, signature []byte) error {
+	if !ed25519.Verify(k.key, content, decodeSignature(signature)) {
+		return errInvalidSignature
+	}
+	return nil
+}
+
+type rsaKey struct{ key *rsa.PublicKey }
+
+func (k rsaKey) verify(content, signature []byte) error {
+	sig := decodeSignature(signature)
+	digest := sha256.Sum256(content)
+	if rsa.VerifyPKCS1v15(k.key, crypto.SHA256, digest[:], sig) == nil {
+		return nil
+	}
+	if rsa.VerifyPSS(k.key, crypto.SHA256, digest[:], sig, nil) == nil {
+		return nil
+	}
+	return errInvalidSignature
+}
+
+// minisign keys and signatures, as documented in
+// https://jedisct1.github.io/minisign/.
+const (
+	minisignKeyIDSize    = 8
+	minisignAlgorithm    = "Ed"
+	minisignAlgorithmPre = "ED" // Signature of the BLAKE2b-512 hash of the content.
+)
+
+type minisignKey struct {
+	keyID []byte
+	key   ed25519.PublicKey
+}
+
+// parseMinisignKey parses a minisign public key, with or without its
+// untrusted comment line.
+func parseMinisignKey(data []byte) (publicKey, error) {
+	lines := nonCommentLines(string(data), "untrusted comment:")
+	if len(lines) != 1 {
+		return nil, errors.New("public key must be either a PEM-encoded public key or a minisign public key")
+	}
+	bb, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil || len(bb) != 2+minisignKeyIDSize+ed25519.PublicKeySize || string(bb[:2]) != minisignAlgorithm {
+		return nil, errors.New("public key must be either a PEM-encoded public key or a minisign public key")
+	}
+	return minisignKey{
+		keyID: bb[2 : 2+minisignKeyIDSize],
+		key:   ed25519.PublicKey(bb[2+minisignKeyIDSize:]),
+	}, nil
+}
+
+func (k minisignKey) verify(content, signature []byte) error {
+	var (
+		sigLine, globalSigLine, trustedComment string
+		afterTrustedComment                    bool
+	)
+	for _, line := range strings.Split(strings.TrimSpace(string(signature)), "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case strings.HasPrefix(line, "untrusted comment:"):
+		case strings.HasPrefix(line, "trusted comment: "):
+			trustedComment = strings.TrimPrefix(line, "trusted comment: ")
+			afterTrustedComment = true
+		case afterTrustedComment:
+			globalSigLine = line
+		default:
+			sigLine = line
+		}
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(sigLine)
+	if err != nil || len(sig) != 2+minisignKeyIDSize+ed25519.SignatureSize {
+		return errors.New("malformed minisign signature")
+	}
+	if !bytes.Equal(sig[2:2+minisignKeyIDSize], k.keyID) {
+		return errors.New("signature was created with a different key")
+	}
+
+	message := content
+	switch string(sig[:2]) {
+	case minisignAlgorithm:
+	case minisignAlgorithmPre:
+		sum := blake2b.Sum512(content)
+		message = sum[:]
+	default:
+		return fmt.Errorf("unsupported minisign signature algorithm %q", sig[:2])
+	}
+	sigBytes := sig[2+minisignKeyIDSize:]
+	if !ed25519.Verify(k.key, message, sigBytes) {
+		return errInvalidSignature
+	}
+
+	// The global signature authenticates the trusted comment.
+	globalMessage := append(append([]byte{}, sigBytes...), trustedComment...)
+	globalSig, err := base64.StdEncoding.DecodeString(globalSigLine)
+	if err != nil || !ed25519.Verify(k.key, globalMessage, globalSig) {
+		return errors.New("invalid trusted comment signature")
+	}
+	return nil
+}
+
+func nonCommentLines(s string, commentPrefix string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, commentPrefix) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
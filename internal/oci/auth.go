@@ -0,0 +1,45 @@
+package oci
+
+import (
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/grafana/river/rivertypes"
+)
+
+// AuthConfig configures how to authenticate to a registry. When no
+// credentials are set, the Docker config file and its credential helpers are
+// used, as with `docker pull`.
+type AuthConfig struct {
+	BasicAuth   *BasicAuth        `river:"basic_auth,block,optional"`
+	BearerToken rivertypes.Secret `river:"bearer_token,attr,optional"`
+}
+
+// Validate implements river.Validator.
+func (a *AuthConfig) Validate() error {
+	if a.BasicAuth != nil && a.BearerToken != "" {
+		return fmt.Errorf("at most one of basic_auth and bearer_token can be set")
+	}
+	return nil
+}
+
+// Convert converts AuthConfig to a remote.Option.
+func (a *AuthConfig) Convert() remote.Option {
+	switch {
+	case a.BasicAuth != nil:
+		return remote.WithAuth(&authn.Basic{
+			Username: a.BasicAuth.Username,
+			Password: string(a.BasicAuth.Password),
+		})
+	case a.BearerToken != "":
+		return remote.WithAuth(&authn.Bearer{Token: string(a.BearerToken)})
+	default:
+		return remote.WithAuthFromKeychain(authn.DefaultKeychain)
+	}
+}
+
+type BasicAuth struct {
+	Username string            `river:"username,attr"`
+	Password rivertypes.Secret `river:"password,attr"`
+}
@@ -0,0 +1,331 @@
+// Package oci retrieves files stored as artifacts in OCI registries.
+package oci
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/grafana/agent/internal/useragent"
+)
+
+const (
+	// AnnotationTitle holds the file name of a layer in artifacts pushed by
+	// tools such as ORAS.
+	AnnotationTitle = "org.opencontainers.image.title"
+
+	// AnnotationUnpack marks a layer holding a tarball of a directory in
+	// artifacts pushed by ORAS.
+	AnnotationUnpack = "io.deis.oras.content.unpack"
+
+	stateFile = "state.json"
+)
+
+type RepositoryOptions struct {
+	// Reference of the artifact, either tagged (registry/repository:tag) or
+	// pinned to a digest (registry/repository@sha256:...).
+	Reference string
+	// PlainHTTP connects to the registry over plain HTTP instead of HTTPS.
+	PlainHTTP bool
+	// InsecureSkipVerify disables the verification of the TLS certificate of
+	// the registry.
+	InsecureSkipVerify bool
+	Auth               AuthConfig
+}
+
+// Repository manages an artifact from an OCI registry for the purposes of
+// retrieving files from it.
+//
+// The layers of the artifact are extracted to a directory named after the
+// digest of the artifact. Layers annotated with a title are written as a
+// file named after the title, other layers are extracted as (optionally
+// gzipped) tarballs.
+type Repository struct {
+	storagePath string
+	opts        RepositoryOptions
+	ref         name.Reference
+
+	// digest of the artifact whose files are served.
+	digest string
+}
+
+// state is persisted in the storage path to find the last downloaded
+// artifact when the registry can't be reached.
+type state struct {
+	Reference string `json:"reference"`
+	Digest    string `json:"digest"`
+}
+
+// NewRepository creates a new instance of a Repository, where the artifact is
+// cached at storagePath.
+//
+// NewRepository resolves and downloads the artifact. If that fails but the
+// artifact was previously downloaded to storagePath, NewRepository returns
+// the Repository serving the cached artifact along with an
+// UpdateFailedError.
+func NewRepository(ctx context.Context, storagePath string, opts RepositoryOptions) (*Repository, error) {
+	var nameOpts []name.Option
+	if opts.PlainHTTP {
+		nameOpts = append(nameOpts, name.Insecure)
+	}
+	ref, err := name.ParseReference(opts.Reference, nameOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference %q: %w", opts.Reference, err)
+	}
+	if err := os.MkdirAll(storagePath, 0750); err != nil {
+		return nil, err
+	}
+
+	repo := &Repository{
+		storagePath: storagePath,
+		opts:        opts,
+		ref:         ref,
+	}
+	repo.loadState()
+
+	if err := repo.Update(ctx); err != nil {
+		if repo.digest == "" {
+			var updateErr UpdateFailedError
+			if errors.As(err, &updateErr) {
+				err = updateErr.Inner
+			}
+			return nil, DownloadFailedError{Reference: opts.Reference, Inner: err}
+		}
+		return repo, err
+	}
+	return repo, nil
+}
+
+// loadState serves the artifact previously downloaded for the same
+// reference, if any.
+func (repo *Repository) loadState() {
+	bb, err := os.ReadFile(filepath.Join(repo.storagePath, stateFile))
+	if err != nil {
+		return
+	}
+	var s state
+	if err := json.Unmarshal(bb, &s); err != nil || s.Reference != repo.opts.Reference {
+		return
+	}
+	if _, err := os.Stat(repo.contentPath(s.Digest)); err == nil {
+		repo.digest = s.Digest
+	}
+}
+
+// Update resolves the reference of the artifact and downloads the artifact
+// if its digest changed. Artifacts pinned to a digest are only downloaded
+// once.
+func (repo *Repository) Update(ctx context.Context) error {
+	digest, err := repo.resolve(ctx)
+	if err != nil {
+		return UpdateFailedError{Reference: repo.opts.Reference, Inner: err}
+	}
+	if digest == repo.digest {
+		return nil
+	}
+
+	if _, err := os.Stat(repo.contentPath(digest)); err != nil {
+		if err := repo.pull(ctx, digest); err != nil {
+			return UpdateFailedError{Reference: repo.opts.Reference, Inner: err}
+		}
+	}
+
+	bb, err := json.Marshal(state{Reference: repo.opts.Reference, Digest: digest})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(repo.storagePath, stateFile), bb, 0640); err != nil {
+		return UpdateFailedError{Reference: repo.opts.Reference, Inner: err}
+	}
+	repo.digest = digest
+	repo.prune()
+	return nil
+}
+
+// resolve returns the digest the reference points to.
+func (repo *Repository) resolve(ctx context.Context) (string, error) {
+	if d, ok := repo.ref.(name.Digest); ok {
+		return d.DigestStr(), nil
+	}
+
+	desc, err := remote.Head(repo.ref, repo.remoteOptions(ctx)...)
+	if err == nil {
+		return desc.Digest.String(), nil
+	}
+	// Some registries don't support HEAD requests for manifests.
+	getDesc, getErr := remote.Get(repo.ref, repo.remoteOptions(ctx)...)
+	if getErr != nil {
+		return "", getErr
+	}
+	return getDesc.Digest.String(), nil
+}
+
+// pull downloads the artifact with the given digest and extracts its layers
+// to its content path.
+func (repo *Repository) pull(ctx context.Context, digest string) error {
+	img, err := remote.Image(repo.ref.Context().Digest(digest), repo.remoteOptions(ctx)...)
+	if err != nil {
+		return err
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return err
+	}
+
+	// Extract to a temporary directory first so that a partially extracted
+	// artifact is never served.
+	tmp, err := os.MkdirTemp(repo.storagePath, ".pull-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	for _, desc := range manifest.Layers {
+		layer, err := img.LayerByDigest(desc.Digest)
+		if err != nil {
+			return err
+		}
+		if err := extractLayer(tmp, layer, desc); err != nil {
+			return fmt.Errorf("extracting layer %s: %w", desc.Digest, err)
+		}
+	}
+	return os.Rename(tmp, repo.contentPath(digest))
+}
+
+func (repo *Repository) remoteOptions(ctx context.Context) []remote.Option {
+	opts := []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithUserAgent(useragent.Get()),
+		repo.opts.Auth.Convert(),
+	}
+	if repo.opts.InsecureSkipVerify {
+		transport := remote.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+		opts = append(opts, remote.WithTransport(transport))
+	}
+	return opts
+}
+
+// prune removes the content of artifacts other than the current one.
+func (repo *Repository) prune() {
+	entries, err := os.ReadDir(repo.storagePath)
+	if err != nil {
+		return
+	}
+	current := filepath.Base(repo.contentPath(repo.digest))
+	for _, e := range entries {
+		if e.IsDir() && e.Name() != current {
+			_ = os.RemoveAll(filepath.Join(repo.storagePath, e.Name()))
+		}
+	}
+}
+
+func (repo *Repository) contentPath(digest string) string {
+	return filepath.Join(repo.storagePath, strings.ReplaceAll(digest, ":", "-"))
+}
+
+func extractLayer(dir string, layer v1.Layer, desc v1.Descriptor) error {
+	rc, err := layer.Compressed()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if title, ok := desc.Annotations[AnnotationTitle]; ok && desc.Annotations[AnnotationUnpack] != "true" {
+		return writeFile(dir, title, rc)
+	}
+
+	r := bufio.NewReader(rc)
+	if magic, _ := r.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		return extractTar(dir, gz)
+	}
+	return extractTar(dir, r)
+}
+
+func extractTar(dir string, r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := writeFile(dir, hdr.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func writeFile(dir string, name string, r io.Reader) error {
+	name = path.Clean(name)
+	if !fs.ValidPath(name) || name == "." {
+		return fmt.Errorf("invalid file name %q", name)
+	}
+
+	p := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0750); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0640)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Digest returns the digest of the artifact currently served.
+func (repo *Repository) Digest() string {
+	return repo.digest
+}
+
+// ReadFile returns a file from the artifact specified by path.
+func (repo *Repository) ReadFile(path string) ([]byte, error) {
+	return fs.ReadFile(repo.fs(), cleanPath(path))
+}
+
+// Stat returns info from the artifact specified by path.
+func (repo *Repository) Stat(path string) (fs.FileInfo, error) {
+	return fs.Stat(repo.fs(), cleanPath(path))
+}
+
+// ReadDir returns info about the content of the directory in the artifact.
+func (repo *Repository) ReadDir(path string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(repo.fs(), cleanPath(path))
+}
+
+func (repo *Repository) fs() fs.FS {
+	return os.DirFS(repo.contentPath(repo.digest))
+}
+
+func cleanPath(p string) string {
+	return path.Clean(strings.TrimPrefix(filepath.ToSlash(p), "/"))
+}
@@ -0,0 +1,212 @@
+package oci_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/grafana/agent/internal/oci"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository(t *testing.T) {
+	srv := httptest.NewServer(registry.New())
+	defer srv.Close()
+
+	ref := registryHost(srv) + "/modules/math:v1"
+	pushFiles(t, ref, map[string]string{"math.river": "v1"})
+
+	storagePath := t.TempDir()
+	repo, err := oci.NewRepository(context.Background(), storagePath, oci.RepositoryOptions{Reference: ref})
+	require.NoError(t, err)
+
+	bb, err := repo.ReadFile("math.river")
+	require.NoError(t, err)
+	require.Equal(t, "v1", string(bb))
+	v1Digest := repo.Digest()
+
+	// Updating without changes to the tag keeps the same artifact.
+	require.NoError(t, repo.Update(context.Background()))
+	require.Equal(t, v1Digest, repo.Digest())
+
+	// Moving the tag is picked up on the next update.
+	pushFiles(t, ref, map[string]string{"math.river": "v2"})
+	require.NoError(t, repo.Update(context.Background()))
+	require.NotEqual(t, v1Digest, repo.Digest())
+
+	bb, err = repo.ReadFile("math.river")
+	require.NoError(t, err)
+	require.Equal(t, "v2", string(bb))
+
+	// Artifacts which aren't served anymore are removed from disk.
+	entries, err := os.ReadDir(storagePath)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+}
+
+func TestRepository_Digest(t *testing.T) {
+	srv := httptest.NewServer(registry.New())
+
+	ref := registryHost(srv) + "/modules/math:v1"
+	digest := pushFiles(t, ref, map[string]string{"math.river": "v1"})
+	pinned := registryHost(srv) + "/modules/math@" + digest
+
+	storagePath := t.TempDir()
+	repo, err := oci.NewRepository(context.Background(), storagePath, oci.RepositoryOptions{Reference: pinned})
+	require.NoError(t, err)
+	require.Equal(t, digest, repo.Digest())
+
+	// Artifacts pinned to a digest are served from disk without contacting
+	// the registry.
+	srv.Close()
+	require.NoError(t, repo.Update(context.Background()))
+
+	repo, err = oci.NewRepository(context.Background(), storagePath, oci.RepositoryOptions{Reference: pinned})
+	require.NoError(t, err)
+	bb, err := repo.ReadFile("math.river")
+	require.NoError(t, err)
+	require.Equal(t, "v1", string(bb))
+}
+
+func TestRepository_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(registry.New())
+
+	ref := registryHost(srv) + "/modules/math:v1"
+	pushFiles(t, ref, map[string]string{"math.river": "v1"})
+
+	storagePath := t.TempDir()
+	_, err := oci.NewRepository(context.Background(), storagePath, oci.RepositoryOptions{Reference: ref})
+	require.NoError(t, err)
+	srv.Close()
+
+	// The cached artifact is served when the registry can't be reached.
+	repo, err := oci.NewRepository(context.Background(), storagePath, oci.RepositoryOptions{Reference: ref})
+	require.ErrorAs(t, err, &oci.UpdateFailedError{})
+	bb, err := repo.ReadFile("math.river")
+	require.NoError(t, err)
+	require.Equal(t, "v1", string(bb))
+
+	// Nothing was cached for another reference.
+	_, err = oci.NewRepository(context.Background(), storagePath, oci.RepositoryOptions{Reference: ref + "-other"})
+	require.ErrorAs(t, err, &oci.DownloadFailedError{})
+}
+
+func TestRepository_Tarball(t *testing.T) {
+	srv := httptest.NewServer(registry.New())
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{
+		"modules/math.river":   "math",
+		"modules/string.river": "string",
+	} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	ref := registryHost(srv) + "/modules/all:v1"
+	push(t, ref, mutate.Addendum{
+		Layer: static.NewLayer(buf.Bytes(), types.OCILayer),
+		Annotations: map[string]string{
+			oci.AnnotationTitle:  "modules",
+			oci.AnnotationUnpack: "true",
+		},
+	})
+
+	repo, err := oci.NewRepository(context.Background(), t.TempDir(), oci.RepositoryOptions{Reference: ref})
+	require.NoError(t, err)
+
+	entries, err := repo.ReadDir("/modules")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	bb, err := repo.ReadFile("modules/string.river")
+	require.NoError(t, err)
+	require.Equal(t, "string", string(bb))
+}
+
+func TestRepository_InvalidFileName(t *testing.T) {
+	srv := httptest.NewServer(registry.New())
+	defer srv.Close()
+
+	ref := registryHost(srv) + "/modules/math:v1"
+	pushFiles(t, ref, map[string]string{"../math.river": "v1"})
+
+	_, err := oci.NewRepository(context.Background(), t.TempDir(), oci.RepositoryOptions{Reference: ref})
+	require.ErrorContains(t, err, "invalid file name")
+}
+
+func TestRepository_InsecureSkipVerify(t *testing.T) {
+	reg := registry.New()
+	srv := httptest.NewServer(reg)
+	defer srv.Close()
+	tlsSrv := httptest.NewTLSServer(reg)
+	defer tlsSrv.Close()
+
+	pushFiles(t, registryHost(srv)+"/modules/math:v1", map[string]string{"math.river": "v1"})
+	ref := registryHost(tlsSrv) + "/modules/math:v1"
+
+	// The certificate of the test server isn't trusted.
+	_, err := oci.NewRepository(context.Background(), t.TempDir(), oci.RepositoryOptions{Reference: ref})
+	require.Error(t, err)
+
+	repo, err := oci.NewRepository(context.Background(), t.TempDir(), oci.RepositoryOptions{
+		Reference:          ref,
+		InsecureSkipVerify: true,
+	})
+	require.NoError(t, err)
+
+	bb, err := repo.ReadFile("math.river")
+	require.NoError(t, err)
+	require.Equal(t, "v1", string(bb))
+}
+
+func registryHost(srv *httptest.Server) string {
+	return strings.TrimPrefix(strings.TrimPrefix(srv.URL, "http://"), "https://")
+}
+
+// pushFiles pushes an artifact holding one layer per file, as pushed by
+// ORAS, and returns its digest.
+func pushFiles(t *testing.T, ref string, files map[string]string) string {
+	t.Helper()
+
+	var adds []mutate.Addendum
+	for name, content := range files {
+		adds = append(adds, mutate.Addendum{
+			Layer:       static.NewLayer([]byte(content), "application/vnd.grafana.river"),
+			Annotations: map[string]string{oci.AnnotationTitle: name},
+		})
+	}
+	return push(t, ref, adds...)
+}
+
+func push(t *testing.T, ref string, adds ...mutate.Addendum) string {
+	t.Helper()
+
+	img, err := mutate.Append(mutate.MediaType(empty.Image, types.OCIManifestSchema1), adds...)
+	require.NoError(t, err)
+	tag, err := name.ParseReference(ref)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(tag, img))
+
+	digest, err := img.Digest()
+	require.NoError(t, err)
+	return digest.String()
+}
@@ -78,6 +78,12 @@ type Service struct {
 
 	memLis *memconn.Listener
 
+	// peerMut guards the client used to send requests to the HTTP service of
+	// other peers, which follows the TLS settings of the service.
+	peerMut    sync.RWMutex
+	peerClient *http.Client
+	peerScheme string
+
 	componentHttpPathPrefix string
 }
 
@@ -119,6 +125,9 @@ func New(opts Options) *Service {
 		tcpLis:    tcpLis,
 		memLis:    memconn.NewListener(l),
 
+		peerClient: &http.Client{},
+		peerScheme: "http",
+
 		componentHttpPathPrefix: "/api/v0/component/",
 	}
 }
@@ -323,6 +332,9 @@ func (s *Service) Update(newConfig any) error {
 		if err := s.publicLis.SetInner(newTLSListener); err != nil {
 			return err
 		}
+		s.setPeerClient(&http.Client{
+			Transport: &http.Transport{TLSClientConfig: peerTLSConfig(tlsConfig)},
+		}, "https")
 	} else {
 		// Ensure that the outer lazy listener is sending requests directly to the
 		// network, instead of any previous instance of a TLS listener.
@@ -330,11 +342,42 @@ func (s *Service) Update(newConfig any) error {
 		if err := s.publicLis.SetInner(s.tcpLis); err != nil {
 			return err
 		}
+		s.setPeerClient(&http.Client{}, "http")
 	}
 
 	return nil
 }
 
+func (s *Service) setPeerClient(client *http.Client, scheme string) {
+	s.peerMut.Lock()
+	defer s.peerMut.Unlock()
+	s.peerClient, s.peerScheme = client, scheme
+}
+
+// peerTLSConfig returns the client TLS settings used to connect to other
+// peers sharing the server TLS settings serverConfig. Peers are trusted if
+// their certificate is signed by the client CA, and the certificate of the
+// server is presented to peers which require client certificates.
+func peerTLSConfig(serverConfig *tls.Config) *tls.Config {
+	return &tls.Config{
+		MinVersion:       serverConfig.MinVersion,
+		MaxVersion:       serverConfig.MaxVersion,
+		CipherSuites:     serverConfig.CipherSuites,
+		CurvePreferences: serverConfig.CurvePreferences,
+		RootCAs:          serverConfig.ClientCAs,
+
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := serverConfig.GetCertificate(&tls.ClientHelloInfo{})
+			if err != nil || cert == nil {
+				// Send no certificate; the peer rejects the connection if it
+				// requires one.
+				return &tls.Certificate{}, nil
+			}
+			return cert, nil
+		},
+	}
+}
+
 // Data returns an instance of [Data]. Calls to Data are cachable by the
 // caller.
 //
@@ -353,6 +396,12 @@ func (s *Service) Data() any {
 				return (&net.Dialer{}).DialContext(ctx, network, address)
 			}
 		},
+
+		PeerClient: func() (*http.Client, string) {
+			s.peerMut.RLock()
+			defer s.peerMut.RUnlock()
+			return s.peerClient, s.peerScheme
+		},
 	}
 }
 
@@ -372,6 +421,11 @@ type Data struct {
 	// address is MemoryListenAddr. If address is not MemoryListenAddr, DialFunc
 	// establishes an outbound network connection.
 	DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+	// PeerClient returns the client and URL scheme to use for sending requests
+	// to the HTTP service of other peers of the cluster. They follow the TLS
+	// settings of the HTTP service, which all peers are expected to share.
+	PeerClient func() (client *http.Client, scheme string)
 }
 
 // HTTPPathForComponent returns the full HTTP path for a given global component
@@ -21,10 +21,36 @@ type LabelStore interface {
 
 	// CheckAndRemoveStaleMarkers identifies any series with a stale marker and removes those entries from the LabelStore.
 	CheckAndRemoveStaleMarkers()
+
+	// ComponentStats returns the accounting of the series tracked for a component.
+	ComponentStats(componentID string) ComponentStats
+
+	// OnSeriesRemoved registers f to be called with the global ids of the series removed from the LabelStore after
+	// going stale. f must not register or unregister handlers. The returned function unregisters f.
+	OnSeriesRemoved(f func(globalRefIDs []uint64)) (unregister func())
 }
 
 type StalenessTracker struct {
 	GlobalRefID uint64
 	Value       float64
 	Labels      labels.Labels
+	// ComponentID is the component which sent the series. Series without a
+	// ComponentID aren't accounted to any component.
+	ComponentID string
+}
+
+// ComponentStats reports the series a component sent through the LabelStore.
+type ComponentStats struct {
+	// Series is the number of series the component sent which weren't removed
+	// since.
+	Series int `river:"series,attr"`
+	// EstimatedBytes is the estimated memory used by the labels of Series.
+	EstimatedBytes int `river:"estimated_bytes,attr"`
+	// SeriesCreated is the number of series the component sent for the first time.
+	SeriesCreated uint64 `river:"series_created_total,attr"`
+	// SeriesRemoved is the number of series of the component which went stale and were removed.
+	SeriesRemoved uint64 `river:"series_removed_total,attr"`
+	// ChurnPerMinute is the number of series created and removed per minute,
+	// averaged over the last minute or more.
+	ChurnPerMinute float64 `river:"churn_per_minute,attr"`
 }
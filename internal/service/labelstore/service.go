@@ -1,7 +1,9 @@
 package labelstore
 
 import (
+	"container/list"
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
@@ -18,15 +20,39 @@ import (
 const ServiceName = "labelstore"
 
 type service struct {
-	log                 log.Logger
-	mut                 sync.Mutex
-	globalRefID         uint64
-	mappings            map[string]*remoteWriteMapping
-	labelsHashToGlobal  map[uint64]uint64
-	staleGlobals        map[uint64]*staleMarker
-	totalIDs            *prometheus.Desc
-	idsInRemoteWrapping *prometheus.Desc
-	lastStaleCheck      prometheus.Gauge
+	log                log.Logger
+	mut                sync.Mutex
+	globalRefID        uint64
+	mappings           map[string]*remoteWriteMapping
+	labelsHashToGlobal map[uint64]uint64
+	// staleGlobals holds the elements of staleOrder by global id. staleOrder
+	// holds *staleMarker values, from the least to the most recently marked
+	// stale.
+	staleGlobals    map[uint64]*list.Element
+	staleOrder      *list.List
+	maxStaleMarkers int
+
+	// accountingMut guards the accounting of series per component, so that
+	// it's done without holding mut on every append. When both are held, mut
+	// must be locked first.
+	accountingMut sync.Mutex
+	series        map[uint64]*globalSeries
+	components    map[string]*componentSeries
+
+	// removalHandlers are called with the ids removed from the labelstore.
+	handlersMut     sync.RWMutex
+	nextHandlerID   int
+	removalHandlers map[int]func([]uint64)
+
+	totalIDs               *prometheus.Desc
+	idsInRemoteWrapping    *prometheus.Desc
+	staleMarkers           *prometheus.Desc
+	componentSeriesCount   *prometheus.Desc
+	componentSeriesBytes   *prometheus.Desc
+	componentSeriesCreated *prometheus.Desc
+	componentSeriesRemoved *prometheus.Desc
+	lastStaleCheck         prometheus.Gauge
+	staleMarkersEvicted    prometheus.Counter
 }
 type staleMarker struct {
 	globalID        uint64
@@ -34,7 +60,62 @@ type staleMarker struct {
 	labelHash       uint64
 }
 
-type Arguments struct{}
+// globalSeries is the accounting of a series known to the labelstore, by
+// global id.
+type globalSeries struct {
+	// owners are the components which sent the series, and bytes the
+	// estimated size of the series accounted to each of them.
+	owners []*componentSeries
+	bytes  int
+}
+
+// componentSeries tracks the series a component sent through the labelstore.
+// It's removed once none of its series are left.
+type componentSeries struct {
+	id      string
+	series  int
+	bytes   int
+	created uint64
+	removed uint64
+
+	// The churn rate is computed over windows of at least churnWindow.
+	windowStart time.Time
+	windowChurn uint64
+	churnRate   float64
+}
+
+// churnWindow is the minimum duration over which the churn rate of a component is computed.
+const churnWindow = time.Minute
+
+// seriesOverheadBytes is the estimated memory used to track a series, in
+// addition to its label names and values.
+const seriesOverheadBytes = 64
+
+// Arguments holds runtime settings for the labelstore service.
+type Arguments struct {
+	// MaxStaleMarkers bounds the number of series tracked as stale. When the
+	// limit is reached, the series marked stale the longest ago are removed
+	// right away instead of after staleDuration.
+	MaxStaleMarkers int `river:"max_stale_markers,attr,optional"`
+}
+
+// DefaultArguments holds the default settings for the labelstore service.
+var DefaultArguments = Arguments{
+	MaxStaleMarkers: 1_000_000,
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	if a.MaxStaleMarkers <= 0 {
+		return fmt.Errorf("max_stale_markers must be greater than 0")
+	}
+	return nil
+}
 
 var _ flow_service.Service = (*service)(nil)
 
@@ -43,19 +124,34 @@ func New(l log.Logger, r prometheus.Registerer) *service {
 		l = log.NewNopLogger()
 	}
 	s := &service{
-		log:                 l,
-		globalRefID:         0,
-		mappings:            make(map[string]*remoteWriteMapping),
-		labelsHashToGlobal:  make(map[uint64]uint64),
-		staleGlobals:        make(map[uint64]*staleMarker),
-		totalIDs:            prometheus.NewDesc("agent_labelstore_global_ids_count", "Total number of global ids.", nil, nil),
-		idsInRemoteWrapping: prometheus.NewDesc("agent_labelstore_remote_store_ids_count", "Total number of ids per remote write", []string{"remote_name"}, nil),
+		log:                    l,
+		globalRefID:            0,
+		mappings:               make(map[string]*remoteWriteMapping),
+		labelsHashToGlobal:     make(map[uint64]uint64),
+		staleGlobals:           make(map[uint64]*list.Element),
+		staleOrder:             list.New(),
+		maxStaleMarkers:        DefaultArguments.MaxStaleMarkers,
+		series:                 make(map[uint64]*globalSeries),
+		components:             make(map[string]*componentSeries),
+		removalHandlers:        make(map[int]func([]uint64)),
+		totalIDs:               prometheus.NewDesc("agent_labelstore_global_ids_count", "Total number of global ids.", nil, nil),
+		idsInRemoteWrapping:    prometheus.NewDesc("agent_labelstore_remote_store_ids_count", "Total number of ids per remote write", []string{"remote_name"}, nil),
+		staleMarkers:           prometheus.NewDesc("agent_labelstore_stale_markers_count", "Number of series tracked as stale.", nil, nil),
+		componentSeriesCount:   prometheus.NewDesc("agent_labelstore_component_series_count", "Number of series tracked per component.", []string{"component_id"}, nil),
+		componentSeriesBytes:   prometheus.NewDesc("agent_labelstore_component_series_estimated_bytes", "Estimated memory used by the labels of the series tracked per component.", []string{"component_id"}, nil),
+		componentSeriesCreated: prometheus.NewDesc("agent_labelstore_component_series_created_total", "Total number of series sent for the first time per component.", []string{"component_id"}, nil),
+		componentSeriesRemoved: prometheus.NewDesc("agent_labelstore_component_series_removed_total", "Total number of stale series removed per component.", []string{"component_id"}, nil),
 		lastStaleCheck: prometheus.NewGauge(prometheus.GaugeOpts{
 			Name: "agent_labelstore_last_stale_check_timestamp",
 			Help: "Last time stale check was ran expressed in unix timestamp.",
 		}),
+		staleMarkersEvicted: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "agent_labelstore_stale_markers_evicted_total",
+			Help: "Total number of stale series removed early because max_stale_markers was reached.",
+		}),
 	}
 	_ = r.Register(s.lastStaleCheck)
+	_ = r.Register(s.staleMarkersEvicted)
 	_ = r.Register(s)
 	return s
 }
@@ -75,6 +171,11 @@ func (s *service) Definition() agent_service.Definition {
 func (s *service) Describe(m chan<- *prometheus.Desc) {
 	m <- s.totalIDs
 	m <- s.idsInRemoteWrapping
+	m <- s.staleMarkers
+	m <- s.componentSeriesCount
+	m <- s.componentSeriesBytes
+	m <- s.componentSeriesCreated
+	m <- s.componentSeriesRemoved
 }
 
 func (s *service) Collect(m chan<- prometheus.Metric) {
@@ -85,6 +186,16 @@ func (s *service) Collect(m chan<- prometheus.Metric) {
 	for name, rw := range s.mappings {
 		m <- prometheus.MustNewConstMetric(s.idsInRemoteWrapping, prometheus.GaugeValue, float64(len(rw.globalToLocal)), name)
 	}
+	m <- prometheus.MustNewConstMetric(s.staleMarkers, prometheus.GaugeValue, float64(len(s.staleGlobals)))
+
+	s.accountingMut.Lock()
+	defer s.accountingMut.Unlock()
+	for id, cs := range s.components {
+		m <- prometheus.MustNewConstMetric(s.componentSeriesCount, prometheus.GaugeValue, float64(cs.series), id)
+		m <- prometheus.MustNewConstMetric(s.componentSeriesBytes, prometheus.GaugeValue, float64(cs.bytes), id)
+		m <- prometheus.MustNewConstMetric(s.componentSeriesCreated, prometheus.CounterValue, float64(cs.created), id)
+		m <- prometheus.MustNewConstMetric(s.componentSeriesRemoved, prometheus.CounterValue, float64(cs.removed), id)
+	}
 }
 
 // Run starts a Service. Run must block until the provided
@@ -110,7 +221,15 @@ func (s *service) Run(ctx context.Context, host agent_service.Host) error {
 //
 // Update will be called once before Run, and may be called
 // while Run is active.
-func (s *service) Update(_ any) error {
+func (s *service) Update(newConfig any) error {
+	newArgs := newConfig.(Arguments)
+
+	s.mut.Lock()
+	s.maxStaleMarkers = newArgs.MaxStaleMarkers
+	removed := s.evictStaleMarkers()
+	s.mut.Unlock()
+
+	s.notifyRemoved(removed)
 	return nil
 }
 
@@ -147,8 +266,7 @@ func (s *service) GetOrAddLink(componentID string, localRefID uint64, lbls label
 		return globalID
 	}
 	// We have a value we have never seen before so increment the globalrefid and assign
-	s.globalRefID++
-	s.labelsHashToGlobal[labelHash] = s.globalRefID
+	s.addGlobalRefID(labelHash)
 	m.localToGlobal[localRefID] = s.globalRefID
 	m.globalToLocal[s.globalRefID] = localRefID
 	return s.globalRefID
@@ -169,8 +287,18 @@ func (s *service) GetOrAddGlobalRefID(l labels.Labels) uint64 {
 	if found {
 		return globalID
 	}
+	return s.addGlobalRefID(labelHash)
+}
+
+// addGlobalRefID assigns a new global id to the series with the given label
+// hash. s.mut must be held.
+func (s *service) addGlobalRefID(labelHash uint64) uint64 {
 	s.globalRefID++
 	s.labelsHashToGlobal[labelHash] = s.globalRefID
+
+	s.accountingMut.Lock()
+	s.series[s.globalRefID] = &globalSeries{}
+	s.accountingMut.Unlock()
 	return s.globalRefID
 }
 
@@ -219,22 +347,149 @@ func (s *service) TrackStaleness(ids []StalenessTracker) {
 		}
 	}
 
-	s.mut.Lock()
-	defer s.mut.Unlock()
+	s.trackComponentSeries(ids, now)
 
+	s.mut.Lock()
 	for _, marker := range toAdd {
-		s.staleGlobals[marker.globalID] = marker
+		if e, found := s.staleGlobals[marker.globalID]; found {
+			e.Value = marker
+			s.staleOrder.MoveToBack(e)
+			continue
+		}
+		s.staleGlobals[marker.globalID] = s.staleOrder.PushBack(marker)
 	}
 	for _, id := range toRemove {
-		delete(s.staleGlobals, id)
+		if e, found := s.staleGlobals[id]; found {
+			s.staleOrder.Remove(e)
+			delete(s.staleGlobals, id)
+		}
+	}
+	removed := s.evictStaleMarkers()
+	s.mut.Unlock()
+
+	s.notifyRemoved(removed)
+}
+
+// trackComponentSeries accounts the series of ids to the components which
+// sent them. Series are looked up by global id, so that labels are only
+// hashed once per append.
+func (s *service) trackComponentSeries(ids []StalenessTracker, now time.Time) {
+	var (
+		lastID string
+		cs     *componentSeries
+	)
+
+	s.accountingMut.Lock()
+	defer s.accountingMut.Unlock()
+
+	for _, id := range ids {
+		if id.ComponentID == "" {
+			continue
+		}
+		// Series which were removed since the global id was handed out
+		// aren't accounted anymore.
+		gs, found := s.series[id.GlobalRefID]
+		if !found {
+			continue
+		}
+		// Batches almost always come from a single component.
+		if cs == nil || id.ComponentID != lastID {
+			cs = s.getOrAddComponent(id.ComponentID, now)
+			lastID = id.ComponentID
+		}
+		if gs.ownedBy(cs) {
+			continue
+		}
+		if gs.owners == nil {
+			gs.bytes = estimateSeriesBytes(id.Labels)
+		}
+		gs.owners = append(gs.owners, cs)
+		cs.series++
+		cs.bytes += gs.bytes
+		cs.created++
+	}
+	if cs != nil {
+		cs.updateChurnRate(now)
 	}
 }
 
+// ownedBy returns whether the series is accounted to cs. Series are only
+// sent by a handful of components, so a linear search is enough.
+func (gs *globalSeries) ownedBy(cs *componentSeries) bool {
+	for _, owner := range gs.owners {
+		if owner == cs {
+			return true
+		}
+	}
+	return false
+}
+
+// getOrAddComponent returns the accounting of a component. s.accountingMut
+// must be held.
+func (s *service) getOrAddComponent(componentID string, now time.Time) *componentSeries {
+	cs, found := s.components[componentID]
+	if !found {
+		cs = &componentSeries{
+			id:          componentID,
+			windowStart: now,
+		}
+		s.components[componentID] = cs
+	}
+	return cs
+}
+
+// ComponentStats returns the accounting of the series tracked for a component.
+func (s *service) ComponentStats(componentID string) ComponentStats {
+	s.accountingMut.Lock()
+	defer s.accountingMut.Unlock()
+
+	cs, found := s.components[componentID]
+	if !found {
+		return ComponentStats{}
+	}
+	cs.updateChurnRate(time.Now())
+	return ComponentStats{
+		Series:         cs.series,
+		EstimatedBytes: cs.bytes,
+		SeriesCreated:  cs.created,
+		SeriesRemoved:  cs.removed,
+		ChurnPerMinute: cs.churnRate,
+	}
+}
+
+// updateChurnRate computes the churn rate of the component once the current
+// window is at least churnWindow long, then starts a new window.
+func (cs *componentSeries) updateChurnRate(now time.Time) {
+	elapsed := now.Sub(cs.windowStart)
+	if elapsed < churnWindow {
+		return
+	}
+	churn := cs.created + cs.removed
+	cs.churnRate = float64(churn-cs.windowChurn) / elapsed.Minutes()
+	cs.windowStart, cs.windowChurn = now, churn
+}
+
+// estimateSeriesBytes estimates the memory used by the labels of a series.
+func estimateSeriesBytes(lbls labels.Labels) int {
+	size := seriesOverheadBytes
+	lbls.Range(func(l labels.Label) {
+		size += len(l.Name) + len(l.Value)
+	})
+	return size
+}
+
 // staleDuration determines how long we should wait after a stale value is received to GC that value
 var staleDuration = time.Minute * 10
 
 // CheckAndRemoveStaleMarkers is called to garbage collect and items that have grown stale over stale duration (10m)
 func (s *service) CheckAndRemoveStaleMarkers() {
+	removed := s.removeExpiredStaleMarkers()
+	s.notifyRemoved(removed)
+}
+
+// removeExpiredStaleMarkers removes the series marked stale more than
+// staleDuration ago, returning their global ids.
+func (s *service) removeExpiredStaleMarkers() []uint64 {
 	s.mut.Lock()
 	defer s.mut.Unlock()
 
@@ -242,24 +497,106 @@ func (s *service) CheckAndRemoveStaleMarkers() {
 	level.Debug(s.log).Log("msg", "labelstore removing stale markers")
 	curr := time.Now()
 	idsToBeGCed := make([]*staleMarker, 0)
-	for _, stale := range s.staleGlobals {
-		// If the difference between now and the last time the stale was marked doesn't exceed stale then let it stay
+	// Markers are ordered by the last time they were marked stale, so we can
+	// stop at the first one which isn't old enough.
+	for e := s.staleOrder.Front(); e != nil; e = e.Next() {
+		stale := e.Value.(*staleMarker)
 		if curr.Sub(stale.lastMarkedStale) < staleDuration {
-			continue
+			break
 		}
 		idsToBeGCed = append(idsToBeGCed, stale)
 	}
 
 	level.Debug(s.log).Log("msg", "number of ids to remove", "count", len(idsToBeGCed))
 
+	removed := make([]uint64, 0, len(idsToBeGCed))
 	for _, marker := range idsToBeGCed {
+		s.removeStaleSeries(marker)
+		removed = append(removed, marker.globalID)
+	}
+	return removed
+}
+
+// evictStaleMarkers removes the series marked stale the longest ago until at
+// most maxStaleMarkers series are tracked as stale, returning their global
+// ids. s.mut must be held.
+func (s *service) evictStaleMarkers() []uint64 {
+	var removed []uint64
+	for len(s.staleGlobals) > s.maxStaleMarkers {
+		marker := s.staleOrder.Front().Value.(*staleMarker)
+		s.removeStaleSeries(marker)
+		s.staleMarkersEvicted.Inc()
+		removed = append(removed, marker.globalID)
+	}
+	return removed
+}
+
+// OnSeriesRemoved registers f to be called with the global ids of the series
+// removed from the labelstore.
+func (s *service) OnSeriesRemoved(f func(globalRefIDs []uint64)) func() {
+	s.handlersMut.Lock()
+	defer s.handlersMut.Unlock()
+
+	id := s.nextHandlerID
+	s.nextHandlerID++
+	s.removalHandlers[id] = f
+
+	return func() {
+		s.handlersMut.Lock()
+		defer s.handlersMut.Unlock()
+		delete(s.removalHandlers, id)
+	}
+}
+
+// notifyRemoved calls the removal handlers with ids. s.mut must not be held,
+// so that handlers don't block the labelstore.
+func (s *service) notifyRemoved(ids []uint64) {
+	if len(ids) == 0 {
+		return
+	}
+
+	s.handlersMut.RLock()
+	defer s.handlersMut.RUnlock()
+	for _, f := range s.removalHandlers {
+		f(ids)
+	}
+}
+
+// removeStaleSeries removes all traces of a stale series. s.mut must be held.
+func (s *service) removeStaleSeries(marker *staleMarker) {
+	if e, found := s.staleGlobals[marker.globalID]; found {
+		s.staleOrder.Remove(e)
 		delete(s.staleGlobals, marker.globalID)
-		delete(s.labelsHashToGlobal, marker.labelHash)
-		// Delete our mapping keys
-		for _, mapping := range s.mappings {
-			mapping.deleteStaleIDs(marker.globalID)
+	}
+	s.untrackComponentSeries(marker.globalID)
+	delete(s.labelsHashToGlobal, marker.labelHash)
+	// Delete our mapping keys
+	for _, mapping := range s.mappings {
+		mapping.deleteStaleIDs(marker.globalID)
+	}
+}
+
+// untrackComponentSeries removes a series from the accounting of the
+// components which sent it.
+func (s *service) untrackComponentSeries(globalID uint64) {
+	s.accountingMut.Lock()
+	defer s.accountingMut.Unlock()
+
+	gs, found := s.series[globalID]
+	if !found {
+		return
+	}
+	for _, cs := range gs.owners {
+		cs.series--
+		cs.bytes -= gs.bytes
+		cs.removed++
+		// Components which were removed, or whose series all went stale,
+		// stop being tracked.
+		if cs.series == 0 {
+			delete(s.components, cs.id)
 		}
 	}
+	delete(s.series, globalID)
 }
 
 func (rw *remoteWriteMapping) deleteStaleIDs(globalID uint64) {
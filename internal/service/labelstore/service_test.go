@@ -202,3 +202,72 @@ func BenchmarkStaleness(b *testing.B) {
 	}
 	wg.Wait()
 }
+
+func TestComponentStats(t *testing.T) {
+	mapping := New(log.NewNopLogger(), prometheus.NewRegistry())
+	l := labels.FromStrings("__name__", "test")
+	l2 := labels.FromStrings("__name__", "test2")
+
+	global1 := mapping.GetOrAddGlobalRefID(l)
+	global2 := mapping.GetOrAddGlobalRefID(l2)
+	mapping.TrackStaleness([]StalenessTracker{
+		{GlobalRefID: global1, Value: 1, Labels: l, ComponentID: "a"},
+		{GlobalRefID: global2, Value: 1, Labels: l2, ComponentID: "a"},
+		{GlobalRefID: global1, Value: 1, Labels: l, ComponentID: "b"},
+	})
+	// Series sent again aren't counted twice.
+	mapping.TrackStaleness([]StalenessTracker{
+		{GlobalRefID: global1, Value: 2, Labels: l, ComponentID: "a"},
+	})
+
+	stats := mapping.ComponentStats("a")
+	require.Equal(t, 2, stats.Series)
+	require.Equal(t, uint64(2), stats.SeriesCreated)
+	require.Equal(t, uint64(0), stats.SeriesRemoved)
+	require.Equal(t, estimateSeriesBytes(l)+estimateSeriesBytes(l2), stats.EstimatedBytes)
+	require.Equal(t, 1, mapping.ComponentStats("b").Series)
+	require.Equal(t, ComponentStats{}, mapping.ComponentStats("missing"))
+
+	// Removing a stale series removes it from every component.
+	mapping.TrackStaleness([]StalenessTracker{
+		{GlobalRefID: global1, Value: math.Float64frombits(value.StaleNaN), Labels: l, ComponentID: "a"},
+	})
+	staleDuration = 1 * time.Millisecond
+	time.Sleep(10 * time.Millisecond)
+	mapping.CheckAndRemoveStaleMarkers()
+
+	stats = mapping.ComponentStats("a")
+	require.Equal(t, 1, stats.Series)
+	require.Equal(t, uint64(1), stats.SeriesRemoved)
+	require.Equal(t, estimateSeriesBytes(l2), stats.EstimatedBytes)
+	require.Equal(t, 0, mapping.ComponentStats("b").Series)
+	// Components without series left aren't tracked anymore.
+	require.NotContains(t, mapping.components, "b")
+	require.Contains(t, mapping.components, "a")
+}
+
+func TestMaxStaleMarkers(t *testing.T) {
+	mapping := New(log.NewNopLogger(), prometheus.NewRegistry())
+	require.NoError(t, mapping.Update(Arguments{MaxStaleMarkers: 2}))
+
+	var ids []uint64
+	for i := 0; i < 3; i++ {
+		l := labels.FromStrings("id", strconv.Itoa(i))
+		id := mapping.GetOrAddGlobalRefID(l)
+		ids = append(ids, id)
+		mapping.TrackStaleness([]StalenessTracker{
+			{GlobalRefID: id, Value: math.Float64frombits(value.StaleNaN), Labels: l},
+		})
+	}
+
+	// The series marked stale first was evicted and removed right away.
+	require.Len(t, mapping.staleGlobals, 2)
+	require.Len(t, mapping.labelsHashToGlobal, 2)
+	require.NotContains(t, mapping.staleGlobals, ids[0])
+
+	// Lowering the limit evicts markers right away.
+	require.NoError(t, mapping.Update(Arguments{MaxStaleMarkers: 1}))
+	require.Len(t, mapping.staleGlobals, 1)
+	require.Contains(t, mapping.staleGlobals, ids[2])
+	require.Len(t, mapping.labelsHashToGlobal, 1)
+}
@@ -0,0 +1,376 @@
+// Package livedebugging implements the live debugging service, which streams
+// samples of the data received and emitted by components.
+//
+// Components publish their data through the [Publisher] returned by
+// [PublisherFrom]. Publishing is cheap when nobody is watching a component:
+// data is only rendered for the components which have subscribers.
+package livedebugging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/agent/internal/service"
+	"go.uber.org/atomic"
+	"golang.org/x/time/rate"
+)
+
+// ServiceName defines the name used for the live debugging service.
+const ServiceName = "livedebugging"
+
+// ErrDisabled is returned when subscribing while live debugging is disabled.
+var ErrDisabled = errors.New("live debugging is disabled, enable it with the livedebugging block")
+
+// DataType is the type of data published by a component.
+type DataType string
+
+// Supported data types.
+const (
+	DataTypeLog     DataType = "log"
+	DataTypeMetric  DataType = "metric"
+	DataTypeSpan    DataType = "span"
+	DataTypeProfile DataType = "profile"
+)
+
+// Direction is whether data was received or emitted by a component.
+type Direction string
+
+// Supported directions.
+const (
+	DirectionReceived Direction = "received"
+	DirectionEmitted  Direction = "emitted"
+)
+
+// Data is a piece of data received or emitted by a component.
+type Data struct {
+	ComponentID string
+	Type        DataType
+	Direction   Direction
+
+	// Render returns the text representation of the data. It's only called
+	// if the data is streamed to a subscriber, so that components don't pay
+	// for rendering data nobody watches.
+	Render func() string
+}
+
+// Event is a piece of data streamed to a subscriber.
+type Event struct {
+	Time      time.Time `json:"time"`
+	Type      DataType  `json:"type"`
+	Direction Direction `json:"direction"`
+	Data      string    `json:"data"`
+}
+
+// Publisher is used by components to publish the data they receive and emit.
+type Publisher interface {
+	// IsActive returns whether the data of the component with the given
+	// global ID is streamed to subscribers. Components can use it to avoid
+	// preparing data for Publish.
+	IsActive(componentID string) bool
+
+	// Publish streams d to the subscribers of its component. Publish never
+	// blocks.
+	Publish(d Data)
+}
+
+// LiveDebugging is the data exposed by the live debugging service.
+type LiveDebugging interface {
+	Publisher
+
+	// Subscribe streams the data published by the component with the given
+	// global ID until the subscription is closed. It returns ErrDisabled if
+	// live debugging is disabled.
+	Subscribe(componentID string, opts SubscribeOptions) (*Subscription, error)
+}
+
+// SubscribeOptions configures which data is streamed to a subscriber.
+type SubscribeOptions struct {
+	// SampleRatio is the ratio of data which is streamed, between 0 and 1.
+	// Defaults to 1.
+	SampleRatio float64
+
+	// Rate is the maximum number of events streamed per second. Defaults to,
+	// and is capped at, the max_rate of the service.
+	Rate float64
+
+	// Filter only streams the data whose text representation matches Filter,
+	// if set.
+	Filter *regexp.Regexp
+}
+
+// subscriptionBufferSize is the number of events buffered for a subscriber
+// before new events are dropped.
+const subscriptionBufferSize = 100
+
+// Subscription is a stream of the data published by a component.
+type Subscription struct {
+	events      chan Event
+	sampleRatio float64
+	limiter     *rate.Limiter
+	filter      *regexp.Regexp
+
+	mut     sync.Mutex
+	dropped uint64
+
+	closeOnce   sync.Once
+	unsubscribe func()
+}
+
+// Events returns the channel events are streamed to.
+func (s *Subscription) Events() <-chan Event { return s.events }
+
+// Dropped returns the number of events dropped because they exceeded the rate
+// of the subscription, or because the subscriber didn't keep up.
+func (s *Subscription) Dropped() uint64 {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.dropped
+}
+
+// Close stops streaming events to s.
+func (s *Subscription) Close() {
+	s.closeOnce.Do(s.unsubscribe)
+}
+
+func (s *Subscription) drop() {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.dropped++
+}
+
+// Arguments holds runtime settings for the live debugging service.
+type Arguments struct {
+	Enabled bool    `river:"enabled,attr,optional"`
+	MaxRate float64 `river:"max_rate,attr,optional"`
+}
+
+// DefaultArguments holds the default settings for the live debugging service.
+var DefaultArguments = Arguments{
+	Enabled: false,
+	MaxRate: 100,
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	if a.MaxRate <= 0 {
+		return fmt.Errorf("max_rate must be greater than 0")
+	}
+	return nil
+}
+
+// Service implements the live debugging service.
+type Service struct {
+	mut  sync.RWMutex
+	args Arguments
+	subs map[string]map[*Subscription]struct{}
+
+	// active holds the IDs of the components which have subscribers. It's
+	// replaced whenever subs changes, so that IsActive, which components call
+	// on every piece of data, doesn't take mut.
+	active atomic.Pointer[map[string]struct{}]
+
+	// random returns the numbers in [0, 1) used to sample data. It's safe for
+	// concurrent use, and replaced in tests to make sampling deterministic.
+	random func() float64
+}
+
+var (
+	_ service.Service = (*Service)(nil)
+	_ LiveDebugging   = (*Service)(nil)
+)
+
+// New returns a new, unstarted live debugging service.
+func New() *Service {
+	s := &Service{
+		args: DefaultArguments,
+		subs: make(map[string]map[*Subscription]struct{}),
+
+		random: rand.Float64,
+	}
+	s.updateActiveLocked()
+	return s
+}
+
+// Definition returns the definition of the live debugging service.
+func (s *Service) Definition() service.Definition {
+	return service.Definition{
+		Name:       ServiceName,
+		ConfigType: Arguments{},
+		DependsOn:  nil,
+		Stability:  featuregate.StabilityExperimental,
+	}
+}
+
+// Run implements [service.Service].
+func (s *Service) Run(ctx context.Context, _ service.Host) error {
+	<-ctx.Done()
+	return nil
+}
+
+// Update implements [service.Service]. Disabling live debugging closes the
+// streams of all subscribers.
+func (s *Service) Update(newConfig any) error {
+	newArgs := newConfig.(Arguments)
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.args = newArgs
+	if !newArgs.Enabled {
+		for id, subs := range s.subs {
+			for sub := range subs {
+				close(sub.events)
+			}
+			delete(s.subs, id)
+		}
+		s.updateActiveLocked()
+	}
+	return nil
+}
+
+// Data implements [service.Service]. It returns a [LiveDebugging].
+func (s *Service) Data() any {
+	return s
+}
+
+// Subscribe implements [LiveDebugging].
+func (s *Service) Subscribe(componentID string, opts SubscribeOptions) (*Subscription, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if !s.args.Enabled {
+		return nil, ErrDisabled
+	}
+
+	if opts.SampleRatio <= 0 || opts.SampleRatio > 1 {
+		opts.SampleRatio = 1
+	}
+	if opts.Rate <= 0 || opts.Rate > s.args.MaxRate {
+		opts.Rate = s.args.MaxRate
+	}
+
+	sub := &Subscription{
+		events:      make(chan Event, subscriptionBufferSize),
+		sampleRatio: opts.SampleRatio,
+		limiter:     rate.NewLimiter(rate.Limit(opts.Rate), max(1, int(opts.Rate))),
+		filter:      opts.Filter,
+	}
+	sub.unsubscribe = func() { s.unsubscribe(componentID, sub) }
+
+	if s.subs[componentID] == nil {
+		s.subs[componentID] = make(map[*Subscription]struct{})
+	}
+	s.subs[componentID][sub] = struct{}{}
+	s.updateActiveLocked()
+	return sub, nil
+}
+
+func (s *Service) unsubscribe(componentID string, sub *Subscription) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	// The subscription may already have been removed if live debugging was
+	// disabled.
+	if _, ok := s.subs[componentID][sub]; !ok {
+		return
+	}
+	delete(s.subs[componentID], sub)
+	if len(s.subs[componentID]) == 0 {
+		delete(s.subs, componentID)
+		s.updateActiveLocked()
+	}
+	close(sub.events)
+}
+
+// updateActiveLocked replaces the set of active components from subs. s.mut
+// must be held.
+func (s *Service) updateActiveLocked() {
+	active := make(map[string]struct{}, len(s.subs))
+	for id := range s.subs {
+		active[id] = struct{}{}
+	}
+	s.active.Store(&active)
+}
+
+// IsActive implements [Publisher].
+func (s *Service) IsActive(componentID string) bool {
+	_, ok := (*s.active.Load())[componentID]
+	return ok
+}
+
+// Publish implements [Publisher].
+func (s *Service) Publish(d Data) {
+	if !s.IsActive(d.ComponentID) {
+		return
+	}
+
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	subs := s.subs[d.ComponentID]
+	if len(subs) == 0 {
+		return
+	}
+
+	// The data is rendered at most once, and only if a subscriber samples it.
+	var (
+		rendered bool
+		text     string
+		now      = time.Now()
+	)
+	for sub := range subs {
+		if sub.sampleRatio < 1 && s.random() >= sub.sampleRatio {
+			continue
+		}
+		if !rendered {
+			text, rendered = d.Render(), true
+		}
+		if sub.filter != nil && !sub.filter.MatchString(text) {
+			continue
+		}
+		if !sub.limiter.AllowN(now, 1) {
+			sub.drop()
+			continue
+		}
+
+		select {
+		case sub.events <- Event{Time: now, Type: d.Type, Direction: d.Direction, Data: text}:
+		default:
+			sub.drop()
+		}
+	}
+}
+
+// PublisherFrom returns the [Publisher] of the live debugging service from
+// the getServiceData function of a component's options. It returns a
+// Publisher which discards all data if the service isn't available, for
+// example when the component runs in a test.
+func PublisherFrom(getServiceData func(name string) (interface{}, error)) Publisher {
+	if getServiceData == nil {
+		return noopPublisher{}
+	}
+	data, err := getServiceData(ServiceName)
+	if err != nil {
+		return noopPublisher{}
+	}
+	if pub, ok := data.(Publisher); ok {
+		return pub
+	}
+	return noopPublisher{}
+}
+
+type noopPublisher struct{}
+
+func (noopPublisher) IsActive(string) bool { return false }
+func (noopPublisher) Publish(Data)         {}
@@ -0,0 +1,148 @@
+package livedebugging
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newEnabledService(t *testing.T, maxRate float64) *Service {
+	t.Helper()
+
+	s := New()
+	require.NoError(t, s.Update(Arguments{Enabled: true, MaxRate: maxRate}))
+	return s
+}
+
+func publishLine(s *Service, componentID, line string) {
+	s.Publish(Data{
+		ComponentID: componentID,
+		Type:        DataTypeLog,
+		Direction:   DirectionReceived,
+		Render:      func() string { return line },
+	})
+}
+
+func TestSubscribe_Disabled(t *testing.T) {
+	s := New()
+	_, err := s.Subscribe("loki.process.a", SubscribeOptions{})
+	require.ErrorIs(t, err, ErrDisabled)
+}
+
+func TestPublish(t *testing.T) {
+	s := newEnabledService(t, 100)
+	require.False(t, s.IsActive("loki.process.a"))
+
+	// Data is never rendered for components without subscribers.
+	s.Publish(Data{
+		ComponentID: "loki.process.a",
+		Render:      func() string { panic("rendered without subscribers") },
+	})
+
+	sub, err := s.Subscribe("loki.process.a", SubscribeOptions{})
+	require.NoError(t, err)
+	require.True(t, s.IsActive("loki.process.a"))
+	require.False(t, s.IsActive("loki.process.b"))
+
+	publishLine(s, "loki.process.a", "hello")
+	publishLine(s, "loki.process.b", "other component")
+
+	ev := <-sub.Events()
+	require.Equal(t, DataTypeLog, ev.Type)
+	require.Equal(t, DirectionReceived, ev.Direction)
+	require.Equal(t, "hello", ev.Data)
+	require.Empty(t, sub.Events())
+
+	sub.Close()
+	sub.Close()
+	require.False(t, s.IsActive("loki.process.a"))
+	_, ok := <-sub.Events()
+	require.False(t, ok)
+}
+
+func TestPublish_Filter(t *testing.T) {
+	s := newEnabledService(t, 100)
+
+	sub, err := s.Subscribe("loki.process.a", SubscribeOptions{Filter: regexp.MustCompile("level=error")})
+	require.NoError(t, err)
+	defer sub.Close()
+
+	publishLine(s, "loki.process.a", "level=info msg=ok")
+	publishLine(s, "loki.process.a", "level=error msg=failed")
+
+	ev := <-sub.Events()
+	require.Equal(t, "level=error msg=failed", ev.Data)
+	require.Empty(t, sub.Events())
+	require.Zero(t, sub.Dropped())
+}
+
+func TestPublish_SampleRatio(t *testing.T) {
+	s := newEnabledService(t, 1000)
+
+	// Sample with the repeating sequence 0, 0.1, ..., 0.9, so that exactly
+	// one in ten pieces of data is streamed with a ratio of 0.1.
+	var n int
+	s.random = func() float64 {
+		defer func() { n++ }()
+		return float64(n%10) / 10
+	}
+
+	sub, err := s.Subscribe("loki.process.a", SubscribeOptions{SampleRatio: 0.1})
+	require.NoError(t, err)
+	defer sub.Close()
+
+	for i := 0; i < 500; i++ {
+		publishLine(s, "loki.process.a", "line")
+	}
+
+	require.Len(t, sub.Events(), 50)
+	require.Zero(t, sub.Dropped())
+}
+
+func TestPublish_Rate(t *testing.T) {
+	s := newEnabledService(t, 10)
+
+	// The rate of a subscription is capped by max_rate.
+	sub, err := s.Subscribe("loki.process.a", SubscribeOptions{Rate: 1000})
+	require.NoError(t, err)
+	defer sub.Close()
+
+	for i := 0; i < 50; i++ {
+		publishLine(s, "loki.process.a", "line")
+	}
+
+	// The burst of the limiter allows one second worth of events.
+	require.Len(t, sub.Events(), 10)
+	require.Equal(t, uint64(40), sub.Dropped())
+}
+
+func TestUpdate_DisableClosesSubscriptions(t *testing.T) {
+	s := newEnabledService(t, 100)
+
+	sub, err := s.Subscribe("loki.process.a", SubscribeOptions{})
+	require.NoError(t, err)
+
+	require.NoError(t, s.Update(Arguments{Enabled: false, MaxRate: 100}))
+	_, ok := <-sub.Events()
+	require.False(t, ok)
+	require.False(t, s.IsActive("loki.process.a"))
+
+	// Closing a subscription after live debugging was disabled is a no-op.
+	sub.Close()
+}
+
+func TestPublisherFrom(t *testing.T) {
+	s := New()
+	pub := PublisherFrom(func(name string) (interface{}, error) {
+		require.Equal(t, ServiceName, name)
+		return s, nil
+	})
+	require.Equal(t, s, pub)
+
+	pub = PublisherFrom(func(string) (interface{}, error) {
+		return nil, ErrDisabled
+	})
+	require.False(t, pub.IsActive("loki.process.a"))
+	pub.Publish(Data{ComponentID: "loki.process.a"})
+}
@@ -2,6 +2,7 @@ package remotecfg
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"hash/fnv"
 	"math"
@@ -21,6 +22,7 @@ import (
 	"github.com/grafana/agent/internal/flow/logging/level"
 	"github.com/grafana/agent/internal/service"
 	"github.com/grafana/river"
+	"github.com/prometheus/client_golang/prometheus"
 	commonconfig "github.com/prometheus/common/config"
 )
 
@@ -42,12 +44,34 @@ type Service struct {
 
 	ctrl service.Controller
 
+	// loadMut serializes loading configurations into ctrl. It must be
+	// acquired before mut.
+	loadMut sync.Mutex
+
 	mut               sync.RWMutex
 	asClient          agentv1connect.AgentServiceClient
 	ch                <-chan time.Time
 	ticker            *time.Ticker
 	dataPath          string
 	currentConfigHash string
+	status            configStatus
+	metrics           *metrics
+
+	// runCtx is the context passed to Run. It's used to stop health checks
+	// started by loading a new configuration.
+	runCtx context.Context
+
+	// State for rolling back configurations which make components unhealthy.
+	lastKnownGoodHash string
+	rejectedHash      string // Hash of the last configuration rolled back.
+	healthCancel      context.CancelFunc
+	rollbacks         int
+	lastRollback      *Rollback
+
+	// wake notifies the long-polling loop that the Arguments changed, and
+	// longPollCancel cancels the in-flight long-poll request, if any.
+	wake           chan struct{}
+	longPollCancel context.CancelFunc
 }
 
 // ServiceName defines the name used for the remotecfg service.
@@ -56,17 +80,20 @@ const ServiceName = "remotecfg"
 // Options are used to configure the remotecfg service. Options are
 // constant for the lifetime of the remotecfg service.
 type Options struct {
-	Logger      log.Logger // Where to send logs.
-	StoragePath string     // Where to cache configuration on-disk.
+	Logger      log.Logger            // Where to send logs.
+	StoragePath string                // Where to cache configuration on-disk.
+	Metrics     prometheus.Registerer // Where to send metrics to.
 }
 
 // Arguments holds runtime settings for the remotecfg service.
 type Arguments struct {
-	URL              string                   `river:"url,attr,optional"`
-	ID               string                   `river:"id,attr,optional"`
-	Metadata         map[string]string        `river:"metadata,attr,optional"`
-	PollFrequency    time.Duration            `river:"poll_frequency,attr,optional"`
-	HTTPClientConfig *config.HTTPClientConfig `river:",squash"`
+	URL                 string                   `river:"url,attr,optional"`
+	ID                  string                   `river:"id,attr,optional"`
+	Metadata            map[string]string        `river:"metadata,attr,optional"`
+	PollFrequency       time.Duration            `river:"poll_frequency,attr,optional"`
+	LongPollTimeout     time.Duration            `river:"long_poll_timeout,attr,optional"`
+	RollbackGracePeriod time.Duration            `river:"rollback_grace_period,attr,optional"`
+	HTTPClientConfig    *config.HTTPClientConfig `river:",squash"`
 }
 
 // GetDefaultArguments populates the default values for the Arguments struct.
@@ -86,6 +113,16 @@ func (a *Arguments) SetToDefault() {
 
 // Validate implements river.Validator.
 func (a *Arguments) Validate() error {
+	if a.PollFrequency <= 0 {
+		return fmt.Errorf("poll_frequency must be greater than 0")
+	}
+	if a.LongPollTimeout < 0 {
+		return fmt.Errorf("long_poll_timeout must not be negative")
+	}
+	if a.RollbackGracePeriod < 0 {
+		return fmt.Errorf("rollback_grace_period must not be negative")
+	}
+
 	// We must explicitly Validate because HTTPClientConfig is squashed and it
 	// won't run otherwise
 	if a.HTTPClientConfig != nil {
@@ -113,14 +150,17 @@ func New(opts Options) (*Service, error) {
 	}
 
 	return &Service{
-		opts:   opts,
-		ticker: time.NewTicker(math.MaxInt64),
+		opts:    opts,
+		ticker:  time.NewTicker(math.MaxInt64),
+		wake:    make(chan struct{}, 1),
+		metrics: newMetrics(opts.Metrics),
 	}, nil
 }
 
-// Data is a no-op for the remotecfg service.
+// Data returns an instance of [Data]. Calls to Data are cachable by the
+// caller.
 func (s *Service) Data() any {
-	return nil
+	return s
 }
 
 // Definition returns the definition of the remotecfg service.
@@ -138,7 +178,10 @@ var _ service.Service = (*Service)(nil)
 // Run implements [service.Service] and starts the remotecfg service. It will
 // run until the provided context is canceled or there is a fatal error.
 func (s *Service) Run(ctx context.Context, host service.Host) error {
+	s.mut.Lock()
 	s.ctrl = host.NewController(ServiceName)
+	s.runCtx = ctx
+	s.mut.Unlock()
 
 	s.fetch()
 
@@ -147,6 +190,10 @@ func (s *Service) Run(ctx context.Context, host service.Host) error {
 		s.ctrl.Run(ctx)
 	}()
 
+	// Long-polling runs separately so that it doesn't hold up the polling
+	// loop below while waiting on the API.
+	go s.runLongPoll(ctx)
+
 	for {
 		select {
 		case <-s.ch:
@@ -173,6 +220,11 @@ func (s *Service) Update(newConfig any) error {
 		s.ticker.Reset(math.MaxInt64)
 		s.asClient = noopClient{}
 		s.args.HTTPClientConfig = config.CloneDefaultHTTPClientConfig()
+		s.args.LongPollTimeout = 0
+		s.status = configStatus{}
+		s.stopLongPoll()
+		s.stopHealthCheck()
+		s.lastKnownGoodHash = ""
 		s.mut.Unlock()
 
 		s.setCfgHash("")
@@ -180,17 +232,31 @@ func (s *Service) Update(newConfig any) error {
 	}
 
 	s.mut.Lock()
+	defer s.wakeLongPoll()
 	hash, err := newArgs.Hash()
 	if err != nil {
+		s.mut.Unlock()
 		return err
 	}
 	s.dataPath = filepath.Join(s.opts.StoragePath, ServiceName, hash)
-	s.ticker.Reset(newArgs.PollFrequency)
-	s.ch = s.ticker.C
+	s.lastKnownGoodHash = ""
+	if b, err := os.ReadFile(s.dataPath); err == nil {
+		s.lastKnownGoodHash = getHash(b)
+	}
+	s.stopLongPoll()
+	if newArgs.LongPollTimeout > 0 {
+		// The long-polling loop takes over from the ticker.
+		s.ticker.Reset(math.MaxInt64)
+		s.ch = nil
+	} else {
+		s.ticker.Reset(newArgs.PollFrequency)
+		s.ch = s.ticker.C
+	}
 	// Update the HTTP client last since it might fail.
 	if !reflect.DeepEqual(s.args.HTTPClientConfig, newArgs.HTTPClientConfig) {
 		httpClient, err := commonconfig.NewClientFromConfig(*newArgs.HTTPClientConfig.Convert(), "remoteconfig")
 		if err != nil {
+			s.mut.Unlock()
 			return err
 		}
 		s.asClient = agentv1connect.NewAgentServiceClient(
@@ -203,7 +269,8 @@ func (s *Service) Update(newConfig any) error {
 
 	// If we've already called Run, then immediately trigger an API call with
 	// the updated Arguments, and/or fall back to the updated cache location.
-	if s.ctrl != nil && s.ctrl.Ready() {
+	// When long-polling, the long-polling loop makes the call instead.
+	if s.ctrl != nil && s.ctrl.Ready() && newArgs.LongPollTimeout == 0 {
 		s.fetch()
 	}
 
@@ -217,32 +284,64 @@ func (s *Service) fetch() {
 		s.fetchLocal()
 	}
 }
+
 func (s *Service) fetchRemote() error {
+	_, err := s.fetchRemoteWithWait(context.Background(), 0)
+	return err
+}
+
+// fetchResult describes the outcome of a request to the API.
+type fetchResult struct {
+	held    bool // The API held the request until there was new configuration.
+	changed bool // The API returned a different configuration.
+}
+
+// fetchRemoteWithWait fetches configuration from the API and loads it. If
+// wait is non-zero, the API may hold the request for up to wait until a new
+// configuration is available.
+func (s *Service) fetchRemoteWithWait(ctx context.Context, wait time.Duration) (fetchResult, error) {
 	if !s.isEnabled() {
-		return nil
+		return fetchResult{}, nil
 	}
 
-	b, err := s.getAPIConfig()
+	rsp, err := s.getAPIConfig(ctx, wait)
 	if err != nil {
-		return err
+		return fetchResult{}, err
 	}
+	res := fetchResult{held: rsp.held}
 
 	// API return the same configuration, no need to reload.
-	newConfigHash := getHash(b)
+	newConfigHash := getHash(rsp.content)
 	if s.getCfgHash() == newConfigHash {
 		level.Debug(s.opts.Logger).Log("msg", "skipping over API response since it contained the same hash")
-		return nil
+		return res, nil
 	}
+	if s.getRejectedHash() == newConfigHash {
+		level.Debug(s.opts.Logger).Log("msg", "skipping over API response since it contained a configuration which was rolled back")
+		return res, nil
+	}
+	res.changed = true
 
-	err = s.parseAndLoad(b)
+	revision := rsp.revision
+	if revision == "" {
+		revision = newConfigHash
+	}
+
+	// Components which are already unhealthy don't make the new configuration
+	// roll back.
+	unhealthyBefore := s.unhealthyComponents()
+
+	err = s.parseAndLoad(rsp.content)
 	if err != nil {
-		return err
+		s.setStatus(revision, err)
+		return res, err
 	}
 
-	// If successful, flush to disk and keep a copy.
-	s.setCachedConfig(b)
-	s.setCfgHash(newConfigHash)
-	return nil
+	// If successful, flush to disk and keep a copy once the configuration
+	// proved to be healthy. The configuration is reported as applied from
+	// then on.
+	s.accept(rsp.content, newConfigHash, revision, unhealthyBefore)
+	return res, nil
 }
 
 func (s *Service) fetchLocal() {
@@ -255,46 +354,73 @@ func (s *Service) fetchLocal() {
 	err = s.parseAndLoad(b)
 	if err != nil {
 		level.Error(s.opts.Logger).Log("msg", "failed to load from cache", "err", err)
+		return
 	}
+
+	// The cache only ever holds the last-known-good configuration.
+	s.mut.Lock()
+	s.lastKnownGoodHash = getHash(b)
+	s.metrics.runningLastKnownGood.Set(1)
+	s.mut.Unlock()
 }
 
-func (s *Service) getAPIConfig() ([]byte, error) {
+// apiResponse is a configuration returned by the API.
+type apiResponse struct {
+	content  []byte
+	revision string // Revision reported by the API, if any.
+	held     bool   // Whether the API held the request.
+}
+
+func (s *Service) getAPIConfig(ctx context.Context, wait time.Duration) (apiResponse, error) {
 	s.mut.RLock()
 	req := connect.NewRequest(&agentv1.GetConfigRequest{
 		Id:       s.args.ID,
 		Metadata: s.args.Metadata,
 	})
 	client := s.asClient
+	req.Header().Set(headerConfigHash, s.currentConfigHash)
+	s.status.writeHeaders(req.Header())
 	s.mut.RUnlock()
 
-	gcr, err := client.GetConfig(context.Background(), req)
+	if wait > 0 {
+		req.Header().Set(headerLongPollWait, wait.String())
+	}
+
+	gcr, err := client.GetConfig(ctx, req)
 	if err != nil {
-		return nil, err
+		return apiResponse{}, err
 	}
 
-	return []byte(gcr.Msg.GetContent()), nil
+	return apiResponse{
+		content:  []byte(gcr.Msg.GetContent()),
+		revision: gcr.Header().Get(headerConfigRevision),
+		held:     gcr.Header().Get(headerLongPoll) != "",
+	}, nil
 }
 
 func (s *Service) getCachedConfig() ([]byte, error) {
 	s.mut.RLock()
-	p := s.dataPath
-	s.mut.RUnlock()
+	defer s.mut.RUnlock()
 
-	return os.ReadFile(p)
+	return s.readCachedConfigLocked()
 }
 
-func (s *Service) setCachedConfig(b []byte) {
-	s.mut.RLock()
-	p := s.dataPath
-	s.mut.RUnlock()
+// readCachedConfigLocked reads the on-disk cache. It must be called with
+// s.mut held.
+func (s *Service) readCachedConfigLocked() ([]byte, error) {
+	return os.ReadFile(s.dataPath)
+}
 
-	err := os.WriteFile(p, b, 0750)
-	if err != nil {
-		level.Error(s.opts.Logger).Log("msg", "failed to flush remote configuration contents the on-disk cache", "err", err)
-	}
+// writeCachedConfigLocked flushes b to the on-disk cache. It must be called
+// with s.mut held.
+func (s *Service) writeCachedConfigLocked(b []byte) error {
+	return os.WriteFile(s.dataPath, b, 0750)
 }
 
 func (s *Service) parseAndLoad(b []byte) error {
+	s.loadMut.Lock()
+	defer s.loadMut.Unlock()
+
 	s.mut.RLock()
 	ctrl := s.ctrl
 	s.mut.RUnlock()
@@ -319,6 +445,13 @@ func (s *Service) getCfgHash() string {
 	return s.currentConfigHash
 }
 
+func (s *Service) getRejectedHash() string {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	return s.rejectedHash
+}
+
 func (s *Service) setCfgHash(h string) {
 	s.mut.Lock()
 	defer s.mut.Unlock()
@@ -326,6 +459,99 @@ func (s *Service) setCfgHash(h string) {
 	s.currentConfigHash = h
 }
 
+func (s *Service) setStatus(revision string, err error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.status = configStatus{Revision: revision, Applied: err == nil}
+	if err != nil {
+		s.status.Error = err.Error()
+	}
+}
+
+// runLongPoll long-polls the API for new configuration for as long as
+// long-polling is enabled. It runs until ctx is canceled.
+func (s *Service) runLongPoll(ctx context.Context) {
+	for {
+		wait := s.longPoll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-time.After(wait):
+		}
+	}
+}
+
+// longPoll performs a single long-poll request and returns how long to wait
+// before the next one.
+func (s *Service) longPoll(ctx context.Context) time.Duration {
+	s.mut.Lock()
+	var (
+		timeout       = s.args.LongPollTimeout
+		pollFrequency = s.args.PollFrequency
+	)
+	if timeout <= 0 || s.args.URL == "" || s.asClient == nil {
+		// Long-polling is disabled; wait to be woken up by an update.
+		s.mut.Unlock()
+		return math.MaxInt64
+	}
+
+	// Give the API some leeway to respond after the wait time elapsed.
+	reqCtx, cancel := context.WithTimeout(ctx, timeout+longPollGracePeriod)
+	s.longPollCancel = cancel
+	s.mut.Unlock()
+	defer cancel()
+
+	res, err := s.fetchRemoteWithWait(reqCtx, timeout)
+	switch {
+	case ctx.Err() == nil && errors.Is(err, context.Canceled):
+		// The request was canceled by an update; retry with the new Arguments.
+		return 0
+	case err != nil:
+		level.Error(s.opts.Logger).Log("msg", "failed to fetch remote configuration from the API", "err", err)
+		if s.getCfgHash() == "" {
+			s.fetchLocal()
+		}
+		return pollFrequency
+	case res.held || res.changed:
+		// Either the API supports long-polling, or the configuration changed
+		// and the next request reports its status back right away.
+		return 0
+	default:
+		// The API responded immediately; it doesn't support long-polling, so
+		// fall back to polling.
+		return pollFrequency
+	}
+}
+
+// stopLongPoll cancels the in-flight long-poll request. It must be called
+// with s.mut held.
+func (s *Service) stopLongPoll() {
+	if s.longPollCancel != nil {
+		s.longPollCancel()
+		s.longPollCancel = nil
+	}
+}
+
+// stopHealthCheck stops the health check in progress, if any. It must be
+// called with s.mut held.
+func (s *Service) stopHealthCheck() {
+	if s.healthCancel != nil {
+		s.healthCancel()
+		s.healthCancel = nil
+	}
+}
+
+// wakeLongPoll notifies the long-polling loop that the Arguments changed.
+func (s *Service) wakeLongPoll() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
 func (s *Service) isEnabled() bool {
 	s.mut.RLock()
 	defer s.mut.RUnlock()
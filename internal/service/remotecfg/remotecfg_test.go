@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -12,6 +14,7 @@ import (
 	"connectrpc.com/connect"
 	agentv1 "github.com/grafana/agent-remote-config/api/gen/proto/go/agent/v1"
 	"github.com/grafana/agent/internal/component"
+	_ "github.com/grafana/agent/internal/component/local/file"
 	_ "github.com/grafana/agent/internal/component/loki/process"
 	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/agent/internal/flow"
@@ -21,6 +24,7 @@ import (
 	"github.com/grafana/agent/internal/util"
 	"github.com/grafana/river"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -103,6 +107,310 @@ func TestAPIResponse(t *testing.T) {
 	}, time.Second, 10*time.Millisecond)
 }
 
+func TestLongPoll(t *testing.T) {
+	ctx := componenttest.TestContext(t)
+	url := "https://example.com/"
+	cfg1 := `loki.process "default" { forward_to = [] }`
+	cfg2 := `loki.process "updated" { forward_to = [] }`
+
+	// Create a new service. The poll frequency is long enough that any
+	// update must have been pushed through the long-poll request.
+	env := newTestEnvironment(t)
+	require.NoError(t, env.ApplyConfig(fmt.Sprintf(`
+		url               = "%s"
+		poll_frequency    = "1h"
+		long_poll_timeout = "1m"
+	`, url)))
+
+	// Mock an API which holds long-poll requests until it has a configuration
+	// the agent isn't running yet.
+	updates := make(chan string)
+	var (
+		mut     sync.Mutex
+		current = cfg1
+	)
+	client := &agentClient{}
+	client.getConfigFunc = func(ctx context.Context, req *connect.Request[agentv1.GetConfigRequest]) (*connect.Response[agentv1.GetConfigResponse], error) {
+		mut.Lock()
+		content := current
+		mut.Unlock()
+
+		if req.Header().Get(headerLongPollWait) != "" && req.Header().Get(headerConfigHash) == getHash([]byte(content)) {
+			select {
+			case content = <-updates:
+				mut.Lock()
+				current = content
+				mut.Unlock()
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		rsp := connect.NewResponse(&agentv1.GetConfigResponse{Content: content})
+		rsp.Header().Set(headerLongPoll, "true")
+		return rsp, nil
+	}
+	env.svc.asClient = client
+
+	// Run the service.
+	go func() {
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg1)), env.svc.getCfgHash())
+	}, time.Second, 10*time.Millisecond)
+
+	// Push a new configuration, and verify that it's loaded without waiting
+	// for the poll frequency.
+	select {
+	case updates <- cfg2:
+	case <-time.After(time.Second):
+		require.FailNow(t, "agent never sent a long-poll request")
+	}
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg2)), env.svc.getCfgHash())
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestStatusReporting(t *testing.T) {
+	ctx := componenttest.TestContext(t)
+	url := "https://example.com/"
+	cfg := `loki.process "default" { forward_to = [] }`
+
+	// Create a new service.
+	env := newTestEnvironment(t)
+	require.NoError(t, env.ApplyConfig(fmt.Sprintf(`
+		url            = "%s"
+		poll_frequency = "10ms"
+	`, url)))
+
+	// Mock an API which records the headers of the last request, and reports
+	// a revision for the configuration it returns.
+	var (
+		mut     sync.Mutex
+		headers http.Header
+	)
+	content, revision := "unparseable river config", "rev-1"
+	client := &agentClient{}
+	client.getConfigFunc = func(_ context.Context, req *connect.Request[agentv1.GetConfigRequest]) (*connect.Response[agentv1.GetConfigResponse], error) {
+		mut.Lock()
+		defer mut.Unlock()
+		headers = req.Header().Clone()
+
+		rsp := connect.NewResponse(&agentv1.GetConfigResponse{Content: content})
+		rsp.Header().Set(headerConfigRevision, revision)
+		return rsp, nil
+	}
+	env.svc.asClient = client
+
+	// Run the service.
+	go func() {
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	// The failure to load the first revision is reported back to the API.
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		mut.Lock()
+		defer mut.Unlock()
+		assert.Equal(c, "rev-1", headers.Get(headerStatusRevision))
+		assert.Equal(c, "false", headers.Get(headerStatusApplied))
+		assert.NotEmpty(c, headers.Get(headerStatusError))
+	}, time.Second, 10*time.Millisecond)
+
+	// Serve a valid revision, which is then acknowledged as applied.
+	mut.Lock()
+	content, revision = cfg, "rev-2"
+	mut.Unlock()
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		mut.Lock()
+		defer mut.Unlock()
+		assert.Equal(c, "rev-2", headers.Get(headerStatusRevision))
+		assert.Equal(c, "true", headers.Get(headerStatusApplied))
+		assert.Empty(c, headers.Get(headerStatusError))
+		assert.Equal(c, getHash([]byte(cfg)), headers.Get(headerConfigHash))
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestStatusReporting_Pending(t *testing.T) {
+	ctx := componenttest.TestContext(t)
+	url := "https://example.com/"
+	cfg := `loki.process "default" { forward_to = [] }`
+
+	// Create a new service which checks the health of new configurations.
+	env := newTestEnvironment(t)
+	require.NoError(t, env.ApplyConfig(fmt.Sprintf(`
+		url                   = "%s"
+		poll_frequency        = "10ms"
+		rollback_grace_period = "500ms"
+	`, url)))
+
+	var (
+		mut     sync.Mutex
+		headers http.Header
+	)
+	client := &agentClient{}
+	client.getConfigFunc = func(_ context.Context, req *connect.Request[agentv1.GetConfigRequest]) (*connect.Response[agentv1.GetConfigResponse], error) {
+		mut.Lock()
+		defer mut.Unlock()
+		headers = req.Header().Clone()
+
+		rsp := connect.NewResponse(&agentv1.GetConfigResponse{Content: cfg})
+		rsp.Header().Set(headerConfigRevision, "rev-1")
+		return rsp, nil
+	}
+	env.svc.asClient = client
+
+	// Run the service.
+	go func() {
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	// The configuration is reported as pending during the grace period, and
+	// as applied once it passed the health check.
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		mut.Lock()
+		defer mut.Unlock()
+		assert.Equal(c, "rev-1", headers.Get(headerStatusRevision))
+		assert.Equal(c, "pending", headers.Get(headerStatusApplied))
+	}, 400*time.Millisecond, 10*time.Millisecond)
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		mut.Lock()
+		defer mut.Unlock()
+		assert.Equal(c, "true", headers.Get(headerStatusApplied))
+		assert.Equal(c, getHash([]byte(cfg)), env.svc.Status().LastKnownGoodHash)
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestRollback(t *testing.T) {
+	ctx := componenttest.TestContext(t)
+	url := "https://example.com/"
+
+	// cfg2 loads successfully, but its component becomes unhealthy once the
+	// file it reads is removed.
+	filename := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(filename, []byte("contents"), 0644))
+	cfg1 := `loki.process "default" { forward_to = [] }`
+	cfg2 := fmt.Sprintf(`local.file "broken" {
+		filename       = %q
+		detector       = "poll"
+		poll_frequency = "10ms"
+	}`, filename)
+
+	// Create a new service.
+	env := newTestEnvironment(t)
+	require.NoError(t, env.ApplyConfig(fmt.Sprintf(`
+		url                   = "%s"
+		poll_frequency        = "10ms"
+		rollback_grace_period = "200ms"
+	`, url)))
+
+	client := &agentClient{}
+	client.getConfigFunc = buildGetConfigHandler(cfg1)
+	env.svc.asClient = client
+
+	// Run the service.
+	go func() {
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	// The first configuration becomes the last-known-good configuration
+	// after the grace period.
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg1)), env.svc.Status().LastKnownGoodHash)
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// Serve the broken configuration and break it once it's loaded.
+	client.mut.Lock()
+	client.getConfigFunc = buildGetConfigHandler(cfg2)
+	client.mut.Unlock()
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg2)), env.svc.getCfgHash())
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, os.Remove(filename))
+
+	// Verify that the service rolled back to the last-known-good
+	// configuration, and reports the rollback.
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		st := env.svc.Status()
+		assert.Equal(c, getHash([]byte(cfg1)), st.ConfigHash)
+		assert.Equal(c, 1, st.Rollbacks)
+		if assert.NotNil(c, st.LastRollback) {
+			assert.Equal(c, getHash([]byte(cfg2)), st.LastRollback.FromHash)
+			assert.Equal(c, getHash([]byte(cfg1)), st.LastRollback.ToHash)
+			assert.Contains(c, st.LastRollback.Reason, `local.file.broken`)
+		}
+	}, 2*time.Second, 10*time.Millisecond)
+	require.Equal(t, 1.0, testutil.ToFloat64(env.svc.metrics.rollbacks))
+	require.Equal(t, 1.0, testutil.ToFloat64(env.svc.metrics.runningLastKnownGood))
+
+	// The broken configuration is still served by the API, but isn't loaded
+	// again.
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, getHash([]byte(cfg1)), env.svc.getCfgHash())
+
+	cached, err := os.ReadFile(env.svc.dataPath)
+	require.NoError(t, err)
+	require.Equal(t, cfg1, string(cached))
+}
+
+func TestRollback_AlreadyUnhealthy(t *testing.T) {
+	ctx := componenttest.TestContext(t)
+	url := "https://example.com/"
+
+	// The component of cfg1 becomes unhealthy once the file it reads is
+	// removed, and is kept unchanged by cfg2.
+	filename := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(filename, []byte("contents"), 0644))
+	cfg1 := fmt.Sprintf(`local.file "broken" {
+		filename       = %q
+		detector       = "poll"
+		poll_frequency = "10ms"
+	}`, filename)
+	cfg2 := cfg1 + "\n" + `loki.process "default" { forward_to = [] }`
+
+	// Create a new service.
+	env := newTestEnvironment(t)
+	require.NoError(t, env.ApplyConfig(fmt.Sprintf(`
+		url                   = "%s"
+		poll_frequency        = "10ms"
+		rollback_grace_period = "200ms"
+	`, url)))
+
+	client := &agentClient{}
+	client.getConfigFunc = buildGetConfigHandler(cfg1)
+	env.svc.asClient = client
+
+	// Run the service.
+	go func() {
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg1)), env.svc.Status().LastKnownGoodHash)
+	}, 2*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, os.Remove(filename))
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Contains(c, env.svc.unhealthyComponents(), "remotecfg/local.file.broken")
+	}, time.Second, 10*time.Millisecond)
+
+	client.mut.Lock()
+	client.getConfigFunc = buildGetConfigHandler(cfg2)
+	client.mut.Unlock()
+
+	// The component which was already unhealthy doesn't roll back cfg2.
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg2)), env.svc.Status().LastKnownGoodHash)
+	}, 2*time.Second, 10*time.Millisecond)
+	st := env.svc.Status()
+	require.Equal(t, getHash([]byte(cfg2)), st.ConfigHash)
+	require.Zero(t, st.Rollbacks)
+}
+
 func buildGetConfigHandler(in string) func(context.Context, *connect.Request[agentv1.GetConfigRequest]) (*connect.Response[agentv1.GetConfigResponse], error) {
 	return func(context.Context, *connect.Request[agentv1.GetConfigRequest]) (*connect.Response[agentv1.GetConfigResponse], error) {
 		rsp := &connect.Response[agentv1.GetConfigResponse]{
@@ -123,6 +431,7 @@ func newTestEnvironment(t *testing.T) *testEnvironment {
 	svc, err := New(Options{
 		Logger:      util.TestLogger(t),
 		StoragePath: t.TempDir(),
+		Metrics:     prometheus.NewRegistry(),
 	})
 	svc.asClient = nil
 	require.NoError(t, err)
@@ -223,3 +532,6 @@ func (sc serviceController) LoadSource(b []byte, args map[string]any) error {
 	return sc.f.LoadSource(source, args)
 }
 func (sc serviceController) Ready() bool { return sc.f.Ready() }
+func (sc serviceController) ListComponents(moduleID string, opts component.InfoOptions) ([]*component.Info, error) {
+	return sc.f.ListComponents(moduleID, opts)
+}
@@ -0,0 +1,297 @@
+package remotecfg
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/flow/logging/level"
+	"github.com/grafana/agent/internal/util"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Data is the data exported by the remotecfg service.
+type Data interface {
+	// Status returns the status of the configuration loaded by the remotecfg
+	// service.
+	Status() Status
+}
+
+// Status describes the configuration loaded by the remotecfg service.
+type Status struct {
+	// ConfigHash is the hash of the configuration currently loaded.
+	ConfigHash string
+	// LastKnownGoodHash is the hash of the last configuration which passed
+	// the health check, and which is cached on disk.
+	LastKnownGoodHash string
+	// Rollbacks is the number of times a configuration was rolled back.
+	Rollbacks int
+	// LastRollback describes the latest rollback, if any.
+	LastRollback *Rollback
+}
+
+// Rollback describes the rollback of a configuration which made components
+// unhealthy.
+type Rollback struct {
+	Time     time.Time // When the rollback happened.
+	FromHash string    // Hash of the configuration which was rolled back.
+	ToHash   string    // Hash of the last-known-good configuration.
+	Reason   string    // Why the configuration was rolled back.
+}
+
+// Status implements [Data].
+func (s *Service) Status() Status {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	st := Status{
+		ConfigHash:        s.currentConfigHash,
+		LastKnownGoodHash: s.lastKnownGoodHash,
+		Rollbacks:         s.rollbacks,
+	}
+	if s.lastRollback != nil {
+		rollback := *s.lastRollback
+		st.LastRollback = &rollback
+	}
+	return st
+}
+
+type metrics struct {
+	rollbacks            prometheus.Counter
+	lastRollback         prometheus.Gauge
+	runningLastKnownGood prometheus.Gauge
+}
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	m := &metrics{
+		rollbacks: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "remotecfg_rollbacks_total",
+			Help: "Total number of remote configurations rolled back because components became unhealthy.",
+		}),
+		lastRollback: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "remotecfg_last_rollback_timestamp_seconds",
+			Help: "Timestamp of the last rollback of a remote configuration.",
+		}),
+		runningLastKnownGood: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "remotecfg_running_last_known_good",
+			Help: "Whether the loaded remote configuration is the last-known-good configuration.",
+		}),
+	}
+
+	if reg != nil {
+		m.rollbacks = util.MustRegisterOrGet(reg, m.rollbacks).(prometheus.Counter)
+		m.lastRollback = util.MustRegisterOrGet(reg, m.lastRollback).(prometheus.Gauge)
+		m.runningLastKnownGood = util.MustRegisterOrGet(reg, m.runningLastKnownGood).(prometheus.Gauge)
+	}
+	return m
+}
+
+// accept is called after the configuration b was loaded. The configuration
+// becomes the last-known-good configuration and is reported as applied once it
+// passed the health check, or immediately if the health check is disabled.
+// Until then, it's reported as pending. unhealthyBefore holds the components
+// which were unhealthy before b was loaded.
+func (s *Service) accept(b []byte, hash, revision string, unhealthyBefore map[string]struct{}) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	// A newer configuration supersedes any health check in progress.
+	s.stopHealthCheck()
+
+	gracePeriod := s.args.RollbackGracePeriod
+	if gracePeriod <= 0 || s.runCtx == nil {
+		s.promoteLocked(b, hash, revision)
+		return
+	}
+
+	s.status = configStatus{Revision: revision, Pending: true}
+	s.metrics.runningLastKnownGood.Set(0)
+
+	ctx, cancel := context.WithCancel(s.runCtx)
+	s.healthCancel = cancel
+	go s.watchHealth(ctx, b, hash, revision, gracePeriod, unhealthyBefore)
+}
+
+// watchHealth watches the health of the components for the grace period
+// after the configuration b was loaded, and rolls back to the last-known-good
+// configuration if their health degrades. Components which were unhealthy
+// before the configuration was loaded are ignored.
+//
+// Components may be unhealthy for a while when they start, so the
+// configuration is only rolled back during the grace period for components
+// which were seen healthy and then became unhealthy. Components which never
+// became healthy roll the configuration back if they're still unhealthy at
+// the end of the grace period.
+func (s *Service) watchHealth(ctx context.Context, b []byte, hash, revision string, gracePeriod time.Duration, unhealthyBefore map[string]struct{}) {
+	ticker := time.NewTicker(gracePeriod / healthChecksPerGracePeriod)
+	defer ticker.Stop()
+	deadline := time.NewTimer(gracePeriod)
+	defer deadline.Stop()
+
+	// degraded returns the components which are unhealthy, weren't unhealthy
+	// before the configuration was loaded, and for which include returns true.
+	degraded := func(unhealthy map[string]struct{}, include func(id string) bool) []string {
+		var ids []string
+		for id := range unhealthy {
+			if _, ok := unhealthyBefore[id]; !ok && include(id) {
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	// seenHealthy holds the components which were healthy at a check.
+	seenHealthy := make(map[string]struct{})
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			infos := s.componentInfos()
+			unhealthy := unhealthySet(infos)
+			if ids := degraded(unhealthy, func(id string) bool {
+				_, ok := seenHealthy[id]
+				return ok
+			}); len(ids) > 0 {
+				s.rollback(ctx, hash, revision, ids)
+				return
+			}
+			for _, info := range infos {
+				if _, ok := unhealthy[info.ID.String()]; !ok {
+					seenHealthy[info.ID.String()] = struct{}{}
+				}
+			}
+		case <-deadline.C:
+			if ids := degraded(s.unhealthyComponents(), func(string) bool { return true }); len(ids) > 0 {
+				s.rollback(ctx, hash, revision, ids)
+				return
+			}
+
+			s.mut.Lock()
+			if ctx.Err() == nil {
+				s.promoteLocked(b, hash, revision)
+			}
+			s.mut.Unlock()
+			return
+		}
+	}
+}
+
+// healthChecksPerGracePeriod is how many times component health is checked
+// during the grace period.
+const healthChecksPerGracePeriod = 10
+
+// componentInfos returns the components of the loaded configuration along
+// with their health. It returns nil if the health couldn't be retrieved.
+func (s *Service) componentInfos() []*component.Info {
+	s.mut.RLock()
+	ctrl := s.ctrl
+	s.mut.RUnlock()
+
+	infos, err := ctrl.ListComponents("", component.InfoOptions{GetHealth: true})
+	if err != nil {
+		level.Warn(s.opts.Logger).Log("msg", "failed to get the health of components", "err", err)
+		return nil
+	}
+	return infos
+}
+
+// unhealthyComponents returns the IDs of the components which are unhealthy
+// or have exited.
+func (s *Service) unhealthyComponents() map[string]struct{} {
+	return unhealthySet(s.componentInfos())
+}
+
+// unhealthySet returns the IDs of the components in infos which are unhealthy
+// or have exited.
+func unhealthySet(infos []*component.Info) map[string]struct{} {
+	unhealthy := make(map[string]struct{})
+	for _, info := range infos {
+		switch info.Health.Health {
+		case component.HealthTypeUnhealthy, component.HealthTypeExited:
+			unhealthy[info.ID.String()] = struct{}{}
+		}
+	}
+	return unhealthy
+}
+
+// rollback reverts the configuration with the given hash to the
+// last-known-good configuration. The configuration won't be loaded again
+// until the API serves a different one.
+//
+// s.mut isn't held while loading the last-known-good configuration, since
+// loading evaluates components which may call back into the service.
+func (s *Service) rollback(ctx context.Context, hash, revision string, unhealthy []string) {
+	s.loadMut.Lock()
+	defer s.loadMut.Unlock()
+
+	s.mut.Lock()
+	// The configuration was replaced while checking its health.
+	if ctx.Err() != nil || s.currentConfigHash != hash {
+		s.mut.Unlock()
+		return
+	}
+	s.healthCancel = nil
+
+	reason := fmt.Sprintf("components became unhealthy: %s", strings.Join(unhealthy, ", "))
+	s.rejectedHash = hash
+
+	// Report the outcome to the API right away.
+	s.stopLongPoll()
+
+	b, err := s.readCachedConfigLocked()
+	if err != nil || len(b) == 0 {
+		level.Error(s.opts.Logger).Log("msg", "remote configuration made components unhealthy, but there is no last-known-good configuration to roll back to", "reason", reason)
+		s.status = configStatus{Revision: revision, Error: "no last-known-good configuration to roll back to: " + reason}
+		s.mut.Unlock()
+		return
+	}
+	s.status = configStatus{Revision: revision, Error: "rolled back: " + reason}
+	ctrl, lastKnownGoodHash := s.ctrl, s.lastKnownGoodHash
+	s.mut.Unlock()
+
+	level.Warn(s.opts.Logger).Log("msg", "rolling back to the last-known-good remote configuration", "reason", reason, "hash", hash, "last_known_good_hash", lastKnownGoodHash)
+	if err := ctrl.LoadSource(b, nil); err != nil {
+		level.Error(s.opts.Logger).Log("msg", "failed to load the last-known-good remote configuration", "err", err)
+		return
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	now := time.Now()
+	s.currentConfigHash = getHash(b)
+	s.rollbacks++
+	s.lastRollback = &Rollback{
+		Time:     now,
+		FromHash: hash,
+		ToHash:   s.currentConfigHash,
+		Reason:   reason,
+	}
+	s.metrics.rollbacks.Inc()
+	s.metrics.lastRollback.Set(float64(now.Unix()))
+	s.metrics.runningLastKnownGood.Set(1)
+}
+
+// promoteLocked makes the configuration b the last-known-good configuration
+// by flushing it to the on-disk cache, and reports it as applied. It must be
+// called with s.mut held.
+func (s *Service) promoteLocked(b []byte, hash, revision string) {
+	if s.currentConfigHash != hash {
+		return
+	}
+
+	s.status = configStatus{Revision: revision, Applied: true}
+
+	if err := s.writeCachedConfigLocked(b); err != nil {
+		level.Error(s.opts.Logger).Log("msg", "failed to flush remote configuration contents the on-disk cache", "err", err)
+	}
+	s.lastKnownGoodHash = hash
+	s.rejectedHash = ""
+	s.metrics.runningLastKnownGood.Set(1)
+}
@@ -0,0 +1,88 @@
+package remotecfg
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// The API definition has no messages for long-polling or for reporting the
+// status of a configuration, so they are negotiated through HTTP headers on
+// the GetConfig RPC. APIs which don't know about these headers keep working
+// as before.
+const (
+	// headerConfigHash is sent with every request and holds the hash of the
+	// configuration the agent is currently running.
+	headerConfigHash = "X-Agent-Config-Hash"
+
+	// headerLongPollWait is sent when long-polling is enabled and holds how
+	// long the API may hold the request while the configuration is unchanged.
+	headerLongPollWait = "X-Agent-Long-Poll-Wait"
+
+	// headerLongPoll is set by APIs which held the request. If it is missing
+	// from a response to a long-poll request, the agent falls back to polling.
+	headerLongPoll = "X-Agent-Long-Poll"
+
+	// headerConfigRevision is optionally set by the API to identify the
+	// returned configuration. The hash of the configuration is used otherwise.
+	headerConfigRevision = "X-Agent-Config-Revision"
+
+	// headerStatusRevision, headerStatusApplied and headerStatusError report
+	// whether the last configuration received from the API was loaded
+	// successfully. headerStatusApplied is "pending" while the configuration
+	// is loaded but its health is still being checked.
+	headerStatusRevision = "X-Agent-Config-Status-Revision"
+	headerStatusApplied  = "X-Agent-Config-Status-Applied"
+	headerStatusError    = "X-Agent-Config-Status-Error"
+)
+
+// longPollGracePeriod is how long to wait for a response after the long-poll
+// wait time elapsed before giving up on the request.
+const longPollGracePeriod = 30 * time.Second
+
+// maxStatusErrorLength limits the size of the error reported to the API.
+const maxStatusErrorLength = 1024
+
+// configStatus is the status of the last configuration received from the API.
+type configStatus struct {
+	Revision string // Revision of the configuration.
+	Applied  bool   // Whether the configuration was loaded successfully.
+	Pending  bool   // Whether the configuration is loaded, but its health is still being checked.
+	Error    string // Error encountered while loading the configuration.
+}
+
+// writeHeaders reports the status through h. Nothing is reported if no
+// configuration has been received yet.
+func (cs configStatus) writeHeaders(h http.Header) {
+	if cs.Revision == "" {
+		return
+	}
+
+	h.Set(headerStatusRevision, cs.Revision)
+	applied := strconv.FormatBool(cs.Applied)
+	if cs.Pending {
+		applied = "pending"
+	}
+	h.Set(headerStatusApplied, applied)
+	if cs.Error != "" {
+		h.Set(headerStatusError, sanitizeHeaderValue(cs.Error))
+	}
+}
+
+// sanitizeHeaderValue makes s safe to use as an HTTP header value by
+// replacing control characters (such as the newlines in multi-line River
+// diagnostics) with spaces and truncating it.
+func sanitizeHeaderValue(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r > unicode.MaxASCII {
+			return ' '
+		}
+		return r
+	}, s)
+	if len(s) > maxStatusErrorLength {
+		s = s[:maxStatusErrorLength]
+	}
+	return s
+}
@@ -77,6 +77,10 @@ type Controller interface {
 	Run(ctx context.Context)
 	LoadSource(source []byte, args map[string]any) error
 	Ready() bool
+
+	// ListComponents lists all running components within a given module of
+	// the Controller.
+	ListComponents(moduleID string, opts component.InfoOptions) ([]*component.Info, error)
 }
 
 type Consumer struct {
@@ -22,6 +22,15 @@ const ServiceName = "ui"
 // lifetime of the UI service.
 type Options struct {
 	UIPrefix string // Path prefix to host the UI at.
+
+	// WriteToken is the bearer token which authenticates requests to the write
+	// endpoints of the API. The write endpoints are disabled if WriteToken is
+	// empty.
+	WriteToken string
+
+	// ReloadFunc reloads the configuration from its source. It is used by the
+	// reload endpoint of the API.
+	ReloadFunc func() error
 }
 
 // Service implements the UI service.
@@ -75,7 +84,10 @@ func (s *Service) Data() any {
 func (s *Service) ServiceHandler(host service.Host) (base string, handler http.Handler) {
 	r := mux.NewRouter()
 
-	fa := api.NewFlowAPI(host)
+	fa := api.NewFlowAPI(host, api.Options{
+		WriteToken: s.opts.WriteToken,
+		ReloadFunc: s.opts.ReloadFunc,
+	})
 	fa.RegisterRoutes(path.Join(s.opts.UIPrefix, "/api/v0/web"), r)
 	ui.RegisterRoutes(s.opts.UIPrefix, r)
 
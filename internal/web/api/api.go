@@ -5,25 +5,65 @@
 package api
 
 import (
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"path"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/grafana/agent/internal/component"
 	"github.com/grafana/agent/internal/service"
 	"github.com/grafana/agent/internal/service/cluster"
+	"github.com/grafana/river/diag"
 	"github.com/prometheus/prometheus/util/httputil"
 )
 
+// maxRequestBodySize limits the size of River fragments accepted by the write
+// endpoints.
+const maxRequestBodySize = 1 << 20
+
+// Options configures the Flow API.
+type Options struct {
+	// WriteToken is the bearer token which authenticates requests to the
+	// write endpoints. The write endpoints are disabled if WriteToken is empty.
+	WriteToken string
+
+	// ReloadFunc reloads the configuration from its source. The reload
+	// endpoint is disabled if ReloadFunc is nil.
+	ReloadFunc func() error
+}
+
+// ComponentEditor is implemented by hosts which allow the components of the
+// root module to be changed at runtime.
+//
+// Methods of ComponentEditor return a [diag.Diagnostics] error when the
+// change doesn't pass validation, [component.ErrComponentNotFound] when the
+// targeted component doesn't exist, and [component.ErrComponentExists] when
+// adding a component which already exists.
+type ComponentEditor interface {
+	// UpdateComponent replaces the arguments of the component with the given
+	// ID with the River fragment args.
+	UpdateComponent(id string, args []byte) error
+
+	// AddComponent adds the component defined by the River fragment block.
+	AddComponent(block []byte) error
+
+	// RemoveComponent removes the component with the given ID.
+	RemoveComponent(id string) error
+}
+
 // FlowAPI is a wrapper around the component API.
 type FlowAPI struct {
 	flow service.Host
+	opts Options
 }
 
 // NewFlowAPI instantiates a new Flow API.
-func NewFlowAPI(flow service.Host) *FlowAPI {
-	return &FlowAPI{flow: flow}
+func NewFlowAPI(flow service.Host, opts Options) *FlowAPI {
+	return &FlowAPI{flow: flow, opts: opts}
 }
 
 // RegisterRoutes registers all the API's routes.
@@ -32,10 +72,20 @@ func (f *FlowAPI) RegisterRoutes(urlPrefix string, r *mux.Router) {
 	// id to contain / characters, which is used by nested module IDs and
 	// component IDs.
 
+	// Write routes are registered first, as the read routes below match any
+	// method.
+	r.Handle(path.Join(urlPrefix, "/components"), f.writeHandler(f.addComponentHandler())).Methods(http.MethodPost)
+	r.Handle(path.Join(urlPrefix, "/components/{id:.+}"), f.writeHandler(f.updateComponentHandler())).Methods(http.MethodPut)
+	r.Handle(path.Join(urlPrefix, "/components/{id:.+}"), f.writeHandler(f.removeComponentHandler())).Methods(http.MethodDelete)
+	r.Handle(path.Join(urlPrefix, "/reload"), f.writeHandler(f.reloadHandler())).Methods(http.MethodPost)
+
 	r.Handle(path.Join(urlPrefix, "/modules/{moduleID:.+}/components"), httputil.CompressionHandler{Handler: f.listComponentsHandler()})
 	r.Handle(path.Join(urlPrefix, "/components"), httputil.CompressionHandler{Handler: f.listComponentsHandler()})
 	r.Handle(path.Join(urlPrefix, "/components/{id:.+}"), httputil.CompressionHandler{Handler: f.getComponentHandler()})
 	r.Handle(path.Join(urlPrefix, "/peers"), httputil.CompressionHandler{Handler: f.getClusteringPeersHandler()})
+
+	// Streams aren't compressed, as compression buffers the events.
+	r.Handle(path.Join(urlPrefix, "/debug/{id:.+}"), f.liveDebuggingHandler()).Methods(http.MethodGet)
 }
 
 func (f *FlowAPI) listComponentsHandler() http.HandlerFunc {
@@ -107,3 +157,181 @@ func (f *FlowAPI) getClusteringPeersHandler() http.HandlerFunc {
 		_, _ = w.Write(bb)
 	}
 }
+
+// writeHandler wraps next to require the write token. Requests are rejected
+// with 403 Forbidden if no write token is configured, and with 401
+// Unauthorized if the request doesn't carry the right token.
+func (f *FlowAPI) writeHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if f.opts.WriteToken == "" {
+			http.Error(w, "write endpoints are disabled", http.StatusForbidden)
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(f.opts.WriteToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "invalid or missing bearer token", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (f *FlowAPI) updateComponentHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		editor, ok := f.editor(w)
+		if !ok {
+			return
+		}
+		body, ok := readBody(w, r)
+		if !ok {
+			return
+		}
+
+		writeEditResult(w, http.StatusOK, editor.UpdateComponent(mux.Vars(r)["id"], body))
+	}
+}
+
+func (f *FlowAPI) addComponentHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		editor, ok := f.editor(w)
+		if !ok {
+			return
+		}
+		body, ok := readBody(w, r)
+		if !ok {
+			return
+		}
+
+		writeEditResult(w, http.StatusCreated, editor.AddComponent(body))
+	}
+}
+
+func (f *FlowAPI) removeComponentHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		editor, ok := f.editor(w)
+		if !ok {
+			return
+		}
+
+		writeEditResult(w, http.StatusOK, editor.RemoveComponent(mux.Vars(r)["id"]))
+	}
+}
+
+func (f *FlowAPI) reloadHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		if f.opts.ReloadFunc == nil {
+			http.Error(w, "reloading is not supported", http.StatusNotImplemented)
+			return
+		}
+
+		// The reloaded configuration comes from the server, so its diagnostics
+		// aren't caused by the request.
+		writeResult(w, http.StatusOK, http.StatusInternalServerError, f.opts.ReloadFunc())
+	}
+}
+
+// editor returns the host as a [ComponentEditor], writing an error to w if
+// the host doesn't support editing components.
+func (f *FlowAPI) editor(w http.ResponseWriter) (ComponentEditor, bool) {
+	editor, ok := f.flow.(ComponentEditor)
+	if !ok {
+		http.Error(w, "editing components is not supported", http.StatusNotImplemented)
+	}
+	return editor, ok
+}
+
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return body, true
+}
+
+// editResponse is the response of the write endpoints.
+type editResponse struct {
+	Error       string           `json:"error,omitempty"`
+	Diagnostics []diagnosticJSON `json:"diagnostics,omitempty"`
+}
+
+type diagnosticJSON struct {
+	Severity string       `json:"severity"`
+	Message  string       `json:"message"`
+	Value    string       `json:"value,omitempty"`
+	Start    positionJSON `json:"start"`
+	End      positionJSON `json:"end"`
+}
+
+type positionJSON struct {
+	Filename string `json:"filename,omitempty"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+// writeEditResult writes the outcome of a request editing components.
+// Validation failures are caused by the edit and are reported with their
+// diagnostics.
+func writeEditResult(w http.ResponseWriter, okStatus int, err error) {
+	writeResult(w, okStatus, http.StatusBadRequest, err)
+}
+
+// writeResult writes the outcome of a write request. Diagnostics are reported
+// with diagsStatus, and any other error which isn't caused by the request
+// with a 500 status code.
+func writeResult(w http.ResponseWriter, okStatus, diagsStatus int, err error) {
+	var (
+		resp   editResponse
+		status = okStatus
+		diags  diag.Diagnostics
+		d      diag.Diagnostic
+	)
+	if errors.As(err, &d) {
+		diags = diag.Diagnostics{d}
+	}
+
+	switch {
+	case err == nil:
+	case errors.Is(err, component.ErrComponentNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, component.ErrComponentExists):
+		status = http.StatusConflict
+	case len(diags) > 0 || errors.As(err, &diags):
+		status = diagsStatus
+		for _, d := range diags {
+			resp.Diagnostics = append(resp.Diagnostics, diagnosticJSON{
+				Severity: severityString(d.Severity),
+				Message:  d.Message,
+				Value:    d.Value,
+				Start:    positionJSON{Filename: d.StartPos.Filename, Line: d.StartPos.Line, Column: d.StartPos.Column},
+				End:      positionJSON{Filename: d.EndPos.Filename, Line: d.EndPos.Line, Column: d.EndPos.Column},
+			})
+		}
+	default:
+		status = http.StatusInternalServerError
+	}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+
+	bb, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(bb)
+}
+
+func severityString(s diag.Severity) string {
+	switch s {
+	case diag.SeverityLevelWarn:
+		return "warning"
+	default:
+		return "error"
+	}
+}
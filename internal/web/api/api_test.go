@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/service"
+	"github.com/grafana/river/diag"
+	"github.com/grafana/river/token"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteEndpoints(t *testing.T) {
+	host := &fakeEditor{}
+	reloads := 0
+
+	newRouter := func(opts Options) *mux.Router {
+		r := mux.NewRouter()
+		NewFlowAPI(host, opts).RegisterRoutes("/api/v0/web", r)
+		return r
+	}
+	r := newRouter(Options{
+		WriteToken: "secret",
+		ReloadFunc: func() error { reloads++; return nil },
+	})
+
+	do := func(r *mux.Router, method, url, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("disabled without token", func(t *testing.T) {
+		rec := do(newRouter(Options{}), http.MethodDelete, "/api/v0/web/components/a.b.c", "secret", "")
+		require.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("rejects bad token", func(t *testing.T) {
+		rec := do(r, http.MethodDelete, "/api/v0/web/components/a.b.c", "", "")
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		rec = do(r, http.MethodDelete, "/api/v0/web/components/a.b.c", "wrong", "")
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		rec := do(r, http.MethodPut, "/api/v0/web/components/local.file/nested", "secret", `filename = "x"`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "local.file/nested", host.updatedID)
+		require.Equal(t, `filename = "x"`, string(host.updatedArgs))
+	})
+
+	t.Run("update reports diagnostics", func(t *testing.T) {
+		host.err = diag.Diagnostics{{
+			Severity: diag.SeverityLevelError,
+			StartPos: token.Position{Filename: "<request>", Line: 1, Column: 12},
+			EndPos:   token.Position{Filename: "<request>", Line: 1, Column: 15},
+			Message:  "bad value",
+		}}
+		defer func() { host.err = nil }()
+
+		rec := do(r, http.MethodPut, "/api/v0/web/components/local.file.a", "secret", `filename = 5`)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+
+		var resp editResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Equal(t, []diagnosticJSON{{
+			Severity: "error",
+			Message:  "bad value",
+			Start:    positionJSON{Filename: "<request>", Line: 1, Column: 12},
+			End:      positionJSON{Filename: "<request>", Line: 1, Column: 15},
+		}}, resp.Diagnostics)
+	})
+
+	t.Run("add existing", func(t *testing.T) {
+		host.err = component.ErrComponentExists
+		defer func() { host.err = nil }()
+
+		rec := do(r, http.MethodPost, "/api/v0/web/components", "secret", `local.file "a" {}`)
+		require.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("remove missing", func(t *testing.T) {
+		host.err = component.ErrComponentNotFound
+		defer func() { host.err = nil }()
+
+		rec := do(r, http.MethodDelete, "/api/v0/web/components/local.file.a", "secret", "")
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		host.err = errors.New("no configuration has been loaded")
+		defer func() { host.err = nil }()
+
+		rec := do(r, http.MethodDelete, "/api/v0/web/components/local.file.a", "secret", "")
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		rec := do(r, http.MethodPost, "/api/v0/web/reload", "secret", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, 1, reloads)
+	})
+
+	t.Run("failed reload", func(t *testing.T) {
+		rec := do(newRouter(Options{
+			WriteToken: "secret",
+			ReloadFunc: func() error {
+				return diag.Diagnostics{{Severity: diag.SeverityLevelError, Message: "bad config file"}}
+			},
+		}), http.MethodPost, "/api/v0/web/reload", "secret", "")
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+
+		var resp editResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Diagnostics, 1)
+	})
+}
+
+// fakeEditor is a service.Host which records calls to the ComponentEditor
+// methods.
+type fakeEditor struct {
+	service.Host
+
+	err         error
+	updatedID   string
+	updatedArgs []byte
+}
+
+func (f *fakeEditor) UpdateComponent(id string, args []byte) error {
+	f.updatedID, f.updatedArgs = id, args
+	return f.err
+}
+
+func (f *fakeEditor) AddComponent(block []byte) error { return f.err }
+func (f *fakeEditor) RemoveComponent(id string) error { return f.err }
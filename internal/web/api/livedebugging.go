@@ -0,0 +1,140 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/service/livedebugging"
+)
+
+// liveDebuggingKeepalive is how often a comment is sent on idle streams so
+// that proxies don't close them.
+const liveDebuggingKeepalive = 15 * time.Second
+
+// liveDebuggingHandler streams the data received and emitted by a component
+// as server-sent events.
+//
+// The following query parameters are supported:
+//
+//   - sample_ratio: the ratio of data streamed, between 0 and 1.
+//   - rate: the maximum number of events streamed per second.
+//   - filter: a regular expression the streamed data must match.
+//
+// Each event holds a JSON-encoded [livedebugging.Event]. A "dropped" event
+// holding the total number of dropped events is sent whenever events were
+// dropped because they exceeded the rate of the stream.
+func (f *FlowAPI) liveDebuggingHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		if _, err := f.flow.GetComponent(component.ParseID(id), component.InfoOptions{}); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		svc, found := f.flow.GetService(livedebugging.ServiceName)
+		if !found {
+			http.Error(w, "live debugging service not running", http.StatusInternalServerError)
+			return
+		}
+
+		opts, err := parseSubscribeOptions(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+			return
+		}
+
+		sub, err := svc.Data().(livedebugging.LiveDebugging).Subscribe(id, opts)
+		if errors.Is(err, livedebugging.ErrDisabled) {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer sub.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		keepalive := time.NewTicker(liveDebuggingKeepalive)
+		defer keepalive.Stop()
+
+		var dropped uint64
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+
+			case <-keepalive.C:
+				if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+					return
+				}
+
+			case ev, ok := <-sub.Events():
+				if !ok {
+					// The stream was closed because live debugging was
+					// disabled.
+					return
+				}
+				bb, err := json.Marshal(ev)
+				if err != nil {
+					return
+				}
+				if _, err := fmt.Fprintf(w, "data: %s\n\n", bb); err != nil {
+					return
+				}
+			}
+
+			if d := sub.Dropped(); d != dropped {
+				dropped = d
+				if _, err := fmt.Fprintf(w, "event: dropped\ndata: %d\n\n", dropped); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func parseSubscribeOptions(r *http.Request) (livedebugging.SubscribeOptions, error) {
+	var (
+		opts  livedebugging.SubscribeOptions
+		query = r.URL.Query()
+		err   error
+	)
+
+	if v := query.Get("sample_ratio"); v != "" {
+		opts.SampleRatio, err = strconv.ParseFloat(v, 64)
+		if err != nil || opts.SampleRatio <= 0 || opts.SampleRatio > 1 {
+			return opts, fmt.Errorf("sample_ratio must be a number between 0 and 1")
+		}
+	}
+	if v := query.Get("rate"); v != "" {
+		opts.Rate, err = strconv.ParseFloat(v, 64)
+		if err != nil || opts.Rate <= 0 {
+			return opts, fmt.Errorf("rate must be a number greater than 0")
+		}
+	}
+	if v := query.Get("filter"); v != "" {
+		opts.Filter, err = regexp.Compile(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid filter: %w", err)
+		}
+	}
+	return opts, nil
+}
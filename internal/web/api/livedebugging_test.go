@@ -0,0 +1,111 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/grafana/agent/internal/component"
+	"github.com/grafana/agent/internal/service"
+	"github.com/grafana/agent/internal/service/livedebugging"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLiveDebugging(t *testing.T) {
+	debugging := livedebugging.New()
+	host := &fakeDebugHost{svc: debugging, componentID: "module.file.a/loki.process.a"}
+
+	r := mux.NewRouter()
+	NewFlowAPI(host, Options{}).RegisterRoutes("/api/v0/web", r)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	get := func(url string) *http.Response {
+		resp, err := http.Get(srv.URL + url)
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		resp := get("/api/v0/web/debug/module.file.a/loki.process.a")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	})
+
+	require.NoError(t, debugging.Update(livedebugging.Arguments{Enabled: true, MaxRate: 100}))
+
+	t.Run("unknown component", func(t *testing.T) {
+		resp := get("/api/v0/web/debug/loki.process.unknown")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		for _, query := range []string{"sample_ratio=2", "rate=-1", "filter=("} {
+			resp := get("/api/v0/web/debug/module.file.a/loki.process.a?" + query)
+			resp.Body.Close()
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+		}
+	})
+
+	t.Run("streams events", func(t *testing.T) {
+		resp := get("/api/v0/web/debug/module.file.a/loki.process.a?filter=error")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+		require.True(t, debugging.IsActive("module.file.a/loki.process.a"))
+
+		for _, line := range []string{"level=info", "level=error"} {
+			line := line
+			debugging.Publish(livedebugging.Data{
+				ComponentID: "module.file.a/loki.process.a",
+				Type:        livedebugging.DataTypeLog,
+				Direction:   livedebugging.DirectionEmitted,
+				Render:      func() string { return line },
+			})
+		}
+
+		data, ok := strings.CutPrefix(readLine(t, bufio.NewReader(resp.Body)), "data: ")
+		require.True(t, ok)
+
+		var ev livedebugging.Event
+		require.NoError(t, json.Unmarshal([]byte(data), &ev))
+		require.Equal(t, livedebugging.DataTypeLog, ev.Type)
+		require.Equal(t, livedebugging.DirectionEmitted, ev.Direction)
+		require.Equal(t, "level=error", ev.Data)
+	})
+}
+
+func readLine(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+	line, err := r.ReadString('\n')
+	require.NoError(t, err)
+	return strings.TrimSuffix(line, "\n")
+}
+
+// fakeDebugHost is a service.Host running the live debugging service and a
+// single component.
+type fakeDebugHost struct {
+	service.Host
+
+	svc         *livedebugging.Service
+	componentID string
+}
+
+func (h *fakeDebugHost) GetComponent(id component.ID, _ component.InfoOptions) (*component.Info, error) {
+	if id.String() != h.componentID {
+		return nil, component.ErrComponentNotFound
+	}
+	return &component.Info{ID: id}, nil
+}
+
+func (h *fakeDebugHost) GetService(name string) (service.Service, bool) {
+	if name != livedebugging.ServiceName {
+		return nil, false
+	}
+	return h.svc, true
+}
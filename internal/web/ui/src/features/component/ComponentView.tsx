@@ -1,4 +1,4 @@
-import { FC, Fragment, ReactElement } from 'react';
+import { FC, Fragment, ReactElement, useState } from 'react';
 import { Link } from 'react-router-dom';
 import { faCubes, faLink } from '@fortawesome/free-solid-svg-icons';
 import { FontAwesomeIcon } from '@fortawesome/react-fontawesome';
@@ -8,6 +8,7 @@ import { partitionBody } from '../../utils/partition';
 import ComponentBody from './ComponentBody';
 import ComponentList from './ComponentList';
 import { HealthLabel } from './HealthLabel';
+import LiveDebugging from './LiveDebugging';
 import { ComponentDetail, ComponentInfo, PartitionedBody } from './types';
 
 import styles from './ComponentView.module.css';
@@ -17,9 +18,13 @@ export interface ComponentViewProps {
   info: Record<string, ComponentInfo>;
 }
 
+type Tab = 'details' | 'liveDebugging';
+
 export const ComponentView: FC<ComponentViewProps> = (props) => {
   // TODO(rfratto): expand/collapse icon for sections (treat it like Row in grafana dashboard)
 
+  const [tab, setTab] = useState<Tab>('details');
+
   const referencedBy = props.component.referencedBy.filter((id) => props.info[id] !== undefined).map((id) => props.info[id]);
   const referencesTo = props.component.referencesTo.filter((id) => props.info[id] !== undefined).map((id) => props.info[id]);
 
@@ -112,38 +117,53 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
           </blockquote>
         )}
 
-        <ComponentBody partition={argsPartition} />
-        {exportsPartition && <ComponentBody partition={exportsPartition} />}
-        {debugPartition && <ComponentBody partition={debugPartition} />}
-
-        {props.component.referencesTo.length > 0 && (
-          <section id="dependencies">
-            <h2>Dependencies</h2>
-            <div className={styles.sectionContent}>
-              <ComponentList components={referencesTo} moduleID={props.component.moduleID} />
-            </div>
-          </section>
-        )}
-
-        {props.component.referencedBy.length > 0 && (
-          <section id="dependants">
-            <h2>Dependants</h2>
-            <div className={styles.sectionContent}>
-              <ComponentList components={referencedBy} moduleID={props.component.moduleID} />
-            </div>
-          </section>
-        )}
+        <div className={styles.tabs}>
+          <button className={tab === 'details' ? styles.activeTab : ''} onClick={() => setTab('details')}>
+            Details
+          </button>
+          <button className={tab === 'liveDebugging' ? styles.activeTab : ''} onClick={() => setTab('liveDebugging')}>
+            Live debugging
+          </button>
+        </div>
 
-        {props.component.moduleInfo && (
-          <section id="module">
-            <h2>Module components</h2>
-            <div className={styles.sectionContent}>
-              <ComponentList
-                components={props.component.moduleInfo}
-                moduleID={pathJoin([props.component.moduleID, props.component.localID])}
-              />
-            </div>
-          </section>
+        {tab === 'liveDebugging' ? (
+          <LiveDebugging componentID={pathJoin([props.component.moduleID, props.component.localID])} />
+        ) : (
+          <>
+            <ComponentBody partition={argsPartition} />
+            {exportsPartition && <ComponentBody partition={exportsPartition} />}
+            {debugPartition && <ComponentBody partition={debugPartition} />}
+
+            {props.component.referencesTo.length > 0 && (
+              <section id="dependencies">
+                <h2>Dependencies</h2>
+                <div className={styles.sectionContent}>
+                  <ComponentList components={referencesTo} moduleID={props.component.moduleID} />
+                </div>
+              </section>
+            )}
+
+            {props.component.referencedBy.length > 0 && (
+              <section id="dependants">
+                <h2>Dependants</h2>
+                <div className={styles.sectionContent}>
+                  <ComponentList components={referencedBy} moduleID={props.component.moduleID} />
+                </div>
+              </section>
+            )}
+
+            {props.component.moduleInfo && (
+              <section id="module">
+                <h2>Module components</h2>
+                <div className={styles.sectionContent}>
+                  <ComponentList
+                    components={props.component.moduleInfo}
+                    moduleID={pathJoin([props.component.moduleID, props.component.localID])}
+                  />
+                </div>
+              </section>
+            )}
+          </>
         )}
       </main>
     </div>
@@ -0,0 +1,97 @@
+import { FC, FormEvent, useState } from 'react';
+
+import { LiveDebuggingOptions, useLiveDebugging } from '../../hooks/liveDebugging';
+
+import styles from './LiveDebugging.module.css';
+
+interface LiveDebuggingProps {
+  /** The global ID of the component. */
+  componentID: string;
+}
+
+/**
+ * LiveDebugging streams and renders the data received and emitted by a
+ * component.
+ */
+const LiveDebugging: FC<LiveDebuggingProps> = ({ componentID }) => {
+  const [running, setRunning] = useState(false);
+  const [options, setOptions] = useState<LiveDebuggingOptions>({ sampleRatio: 1, filter: '' });
+  const [form, setForm] = useState({ sampleRatio: '1', filter: '' });
+  const [state, clear] = useLiveDebugging(componentID, options, running);
+
+  const apply = (e: FormEvent) => {
+    e.preventDefault();
+    const sampleRatio = Number(form.sampleRatio);
+    setOptions({
+      sampleRatio: sampleRatio > 0 && sampleRatio <= 1 ? sampleRatio : 1,
+      filter: form.filter,
+    });
+    setRunning(true);
+  };
+
+  return (
+    <div>
+      <form className={styles.controls} onSubmit={apply}>
+        <label>
+          Filter
+          <input
+            type="text"
+            placeholder="Regular expression"
+            value={form.filter}
+            onChange={(e) => setForm({ ...form, filter: e.target.value })}
+          />
+        </label>
+        <label>
+          Sample ratio
+          <input
+            type="number"
+            min="0.01"
+            max="1"
+            step="0.01"
+            value={form.sampleRatio}
+            onChange={(e) => setForm({ ...form, sampleRatio: e.target.value })}
+          />
+        </label>
+        <button type="submit">{running ? 'Apply' : 'Start'}</button>
+        <button type="button" disabled={!running} onClick={() => setRunning(false)}>
+          Stop
+        </button>
+        <button type="button" onClick={clear}>
+          Clear
+        </button>
+        {state.dropped > 0 && <span className={styles.dropped}>{state.dropped} events dropped</span>}
+      </form>
+
+      {state.error && <blockquote className={styles.error}>{state.error}</blockquote>}
+
+      {state.events.length === 0 ? (
+        <em className={styles.informative}>
+          {running ? 'Waiting for data…' : 'Start streaming to see the data the component receives and emits.'}
+        </em>
+      ) : (
+        <table className={styles.events}>
+          <thead>
+            <tr>
+              <th className={styles.time}>Time</th>
+              <th className={styles.kind}>Direction</th>
+              <th className={styles.kind}>Type</th>
+              <th>Data</th>
+            </tr>
+          </thead>
+          <tbody>
+            {state.events.map((ev, idx) => (
+              <tr key={idx}>
+                <td>{ev.time}</td>
+                <td className={styles[ev.direction]}>{ev.direction}</td>
+                <td>{ev.type}</td>
+                <td className={styles.data}>{ev.data}</td>
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      )}
+    </div>
+  );
+};
+
+export default LiveDebugging;
@@ -0,0 +1,122 @@
+import { useEffect, useState } from 'react';
+
+/**
+ * DebugEvent is a piece of data received or emitted by a component.
+ */
+export interface DebugEvent {
+  time: string;
+  type: 'log' | 'metric' | 'span' | 'profile';
+  direction: 'received' | 'emitted';
+  data: string;
+}
+
+/**
+ * LiveDebuggingOptions configures which data is streamed.
+ */
+export interface LiveDebuggingOptions {
+  /** Ratio of the data which is streamed, between 0 and 1. */
+  sampleRatio: number;
+  /** Regular expression the streamed data must match. */
+  filter: string;
+}
+
+export interface LiveDebuggingState {
+  events: DebugEvent[];
+  /** Number of events dropped by the agent because of rate limiting. */
+  dropped: number;
+  error?: string;
+}
+
+/** Maximum number of events kept in memory. */
+const maxEvents = 500;
+
+/**
+ * useLiveDebugging streams the data received and emitted by a component while
+ * running is true. Newest events come first.
+ *
+ * @param componentID The global ID of the component.
+ * @param options Options of the stream. Changing them restarts the stream.
+ * @param running Whether to stream.
+ */
+export const useLiveDebugging = (
+  componentID: string,
+  options: LiveDebuggingOptions,
+  running: boolean
+): [LiveDebuggingState, () => void] => {
+  const [state, setState] = useState<LiveDebuggingState>({ events: [], dropped: 0 });
+
+  useEffect(
+    function () {
+      if (!running) {
+        return;
+      }
+
+      const abort = new AbortController();
+      const params = new URLSearchParams({ sample_ratio: options.sampleRatio.toString() });
+      if (options.filter !== '') {
+        params.set('filter', options.filter);
+      }
+
+      const handleMessage = (message: string) => {
+        let event = 'message';
+        let data = '';
+        for (const line of message.split('\n')) {
+          if (line.startsWith('event: ')) {
+            event = line.substring('event: '.length);
+          } else if (line.startsWith('data: ')) {
+            data = line.substring('data: '.length);
+          }
+        }
+
+        if (event === 'dropped') {
+          setState((prev) => ({ ...prev, dropped: Number(data) }));
+        } else if (data !== '') {
+          const ev = JSON.parse(data) as DebugEvent;
+          setState((prev) => ({ ...prev, events: [ev, ...prev.events].slice(0, maxEvents) }));
+        }
+      };
+
+      const worker = async () => {
+        setState((prev) => ({ ...prev, error: undefined }));
+
+        // Request is relative to the <base> tag inside of <head>.
+        const resp = await fetch(`./api/v0/web/debug/${componentID}?${params.toString()}`, {
+          cache: 'no-cache',
+          credentials: 'same-origin',
+          signal: abort.signal,
+        });
+        if (!resp.ok || resp.body === null) {
+          const error = (await resp.text()).trim();
+          setState((prev) => ({ ...prev, error }));
+          return;
+        }
+
+        // Events are separated by blank lines.
+        const reader = resp.body.getReader();
+        const decoder = new TextDecoder();
+        let buffer = '';
+        for (;;) {
+          const { value, done } = await reader.read();
+          if (done) {
+            return;
+          }
+          buffer += decoder.decode(value, { stream: true });
+          const messages = buffer.split('\n\n');
+          buffer = messages.pop() || '';
+          messages.forEach(handleMessage);
+        }
+      };
+
+      worker().catch((err) => {
+        if (!abort.signal.aborted) {
+          setState((prev) => ({ ...prev, error: String(err) }));
+        }
+      });
+      return () => abort.abort();
+    },
+    [componentID, options.sampleRatio, options.filter, running]
+  );
+
+  const clear = () => setState((prev) => ({ ...prev, events: [], dropped: 0 }));
+  return [state, clear];
+};
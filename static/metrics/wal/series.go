@@ -9,6 +9,7 @@ import (
 
 	"github.com/prometheus/prometheus/model/exemplar"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
 	"github.com/prometheus/prometheus/tsdb/chunks"
 )
 
@@ -21,6 +22,9 @@ type memSeries struct {
 
 	// Last recorded timestamp. Used by gc to determine if a series is stale.
 	lastTs int64
+
+	// Last recorded metadata, nil if the series has no metadata.
+	meta *metadata.Metadata
 }
 
 // updateTimestamp obtains the lock on s and will attempt to update lastTs.
@@ -117,7 +121,7 @@ func newStripeSeries(stripeSize int) *stripeSeries {
 
 // gc garbage collects old chunks that are strictly before mint and removes
 // series entirely that have no chunks left.
-func (s *stripeSeries) gc(mint int64) map[chunks.HeadSeriesRef]struct{} {
+func (s *stripeSeries) gc(mint int64) map[chunks.HeadSeriesRef]*memSeries {
 	// NOTE(rfratto): GC will grab two locks, one for the hash and the other for
 	// series. It's not valid for any other function to grab both locks,
 	// otherwise a deadlock might occur when running GC in parallel with
@@ -125,7 +129,7 @@ func (s *stripeSeries) gc(mint int64) map[chunks.HeadSeriesRef]struct{} {
 	s.gcMut.Lock()
 	defer s.gcMut.Unlock()
 
-	deleted := map[chunks.HeadSeriesRef]struct{}{}
+	deleted := map[chunks.HeadSeriesRef]*memSeries{}
 	for hashLock := 0; hashLock < s.size; hashLock++ {
 		s.locks[hashLock].Lock()
 
@@ -146,7 +150,7 @@ func (s *stripeSeries) gc(mint int64) map[chunks.HeadSeriesRef]struct{} {
 					s.locks[refLock].Lock()
 				}
 
-				deleted[series.ref] = struct{}{}
+				deleted[series.ref] = series
 				delete(s.series[refLock], series.ref)
 				s.hashes[hashLock].Delete(hash, series.ref)
 
@@ -88,12 +88,28 @@ func (r walReplayer) replayWAL(reader *wlog.Reader) error {
 				return err
 			}
 			r.w.AppendExemplars(exemplars)
+		case record.Metadata:
+			// Metadata isn't part of wlog.WriteTo, so only collectors which
+			// implement metadataWriteTo receive it.
+			mw, ok := r.w.(metadataWriteTo)
+			if !ok {
+				continue
+			}
+			meta, err := dec.Metadata(rec, nil)
+			if err != nil {
+				return err
+			}
+			mw.AppendMetadata(meta)
 		}
 	}
 
 	return nil
 }
 
+type metadataWriteTo interface {
+	AppendMetadata(metadata []record.RefMetadata)
+}
+
 type walDataCollector struct {
 	mut             sync.Mutex
 	samples         []record.RefSample
@@ -101,6 +117,14 @@ type walDataCollector struct {
 	exemplars       []record.RefExemplar
 	histograms      []record.RefHistogramSample
 	floatHistograms []record.RefFloatHistogramSample
+	metadata        []record.RefMetadata
+}
+
+func (c *walDataCollector) AppendMetadata(metadata []record.RefMetadata) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.metadata = append(c.metadata, metadata...)
 }
 
 func (c *walDataCollector) AppendExemplars(exemplars []record.RefExemplar) bool {
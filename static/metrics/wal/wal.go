@@ -140,6 +140,10 @@ type Storage struct {
 	series  *stripeSeries
 	deleted map[chunks.HeadSeriesRef]int // Deleted series, and what WAL segment they must be kept until.
 
+	// Latest metadata recorded for each metric name.
+	metadataMut    sync.RWMutex
+	metadataByName map[string]*nameMetadata
+
 	metrics *storageMetrics
 }
 
@@ -158,6 +162,8 @@ func NewStorage(logger log.Logger, registerer prometheus.Registerer, path string
 		series:  newStripeSeries(tsdb.DefaultStripeSize),
 		metrics: newStorageMetrics(registerer),
 		nextRef: atomic.NewUint64(0),
+
+		metadataByName: map[string]*nameMetadata{},
 	}
 
 	storage.bufPool.New = func() interface{} {
@@ -173,6 +179,7 @@ func NewStorage(logger log.Logger, registerer prometheus.Registerer, path string
 			pendingHistograms:      make([]record.RefHistogramSample, 0, 100),
 			pendingFloatHistograms: make([]record.RefFloatHistogramSample, 0, 100),
 			pendingExamplars:       make([]record.RefExemplar, 0, 10),
+			pendingMetadata:        make([]record.RefMetadata, 0, 10),
 		}
 	}
 
@@ -290,6 +297,11 @@ func (w *Storage) loadWAL(r *wlog.Reader, multiRef map[chunks.HeadSeriesRef]chun
 				return []record.RefFloatHistogramSample{}
 			},
 		}
+		metadataPool = sync.Pool{
+			New: func() interface{} {
+				return []record.RefMetadata{}
+			},
+		}
 	)
 
 	go func() {
@@ -344,6 +356,18 @@ func (w *Storage) loadWAL(r *wlog.Reader, multiRef map[chunks.HeadSeriesRef]chun
 					return
 				}
 				decoded <- floatHistograms
+			case record.Metadata:
+				meta := metadataPool.Get().([]record.RefMetadata)[:0]
+				meta, err = dec.Metadata(rec, meta)
+				if err != nil {
+					errCh <- &wlog.CorruptionErr{
+						Err:     fmt.Errorf("decode metadata: %w", err),
+						Segment: r.Segment(),
+						Offset:  r.Offset(),
+					}
+					return
+				}
+				decoded <- meta
 			case record.Tombstones, record.Exemplars:
 				// We don't care about decoding tombstones or exemplars
 				// TODO: If decide to decode exemplars, we should make sure to prepopulate
@@ -435,6 +459,25 @@ func (w *Storage) loadWAL(r *wlog.Reader, multiRef map[chunks.HeadSeriesRef]chun
 
 			//nolint:staticcheck
 			floatHistogramsPool.Put(v)
+		case []record.RefMetadata:
+			for _, m := range v {
+				ref, ok := multiRef[m.Ref]
+				if !ok {
+					nonExistentSeriesRefs.Inc()
+					continue
+				}
+				series := w.series.GetByID(ref)
+				added := series.meta == nil
+				series.meta = &metadata.Metadata{
+					Type: record.ToTextparseMetricType(m.Type),
+					Unit: m.Unit,
+					Help: m.Help,
+				}
+				w.setMetadata(series.lset, *series.meta, added)
+			}
+
+			//nolint:staticcheck
+			metadataPool.Put(v)
 		default:
 			panic(fmt.Errorf("unexpected decoded type: %T", d))
 		}
@@ -457,6 +500,67 @@ func (w *Storage) loadWAL(r *wlog.Reader, multiRef map[chunks.HeadSeriesRef]chun
 	}
 }
 
+// nameMetadata is the latest metadata recorded for a metric name, along with
+// the number of series of the metric name which have metadata.
+type nameMetadata struct {
+	meta   metadata.Metadata
+	series int
+}
+
+// setMetadata records m as the latest metadata of the metric name of lset.
+// added must be true if the series of lset had no metadata before.
+func (w *Storage) setMetadata(lset labels.Labels, m metadata.Metadata, added bool) {
+	name := lset.Get(labels.MetricName)
+	if name == "" {
+		return
+	}
+
+	w.metadataMut.Lock()
+	defer w.metadataMut.Unlock()
+	nm, ok := w.metadataByName[name]
+	if !ok {
+		nm = &nameMetadata{}
+		w.metadataByName[name] = nm
+	}
+	nm.meta = m
+	if added {
+		nm.series++
+	}
+}
+
+// deleteMetadata forgets the metadata of the series of lset, which was
+// deleted. The metadata of the metric name is removed once none of its series
+// are left.
+func (w *Storage) deleteMetadata(lset labels.Labels) {
+	name := lset.Get(labels.MetricName)
+	if name == "" {
+		return
+	}
+
+	w.metadataMut.Lock()
+	defer w.metadataMut.Unlock()
+	nm, ok := w.metadataByName[name]
+	if !ok {
+		return
+	}
+	nm.series--
+	if nm.series <= 0 {
+		delete(w.metadataByName, name)
+	}
+}
+
+// MetricMetadata returns the latest metadata recorded for series with the
+// given metric name.
+func (w *Storage) MetricMetadata(name string) (metadata.Metadata, bool) {
+	w.metadataMut.RLock()
+	defer w.metadataMut.RUnlock()
+	nm, ok := w.metadataByName[name]
+	if !ok {
+		return metadata.Metadata{}, false
+	}
+	return nm.meta, true
+}
+
 // Directory returns the path where the WAL storage is held.
 func (w *Storage) Directory() string {
 	return w.path
@@ -563,8 +667,15 @@ func (w *Storage) gc(mint int64) {
 	// We want to keep series records for any newly deleted series
 	// until we've passed the last recorded segment. This prevents
 	// the WAL having samples for series records that no longer exist.
-	for ref := range deleted {
+	for ref, series := range deleted {
 		w.deleted[ref] = last
+
+		series.Lock()
+		hasMetadata := series.meta != nil
+		series.Unlock()
+		if hasMetadata {
+			w.deleteMetadata(series.lset)
+		}
 	}
 
 	w.metrics.numDeletedSeries.Set(float64(len(w.deleted)))
@@ -653,6 +764,7 @@ type appender struct {
 	pendingExamplars       []record.RefExemplar
 	pendingHistograms      []record.RefHistogramSample
 	pendingFloatHistograms []record.RefFloatHistogramSample
+	pendingMetadata        []record.RefMetadata
 
 	// Pointers to the series referenced by each element of pendingSamples.
 	// Series lock is not held on elements.
@@ -665,6 +777,10 @@ type appender struct {
 	// Pointers to the series referenced by each element of pendingFloatHistograms.
 	// Series lock is not held on elements.
 	floatHistogramSeries []*memSeries
+
+	// Pointers to the series referenced by each element of pendingMetadata.
+	// Series lock is not held on elements.
+	metadataSeries []*memSeries
 }
 
 var _ storage.Appender = (*appender)(nil)
@@ -848,9 +964,33 @@ func (a *appender) AppendHistogram(ref storage.SeriesRef, l labels.Labels, t int
 	return storage.SeriesRef(series.ref), nil
 }
 
-func (a *appender) UpdateMetadata(ref storage.SeriesRef, _ labels.Labels, m metadata.Metadata) (storage.SeriesRef, error) {
-	// TODO(rfratto): implement pushing metadata to WAL
-	return 0, nil
+func (a *appender) UpdateMetadata(ref storage.SeriesRef, l labels.Labels, m metadata.Metadata) (storage.SeriesRef, error) {
+	series := a.w.series.GetByID(chunks.HeadSeriesRef(ref))
+	if series == nil {
+		series = a.w.series.GetByHash(l.Hash(), l)
+	}
+	if series == nil {
+		// Metadata is only kept for series which have samples.
+		return 0, nil
+	}
+
+	series.Lock()
+	changed := series.meta == nil || *series.meta != m
+	series.Unlock()
+
+	// Like the TSDB, only log metadata which changed, since the metadata of a
+	// series rarely changes but is updated on every scrape.
+	if changed {
+		// NOTE: always modify pendingMetadata and metadataSeries together.
+		a.pendingMetadata = append(a.pendingMetadata, record.RefMetadata{
+			Ref:  series.ref,
+			Type: record.GetMetricType(m.Type),
+			Unit: m.Unit,
+			Help: m.Help,
+		})
+		a.metadataSeries = append(a.metadataSeries, series)
+	}
+	return storage.SeriesRef(series.ref), nil
 }
 
 // Commit submits the collected samples and purges the batch.
@@ -910,6 +1050,14 @@ func (a *appender) log() error {
 		buf = buf[:0]
 	}
 
+	if len(a.pendingMetadata) > 0 {
+		buf = encoder.Metadata(a.pendingMetadata, buf)
+		if err := a.w.wal.Log(buf); err != nil {
+			return err
+		}
+		buf = buf[:0]
+	}
+
 	// Exemplars should be logged after samples (float/native histogram/etc),
 	// otherwise it might happen that we send the exemplars in a remote write
 	// batch before the samples, which in turn means the exemplar is rejected
@@ -941,6 +1089,19 @@ func (a *appender) log() error {
 			a.w.metrics.totalOutOfOrderSamples.Inc()
 		}
 	}
+	for i, m := range a.pendingMetadata {
+		series = a.metadataSeries[i]
+		meta := metadata.Metadata{
+			Type: record.ToTextparseMetricType(m.Type),
+			Unit: m.Unit,
+			Help: m.Help,
+		}
+		series.Lock()
+		added := series.meta == nil
+		series.meta = &meta
+		series.Unlock()
+		a.w.setMetadata(series.lset, meta, added)
+	}
 
 	return nil
 }
@@ -952,9 +1113,11 @@ func (a *appender) clearData() {
 	a.pendingHistograms = a.pendingHistograms[:0]
 	a.pendingFloatHistograms = a.pendingFloatHistograms[:0]
 	a.pendingExamplars = a.pendingExamplars[:0]
+	a.pendingMetadata = a.pendingMetadata[:0]
 	a.sampleSeries = a.sampleSeries[:0]
 	a.histogramSeries = a.histogramSeries[:0]
 	a.floatHistogramSeries = a.floatHistogramSeries[:0]
+	a.metadataSeries = a.metadataSeries[:0]
 }
 
 func (a *appender) Rollback() error {
@@ -13,6 +13,8 @@ import (
 	"github.com/grafana/agent/internal/util"
 	"github.com/prometheus/prometheus/model/exemplar"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/model/textparse"
 	"github.com/prometheus/prometheus/model/value"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb"
@@ -239,6 +241,81 @@ func TestStorage_ExistingWAL(t *testing.T) {
 	require.Equal(t, expectedExemplars, actualExemplars)
 }
 
+func TestStorage_Metadata(t *testing.T) {
+	walDir := t.TempDir()
+
+	s, err := NewStorage(log.NewNopLogger(), nil, walDir)
+	require.NoError(t, err)
+
+	lset := labels.FromStrings("__name__", "http_requests_total", "code", "200")
+	meta := metadata.Metadata{Type: textparse.MetricTypeCounter, Unit: "requests", Help: "Total requests."}
+
+	// Update the metadata on every commit, like a scrape loop would.
+	for i := 0; i < 3; i++ {
+		app := s.Appender(context.Background())
+		ref, err := app.Append(0, lset, int64(i), float64(i))
+		require.NoError(t, err)
+		_, err = app.UpdateMetadata(ref, lset, meta)
+		require.NoError(t, err)
+		require.NoError(t, app.Commit())
+	}
+
+	m, ok := s.MetricMetadata("http_requests_total")
+	require.True(t, ok)
+	require.Equal(t, meta, m)
+
+	// Unchanged metadata is only logged once.
+	collector := walDataCollector{}
+	replayer := walReplayer{w: &collector}
+	require.NoError(t, replayer.Replay(s.wal.Dir()))
+	require.Equal(t, []record.RefMetadata{{
+		Ref:  collector.series[0].Ref,
+		Type: uint8(record.Counter),
+		Unit: "requests",
+		Help: "Total requests.",
+	}}, collector.metadata)
+
+	// Metadata for unknown series is ignored.
+	app := s.Appender(context.Background())
+	ref, err := app.UpdateMetadata(0, labels.FromStrings("__name__", "unknown"), meta)
+	require.NoError(t, err)
+	require.Equal(t, storage.SeriesRef(0), ref)
+	require.NoError(t, app.Commit())
+	_, ok = s.MetricMetadata("unknown")
+	require.False(t, ok)
+
+	require.NoError(t, s.Close())
+
+	// The metadata is restored when the WAL is replayed.
+	s, err = NewStorage(log.NewNopLogger(), nil, walDir)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, s.Close())
+	}()
+
+	m, ok = s.MetricMetadata("http_requests_total")
+	require.True(t, ok)
+	require.Equal(t, meta, m)
+
+	// The metadata is forgotten once the series of the metric name are
+	// garbage collected.
+	other := labels.FromStrings("__name__", "http_requests_total", "code", "500")
+	app = s.Appender(context.Background())
+	ref, err = app.Append(0, other, 10, 1)
+	require.NoError(t, err)
+	_, err = app.UpdateMetadata(ref, other, meta)
+	require.NoError(t, err)
+	require.NoError(t, app.Commit())
+
+	require.NoError(t, s.Truncate(5))
+	_, ok = s.MetricMetadata("http_requests_total")
+	require.True(t, ok)
+
+	require.NoError(t, s.Truncate(20))
+	_, ok = s.MetricMetadata("http_requests_total")
+	require.False(t, ok)
+}
+
 func TestStorage_ExistingWAL_RefID(t *testing.T) {
 	l := util.TestLogger(t)
 